@@ -0,0 +1,424 @@
+package queryfy
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateInto validates data against a schema in strict mode, applying
+// any transformations, and decodes the result into dst. dst must be a
+// non-nil pointer, typically to a struct.
+//
+// Struct fields are matched by their json tag, falling back to the field
+// name (case-insensitively). Fields tagged `json:"-"` are skipped.
+//
+// Validation and decoding failures are both reported as a
+// *ValidationError whose paths match the schema (e.g. "items[0].price").
+// dst is left untouched if validation fails, and may be partially
+// filled if decoding fails.
+func ValidateInto(data interface{}, schema Schema, dst interface{}) error {
+	return ValidateIntoWithMode(data, schema, Strict, dst)
+}
+
+// ValidateIntoWithMode is ValidateInto with a specific validation mode.
+// In loose mode, decoding applies the same conversions validation
+// accepts, such as "42" into an int field.
+func ValidateIntoWithMode(data interface{}, schema Schema, mode ValidationMode, dst interface{}) error {
+	result, err := ValidateAndTransform(data, schema, mode)
+	if err != nil {
+		return err
+	}
+	return decode(result, dst, mode)
+}
+
+// ValidateInto validates data with the validator's schema and mode and
+// decodes the result into dst.
+func (v *Validator) ValidateInto(data interface{}, dst interface{}) error {
+	return ValidateIntoWithMode(data, v.schema, v.mode, dst)
+}
+
+// ToStruct decodes already-validated data into dst without validating
+// it. It uses the same field matching and error reporting as
+// ValidateInto.
+func ToStruct(data interface{}, dst interface{}) error {
+	return decode(data, dst, Strict)
+}
+
+func decode(data interface{}, dst interface{}, mode ValidationMode) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("queryfy: destination must be a non-nil pointer, got %T", dst)
+	}
+	d := &decoder{mode: mode, errs: &ValidationError{}}
+	d.decodeValue("", data, rv.Elem())
+	if d.errs.HasErrors() {
+		return d.errs
+	}
+	return nil
+}
+
+// decoder copies dynamic values into typed Go values, collecting every
+// mismatch rather than stopping at the first.
+type decoder struct {
+	mode ValidationMode
+	errs *ValidationError
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// add records a decoding error with its code. The decoder has no
+// ValidationContext, so the message is English; Localize translates it.
+func (d *decoder) add(path, code string, params Params, value interface{}) {
+	msg, _ := EnglishMessages.Message(code, params)
+	d.errs.AddError(FieldError{Path: path, Message: msg, Code: code, Params: params, Value: value})
+}
+
+func (d *decoder) fail(path string, value interface{}, target reflect.Type) {
+	d.add(path, CodeConversion, Params{"type": fmt.Sprintf("%T", value), "target": target.String()}, value)
+}
+
+func (d *decoder) overflow(path string, value interface{}, target reflect.Type) {
+	d.add(path, CodeOverflow, Params{"value": value, "target": target.String()}, value)
+}
+
+func (d *decoder) decodeValue(path string, value interface{}, dst reflect.Value) {
+	if value == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		d.decodeValue(path, value, dst.Elem())
+		return
+	}
+
+	src := reflect.ValueOf(value)
+	if dst.Kind() != reflect.Interface && src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return
+	}
+
+	// Strings into types that parse themselves (net.IP, custom enums...)
+	if s, ok := value.(string); ok && dst.CanAddr() && dst.Addr().Type().Implements(textUnmarshalerType) {
+		if err := dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			d.add(path, CodeParse, Params{"target": dst.Type().String(), "error": err.Error()}, value)
+		}
+		return
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if src.Type().AssignableTo(dst.Type()) {
+			dst.Set(src)
+		} else {
+			d.fail(path, value, dst.Type())
+		}
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok && d.mode == Loose {
+			s, ok = ConvertToString(value)
+		}
+		if !ok {
+			d.fail(path, value, dst.Type())
+			return
+		}
+		dst.SetString(s)
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok && d.mode == Loose {
+			if s, isStr := value.(string); isStr {
+				b, ok = s == "true", s == "true" || s == "false"
+			}
+		}
+		if !ok {
+			d.fail(path, value, dst.Type())
+			return
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := d.integer(value)
+		if !ok {
+			d.fail(path, value, dst.Type())
+			return
+		}
+		if !n.IsInt64() || dst.OverflowInt(n.Int64()) {
+			d.overflow(path, value, dst.Type())
+			return
+		}
+		dst.SetInt(n.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := d.integer(value)
+		if !ok {
+			d.fail(path, value, dst.Type())
+			return
+		}
+		if n.Sign() < 0 || !n.IsUint64() || dst.OverflowUint(n.Uint64()) {
+			d.overflow(path, value, dst.Type())
+			return
+		}
+		dst.SetUint(n.Uint64())
+	case reflect.Float32, reflect.Float64:
+		f, ok := d.number(value)
+		if !ok {
+			d.fail(path, value, dst.Type())
+			return
+		}
+		if dst.OverflowFloat(f) {
+			d.overflow(path, value, dst.Type())
+			return
+		}
+		dst.SetFloat(f)
+	case reflect.Slice:
+		d.decodeSlice(path, src, dst)
+	case reflect.Array:
+		if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+			d.fail(path, value, dst.Type())
+			return
+		}
+		if src.Len() != dst.Len() {
+			d.add(path, CodeArrayLength, Params{"length": dst.Len(), "actual": src.Len()}, value)
+			return
+		}
+		for i := 0; i < src.Len(); i++ {
+			d.decodeValue(fmt.Sprintf("%s[%d]", path, i), src.Index(i).Interface(), dst.Index(i))
+		}
+	case reflect.Map:
+		d.decodeMap(path, src, dst)
+	case reflect.Struct:
+		if dst.Type() == timeType {
+			d.decodeTime(path, value, dst)
+			return
+		}
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			d.fail(path, value, dst.Type())
+			return
+		}
+		d.decodeStruct(path, m, dst)
+	default:
+		d.fail(path, value, dst.Type())
+	}
+}
+
+// number converts a numeric value to float64. json.Number and big
+// numbers are accepted, and in loose mode numeric strings too.
+func (d *decoder) number(value interface{}) (float64, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.String:
+		if n, ok := value.(json.Number); ok {
+			f, err := n.Float64()
+			return f, err == nil || errors.Is(err, strconv.ErrRange)
+		}
+		if d.mode == Loose {
+			f, err := strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+			return f, err == nil
+		}
+		return 0, false
+	}
+	if r, ok := ConvertToRat(value); ok {
+		f, _ := r.Float64()
+		return f, true
+	}
+	return 0, false
+}
+
+// integer converts a whole number to a big.Int exactly, so integers
+// beyond 2^53 keep their value. json.Number and big numbers are
+// accepted, and in loose mode numeric strings too. Numbers with a
+// fractional part are rejected.
+func (d *decoder) integer(value interface{}) (*big.Int, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(rv.Uint()), true
+	case reflect.String:
+		if _, ok := value.(json.Number); ok {
+			break
+		}
+		if d.mode != Loose {
+			return nil, false
+		}
+		s := strings.TrimSpace(rv.String())
+		if n, ok := new(big.Int).SetString(s, 10); ok {
+			return n, true
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, false
+		}
+		value = f
+	}
+	r, ok := ConvertToRat(value)
+	if !ok || !r.IsInt() {
+		return nil, false
+	}
+	return new(big.Int).Set(r.Num()), true
+}
+
+func (d *decoder) decodeSlice(path string, src, dst reflect.Value) {
+	if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+		d.fail(path, src.Interface(), dst.Type())
+		return
+	}
+	out := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+	for i := 0; i < src.Len(); i++ {
+		d.decodeValue(fmt.Sprintf("%s[%d]", path, i), src.Index(i).Interface(), out.Index(i))
+	}
+	dst.Set(out)
+}
+
+func (d *decoder) decodeMap(path string, src, dst reflect.Value) {
+	if src.Kind() != reflect.Map || src.Type().Key().Kind() != reflect.String || dst.Type().Key().Kind() != reflect.String {
+		d.fail(path, src.Interface(), dst.Type())
+		return
+	}
+	out := reflect.MakeMapWithSize(dst.Type(), src.Len())
+	iter := src.MapRange()
+	for iter.Next() {
+		key := iter.Key().String()
+		elem := reflect.New(dst.Type().Elem()).Elem()
+		d.decodeValue(joinPath(path, key), iter.Value().Interface(), elem)
+		out.SetMapIndex(reflect.ValueOf(key).Convert(dst.Type().Key()), elem)
+	}
+	dst.Set(out)
+}
+
+func (d *decoder) decodeStruct(path string, data map[string]interface{}, dst reflect.Value) {
+	fields := structFields(dst.Type())
+	for key, value := range data {
+		index, ok := fields.lookup(key)
+		if !ok {
+			// Extra keys are the schema's concern, not the decoder's
+			continue
+		}
+		field, ok := fieldByIndexAlloc(dst, index)
+		if !ok {
+			continue
+		}
+		d.decodeValue(joinPath(path, key), value, field)
+	}
+}
+
+// decodeTime accepts a time.Time or a string in RFC 3339 or date-only
+// form, the layouts DateTimeSchema validates by default.
+func (d *decoder) decodeTime(path string, value interface{}, dst reflect.Value) {
+	switch v := value.(type) {
+	case time.Time:
+		dst.Set(reflect.ValueOf(v))
+		return
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				dst.Set(reflect.ValueOf(t))
+				return
+			}
+		}
+	}
+	d.fail(path, value, dst.Type())
+}
+
+// fieldSet maps data keys to struct field indexes.
+type fieldSet struct {
+	exact map[string][]int
+	fold  map[string][]int
+}
+
+func (f fieldSet) lookup(key string) ([]int, bool) {
+	if index, ok := f.exact[key]; ok {
+		return index, true
+	}
+	index, ok := f.fold[strings.ToLower(key)]
+	return index, ok
+}
+
+// structFields lists the exported fields of t by data key, promoting the
+// fields of untagged embedded structs the way encoding/json does.
+func structFields(t reflect.Type) fieldSet {
+	fs := fieldSet{exact: make(map[string][]int), fold: make(map[string][]int)}
+	var collect func(t reflect.Type, prefix []int)
+	collect = func(t reflect.Type, prefix []int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			name, skip := fieldKey(sf)
+			if skip {
+				continue
+			}
+			index := append(append([]int(nil), prefix...), i)
+
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if sf.Anonymous && ft.Kind() == reflect.Struct && sf.Tag.Get("json") == "" {
+				collect(ft, index)
+				continue
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			// Shallower fields win, as in encoding/json
+			if _, exists := fs.exact[name]; !exists {
+				fs.exact[name] = index
+			}
+			if _, exists := fs.fold[strings.ToLower(name)]; !exists {
+				fs.fold[strings.ToLower(name)] = index
+			}
+		}
+	}
+	collect(t, nil)
+	return fs
+}
+
+// fieldKey returns the data key for a struct field and whether the field
+// is excluded with `json:"-"`.
+func fieldKey(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, false
+	}
+	return sf.Name, false
+}
+
+// fieldByIndexAlloc is reflect.Value.FieldByIndex, allocating nil
+// embedded struct pointers along the way. It fails for unexported
+// embedded pointers, which cannot be set.
+func fieldByIndexAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, v.CanSet()
+}
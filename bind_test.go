@@ -0,0 +1,270 @@
+package queryfy_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+	"github.com/ha1tch/queryfy/builders/transformers"
+)
+
+type bindAddress struct {
+	Street string `json:"street"`
+	City   string
+}
+
+type bindBase struct {
+	ID int64 `json:"id"`
+}
+
+type bindOrder struct {
+	bindBase
+	Customer string            `json:"customer"`
+	Email    string            `json:"email"`
+	Total    float64           `json:"total"`
+	Quantity int               `json:"qty"`
+	Paid     bool              `json:"paid"`
+	Tags     []string          `json:"tags"`
+	Address  *bindAddress      `json:"address"`
+	Notes    *string           `json:"notes"`
+	Created  time.Time         `json:"created"`
+	Extra    map[string]string `json:"extra"`
+	Raw      interface{}       `json:"raw"`
+	Ignored  string            `json:"-"`
+}
+
+func bindSchema() queryfy.Schema {
+	return builders.Object().
+		Field("id", builders.Number().Integer().Required()).
+		Field("customer", builders.String().Required()).
+		Field("email", builders.Transform(builders.String().Email()).
+			Add(transformers.Trim()).Add(transformers.Lowercase())).
+		Field("total", builders.Number()).
+		Field("qty", builders.Number().Integer()).
+		Field("paid", builders.Bool()).
+		Field("tags", builders.Array().Of(builders.String())).
+		Field("address", builders.Object().
+			Field("street", builders.String()).
+			Field("city", builders.String()).
+			Nullable()).
+		Field("notes", builders.String().Nullable()).
+		Field("created", builders.DateTime().ISO8601()).
+		Field("extra", builders.Object().AllowAdditional(true)).
+		Field("raw", builders.Number()).
+		AllowAdditional(true)
+}
+
+// ======================================================================
+// ValidateInto
+// ======================================================================
+
+func TestValidateInto_Struct(t *testing.T) {
+	data := map[string]interface{}{
+		"id":       42.0,
+		"customer": "Ada",
+		"email":    "  ADA@Example.COM ",
+		"total":    19.5,
+		"qty":      3.0,
+		"paid":     true,
+		"tags":     []interface{}{"a", "b"},
+		"address":  map[string]interface{}{"street": "1 Main St", "city": "Springfield"},
+		"notes":    nil,
+		"created":  "2026-01-02T03:04:05Z",
+		"extra":    map[string]interface{}{"k": "v"},
+		"raw":      7.0,
+		"Ignored":  "x",
+	}
+
+	var order bindOrder
+	if err := queryfy.ValidateInto(data, bindSchema(), &order); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if order.ID != 42 || order.Customer != "Ada" || order.Quantity != 3 || !order.Paid {
+		t.Errorf("scalar fields not decoded: %+v", order)
+	}
+	if order.Email != "ada@example.com" {
+		t.Errorf("expected transformed email, got %q", order.Email)
+	}
+	if len(order.Tags) != 2 || order.Tags[1] != "b" {
+		t.Errorf("unexpected tags %v", order.Tags)
+	}
+	if order.Address == nil || order.Address.City != "Springfield" {
+		t.Errorf("unexpected address %+v", order.Address)
+	}
+	if order.Notes != nil {
+		t.Error("expected nil notes")
+	}
+	if !order.Created.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("unexpected created %v", order.Created)
+	}
+	if order.Extra["k"] != "v" || order.Raw != 7.0 || order.Ignored != "" {
+		t.Errorf("unexpected extra/raw/ignored: %+v", order)
+	}
+}
+
+func TestValidateInto_ValidationErrors(t *testing.T) {
+	data := map[string]interface{}{
+		"id":   1.0,
+		"tags": []interface{}{"a", 5.0},
+	}
+	order := bindOrder{Customer: "unchanged"}
+	err := queryfy.ValidateInto(data, bindSchema(), &order)
+
+	var verr *queryfy.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	paths := map[string]bool{}
+	for _, fe := range verr.Errors {
+		paths[fe.Path] = true
+	}
+	if !paths["customer"] || !paths["tags[1]"] {
+		t.Errorf("expected errors at customer and tags[1], got %v", verr.Errors)
+	}
+	if order.Customer != "unchanged" {
+		t.Error("destination should not be modified when validation fails")
+	}
+}
+
+func TestValidateInto_DecodeErrors(t *testing.T) {
+	type target struct {
+		Count int8    `json:"count"`
+		Ratio int     `json:"ratio"`
+		Items []int   `json:"items"`
+		When  [2]bool `json:"when"`
+		Addr  net.IP  `json:"addr"`
+	}
+	schema := builders.Object().AllowAdditional(true)
+	data := map[string]interface{}{
+		"count": 300.0,
+		"ratio": 1.5,
+		"items": []interface{}{1.0, "two"},
+		"when":  []interface{}{true},
+		"addr":  "localhost",
+	}
+
+	var dst target
+	err := queryfy.ValidateInto(data, schema, &dst)
+	var verr *queryfy.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	want := map[string]struct {
+		code   string
+		params queryfy.Params
+		es     string
+	}{
+		"count":    {queryfy.CodeOverflow, queryfy.Params{"value": 300.0, "target": "int8"}, "el valor 300 no cabe en int8"},
+		"ratio":    {queryfy.CodeConversion, queryfy.Params{"type": "float64", "target": "int"}, "no se puede convertir float64 a int"},
+		"items[1]": {queryfy.CodeConversion, queryfy.Params{"type": "string", "target": "int"}, "no se puede convertir string a int"},
+		"when":     {queryfy.CodeArrayLength, queryfy.Params{"length": 2, "actual": 1}, "se esperaban 2 elementos, se recibieron 1"},
+		"addr":     {queryfy.CodeParse, queryfy.Params{"target": "net.IP", "error": `invalid IP address: localhost`}, "no se puede interpretar net.IP: invalid IP address: localhost"},
+	}
+	if len(verr.Errors) != len(want) {
+		t.Errorf("got %d errors, want %d: %v", len(verr.Errors), len(want), verr.Errors)
+	}
+	localized := verr.Localize(queryfy.SpanishMessages)
+	for i, fe := range verr.Errors {
+		w, ok := want[fe.Path]
+		if !ok {
+			t.Errorf("unexpected error %v", fe)
+			continue
+		}
+		if fe.Code != w.code || !reflect.DeepEqual(fe.Params, w.params) {
+			t.Errorf("%s: code %q, params %v; want %q, %v", fe.Path, fe.Code, fe.Params, w.code, w.params)
+		}
+		if got := localized.Errors[i].Message; got != w.es {
+			t.Errorf("%s: localized %q, want %q", fe.Path, got, w.es)
+		}
+	}
+}
+
+func TestValidateInto_ExactIntegers(t *testing.T) {
+	type target struct {
+		ID    int     `json:"id"`
+		Big   uint64  `json:"big"`
+		Count int64   `json:"count"`
+		Ratio float64 `json:"ratio"`
+	}
+	schema := builders.Object().
+		Field("id", builders.Number().Integer()).
+		Field("big", builders.Number().Integer()).
+		Field("count", builders.Number().Integer()).
+		Field("ratio", builders.Number())
+
+	data, err := queryfy.DecodeJSON([]byte(
+		`{"id": 9007199254740993, "big": 18446744073709551615, "count": 1e3, "ratio": 0.25}`), schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dst target
+	if err := queryfy.ValidateInto(data, schema, &dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := target{ID: 9007199254740993, Big: 18446744073709551615, Count: 1000, Ratio: 0.25}
+	if dst != want {
+		t.Errorf("got %+v, want %+v", dst, want)
+	}
+
+	// json.Number in strict mode, including values out of range
+	data = map[string]interface{}{
+		"id":    json.Number("12"),
+		"big":   json.Number("18446744073709551616"),
+		"count": json.Number("1.5"),
+		"ratio": json.Number("2.5"),
+	}
+	var verr *queryfy.ValidationError
+	if !errors.As(queryfy.ToStruct(data, &dst), &verr) {
+		t.Fatal("expected decoding errors")
+	}
+	paths := map[string]bool{}
+	for _, fe := range verr.Errors {
+		paths[fe.Path] = true
+	}
+	if len(paths) != 2 || !paths["big"] || !paths["count"] {
+		t.Errorf("errors = %v", verr.Errors)
+	}
+	if dst.ID != 12 || dst.Ratio != 2.5 {
+		t.Errorf("got %+v", dst)
+	}
+}
+
+func TestValidateInto_LooseConversions(t *testing.T) {
+	type target struct {
+		Age    int     `json:"age"`
+		Active bool    `json:"active"`
+		Score  float64 `json:"score"`
+	}
+	schema := builders.Object().
+		Field("age", builders.Number().Integer()).
+		Field("active", builders.Bool()).
+		Field("score", builders.Number())
+	data := map[string]interface{}{"age": "42", "active": "true", "score": "9.5"}
+
+	var dst target
+	if err := queryfy.ValidateInto(data, schema, &dst); err == nil {
+		t.Error("expected strict mode to reject string numbers")
+	}
+	if err := queryfy.NewValidator(schema).Loose().ValidateInto(data, &dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dst.Age != 42 || !dst.Active || dst.Score != 9.5 {
+		t.Errorf("unexpected result %+v", dst)
+	}
+}
+
+func TestValidateInto_BadDestination(t *testing.T) {
+	var order bindOrder
+	if err := queryfy.ValidateInto(map[string]interface{}{}, builders.Object(), order); err == nil {
+		t.Error("expected error for non-pointer destination")
+	}
+	if err := queryfy.ToStruct(map[string]interface{}{}, (*bindOrder)(nil)); err == nil {
+		t.Error("expected error for nil pointer destination")
+	}
+}
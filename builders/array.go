@@ -15,6 +15,7 @@ type ArraySchema struct {
 	minItems        *int
 	maxItems        *int
 	uniqueItems     bool
+	identityKey     string
 	validators      []queryfy.ValidatorFunc
 	asyncValidators []queryfy.AsyncValidatorFunc
 }
@@ -83,6 +84,14 @@ func (s *ArraySchema) UniqueItems() *ArraySchema {
 	return s
 }
 
+// IdentityKey names the field that identifies each element, for arrays
+// of objects. DiffData matches elements by this field rather than by
+// position, treating the array as a set. It does not affect validation.
+func (s *ArraySchema) IdentityKey(field string) *ArraySchema {
+	s.identityKey = field
+	return s
+}
+
 // Custom adds a custom validator function.
 func (s *ArraySchema) Custom(fn queryfy.ValidatorFunc) *ArraySchema {
 	s.validators = append(s.validators, fn)
@@ -106,11 +115,11 @@ func (s *ArraySchema) Validate(value interface{}, ctx *queryfy.ValidationContext
 
 	// Length validation
 	if s.minItems != nil && length < *s.minItems {
-		ctx.AddError(fmt.Sprintf("must have at least %d items, got %d", *s.minItems, length), value)
+		ctx.AddCodedError(queryfy.CodeMinItems, queryfy.Params{"min": *s.minItems, "actual": length}, value)
 	}
 
 	if s.maxItems != nil && length > *s.maxItems {
-		ctx.AddError(fmt.Sprintf("must have at most %d items, got %d", *s.maxItems, length), value)
+		ctx.AddCodedError(queryfy.CodeMaxItems, queryfy.Params{"max": *s.maxItems, "actual": length}, value)
 	}
 
 	// Unique items validation
@@ -121,7 +130,7 @@ func (s *ArraySchema) Validate(value interface{}, ctx *queryfy.ValidationContext
 			// In production, this would need better handling
 			key := fmt.Sprintf("%v", slice.Index(i).Interface())
 			if seen[key] {
-				ctx.AddError("items must be unique", value)
+				ctx.AddCodedError(queryfy.CodeUniqueItems, nil, value)
 				break
 			}
 			seen[key] = true
@@ -140,7 +149,7 @@ func (s *ArraySchema) Validate(value interface{}, ctx *queryfy.ValidationContext
 	// Custom validators
 	for _, validator := range s.validators {
 		if err := validator(value); err != nil {
-			ctx.AddError(err.Error(), value)
+			ctx.AddValidatorError(err, value)
 		}
 	}
 
@@ -163,6 +172,11 @@ func (s *ArraySchema) IsUniqueItems() bool {
 	return s.uniqueItems
 }
 
+// IdentityField returns the identity key set by IdentityKey, or "".
+func (s *ArraySchema) IdentityField() string {
+	return s.identityKey
+}
+
 // Meta attaches a key-value metadata pair to the schema.
 func (s *ArraySchema) Meta(key string, value interface{}) *ArraySchema {
 	s.SetMeta(key, value)
@@ -198,11 +212,11 @@ func (s *ArraySchema) ValidateAndTransform(value interface{}, ctx *queryfy.Valid
 
 	// Length validation
 	if s.minItems != nil && length < *s.minItems {
-		ctx.AddError(fmt.Sprintf("must have at least %d items, got %d", *s.minItems, length), value)
+		ctx.AddCodedError(queryfy.CodeMinItems, queryfy.Params{"min": *s.minItems, "actual": length}, value)
 	}
 
 	if s.maxItems != nil && length > *s.maxItems {
-		ctx.AddError(fmt.Sprintf("must have at most %d items, got %d", *s.maxItems, length), value)
+		ctx.AddCodedError(queryfy.CodeMaxItems, queryfy.Params{"max": *s.maxItems, "actual": length}, value)
 	}
 
 	// Unique items validation
@@ -211,7 +225,7 @@ func (s *ArraySchema) ValidateAndTransform(value interface{}, ctx *queryfy.Valid
 		for i := 0; i < length; i++ {
 			key := fmt.Sprintf("%v", slice.Index(i).Interface())
 			if seen[key] {
-				ctx.AddError("items must be unique", value)
+				ctx.AddCodedError(queryfy.CodeUniqueItems, nil, value)
 				break
 			}
 			seen[key] = true
@@ -242,7 +256,7 @@ func (s *ArraySchema) ValidateAndTransform(value interface{}, ctx *queryfy.Valid
 	// Custom validators
 	for _, validator := range s.validators {
 		if err := validator(value); err != nil {
-			ctx.AddError(err.Error(), value)
+			ctx.AddValidatorError(err, value)
 		}
 	}
 
@@ -282,7 +296,7 @@ func (s *ArraySchema) ValidateAndTransformAsync(goCtx context.Context, value int
 
 	// Check context cancellation before async phase
 	if goCtx.Err() != nil {
-		ctx.AddError(fmt.Sprintf("validation cancelled: %s", goCtx.Err()), result)
+		ctx.AddCodedError(queryfy.CodeCancelled, queryfy.Params{"error": goCtx.Err().Error()}, result)
 		return result, ctx.Error()
 	}
 
@@ -294,7 +308,7 @@ func (s *ArraySchema) ValidateAndTransformAsync(goCtx context.Context, value int
 			items := result.([]interface{})
 			for i, elem := range items {
 				if goCtx.Err() != nil {
-					ctx.AddError(fmt.Sprintf("validation cancelled: %s", goCtx.Err()), result)
+					ctx.AddCodedError(queryfy.CodeCancelled, queryfy.Params{"error": goCtx.Err().Error()}, result)
 					return result, ctx.Error()
 				}
 				ctx.WithIndex(i, func() {
@@ -310,12 +324,12 @@ func (s *ArraySchema) ValidateAndTransformAsync(goCtx context.Context, value int
 	// Run array-level async validators
 	for _, asyncValidator := range s.asyncValidators {
 		if goCtx.Err() != nil {
-			ctx.AddError(fmt.Sprintf("validation cancelled: %s", goCtx.Err()), result)
+			ctx.AddCodedError(queryfy.CodeCancelled, queryfy.Params{"error": goCtx.Err().Error()}, result)
 			return result, ctx.Error()
 		}
 
 		if err := asyncValidator(goCtx, result); err != nil {
-			ctx.AddError(err.Error(), result)
+			ctx.AddValidatorError(err, result)
 		}
 	}
 
@@ -1,12 +1,11 @@
 package builders
 
-import (
-	"github.com/ha1tch/queryfy"
-)
+import "github.com/ha1tch/queryfy"
 
 // BoolSchema validates boolean values.
 type BoolSchema struct {
 	queryfy.BaseSchema
+	constValue *bool
 	validators []queryfy.ValidatorFunc
 }
 
@@ -37,6 +36,12 @@ func (s *BoolSchema) Nullable() *BoolSchema {
 	return s
 }
 
+// Const restricts the value to exactly true or exactly false.
+func (s *BoolSchema) Const(value bool) *BoolSchema {
+	s.constValue = &value
+	return s
+}
+
 // Custom adds a custom validator function.
 func (s *BoolSchema) Custom(fn queryfy.ValidatorFunc) *BoolSchema {
 	s.validators = append(s.validators, fn)
@@ -54,10 +59,21 @@ func (s *BoolSchema) Validate(value interface{}, ctx *queryfy.ValidationContext)
 		return nil
 	}
 
+	if s.constValue != nil {
+		// Loose mode lets "true" and "false" through the type check
+		b, ok := value.(bool)
+		if !ok {
+			b = value == "true"
+		}
+		if b != *s.constValue {
+			ctx.AddCodedError(queryfy.CodeConst, queryfy.Params{"value": *s.constValue}, value)
+		}
+	}
+
 	// Custom validators
 	for _, validator := range s.validators {
 		if err := validator(value); err != nil {
-			ctx.AddError(err.Error(), value)
+			ctx.AddValidatorError(err, value)
 		}
 	}
 
@@ -70,6 +86,12 @@ func (s *BoolSchema) Meta(key string, value interface{}) *BoolSchema {
 	return s
 }
 
+// ConstValue returns the value set with Const, or nil if either boolean
+// is accepted.
+func (s *BoolSchema) ConstValue() *bool {
+	return s.constValue
+}
+
 // Validators returns the custom validator functions.
 func (s *BoolSchema) Validators() []queryfy.ValidatorFunc {
 	return s.validators
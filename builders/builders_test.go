@@ -1,6 +1,8 @@
 package builders_test
 
 import (
+	"encoding/json"
+	"math/big"
 	"testing"
 	"time"
 
@@ -280,6 +282,15 @@ func TestBool_Custom(t *testing.T) {
 	expectInvalid(t, s, false)
 }
 
+func TestBool_Const(t *testing.T) {
+	s := builders.Bool().Const(true)
+	expectValid(t, s, true)
+	expectInvalid(t, s, false)
+	expectValidLoose(t, s, "true")
+	expectInvalidLoose(t, s, "false")
+	expectInvalid(t, queryfy.Compile(s), false)
+}
+
 // ======================================================================
 // DateTimeSchema
 // ======================================================================
@@ -463,7 +474,7 @@ func TestCustom_Nullable(t *testing.T) {
 }
 
 // ======================================================================
-// CompositeSchema (And, Or, Not)
+// CompositeSchema (And, Or, OneOf, Not)
 // ======================================================================
 
 func TestAnd_BothPass(t *testing.T) {
@@ -500,6 +511,36 @@ func TestOr_NoneMatch(t *testing.T) {
 	expectInvalid(t, s, "just a string")
 }
 
+func TestOneOf_ExactlyOne(t *testing.T) {
+	s := builders.OneOf(
+		builders.Number().MultipleOf(3),
+		builders.Number().MultipleOf(5),
+	)
+	expectValid(t, s, 9)
+	expectValid(t, s, 10)
+	expectInvalid(t, s, 7)
+}
+
+func TestOneOf_MoreThanOneMatches(t *testing.T) {
+	s := builders.OneOf(
+		builders.Number().MultipleOf(3),
+		builders.Number().MultipleOf(5),
+	)
+	ctx := validate(t, s, 15, queryfy.Strict)
+	if !ctx.HasErrors() {
+		t.Fatal("expected 15 to be rejected for matching both schemas")
+	}
+	if msg := ctx.Errors()[0].Message; msg != "must match exactly one schema, matched 2" {
+		t.Errorf("unexpected message: %s", msg)
+	}
+}
+
+func TestOneOf_Nullable(t *testing.T) {
+	s := builders.OneOf(builders.String(), builders.Bool()).Nullable()
+	expectValid(t, s, nil)
+	expectInvalid(t, builders.OneOf(builders.String()).Required(), nil)
+}
+
 func TestNot_Inverts(t *testing.T) {
 	s := builders.Not(builders.String().Email())
 	expectValid(t, s, "not-an-email")
@@ -634,6 +675,86 @@ func TestDependent_ThenElse(t *testing.T) {
 	expectInvalid(t, schema, freeHigh)
 }
 
+func TestDependent_IfSchema(t *testing.T) {
+	business := builders.Object().
+		Field("type", builders.String().Enum("business")).
+		RequiredFields("type").
+		AllowAdditional(true)
+	schema := builders.Object().WithDependencies().
+		Field("type", builders.String().Required()).
+		Field("company", builders.String()).
+		DependentField("company",
+			builders.Dependent("company").
+				If(business).
+				Then(builders.String().MinLength(3).Required()))
+
+	expectValid(t, schema, map[string]interface{}{"type": "business", "company": "Acme"})
+	expectInvalid(t, schema, map[string]interface{}{"type": "business"})
+	expectInvalid(t, schema, map[string]interface{}{"type": "business", "company": "A"})
+	// Then is not applied when the condition fails, the base field is
+	expectValid(t, schema, map[string]interface{}{"type": "personal", "company": "A"})
+	expectInvalid(t, schema, map[string]interface{}{"type": "personal", "company": 1})
+}
+
+func TestDependent_ElseRequired(t *testing.T) {
+	schema := builders.Object().WithDependencies().
+		Field("anonymous", builders.Bool()).
+		DependentField("name",
+			builders.Dependent("name").
+				When(builders.WhenTrue("anonymous")).
+				Then(builders.String()).
+				Else(builders.String().Required()))
+
+	expectValid(t, schema, map[string]interface{}{"anonymous": true})
+	expectInvalid(t, schema, map[string]interface{}{"anonymous": false})
+}
+
+func TestDependent_CompiledAndTransform(t *testing.T) {
+	schema := builders.Object().WithDependencies().
+		Field("amount", builders.Number()).
+		DependentField("approver",
+			builders.Dependent("approver").
+				When(builders.WhenGreaterThan("amount", 1000)).
+				Then(builders.String().Required()))
+
+	compiled := queryfy.Compile(schema)
+	if err := queryfy.Validate(map[string]interface{}{"amount": 5000.0}, compiled); err == nil {
+		t.Error("expected compiled schema to enforce dependent field")
+	}
+
+	ctx := queryfy.NewValidationContext(queryfy.Strict)
+	result, _ := schema.ValidateAndTransform(map[string]interface{}{"amount": 5.0, "approver": "x"}, ctx)
+	if ctx.HasErrors() {
+		t.Fatalf("unexpected errors: %v", ctx.Error())
+	}
+	if result.(map[string]interface{})["approver"] != "x" {
+		t.Errorf("expected dependent field kept in result, got %v", result)
+	}
+}
+
+func TestDependent_OptionalObjectNil(t *testing.T) {
+	schema := builders.Object().WithDependencies().Nullable().
+		DependentField("b", builders.Dependent("b").When(builders.WhenExists("a")))
+	expectValid(t, schema, nil)
+}
+
+func TestDependent_Accessors(t *testing.T) {
+	cond := builders.Object().RequiredFields("a")
+	then := builders.String()
+	dep := builders.Dependent("b").If(cond).Then(then)
+	schema := builders.Object().WithDependencies().DependentField("b", dep)
+
+	if dep.FieldName() != "b" || dep.IfSchema() != cond || dep.ThenSchema() != then || dep.ElseSchema() != nil {
+		t.Error("accessors did not return the configured values")
+	}
+	if got, ok := schema.GetDependentField("b"); !ok || got != dep {
+		t.Error("expected GetDependentField to return the rule")
+	}
+	if names := schema.RequiredFieldNames(); len(names) != 0 {
+		t.Errorf("dependent fields should not be reported as required, got %v", names)
+	}
+}
+
 func TestDependent_PanicOnPlainField(t *testing.T) {
 	// Passing a DependentSchema to ObjectSchema.Field should panic
 	// to prevent the silent-no-validation footgun.
@@ -649,6 +770,175 @@ func TestDependent_PanicOnPlainField(t *testing.T) {
 		Then(builders.String().Required()))
 }
 
+func TestDependent_ConditionAccessors(t *testing.T) {
+	cond := builders.WhenEquals("country", "US")
+	dep := builders.Dependent("state").On("country").When(cond).Then(builders.String())
+
+	if dep.Condition() != cond || dep.IfSchema() != nil {
+		t.Error("expected Condition to return the When condition")
+	}
+	if on := dep.DependsOn(); len(on) != 1 || on[0] != "country" {
+		t.Errorf("unexpected DependsOn %v", on)
+	}
+
+	// A plain function is wrapped with WhenFunc
+	fn := builders.Dependent("x").When(builders.WhenFunc(func(data map[string]interface{}) bool { return data["on"] == true }))
+	if c := fn.Condition(); c == nil || c.Op != builders.OpFunc || c.IsDeclarative() {
+		t.Errorf("expected an OpFunc condition, got %v", c)
+	}
+	schema := builders.Object().WithDependencies().
+		Field("on", builders.Bool()).
+		DependentField("x", fn.Then(builders.String().Required()))
+	expectInvalid(t, schema, map[string]interface{}{"on": true})
+	expectValid(t, schema, map[string]interface{}{"on": false})
+}
+
+// ======================================================================
+// Declarative conditions
+// ======================================================================
+
+func TestCondition_Evaluate(t *testing.T) {
+	data := map[string]interface{}{"country": "US", "total": 150.0, "vip": true, "note": nil}
+	tests := []struct {
+		cond *builders.Condition
+		want bool
+	}{
+		{builders.WhenEquals("country", "US"), true},
+		{builders.WhenNotEquals("country", "US"), false},
+		{builders.WhenExists("note"), false},
+		{builders.WhenNotExists("note"), true},
+		{builders.WhenIn("country", "CA", "US"), true},
+		{builders.WhenGreaterThan("total", 100), true},
+		{builders.WhenLessThan("total", 100), false},
+		{builders.WhenTrue("vip"), true},
+		{builders.WhenFalse("vip"), false},
+		{builders.WhenAll(builders.WhenTrue("vip"), builders.WhenLessThan("total", 100)), false},
+		{builders.WhenAny(builders.WhenTrue("vip"), builders.WhenLessThan("total", 100)), true},
+		{builders.WhenNot(builders.WhenTrue("vip")), false},
+	}
+	for _, tt := range tests {
+		if got := tt.cond.Evaluate(data); got != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.cond, got, tt.want)
+		}
+	}
+}
+
+func TestCondition_ExactNumbers(t *testing.T) {
+	data := map[string]interface{}{
+		"json":  json.Number("60"),
+		"big":   big.NewInt(60),
+		"rat":   big.NewRat(101, 2),
+		"float": big.NewFloat(49.5),
+		"huge":  int64(1<<53 + 1),
+	}
+	tests := []struct {
+		cond *builders.Condition
+		want bool
+	}{
+		{builders.WhenGreaterThan("json", 50), true},
+		{builders.WhenLessThan("json", 50), false},
+		{builders.WhenGreaterThan("big", 50), true},
+		{builders.WhenGreaterThan("rat", 50), true},
+		{builders.WhenLessThan("float", 50), true},
+		// float64 would round the value down to the threshold
+		{builders.WhenGreaterThan("huge", 1<<53), true},
+		{&builders.Condition{Op: builders.OpLessThan, Field: "big", Value: json.Number("60.5")}, true},
+		{&builders.Condition{Op: builders.OpGreaterThan, Field: "json", Value: big.NewInt(59)}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.cond.Evaluate(data); got != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.cond, got, tt.want)
+		}
+	}
+}
+
+func TestCondition_String(t *testing.T) {
+	cond := builders.WhenAll(
+		builders.WhenEquals("country", "US"),
+		builders.WhenNot(builders.WhenIn("state", "AK", "HI")),
+		builders.WhenGreaterThan("total", 100),
+	)
+	want := `(country == "US" && !(state in ["AK", "HI"]) && total > 100)`
+	if got := cond.String(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if fields := cond.Fields(); len(fields) != 3 || fields[0] != "country" || fields[2] != "total" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+}
+
+func TestCondition_JSONRoundTrip(t *testing.T) {
+	cond := builders.WhenAny(
+		builders.WhenEquals("country", "US"),
+		builders.WhenAll(builders.WhenExists("vat"), builders.WhenLessThan("total", 10)),
+	)
+	data, err := json.Marshal(cond)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	parsed, err := builders.ParseCondition(data)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if parsed.String() != cond.String() {
+		t.Errorf("round trip changed the condition: %s vs %s", parsed, cond)
+	}
+	if !parsed.Evaluate(map[string]interface{}{"vat": "x", "total": 5.0}) {
+		t.Error("parsed condition should evaluate like the original")
+	}
+}
+
+func TestCondition_JSONExactThresholds(t *testing.T) {
+	for _, cond := range []*builders.Condition{
+		{Op: builders.OpGreaterThan, Field: "n", Value: json.Number("9007199254740993")},
+		{Op: builders.OpGreaterThan, Field: "n", Value: big.NewRat(1, 4)},
+		{Op: builders.OpLessThan, Field: "n", Value: big.NewFloat(2.5)},
+	} {
+		data, err := json.Marshal(cond)
+		if err != nil {
+			t.Fatalf("%v: marshal error: %v", cond, err)
+		}
+		parsed, err := builders.ParseCondition(data)
+		if err != nil {
+			t.Fatalf("%s: parse error: %v", data, err)
+		}
+		build := func(c *builders.Condition) queryfy.Schema {
+			return builders.Object().WithDependencies().
+				DependentField("x", builders.Dependent("x").When(c).Then(builders.String()))
+		}
+		if !builders.Equal(build(parsed), build(cond)) {
+			t.Errorf("%s: round trip changed the threshold to %v", data, parsed.Value)
+		}
+	}
+
+	parsed, err := builders.ParseCondition([]byte(`{"op": "greater_than", "field": "n", "value": 9007199254740992}`))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if !parsed.Evaluate(map[string]interface{}{"n": int64(1<<53 + 1)}) {
+		t.Error("threshold should keep its exact value")
+	}
+}
+
+func TestCondition_Invalid(t *testing.T) {
+	for _, input := range []string{
+		`{"op": "bogus"}`,
+		`{"op": "equals"}`,
+		`{"op": "greater_than", "field": "n", "value": "ten"}`,
+		`{"op": "not", "conditions": []}`,
+		`{"op": "all", "conditions": [{"op": "func"}]}`,
+	} {
+		if _, err := builders.ParseCondition([]byte(input)); err == nil {
+			t.Errorf("expected error for %s", input)
+		}
+	}
+
+	fn := builders.WhenAll(builders.WhenFunc(func(map[string]interface{}) bool { return true }))
+	if _, err := json.Marshal(fn); err == nil {
+		t.Error("expected function condition to fail to marshal")
+	}
+}
+
 // ======================================================================
 // ObjectSchema edge cases
 // ======================================================================
@@ -0,0 +1,456 @@
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+)
+
+// chain returns a builders expression that rebuilds schema.
+func (g *generator) chain(schema queryfy.Schema, path string) string {
+	if schema == nil {
+		return "nil"
+	}
+	if hasCustomValidators(schema) {
+		g.note(path, "custom validators")
+	}
+
+	switch s := schema.(type) {
+	case *builders.StringSchema:
+		return g.stringChain(s)
+	case *builders.NumberSchema:
+		return g.numberChain(s)
+	case *builders.BoolSchema:
+		expr := "builders.Bool()"
+		if c := s.ConstValue(); c != nil {
+			expr += fmt.Sprintf(".Const(%t)", *c)
+		}
+		return expr + flags(s)
+	case *builders.DateTimeSchema:
+		return g.dateTimeChain(s)
+	case *builders.ArraySchema:
+		return g.arrayChain(s, path)
+	case *builders.ObjectSchema:
+		return g.objectChain(s, path) + flags(s)
+	case *builders.ObjectSchemaWithDependencies:
+		return g.dependentObjectChain(s, path)
+	case *builders.RefSchema:
+		return g.refChain(s, path)
+	case *builders.TransformSchema:
+		g.note(path, "transformers")
+		inner := g.chain(s.InnerSchema(), path)
+		if s.IsRequired() && !isRequired(s.InnerSchema()) {
+			inner = "builders.And(" + inner + ").Required()"
+		}
+		return inner
+	case *builders.AndSchema:
+		return g.compositeChain("And", s.Schemas(), path, "and") + flags(s)
+	case *builders.OrSchema:
+		return g.compositeChain("Or", s.Schemas(), path, "or") + flags(s)
+	case *builders.OneOfSchema:
+		return g.compositeChain("OneOf", s.Schemas(), path, "oneof") + flags(s)
+	case *builders.NotSchema:
+		return "builders.Not(" + g.chain(s.InnerSchema(), path+"<not>") + ")" + flags(s)
+	case *builders.CustomSchema:
+		g.note(path, "custom schema, generated as a schema accepting any value")
+		return "builders.And()" + flags(s)
+	default:
+		g.note(path, "unsupported schema type %T, generated as a schema accepting any value", schema)
+		return "builders.And()" + flags(schema)
+	}
+}
+
+// hasCustomValidators reports whether schema has validators added with
+// Custom. The validators installed by Integer and by registered string
+// formats do not count.
+func hasCustomValidators(schema queryfy.Schema) bool {
+	v, ok := schema.(interface {
+		Validators() []queryfy.ValidatorFunc
+	})
+	if !ok {
+		return false
+	}
+	n := len(v.Validators())
+	switch s := schema.(type) {
+	case *builders.StringSchema:
+		if registeredFormat(s.FormatType()) {
+			n--
+		}
+	case *builders.NumberSchema:
+		if s.IsInteger() {
+			n--
+		}
+	}
+	return n > 0
+}
+
+// registeredFormat reports whether a string format is set with
+// FormatString rather than a dedicated builder method.
+func registeredFormat(format string) bool {
+	return format != "" && builtinFormatPattern(format) == ""
+}
+
+// builtinFormatPattern returns the pattern that Email, URL or UUID sets.
+func builtinFormatPattern(format string) string {
+	switch format {
+	case "email":
+		return builders.String().Email().PatternString()
+	case "url":
+		return builders.String().URL().PatternString()
+	case "uuid":
+		return builders.String().UUID().PatternString()
+	}
+	return ""
+}
+
+// flags renders Required and Nullable.
+func flags(schema queryfy.Schema) string {
+	var out string
+	if isRequired(schema) {
+		out += ".Required()"
+	}
+	if isNullable(schema) {
+		out += ".Nullable()"
+	}
+	return out
+}
+
+func (g *generator) stringChain(s *builders.StringSchema) string {
+	var b strings.Builder
+	b.WriteString("builders.String()")
+	min, max := s.LengthConstraints()
+	if min != nil {
+		fmt.Fprintf(&b, ".MinLength(%d)", *min)
+	}
+	if max != nil {
+		fmt.Fprintf(&b, ".MaxLength(%d)", *max)
+	}
+	if p := s.PatternString(); p != "" && p != builtinFormatPattern(s.FormatType()) {
+		fmt.Fprintf(&b, ".Pattern(%s)", stringLiteral(p))
+	}
+	if values := s.EnumValues(); len(values) > 0 {
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = strconv.Quote(v)
+		}
+		fmt.Fprintf(&b, ".Enum(%s)", strings.Join(quoted, ", "))
+	}
+	switch f := s.FormatType(); f {
+	case "":
+	case "email":
+		b.WriteString(".Email()")
+	case "url":
+		b.WriteString(".URL()")
+	case "uuid":
+		b.WriteString(".UUID()")
+	default:
+		fmt.Fprintf(&b, ".FormatString(%q)", f)
+	}
+	b.WriteString(flags(s))
+	return b.String()
+}
+
+func (g *generator) numberChain(s *builders.NumberSchema) string {
+	var b strings.Builder
+	b.WriteString("builders.Number()")
+	min, max := s.RangeConstraints()
+	if min != nil {
+		fmt.Fprintf(&b, ".Min(%s)", formatFloat(*min))
+	}
+	if max != nil {
+		fmt.Fprintf(&b, ".Max(%s)", formatFloat(*max))
+	}
+	if m := s.MultipleOfValue(); m != nil {
+		fmt.Fprintf(&b, ".MultipleOf(%s)", formatFloat(*m))
+	}
+	if s.IsInteger() {
+		b.WriteString(".Integer()")
+	}
+	if precision, scale, ok := s.DecimalConstraint(); ok {
+		fmt.Fprintf(&b, ".Decimal(%d, %d)", precision, scale)
+	}
+	b.WriteString(flags(s))
+	return b.String()
+}
+
+func (g *generator) dateTimeChain(s *builders.DateTimeSchema) string {
+	var b strings.Builder
+	b.WriteString("builders.DateTime()")
+	switch layout := s.FormatString(); layout {
+	case time.RFC3339:
+	case "2006-01-02":
+		b.WriteString(".DateOnly()")
+	default:
+		fmt.Fprintf(&b, ".Format(%q)", layout)
+	}
+	if s.IsStrictFormat() {
+		b.WriteString(".StrictFormat()")
+	}
+	min, max := s.TimeConstraints()
+	if min != nil {
+		fmt.Fprintf(&b, ".Min(%s)", g.timeLiteral(*min))
+	}
+	if max != nil {
+		fmt.Fprintf(&b, ".Max(%s)", g.timeLiteral(*max))
+	}
+	b.WriteString(flags(s))
+	return b.String()
+}
+
+func (g *generator) timeLiteral(t time.Time) string {
+	g.usesTime = true
+	t = t.UTC()
+	return fmt.Sprintf("time.Date(%d, %d, %d, %d, %d, %d, %d, time.UTC)",
+		t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond())
+}
+
+func (g *generator) arrayChain(s *builders.ArraySchema, path string) string {
+	var b strings.Builder
+	b.WriteString("builders.Array()")
+	if elem := s.ElementSchema(); elem != nil {
+		fmt.Fprintf(&b, ".Of(%s)", g.chain(elem, path+"[*]"))
+	}
+	min, max := s.ItemCountConstraints()
+	if min != nil {
+		fmt.Fprintf(&b, ".MinItems(%d)", *min)
+	}
+	if max != nil {
+		fmt.Fprintf(&b, ".MaxItems(%d)", *max)
+	}
+	if s.IsUniqueItems() {
+		b.WriteString(".UniqueItems()")
+	}
+	b.WriteString(flags(s))
+	return b.String()
+}
+
+// objectChain renders an object and its fields, one per line, without
+// its own Required/Nullable flags.
+func (g *generator) objectChain(s *builders.ObjectSchema, path string) string {
+	var b strings.Builder
+	b.WriteString("builders.Object()")
+	var extraRequired []string
+	for _, name := range s.FieldNames() {
+		field, _ := s.GetField(name)
+		if _, dependent := field.(*builders.DependentSchema); dependent {
+			continue
+		}
+		fmt.Fprintf(&b, ".\nField(%q, %s)", name, g.chain(field, joinPath(path, name)))
+	}
+	for _, name := range s.RequiredFieldNames() {
+		if field, ok := s.GetField(name); !ok || !isRequired(field) {
+			extraRequired = append(extraRequired, strconv.Quote(name))
+		}
+	}
+	if len(extraRequired) > 0 {
+		fmt.Fprintf(&b, ".\nRequiredFields(%s)", strings.Join(extraRequired, ", "))
+	}
+	if allow, explicit := s.AllowsAdditional(); explicit {
+		fmt.Fprintf(&b, ".\nAllowAdditional(%t)", allow)
+	}
+	return b.String()
+}
+
+func (g *generator) dependentObjectChain(s *builders.ObjectSchemaWithDependencies, path string) string {
+	var b strings.Builder
+	b.WriteString(g.objectChain(s.ObjectSchema, path))
+	b.WriteString(flags(s.ObjectSchema))
+	b.WriteString(".\nWithDependencies()")
+	for _, name := range s.DependentFieldNames() {
+		dep, _ := s.GetDependentField(name)
+		expr, ok := g.dependentChain(dep, joinPath(path, name))
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, ".\nDependentField(%q, %s)", name, expr)
+	}
+	return b.String()
+}
+
+// dependentChain renders a dependent rule. Rules with a function
+// condition are skipped.
+func (g *generator) dependentChain(dep *builders.DependentSchema, path string) (string, bool) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "builders.Dependent(%q)", dep.FieldName())
+	if on := dep.DependsOn(); len(on) > 0 {
+		quoted := make([]string, len(on))
+		for i, f := range on {
+			quoted[i] = strconv.Quote(f)
+		}
+		fmt.Fprintf(&b, ".On(%s)", strings.Join(quoted, ", "))
+	}
+	switch {
+	case dep.Condition() != nil:
+		cond, ok := conditionChain(dep.Condition())
+		if !ok {
+			g.note(path, "dependent rule with a function condition")
+			return "", false
+		}
+		fmt.Fprintf(&b, ".\nWhen(%s)", cond)
+	case dep.IfSchema() != nil:
+		fmt.Fprintf(&b, ".\nIf(%s)", g.chain(dep.IfSchema(), path+"<if>"))
+	}
+	if then := dep.ThenSchema(); then != nil {
+		fmt.Fprintf(&b, ".\nThen(%s)", g.chain(then, path+"<then>"))
+	}
+	if elseSchema := dep.ElseSchema(); elseSchema != nil {
+		fmt.Fprintf(&b, ".\nElse(%s)", g.chain(elseSchema, path+"<else>"))
+	}
+	if dep.IsRequired() {
+		b.WriteString(".\nRequired()")
+	}
+	if len(dep.Validators()) > 0 {
+		g.note(path, "custom validators")
+	}
+	return b.String(), true
+}
+
+// conditionChain renders a declarative condition with the When helpers.
+func conditionChain(c *builders.Condition) (string, bool) {
+	var args []string
+	for _, sub := range c.Conditions {
+		expr, ok := conditionChain(sub)
+		if !ok {
+			return "", false
+		}
+		args = append(args, expr)
+	}
+
+	field := strconv.Quote(c.Field)
+	switch c.Op {
+	case builders.OpEquals, builders.OpNotEquals:
+		value, ok := valueLiteral(c.Value)
+		if !ok {
+			return "", false
+		}
+		name := "WhenEquals"
+		if c.Op == builders.OpNotEquals {
+			name = "WhenNotEquals"
+		}
+		return fmt.Sprintf("builders.%s(%s, %s)", name, field, value), true
+	case builders.OpExists:
+		return fmt.Sprintf("builders.WhenExists(%s)", field), true
+	case builders.OpNotExists:
+		return fmt.Sprintf("builders.WhenNotExists(%s)", field), true
+	case builders.OpIn:
+		values := []string{field}
+		for _, v := range c.Values {
+			lit, ok := valueLiteral(v)
+			if !ok {
+				return "", false
+			}
+			values = append(values, lit)
+		}
+		return fmt.Sprintf("builders.WhenIn(%s)", strings.Join(values, ", ")), true
+	case builders.OpGreaterThan, builders.OpLessThan:
+		threshold, ok := c.Value.(float64)
+		if !ok {
+			return "", false
+		}
+		name := "WhenGreaterThan"
+		if c.Op == builders.OpLessThan {
+			name = "WhenLessThan"
+		}
+		return fmt.Sprintf("builders.%s(%s, %s)", name, field, formatFloat(threshold)), true
+	case builders.OpTrue:
+		return fmt.Sprintf("builders.WhenTrue(%s)", field), true
+	case builders.OpFalse:
+		return fmt.Sprintf("builders.WhenFalse(%s)", field), true
+	case builders.OpAll:
+		return "builders.WhenAll(" + strings.Join(args, ", ") + ")", true
+	case builders.OpAny:
+		return "builders.WhenAny(" + strings.Join(args, ", ") + ")", true
+	case builders.OpNot:
+		if len(args) != 1 {
+			return "", false
+		}
+		return "builders.WhenNot(" + args[0] + ")", true
+	default:
+		return "", false
+	}
+}
+
+// valueLiteral renders a condition value so that it keeps its Go type;
+// WhenEquals compares with reflect.DeepEqual.
+func valueLiteral(v interface{}) (string, bool) {
+	switch x := v.(type) {
+	case nil:
+		return "nil", true
+	case string:
+		return strconv.Quote(x), true
+	case bool:
+		return strconv.FormatBool(x), true
+	case int:
+		return strconv.Itoa(x), true
+	case float64:
+		return "float64(" + formatFloat(x) + ")", true
+	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32:
+		return fmt.Sprintf("%T(%v)", x, x), true
+	default:
+		return "", false
+	}
+}
+
+// refChain renders a use of a reference. The reference itself is
+// declared at the top of the schema function and resolved there.
+func (g *generator) refChain(s *builders.RefSchema, path string) string {
+	target := s.Target()
+	ref, ok := g.refByKey[target]
+	if !ok {
+		varName := uniqueName(unexportName(exportName(s.Name()))+"Ref", g.refVars)
+		g.refVars[varName] = true
+		ref = &refDecl{name: s.Name(), varName: varName}
+		g.refByKey[target] = ref
+		g.refs = append(g.refs, ref)
+		if target == nil {
+			g.note(path, "unresolved reference %q", s.Name())
+		} else {
+			ref.target = g.chain(target, path)
+		}
+	}
+
+	expr := ref.varName + ".Clone()"
+	if s.IsRequired() {
+		expr += ".Required()"
+	}
+	if s.BaseSchema.IsNullable() {
+		expr += ".Nullable()"
+	}
+	return expr
+}
+
+func (g *generator) compositeChain(name string, schemas []queryfy.Schema, path, label string) string {
+	parts := make([]string, len(schemas))
+	for i, sub := range schemas {
+		parts[i] = g.chain(sub, fmt.Sprintf("%s<%s[%d]>", path, label, i))
+	}
+	return "builders." + name + "(" + strings.Join(parts, ", ") + ")"
+}
+
+// stringLiteral prefers a raw string, which keeps regular expressions
+// readable.
+func stringLiteral(s string) string {
+	if !strings.Contains(s, "`") {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+// unexportName lower-cases the leading word of an exported identifier:
+// "Node" becomes "node" and "IDNumber" becomes "idNumber".
+func unexportName(name string) string {
+	r := []rune(name)
+	upper := 0
+	for upper < len(r) && unicode.IsUpper(r[upper]) {
+		upper++
+	}
+	if upper > 1 && upper < len(r) {
+		upper-- // the last capital starts the next word
+	}
+	return strings.ToLower(string(r[:upper])) + string(r[upper:])
+}
@@ -0,0 +1,202 @@
+// Package codegen generates Go source from a queryfy schema: struct
+// types matching the schema's objects, and a function that rebuilds the
+// schema with the builders package.
+//
+// Basic usage:
+//
+//	src, err := codegen.Generate(schema, &codegen.Options{
+//		Package:  "orders",
+//		TypeName: "Order",
+//	})
+//
+// The generated structs carry json tags and queryfy tags in the format
+// read by builders.FromStruct. Parts of a schema that are Go functions
+// (custom validators, transformers and function conditions) cannot
+// be reproduced; they are listed in the generated function's comment.
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+)
+
+// Options controls code generation.
+type Options struct {
+	// Package is the package name of the generated file. Defaults to
+	// "schemas".
+	Package string
+
+	// TypeName is the name of the struct generated for the root object.
+	// Defaults to "Root".
+	TypeName string
+
+	// FuncName is the name of the function that builds the schema.
+	// Defaults to "New" + TypeName + "Schema".
+	FuncName string
+}
+
+// Generate returns gofmt-formatted Go source for schema. The root schema
+// must be an object, or a reference to or transform of one. A nil opts
+// uses default settings.
+func Generate(schema queryfy.Schema, opts *Options) ([]byte, error) {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Package == "" {
+		o.Package = "schemas"
+	}
+	if o.TypeName == "" {
+		o.TypeName = "Root"
+	}
+	if o.FuncName == "" {
+		o.FuncName = "New" + o.TypeName + "Schema"
+	}
+
+	if !isObject(schema) {
+		return nil, fmt.Errorf("codegen: root schema must be an object, got %v", schema.Type())
+	}
+
+	g := newGenerator()
+	g.typeNames[o.TypeName] = true
+	g.structFor(schema, o.TypeName, "")
+	root := g.chain(schema, "")
+
+	var b strings.Builder
+	b.WriteString("// Code generated by queryfy-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", o.Package)
+	g.writeImports(&b, schema)
+	for _, decl := range g.types {
+		decl.write(&b)
+	}
+	g.writeFunc(&b, o.FuncName, schema, root)
+
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("codegen: formatting generated source: %v", err)
+	}
+	return src, nil
+}
+
+// generator holds the state of one Generate call.
+type generator struct {
+	types     []*typeDecl
+	typeNames map[string]bool
+	// objects maps each object schema to its struct type, so that an
+	// object shared between fields is generated once.
+	objects map[queryfy.Schema]string
+
+	refs     []*refDecl
+	refByKey map[queryfy.Schema]*refDecl
+	refVars  map[string]bool
+
+	notes       []string
+	usesTime    bool
+	usesQueryfy bool
+}
+
+// refDecl is a reference declared at the top of the schema function.
+type refDecl struct {
+	name    string // the reference name
+	varName string
+	target  string // expression for the target, filled in once built
+}
+
+func newGenerator() *generator {
+	return &generator{
+		typeNames: make(map[string]bool),
+		objects:   make(map[queryfy.Schema]string),
+		refByKey:  make(map[queryfy.Schema]*refDecl),
+		refVars:   make(map[string]bool),
+	}
+}
+
+func (g *generator) note(path, format string, args ...interface{}) {
+	if path == "" {
+		path = "(root)"
+	}
+	g.notes = append(g.notes, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (g *generator) writeImports(b *strings.Builder, root queryfy.Schema) {
+	b.WriteString("import (\n")
+	if g.usesTime {
+		b.WriteString("\t\"time\"\n\n")
+	}
+	if g.usesQueryfy || rootReturnType(root) == "queryfy.Schema" {
+		b.WriteString("\t\"github.com/ha1tch/queryfy\"\n")
+	}
+	b.WriteString("\t\"github.com/ha1tch/queryfy/builders\"\n")
+	b.WriteString(")\n\n")
+}
+
+func (g *generator) writeFunc(b *strings.Builder, name string, root queryfy.Schema, expr string) {
+	fmt.Fprintf(b, "// %s builds the schema the generated types were derived from.\n", name)
+	if len(g.notes) > 0 {
+		b.WriteString("//\n// Not generated, because they are Go functions:\n")
+		for _, n := range g.notes {
+			fmt.Fprintf(b, "//   - %s\n", n)
+		}
+	}
+	fmt.Fprintf(b, "func %s() %s {\n", name, rootReturnType(root))
+	for _, ref := range g.refs {
+		fmt.Fprintf(b, "%s := builders.Ref(%q)\n", ref.varName, ref.name)
+	}
+	for _, ref := range g.refs {
+		fmt.Fprintf(b, "%s.Resolve(%s)\n", ref.varName, ref.target)
+	}
+	fmt.Fprintf(b, "return %s\n}\n", expr)
+}
+
+// rootReturnType is the declared result type of the schema function.
+func rootReturnType(root queryfy.Schema) string {
+	switch root.(type) {
+	case *builders.ObjectSchema:
+		return "*builders.ObjectSchema"
+	case *builders.ObjectSchemaWithDependencies:
+		return "*builders.ObjectSchemaWithDependencies"
+	case *builders.RefSchema:
+		return "*builders.RefSchema"
+	default:
+		return "queryfy.Schema"
+	}
+}
+
+// isObject reports whether schema describes an object, looking through
+// references and transforms.
+func isObject(schema queryfy.Schema) bool {
+	switch s := schema.(type) {
+	case *builders.ObjectSchema, *builders.ObjectSchemaWithDependencies:
+		return true
+	case *builders.RefSchema:
+		return s.Target() != nil && isObject(s.Target())
+	case *builders.TransformSchema:
+		return isObject(s.InnerSchema())
+	}
+	return false
+}
+
+func isRequired(schema queryfy.Schema) bool {
+	if r, ok := schema.(interface{ IsRequired() bool }); ok {
+		return r.IsRequired()
+	}
+	return false
+}
+
+func isNullable(schema queryfy.Schema) bool {
+	if n, ok := schema.(interface{ IsNullable() bool }); ok {
+		return n.IsNullable()
+	}
+	return false
+}
+
+func joinPath(base, name string) string {
+	if base == "" {
+		return name
+	}
+	return base + "." + name
+}
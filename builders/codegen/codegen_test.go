@@ -0,0 +1,266 @@
+package codegen_test
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+	"github.com/ha1tch/queryfy/builders/codegen"
+	"github.com/ha1tch/queryfy/builders/jsonschema"
+)
+
+// generate runs Generate and checks that the result parses as Go.
+func generate(t *testing.T, schema queryfy.Schema, opts *codegen.Options) string {
+	t.Helper()
+	src, err := codegen.Generate(schema, opts)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "gen.go", src, parser.ParseComments); err != nil {
+		t.Fatalf("generated source does not parse: %v\n%s", err, src)
+	}
+	return string(src)
+}
+
+// squash collapses whitespace runs, so that expectations do not depend
+// on gofmt's alignment.
+func squash(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func expectContains(t *testing.T, src string, parts ...string) {
+	t.Helper()
+	for _, p := range parts {
+		if !strings.Contains(squash(src), squash(p)) {
+			t.Errorf("generated source lacks %q:\n%s", p, src)
+		}
+	}
+}
+
+func expectLacks(t *testing.T, src string, parts ...string) {
+	t.Helper()
+	for _, p := range parts {
+		if strings.Contains(src, p) {
+			t.Errorf("generated source unexpectedly contains %q:\n%s", p, src)
+		}
+	}
+}
+
+// ======================================================================
+// Struct types
+// ======================================================================
+
+func TestGenerate_Structs(t *testing.T) {
+	schema := builders.Object().
+		Field("id", builders.String().UUID().Required()).
+		Field("user_name", builders.String().MinLength(3).MaxLength(20).Pattern(`^[a-z_,]+$`)).
+		Field("age", builders.Number().Integer().Min(0).Max(150)).
+		Field("score", builders.Number().Nullable()).
+		Field("role", builders.String().Enum("admin", "user")).
+		Field("tags", builders.Array().Of(builders.String()).MinItems(1).UniqueItems()).
+		Field("born", builders.DateTime().DateOnly().Min(time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC))).
+		Field("address", builders.Object().
+			Field("street", builders.String().Required())).
+		Field("items", builders.Array().Of(builders.Object().
+			Field("sku", builders.String().Required()))).
+		Field("labels", builders.Object().AllowAdditional(true))
+
+	src := generate(t, schema, &codegen.Options{Package: "users", TypeName: "User"})
+	expectContains(t, src,
+		"// Code generated by queryfy-gen. DO NOT EDIT.",
+		"package users",
+		"type User struct",
+		"ID ", "`json:\"id\" queryfy:\"required,format=uuid\"`",
+		"UserName ", "`json:\"user_name,omitempty\" queryfy:\"min=3,max=20,pattern=^[a-z_,]+$\"`",
+		"Age ", "int64",
+		"Score ", "*float64",
+		"`json:\"role,omitempty\" queryfy:\"enum=admin|user\"`",
+		"[]string", "queryfy:\"min=1,unique\"",
+		"time.Time", "queryfy:\"format=date,min=1900-01-01T00:00:00Z\"",
+		"Address Address",
+		"type Address struct",
+		"Items []Item",
+		"type Item struct",
+		"map[string]interface{}",
+		"func NewUserSchema() *builders.ObjectSchema",
+	)
+	expectLacks(t, src, "Not generated")
+}
+
+func TestGenerate_Options(t *testing.T) {
+	schema := builders.Object().Field("name", builders.String())
+
+	src := generate(t, schema, nil)
+	expectContains(t, src, "package schemas", "type Root struct", "func NewRootSchema()")
+
+	src = generate(t, schema, &codegen.Options{FuncName: "Schema"})
+	expectContains(t, src, "func Schema() *builders.ObjectSchema")
+}
+
+func TestGenerate_NotObject(t *testing.T) {
+	if _, err := codegen.Generate(builders.String(), nil); err == nil {
+		t.Error("expected an error for a non-object root schema")
+	}
+}
+
+// ======================================================================
+// Builder chains
+// ======================================================================
+
+func TestGenerate_Chain(t *testing.T) {
+	schema := builders.Object().
+		Field("email", builders.String().Email().Required()).
+		Field("code", builders.String().Pattern("a`b")).
+		Field("price", builders.Number().Min(0.5).MultipleOf(0.01)).
+		Field("count", builders.Number().Integer()).
+		Field("active", builders.Bool().Const(true)).
+		Field("at", builders.DateTime().StrictFormat().Max(time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC))).
+		Field("id", builders.Or(builders.String(), builders.Number()).Required()).
+		Field("other", builders.Not(builders.String())).
+		RequiredFields("extra").
+		AllowAdditional(false)
+
+	src := generate(t, schema, nil)
+	expectContains(t, src,
+		`Field("email", builders.String().Email().Required())`,
+		`Field("code", builders.String().Pattern("a`+"`"+`b"))`,
+		`Field("price", builders.Number().Min(0.5).MultipleOf(0.01))`,
+		`Field("count", builders.Number().Integer())`,
+		`Field("active", builders.Bool().Const(true))`,
+		`builders.DateTime().StrictFormat().Max(time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC))`,
+		`builders.Or(builders.String(), builders.Number()).Required()`,
+		`builders.Not(builders.String())`,
+		`RequiredFields("extra")`,
+		`AllowAdditional(false)`,
+		`"time"`,
+		"Extra interface{} `json:\"extra\" queryfy:\"required\"`",
+	)
+	// Email sets its own pattern
+	expectLacks(t, src, "Email().Pattern", "Not generated")
+}
+
+func TestGenerate_Dependencies(t *testing.T) {
+	schema := builders.Object().
+		Field("payment", builders.String().Enum("card", "cash")).
+		Field("total", builders.Number()).
+		WithDependencies().
+		DependentField("card", builders.Dependent("card").
+			On("payment").
+			When(builders.WhenEquals("payment", "card")).
+			Then(builders.String().Length(16).Required())).
+		DependentField("approval", builders.Dependent("approval").
+			When(builders.WhenAll(builders.WhenGreaterThan("total", 1000), builders.WhenNot(builders.WhenExists("coupon")))).
+			Then(builders.Bool().Required())).
+		DependentField("note", builders.Dependent("note").
+			When(builders.WhenFunc(func(map[string]interface{}) bool { return true })).
+			Then(builders.String().Required()))
+
+	src := generate(t, schema, nil)
+	expectContains(t, src,
+		"func NewRootSchema() *builders.ObjectSchemaWithDependencies",
+		`DependentField("card", builders.Dependent("card").On("payment").`,
+		`When(builders.WhenEquals("payment", "card"))`,
+		`Then(builders.String().MinLength(16).MaxLength(16).Required())`,
+		`When(builders.WhenAll(builders.WhenGreaterThan("total", 1000), builders.WhenNot(builders.WhenExists("coupon"))))`,
+		"Card string `json:\"card,omitempty\"`",
+		"Approval bool `json:\"approval,omitempty\"`",
+		"Not generated, because they are Go functions:",
+		"note: dependent rule with a function condition",
+	)
+	expectLacks(t, src, `DependentField("note"`)
+}
+
+func TestGenerate_ConditionValueTypes(t *testing.T) {
+	schema := builders.Object().
+		Field("level", builders.Number()).
+		WithDependencies().
+		DependentField("a", builders.Dependent("a").
+			When(builders.WhenEquals("level", 3.0)).
+			Then(builders.String())).
+		DependentField("b", builders.Dependent("b").
+			When(builders.WhenIn("level", 1, 2)).
+			Then(builders.String()))
+
+	src := generate(t, schema, nil)
+	// WhenEquals compares with reflect.DeepEqual, so the type must survive
+	expectContains(t, src, `builders.WhenEquals("level", float64(3))`, `builders.WhenIn("level", 1, 2)`)
+}
+
+func TestGenerate_Notes(t *testing.T) {
+	schema := builders.Object().
+		Field("name", builders.Transform(builders.String()).Add(func(v interface{}) (interface{}, error) { return v, nil })).
+		Field("code", builders.String().Custom(func(interface{}) error { return nil })).
+		Field("blob", builders.Custom(func(interface{}) error { return nil }))
+
+	src := generate(t, schema, nil)
+	expectContains(t, src,
+		"name: transformers",
+		"code: custom validators",
+		"blob: custom schema",
+		`Field("name", builders.String())`,
+		`Field("blob", builders.And())`,
+	)
+}
+
+// ======================================================================
+// References and JSON Schema input
+// ======================================================================
+
+func TestGenerate_RecursiveRef(t *testing.T) {
+	node := builders.Ref("tree_node")
+	node.Resolve(builders.Object().
+		Field("name", builders.String().Required()).
+		Field("children", builders.Array().Of(node.Clone())))
+	schema := builders.Object().Field("root", node.Clone().Required())
+
+	src := generate(t, schema, nil)
+	expectContains(t, src,
+		"type TreeNode struct",
+		"Children []*TreeNode",
+		"Root *TreeNode",
+		`treeNodeRef := builders.Ref("tree_node")`,
+		`treeNodeRef.Resolve(builders.Object().`,
+		`Field("children", builders.Array().Of(treeNodeRef.Clone()))`,
+		`Field("root", treeNodeRef.Clone().Required())`,
+	)
+	if strings.Count(src, "type TreeNode struct") != 1 {
+		t.Errorf("TreeNode declared more than once:\n%s", src)
+	}
+}
+
+func TestGenerate_FromJSONSchema(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {
+			"orderId": {"type": "string"},
+			"lines": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {"qty": {"type": "integer", "minimum": 1}},
+					"required": ["qty"]
+				}
+			},
+			"coupon": {"type": ["string", "null"]}
+		},
+		"required": ["orderId"]
+	}`), nil)
+	for _, e := range errs {
+		if !e.IsWarning {
+			t.Fatalf("import: %v", e)
+		}
+	}
+
+	src := generate(t, schema, &codegen.Options{TypeName: "Order"})
+	expectContains(t, src,
+		"OrderID string `json:\"orderId\" queryfy:\"required\"`",
+		"Lines []Line",
+		"Qty int64 `json:\"qty\" queryfy:\"required,min=1\"`",
+		"Coupon *string",
+		`Field("coupon", builders.String().Nullable())`,
+	)
+}
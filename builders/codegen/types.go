@@ -0,0 +1,389 @@
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+)
+
+// typeDecl is a generated struct type.
+type typeDecl struct {
+	name   string
+	path   string
+	fields []fieldDecl
+}
+
+// fieldDecl is one field of a generated struct.
+type fieldDecl struct {
+	name   string
+	goType string
+	tag    string
+}
+
+func (d *typeDecl) write(b *strings.Builder) {
+	if d.path == "" {
+		fmt.Fprintf(b, "// %s is the root object of the schema.\n", d.name)
+	} else {
+		fmt.Fprintf(b, "// %s is the object at %s.\n", d.name, d.path)
+	}
+	fmt.Fprintf(b, "type %s struct {\n", d.name)
+	for _, f := range d.fields {
+		fmt.Fprintf(b, "%s %s %s\n", f.name, f.goType, f.tag)
+	}
+	b.WriteString("}\n\n")
+}
+
+// structFor returns the struct type for an object schema, generating it
+// on first use. name is the preferred type name.
+func (g *generator) structFor(schema queryfy.Schema, name, path string) string {
+	for {
+		switch s := schema.(type) {
+		case *builders.RefSchema:
+			schema = s.Target()
+			continue
+		case *builders.TransformSchema:
+			schema = s.InnerSchema()
+			continue
+		}
+		break
+	}
+	if existing, ok := g.objects[schema]; ok {
+		return existing
+	}
+
+	var obj *builders.ObjectSchema
+	var deps *builders.ObjectSchemaWithDependencies
+	switch s := schema.(type) {
+	case *builders.ObjectSchema:
+		obj = s
+	case *builders.ObjectSchemaWithDependencies:
+		obj, deps = s.ObjectSchema, s
+	}
+
+	decl := &typeDecl{name: name, path: path}
+	g.typeNames[name] = true
+	g.objects[schema] = name
+	g.types = append(g.types, decl)
+
+	goNames := make(map[string]bool)
+	addField := func(jsonName string, field queryfy.Schema, required bool, tagged bool) {
+		goName := uniqueName(exportName(jsonName), goNames)
+		goNames[goName] = true
+		fieldPath := joinPath(path, jsonName)
+
+		goType := g.fieldType(field, goName, name, fieldPath)
+		jsonTag := jsonName
+		if !required {
+			jsonTag += ",omitempty"
+		}
+		tag := "json:" + strconv.Quote(jsonTag)
+		if tagged {
+			if opts := structTagOptions(field, required, goType); opts != "" {
+				tag += " queryfy:" + strconv.Quote(opts)
+			}
+		}
+		decl.fields = append(decl.fields, fieldDecl{name: goName, goType: goType, tag: tagLiteral(tag)})
+	}
+
+	requiredNames := make(map[string]bool)
+	for _, n := range obj.RequiredFieldNames() {
+		requiredNames[n] = true
+	}
+	for _, fieldName := range obj.FieldNames() {
+		field, _ := obj.GetField(fieldName)
+		if _, dependent := field.(*builders.DependentSchema); dependent {
+			continue
+		}
+		addField(fieldName, field, requiredNames[fieldName], true)
+	}
+	// Required names without a field schema still need a struct field
+	for _, fieldName := range obj.RequiredFieldNames() {
+		if _, ok := obj.GetField(fieldName); !ok {
+			addField(fieldName, nil, true, true)
+		}
+	}
+
+	if deps != nil {
+		for _, fieldName := range deps.DependentFieldNames() {
+			if field, ok := obj.GetField(fieldName); ok {
+				if _, dependent := field.(*builders.DependentSchema); !dependent {
+					continue // the base field already declares it
+				}
+			}
+			dep, _ := deps.GetDependentField(fieldName)
+			branch := dep.ThenSchema()
+			if branch == nil {
+				branch = dep.ElseSchema()
+			}
+			// Conditional constraints cannot be expressed in a tag
+			addField(fieldName, branch, false, false)
+		}
+	}
+	return name
+}
+
+// fieldType returns the Go type for a field. Nullable fields become
+// pointers unless the type already has a nil value.
+func (g *generator) fieldType(schema queryfy.Schema, goName, parent, path string) string {
+	t := g.goType(schema, goName, parent, path)
+	if schema == nil || strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map[") || t == "interface{}" || strings.HasPrefix(t, "*") {
+		return t
+	}
+	if isNullable(schema) {
+		return "*" + t
+	}
+	return t
+}
+
+// goType maps a schema to a Go type. hint and parent are used to name
+// nested struct types.
+func (g *generator) goType(schema queryfy.Schema, hint, parent, path string) string {
+	switch s := schema.(type) {
+	case *builders.StringSchema:
+		return "string"
+	case *builders.NumberSchema:
+		if s.IsInteger() {
+			return "int64"
+		}
+		return "float64"
+	case *builders.BoolSchema:
+		return "bool"
+	case *builders.DateTimeSchema:
+		g.usesTime = true
+		return "time.Time"
+	case *builders.ArraySchema:
+		elem := s.ElementSchema()
+		if elem == nil {
+			return "[]interface{}"
+		}
+		return "[]" + g.fieldType(elem, singular(hint), parent, path+"[*]")
+	case *builders.ObjectSchema:
+		if len(s.FieldNames()) == 0 && len(s.RequiredFieldNames()) == 0 {
+			return "map[string]interface{}"
+		}
+		return g.structFor(s, g.typeName(hint, parent, s), path)
+	case *builders.ObjectSchemaWithDependencies:
+		return g.structFor(s, g.typeName(hint, parent, s), path)
+	case *builders.RefSchema:
+		target := s.Target()
+		if target == nil {
+			return "interface{}"
+		}
+		if !isObject(target) {
+			return g.goType(target, hint, parent, path)
+		}
+		// A pointer, since references may be recursive
+		return "*" + g.structFor(target, g.typeName(exportName(s.Name()), "", target), path)
+	case *builders.TransformSchema:
+		return g.goType(s.InnerSchema(), hint, parent, path)
+	default:
+		return "interface{}"
+	}
+}
+
+// typeName picks an unused type name for an object, preferring hint,
+// then parent+hint.
+func (g *generator) typeName(hint, parent string, schema queryfy.Schema) string {
+	if existing, ok := g.objects[schema]; ok {
+		return existing
+	}
+	if hint == "" {
+		hint = "Object"
+	}
+	if !g.typeNames[hint] {
+		return hint
+	}
+	return uniqueName(parent+hint, g.typeNames)
+}
+
+// structTagOptions renders a schema's constraints as a queryfy tag for
+// builders.FromStruct.
+func structTagOptions(schema queryfy.Schema, required bool, goType string) string {
+	var opts []string
+	if required {
+		opts = append(opts, "required")
+	}
+	if schema == nil {
+		return strings.Join(opts, ",")
+	}
+	if isNullable(schema) && !strings.HasPrefix(goType, "*") && goType != "interface{}" {
+		opts = append(opts, "nullable")
+	}
+	if t, ok := schema.(*builders.TransformSchema); ok {
+		schema = t.InnerSchema()
+	}
+
+	var pattern string
+	switch s := schema.(type) {
+	case *builders.StringSchema:
+		min, max := s.LengthConstraints()
+		opts = appendLength(opts, min, max)
+		if values := s.EnumValues(); len(values) > 0 && tagSafe(values) {
+			opts = append(opts, "enum="+strings.Join(values, "|"))
+		}
+		if f := s.FormatType(); f != "" {
+			opts = append(opts, "format="+f)
+		}
+		if p := s.PatternString(); p != builtinFormatPattern(s.FormatType()) {
+			pattern = p
+		}
+	case *builders.NumberSchema:
+		min, max := s.RangeConstraints()
+		if min != nil {
+			opts = append(opts, "min="+formatFloat(*min))
+		}
+		if max != nil {
+			opts = append(opts, "max="+formatFloat(*max))
+		}
+		if m := s.MultipleOfValue(); m != nil {
+			opts = append(opts, "multipleOf="+formatFloat(*m))
+		}
+	case *builders.ArraySchema:
+		min, max := s.ItemCountConstraints()
+		opts = appendLength(opts, min, max)
+		if s.IsUniqueItems() {
+			opts = append(opts, "unique")
+		}
+	case *builders.DateTimeSchema:
+		switch layout := s.FormatString(); {
+		case layout == "2006-01-02":
+			opts = append(opts, "format=date")
+		case layout != time.RFC3339 && !strings.Contains(layout, ","):
+			opts = append(opts, "format="+layout)
+		}
+		min, max := s.TimeConstraints()
+		if min != nil {
+			opts = append(opts, "min="+min.Format(time.RFC3339))
+		}
+		if max != nil {
+			opts = append(opts, "max="+max.Format(time.RFC3339))
+		}
+	}
+	if pattern != "" {
+		// Must come last: the pattern may contain commas
+		opts = append(opts, "pattern="+pattern)
+	}
+	return strings.Join(opts, ",")
+}
+
+func appendLength(opts []string, min, max *int) []string {
+	switch {
+	case min != nil && max != nil && *min == *max:
+		return append(opts, "len="+strconv.Itoa(*min))
+	}
+	if min != nil {
+		opts = append(opts, "min="+strconv.Itoa(*min))
+	}
+	if max != nil {
+		opts = append(opts, "max="+strconv.Itoa(*max))
+	}
+	return opts
+}
+
+// tagSafe reports whether enum values can be written in a tag.
+func tagSafe(values []string) bool {
+	for _, v := range values {
+		if strings.ContainsAny(v, ",|") {
+			return false
+		}
+	}
+	return true
+}
+
+// tagLiteral quotes a struct tag, preferring a raw string.
+func tagLiteral(tag string) string {
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// commonInitialisms are written in upper case in Go identifiers.
+var commonInitialisms = map[string]bool{
+	"api": true, "css": true, "html": true, "http": true, "https": true,
+	"id": true, "ip": true, "json": true, "sql": true, "uri": true,
+	"url": true, "utc": true, "uuid": true, "xml": true,
+}
+
+// exportName turns a JSON property name into an exported Go identifier:
+// "first_name" and "firstName" become FirstName, "userId" becomes UserID.
+func exportName(name string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	var b strings.Builder
+	for _, w := range words {
+		lower := strings.ToLower(w)
+		if commonInitialisms[lower] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		r := []rune(w)
+		b.WriteRune(unicode.ToUpper(r[0]))
+		b.WriteString(string(r[1:]))
+	}
+	out := b.String()
+	if out == "" {
+		return "Field"
+	}
+	if unicode.IsDigit([]rune(out)[0]) {
+		out = "X" + out
+	}
+	return out
+}
+
+// uniqueName returns name, or name with a numeric suffix, so that it is
+// not in used.
+func uniqueName(name string, used map[string]bool) string {
+	if !used[name] {
+		return name
+	}
+	for i := 2; ; i++ {
+		candidate := name + strconv.Itoa(i)
+		if !used[candidate] {
+			return candidate
+		}
+	}
+}
+
+// singular makes a best-effort singular of a plural field name, for
+// naming array element types.
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 4:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") && len(name) > 3:
+		return name[:len(name)-1]
+	}
+	return name + "Item"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
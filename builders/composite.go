@@ -143,7 +143,7 @@ func (s *OrSchema) Validate(value interface{}, ctx *queryfy.ValidationContext) e
 		for len(ctx.Errors()) > originalErrorCount {
 			// In a real implementation, we'd have a method to pop errors
 		}
-		ctx.AddError("none of the validators passed", value)
+		ctx.AddCodedError(queryfy.CodeAnyOf, nil, value)
 	}
 
 	return nil
@@ -154,6 +154,81 @@ func (s *OrSchema) Type() queryfy.SchemaType {
 	return queryfy.TypeComposite
 }
 
+// OneOfSchema validates that exactly one schema passes.
+type OneOfSchema struct {
+	queryfy.BaseSchema
+	schemas []queryfy.Schema
+}
+
+// OneOf creates a new exclusive schema that requires exactly one
+// sub-schema to pass. Unlike Or, a value matching two or more
+// sub-schemas is rejected.
+func OneOf(schemas ...queryfy.Schema) *OneOfSchema {
+	return &OneOfSchema{
+		BaseSchema: queryfy.BaseSchema{
+			SchemaType: queryfy.TypeComposite,
+		},
+		schemas: schemas,
+	}
+}
+
+// Required marks the field as required.
+func (s *OneOfSchema) Required() *OneOfSchema {
+	s.SetRequired(true)
+	return s
+}
+
+// Optional marks the field as optional (default).
+func (s *OneOfSchema) Optional() *OneOfSchema {
+	s.SetRequired(false)
+	return s
+}
+
+// Nullable allows the field to be null.
+func (s *OneOfSchema) Nullable() *OneOfSchema {
+	s.SetNullable(true)
+	return s
+}
+
+// Schemas returns the list of sub-schemas in this OneOf composite.
+func (s *OneOfSchema) Schemas() []queryfy.Schema {
+	return s.schemas
+}
+
+// Validate implements the Schema interface.
+func (s *OneOfSchema) Validate(value interface{}, ctx *queryfy.ValidationContext) error {
+	if !s.CheckRequired(value, ctx) {
+		return nil
+	}
+
+	if len(s.schemas) == 0 {
+		return nil
+	}
+
+	// Every sub-schema has to be tried: a second match is a failure
+	matched := 0
+	for _, schema := range s.schemas {
+		tempCtx := queryfy.NewValidationContext(ctx.Mode())
+		if err := schema.Validate(value, tempCtx); err == nil && !tempCtx.HasErrors() {
+			matched++
+		}
+	}
+
+	switch {
+	case matched == 0:
+		ctx.AddCodedError(queryfy.CodeAnyOf, nil, value)
+	case matched > 1:
+		ctx.AddCodedError(queryfy.CodeOneOf, queryfy.Params{"matched": matched}, value)
+	}
+
+	return nil
+}
+
+// Type implements the Schema interface.
+func (s *OneOfSchema) Type() queryfy.SchemaType {
+	return queryfy.TypeComposite
+}
+
 // NotSchema inverts the result of another schema.
 type NotSchema struct {
 	queryfy.BaseSchema
@@ -204,7 +279,7 @@ func (s *NotSchema) Validate(value interface{}, ctx *queryfy.ValidationContext)
 
 	if err := s.schema.Validate(value, tempCtx); err == nil && !tempCtx.HasErrors() {
 		// Schema passed, but NOT means it should fail
-		ctx.AddError("value must not match the validation", value)
+		ctx.AddCodedError(queryfy.CodeNot, nil, value)
 	}
 
 	return nil
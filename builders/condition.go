@@ -0,0 +1,387 @@
+// condition.go - Declarative conditions for dependent fields
+package builders
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ha1tch/queryfy"
+)
+
+// DependencyCondition is a function that receives the parent object and
+// returns whether a dependent rule applies. Function conditions are
+// opaque: Equal treats each one as distinct from every other, and they
+// cannot be exported or serialised. The When* helpers build a declarative *Condition instead,
+// and WhenFunc places a function inside one.
+type DependencyCondition func(parentData map[string]interface{}) bool
+
+// ConditionOp identifies the kind of a Condition node.
+type ConditionOp string
+
+// Condition operators. Leaf operators test one field of the parent
+// object; OpAll, OpAny and OpNot combine other conditions.
+const (
+	OpEquals      ConditionOp = "equals"
+	OpNotEquals   ConditionOp = "not_equals"
+	OpExists      ConditionOp = "exists"
+	OpNotExists   ConditionOp = "not_exists"
+	OpIn          ConditionOp = "in"
+	OpGreaterThan ConditionOp = "greater_than"
+	OpLessThan    ConditionOp = "less_than"
+	OpTrue        ConditionOp = "true"
+	OpFalse       ConditionOp = "false"
+	OpAll         ConditionOp = "all"
+	OpAny         ConditionOp = "any"
+	OpNot         ConditionOp = "not"
+	OpFunc        ConditionOp = "func"
+)
+
+// Condition is a node in a declarative condition tree. Trees are built
+// with the When* helpers and serialise to JSON as
+//
+//	{"op": "all", "conditions": [
+//	    {"op": "equals", "field": "country", "value": "US"},
+//	    {"op": "true", "field": "hasAddress"}
+//	]}
+//
+// An OpFunc node wraps a DependencyCondition and is the only kind that
+// cannot be serialised.
+type Condition struct {
+	Op         ConditionOp
+	Field      string        // leaf operators
+	Value      interface{}   // OpEquals, OpNotEquals; a number for OpGreaterThan, OpLessThan
+	Values     []interface{} // OpIn
+	Conditions []*Condition  // OpAll, OpAny; exactly one for OpNot
+	fn         DependencyCondition
+}
+
+// Evaluate reports whether the condition holds for the parent object.
+func (c *Condition) Evaluate(data map[string]interface{}) bool {
+	switch c.Op {
+	case OpEquals:
+		fieldValue, exists := data[c.Field]
+		return exists && reflect.DeepEqual(fieldValue, c.Value)
+	case OpNotEquals:
+		fieldValue, exists := data[c.Field]
+		return !exists || !reflect.DeepEqual(fieldValue, c.Value)
+	case OpExists:
+		value, exists := data[c.Field]
+		return exists && value != nil
+	case OpNotExists:
+		value, exists := data[c.Field]
+		return !exists || value == nil
+	case OpIn:
+		if fieldValue, exists := data[c.Field]; exists {
+			for _, v := range c.Values {
+				if reflect.DeepEqual(fieldValue, v) {
+					return true
+				}
+			}
+		}
+		return false
+	case OpGreaterThan, OpLessThan:
+		fieldValue, exists := data[c.Field]
+		if !exists {
+			return false
+		}
+		cmp, ok := compareConditionNumbers(fieldValue, c.Value)
+		if !ok {
+			return false
+		}
+		if c.Op == OpGreaterThan {
+			return cmp > 0
+		}
+		return cmp < 0
+	case OpTrue, OpFalse:
+		boolVal, ok := data[c.Field].(bool)
+		return ok && boolVal == (c.Op == OpTrue)
+	case OpAll:
+		for _, sub := range c.Conditions {
+			if !sub.Evaluate(data) {
+				return false
+			}
+		}
+		return true
+	case OpAny:
+		for _, sub := range c.Conditions {
+			if sub.Evaluate(data) {
+				return true
+			}
+		}
+		return false
+	case OpNot:
+		return len(c.Conditions) == 1 && !c.Conditions[0].Evaluate(data)
+	case OpFunc:
+		return c.fn != nil && c.fn(data)
+	default:
+		return false
+	}
+}
+
+// IsDeclarative reports whether the tree contains no function
+// conditions, i.e. whether it can be compared, exported and serialised.
+func (c *Condition) IsDeclarative() bool {
+	if c.Op == OpFunc {
+		return false
+	}
+	for _, sub := range c.Conditions {
+		if !sub.IsDeclarative() {
+			return false
+		}
+	}
+	return true
+}
+
+// Fields returns the parent fields the condition reads, sorted.
+func (c *Condition) Fields() []string {
+	seen := make(map[string]bool)
+	var collect func(*Condition)
+	collect = func(n *Condition) {
+		if n.Field != "" {
+			seen[n.Field] = true
+		}
+		for _, sub := range n.Conditions {
+			collect(sub)
+		}
+	}
+	collect(c)
+
+	fields := make([]string, 0, len(seen))
+	for f := range seen {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// String renders the condition as an expression, for example
+// `(country == "US" && hasAddress == true)`.
+func (c *Condition) String() string {
+	switch c.Op {
+	case OpEquals:
+		return fmt.Sprintf("%s == %s", c.Field, formatConditionValue(c.Value))
+	case OpNotEquals:
+		return fmt.Sprintf("%s != %s", c.Field, formatConditionValue(c.Value))
+	case OpExists:
+		return fmt.Sprintf("exists(%s)", c.Field)
+	case OpNotExists:
+		return fmt.Sprintf("!exists(%s)", c.Field)
+	case OpIn:
+		vals := make([]string, len(c.Values))
+		for i, v := range c.Values {
+			vals[i] = formatConditionValue(v)
+		}
+		return fmt.Sprintf("%s in [%s]", c.Field, strings.Join(vals, ", "))
+	case OpGreaterThan:
+		return fmt.Sprintf("%s > %s", c.Field, formatConditionValue(c.Value))
+	case OpLessThan:
+		return fmt.Sprintf("%s < %s", c.Field, formatConditionValue(c.Value))
+	case OpTrue:
+		return fmt.Sprintf("%s == true", c.Field)
+	case OpFalse:
+		return fmt.Sprintf("%s == false", c.Field)
+	case OpAll, OpAny:
+		sep := " && "
+		if c.Op == OpAny {
+			sep = " || "
+		}
+		parts := make([]string, len(c.Conditions))
+		for i, sub := range c.Conditions {
+			parts[i] = sub.String()
+		}
+		return "(" + strings.Join(parts, sep) + ")"
+	case OpNot:
+		if len(c.Conditions) == 1 {
+			return "!(" + c.Conditions[0].String() + ")"
+		}
+		return "!()"
+	case OpFunc:
+		return "func"
+	default:
+		return string(c.Op)
+	}
+}
+
+func formatConditionValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	if v == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// MarshalJSON encodes the condition tree. It fails if the tree contains
+// a function condition.
+func (c *Condition) MarshalJSON() ([]byte, error) {
+	out := map[string]interface{}{"op": c.Op}
+	switch c.Op {
+	case OpFunc:
+		return nil, fmt.Errorf("cannot serialise function condition")
+	case OpEquals, OpNotEquals:
+		out["field"] = c.Field
+		out["value"] = c.Value
+	case OpGreaterThan, OpLessThan:
+		out["field"] = c.Field
+		out["value"] = thresholdJSON(c.Value)
+	case OpIn:
+		out["field"] = c.Field
+		out["values"] = c.Values
+	case OpAll, OpAny, OpNot:
+		out["conditions"] = c.Conditions
+	default:
+		out["field"] = c.Field
+	}
+	return json.Marshal(out)
+}
+
+// thresholdJSON returns a threshold in a form json.Marshal writes as a
+// number. *big.Float and *big.Rat marshal as strings, so they are
+// written as their exact decimal, or as the nearest float64 when they
+// have none.
+func thresholdJSON(v interface{}) interface{} {
+	switch v.(type) {
+	case *big.Float, *big.Rat, queryfy.Rational:
+		r, ok := queryfy.ConvertToRat(v)
+		if !ok {
+			return v
+		}
+		if prec, exact := r.FloatPrec(); exact {
+			return json.Number(r.FloatString(prec))
+		}
+		f, _ := r.Float64()
+		return f
+	}
+	return v
+}
+
+// UnmarshalJSON decodes a condition tree produced by MarshalJSON and
+// checks that every node is well formed. The thresholds of OpGreaterThan
+// and OpLessThan decode as json.Number, so they keep their exact value.
+func (c *Condition) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Op         ConditionOp     `json:"op"`
+		Field      string          `json:"field"`
+		Value      json.RawMessage `json:"value"`
+		Values     []interface{}   `json:"values"`
+		Conditions []*Condition    `json:"conditions"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*c = Condition{
+		Op:         raw.Op,
+		Field:      raw.Field,
+		Values:     raw.Values,
+		Conditions: raw.Conditions,
+	}
+	if len(raw.Value) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(raw.Value))
+		if c.Op == OpGreaterThan || c.Op == OpLessThan {
+			dec.UseNumber()
+		}
+		if err := dec.Decode(&c.Value); err != nil {
+			return err
+		}
+	}
+	return c.check()
+}
+
+// ParseCondition decodes a JSON condition tree.
+func ParseCondition(data []byte) (*Condition, error) {
+	var c Condition
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// check validates a single node; children were checked when decoded.
+func (c *Condition) check() error {
+	switch c.Op {
+	case OpEquals, OpNotEquals, OpExists, OpNotExists, OpTrue, OpFalse, OpIn:
+		if c.Field == "" {
+			return fmt.Errorf("condition %q: missing field", c.Op)
+		}
+	case OpGreaterThan, OpLessThan:
+		if c.Field == "" {
+			return fmt.Errorf("condition %q: missing field", c.Op)
+		}
+		if _, ok := queryfy.ConvertToRat(c.Value); !ok {
+			return fmt.Errorf("condition %q: value must be a number", c.Op)
+		}
+	case OpAll, OpAny:
+		if len(c.Conditions) == 0 {
+			return fmt.Errorf("condition %q: missing conditions", c.Op)
+		}
+	case OpNot:
+		if len(c.Conditions) != 1 {
+			return fmt.Errorf("condition %q: expected exactly one condition", c.Op)
+		}
+	case OpFunc:
+		return fmt.Errorf("function conditions cannot be deserialised")
+	default:
+		return fmt.Errorf("unknown condition op %q", c.Op)
+	}
+	for _, sub := range c.Conditions {
+		if sub == nil {
+			return fmt.Errorf("condition %q: null sub-condition", c.Op)
+		}
+	}
+	return nil
+}
+
+// canonicalCondition writes a condition for Equal and Hash. Values keep
+// their Go type, since WhenEquals("n", 1) does not match a float64 1.
+// Functions cannot be compared, so a function condition is written as
+// the address of its node: it is equal only to itself.
+func canonicalCondition(b *canonicalBuilder, c *Condition) {
+	if c == nil {
+		b.WriteString("null")
+		return
+	}
+	b.WriteString(string(c.Op))
+	b.WriteString("(")
+	if c.Op == OpFunc {
+		fmt.Fprintf(b, "%p)", c)
+		return
+	}
+	b.WriteString(strconv.Quote(c.Field))
+	switch c.Op {
+	case OpEquals, OpNotEquals:
+		b.WriteString(",")
+		b.WriteString(canonicalConditionValue(c.Value))
+	case OpGreaterThan, OpLessThan:
+		// Thresholds compare by value, whatever their type
+		b.WriteString(",")
+		if r, ok := queryfy.ConvertToRat(c.Value); ok {
+			b.WriteString("number:" + r.RatString())
+		} else {
+			b.WriteString(canonicalConditionValue(c.Value))
+		}
+	case OpIn:
+		for _, v := range c.Values {
+			b.WriteString(",")
+			b.WriteString(canonicalConditionValue(v))
+		}
+	}
+	for _, sub := range c.Conditions {
+		b.WriteString(",")
+		canonicalCondition(b, sub)
+	}
+	b.WriteString(")")
+}
+
+func canonicalConditionValue(v interface{}) string {
+	if str, ok := v.(string); ok {
+		return "string:" + strconv.Quote(str)
+	}
+	return fmt.Sprintf("%T:%v", v, v)
+}
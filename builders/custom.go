@@ -46,7 +46,7 @@ func (s *CustomSchema) Validate(value interface{}, ctx *queryfy.ValidationContex
 
 	if s.validator != nil {
 		if err := s.validator(value); err != nil {
-			ctx.AddError(err.Error(), value)
+			ctx.AddValidatorError(err, value)
 		}
 	}
 
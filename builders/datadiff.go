@@ -0,0 +1,383 @@
+// datadiff.go - Schema-guided data diff
+package builders
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/patch"
+	"github.com/ha1tch/queryfy/query"
+)
+
+// ChangeKind says how a value differs between two documents.
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// DataChange describes one difference between two documents.
+type DataChange struct {
+	Path string      // query-style path, e.g. "items[2].qty"
+	Kind ChangeKind  // added, removed or changed
+	Old  interface{} // nil for an added value
+	New  interface{} // nil for a removed value
+}
+
+// DataDiff lists the differences between two versions of a document.
+type DataDiff struct {
+	Changes []DataChange
+
+	ops []patch.Operation
+}
+
+// HasChanges reports whether any differences were found.
+func (d *DataDiff) HasChanges() bool {
+	return len(d.Changes) > 0
+}
+
+// Patch returns a JSON Patch that turns the old document into the new
+// one. Elements of identity-keyed and unique-item arrays are added at
+// the end, since their order does not matter.
+func (d *DataDiff) Patch() []patch.Operation {
+	return append([]patch.Operation(nil), d.ops...)
+}
+
+// DiffData compares two versions of a decoded JSON document and reports
+// the values added, removed and changed, with query-style paths. Paths
+// of added and changed values index the new document; paths of removed
+// values index the old one.
+//
+// The schema guides the comparison and may be nil. An array whose
+// schema has an IdentityKey is compared as a set of objects matched by
+// that field, so reordering it is not a change; an array with
+// UniqueItems is compared as a set of values. Other arrays are compared
+// by position.
+func DiffData(old, new interface{}, schema queryfy.Schema) (*DataDiff, error) {
+	d := &dataDiffer{diff: &DataDiff{}}
+	if err := d.compare(nil, nil, nil, schema, old, new); err != nil {
+		return nil, err
+	}
+	return d.diff, nil
+}
+
+// dataDiffer accumulates the changes and patch operations of a diff.
+type dataDiffer struct {
+	diff *DataDiff
+}
+
+// compare records the differences between old and new. oldPath and
+// path locate them in the old and new documents, which differ once an
+// enclosing keyed array has been reordered; opPath locates them in the
+// document as it is when the patch operation applies, which differs
+// once elements of an enclosing array have been removed.
+func (d *dataDiffer) compare(oldPath, path, opPath []interface{}, schema queryfy.Schema, old, new interface{}) error {
+	schema = resolveSchema(schema)
+
+	switch o := old.(type) {
+	case map[string]interface{}:
+		if n, ok := new.(map[string]interface{}); ok {
+			return d.compareObjects(oldPath, path, opPath, schema, o, n)
+		}
+	case []interface{}:
+		if n, ok := new.([]interface{}); ok {
+			arr, _ := schema.(*ArraySchema)
+			switch {
+			case arr != nil && arr.IdentityField() != "":
+				return d.compareKeyed(oldPath, path, opPath, arr, o, n)
+			case arr != nil && arr.IsUniqueItems():
+				d.compareSets(oldPath, path, opPath, o, n)
+				return nil
+			}
+			return d.compareOrdered(oldPath, path, opPath, arr, o, n)
+		}
+	}
+
+	if !sameData(old, new) {
+		d.record(DataChange{Path: dataPath(path), Kind: ChangeChanged, Old: old, New: new},
+			patch.Operation{Op: "replace", Path: jsonPointer(opPath), Value: new})
+	}
+	return nil
+}
+
+func (d *dataDiffer) compareObjects(oldPath, path, opPath []interface{}, schema queryfy.Schema, old, new map[string]interface{}) error {
+	keys := make([]string, 0, len(old)+len(new))
+	for k := range old {
+		keys = append(keys, k)
+	}
+	for k := range new {
+		if _, exists := old[k]; !exists {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		oldValue, inOld := old[k]
+		newValue, inNew := new[k]
+		childOldPath, childPath, childOpPath := extendPath(oldPath, k), extendPath(path, k), extendPath(opPath, k)
+		switch {
+		case !inNew:
+			d.record(DataChange{Path: dataPath(childOldPath), Kind: ChangeRemoved, Old: oldValue},
+				patch.Operation{Op: "remove", Path: jsonPointer(childOpPath)})
+		case !inOld:
+			d.record(DataChange{Path: dataPath(childPath), Kind: ChangeAdded, New: newValue},
+				patch.Operation{Op: "add", Path: jsonPointer(childOpPath), Value: newValue})
+		default:
+			if err := d.compare(childOldPath, childPath, childOpPath, fieldSchema(schema, k), oldValue, newValue); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compareOrdered compares arrays element by element.
+func (d *dataDiffer) compareOrdered(oldPath, path, opPath []interface{}, schema *ArraySchema, old, new []interface{}) error {
+	elem := elementSchema(schema)
+	for i := 0; i < len(old) && i < len(new); i++ {
+		if err := d.compare(extendPath(oldPath, i), extendPath(path, i), extendPath(opPath, i), elem, old[i], new[i]); err != nil {
+			return err
+		}
+	}
+	for i := len(old); i < len(new); i++ {
+		d.record(DataChange{Path: dataPath(extendPath(path, i)), Kind: ChangeAdded, New: new[i]},
+			patch.Operation{Op: "add", Path: jsonPointer(extendPath(opPath, i)), Value: new[i]})
+	}
+	// Remove from the end so the indexes stay valid
+	for i := len(old) - 1; i >= len(new); i-- {
+		d.record(DataChange{Path: dataPath(extendPath(oldPath, i)), Kind: ChangeRemoved, Old: old[i]},
+			patch.Operation{Op: "remove", Path: jsonPointer(extendPath(opPath, i))})
+	}
+	return nil
+}
+
+// compareKeyed compares arrays of objects matched by identity key.
+func (d *dataDiffer) compareKeyed(oldPath, path, opPath []interface{}, schema *ArraySchema, old, new []interface{}) error {
+	field := schema.IdentityField()
+	oldIndex, err := identityIndex(oldPath, field, old)
+	if err != nil {
+		return err
+	}
+	newIndex, err := identityIndex(path, field, new)
+	if err != nil {
+		return err
+	}
+
+	// Removed elements go first, from the end, so that each remaining
+	// element's position in the patched array is its old index less the
+	// removals before it.
+	removedBefore := make([]int, len(old))
+	removed := 0
+	for i, elem := range old {
+		removedBefore[i] = removed
+		if _, kept := newIndex[identity(elem.(map[string]interface{})[field])]; !kept {
+			removed++
+		}
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		if _, kept := newIndex[identity(old[i].(map[string]interface{})[field])]; !kept {
+			d.record(DataChange{Path: dataPath(extendPath(oldPath, i)), Kind: ChangeRemoved, Old: old[i]},
+				patch.Operation{Op: "remove", Path: jsonPointer(extendPath(opPath, i))})
+		}
+	}
+
+	elem := elementSchema(schema)
+	var added []int
+	for j, value := range new {
+		i, matched := oldIndex[identity(value.(map[string]interface{})[field])]
+		if !matched {
+			added = append(added, j)
+			continue
+		}
+		if err := d.compare(extendPath(oldPath, i), extendPath(path, j), extendPath(opPath, i-removedBefore[i]), elem, old[i], value); err != nil {
+			return err
+		}
+	}
+	for _, j := range added {
+		d.record(DataChange{Path: dataPath(extendPath(path, j)), Kind: ChangeAdded, New: new[j]},
+			patch.Operation{Op: "add", Path: jsonPointer(extendPath(opPath, "-")), Value: new[j]})
+	}
+	return nil
+}
+
+// compareSets compares arrays as sets of values.
+func (d *dataDiffer) compareSets(oldPath, path, opPath []interface{}, old, new []interface{}) {
+	matchedNew := make([]bool, len(new))
+	var removed []int
+	for i, o := range old {
+		found := false
+		for j, n := range new {
+			if !matchedNew[j] && sameData(o, n) {
+				matchedNew[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			removed = append(removed, i)
+		}
+	}
+
+	for k := len(removed) - 1; k >= 0; k-- {
+		i := removed[k]
+		d.record(DataChange{Path: dataPath(extendPath(oldPath, i)), Kind: ChangeRemoved, Old: old[i]},
+			patch.Operation{Op: "remove", Path: jsonPointer(extendPath(opPath, i))})
+	}
+	for j, matched := range matchedNew {
+		if !matched {
+			d.record(DataChange{Path: dataPath(extendPath(path, j)), Kind: ChangeAdded, New: new[j]},
+				patch.Operation{Op: "add", Path: jsonPointer(extendPath(opPath, "-")), Value: new[j]})
+		}
+	}
+}
+
+func (d *dataDiffer) record(change DataChange, op patch.Operation) {
+	d.diff.Changes = append(d.diff.Changes, change)
+	d.diff.ops = append(d.diff.ops, op)
+}
+
+// identityIndex maps the identity of each element to its index,
+// failing if an element lacks the key or two elements share it.
+func identityIndex(path []interface{}, field string, elems []interface{}) (map[string]int, error) {
+	index := make(map[string]int, len(elems))
+	for i, elem := range elems {
+		obj, ok := elem.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: element is not an object", dataPath(extendPath(path, i)))
+		}
+		key, ok := obj[field]
+		if !ok {
+			return nil, fmt.Errorf("%s: element has no identity key %q", dataPath(extendPath(path, i)), field)
+		}
+		id := identity(key)
+		if _, dup := index[id]; dup {
+			return nil, fmt.Errorf("%s: duplicate identity key %s = %v", dataPath(extendPath(path, i)), field, key)
+		}
+		index[id] = i
+	}
+	return index, nil
+}
+
+// identity returns a comparable form of an identity key value, under
+// which numbers of different Go types are equal.
+func identity(v interface{}) string {
+	if r, ok := queryfy.ConvertToRat(v); ok {
+		return "n:" + r.RatString()
+	}
+	if f, ok := toFloat64WithMode(v, queryfy.Strict); ok {
+		// A json.Number too large to convert exactly
+		return "n:" + strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	if s, ok := v.(string); ok {
+		return "s:" + s
+	}
+	return fmt.Sprintf("%T:%v", v, v)
+}
+
+// sameData compares decoded JSON values, numbers by value.
+func sameData(a, b interface{}) bool {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, exists := y[k]
+			if !exists || !sameData(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !sameData(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return sameNumber(a, b)
+}
+
+// sameNumber compares numbers exactly, whatever their Go types, and
+// other values deeply.
+func sameNumber(a, b interface{}) bool {
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			return x == y
+		}
+	case int64:
+		if y, ok := b.(int64); ok {
+			return x == y
+		}
+	}
+	if ra, ok := queryfy.ConvertToRat(a); ok {
+		rb, ok := queryfy.ConvertToRat(b)
+		return ok && ra.Cmp(rb) == 0
+	}
+	if fa, ok := toFloat64WithMode(a, queryfy.Strict); ok {
+		// A json.Number too large to convert exactly
+		fb, ok := toFloat64WithMode(b, queryfy.Strict)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// resolveSchema follows references to the schema they stand for.
+func resolveSchema(schema queryfy.Schema) queryfy.Schema {
+	for {
+		ref, ok := schema.(*RefSchema)
+		if !ok || !ref.IsResolved() {
+			return schema
+		}
+		schema = ref.Target()
+	}
+}
+
+func fieldSchema(schema queryfy.Schema, name string) queryfy.Schema {
+	switch s := schema.(type) {
+	case *ObjectSchema:
+		field, _ := s.GetField(name)
+		return field
+	case *ObjectSchemaWithDependencies:
+		field, _ := s.GetField(name)
+		return field
+	}
+	return nil
+}
+
+func elementSchema(schema *ArraySchema) queryfy.Schema {
+	if schema == nil {
+		return nil
+	}
+	return schema.ElementSchema()
+}
+
+func extendPath(path []interface{}, segment interface{}) []interface{} {
+	out := make([]interface{}, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, segment)
+}
+
+// dataPath formats a concrete path in query syntax.
+func dataPath(path []interface{}) string {
+	return query.Match{Path: path}.PathString()
+}
+
+// jsonPointer formats a concrete path as a JSON Pointer. The paths of a
+// diff hold only field names, indexes and "-", which always convert.
+func jsonPointer(path []interface{}) string {
+	pointer, _ := query.PointerFromPath(path)
+	return pointer
+}
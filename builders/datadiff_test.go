@@ -0,0 +1,282 @@
+package builders_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+	"github.com/ha1tch/queryfy/patch"
+)
+
+func decodeDoc(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", s, err)
+	}
+	return v
+}
+
+// changeSummary renders changes as "kind path" lines.
+func changeSummary(d *builders.DataDiff) []string {
+	var out []string
+	for _, c := range d.Changes {
+		out = append(out, string(c.Kind)+" "+c.Path)
+	}
+	return out
+}
+
+// expectPatchRoundTrip checks that the diff's patch, sent as JSON,
+// turns old into a document with no differences from new.
+func expectPatchRoundTrip(t *testing.T, old, new interface{}, d *builders.DataDiff, schema queryfy.Schema) {
+	t.Helper()
+	data, err := json.Marshal(d.Patch())
+	if err != nil {
+		t.Fatalf("patch does not marshal: %v", err)
+	}
+	ops, err := patch.Decode(data)
+	if err != nil {
+		t.Fatalf("marshalled patch does not decode: %v\n%s", err, data)
+	}
+	patched, err := patch.Apply(old, ops)
+	if err != nil {
+		t.Fatalf("patch does not apply: %v", err)
+	}
+	again, err := builders.DiffData(patched, new, schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again.HasChanges() {
+		t.Errorf("patched document still differs: %v", changeSummary(again))
+	}
+}
+
+// ======================================================================
+// Data Diff
+// ======================================================================
+
+func TestDiffData_Objects(t *testing.T) {
+	old := decodeDoc(t, `{"name": "Ann", "age": 30, "tags": ["a", "b"], "address": {"city": "Oslo"}}`)
+	new := decodeDoc(t, `{"name": "Ann", "age": 31, "tags": ["a"], "address": {"city": "Oslo", "zip": "0150"}, "email": "ann@example.com"}`)
+
+	d, err := builders.DiffData(old, new, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		"added address.zip",
+		"changed age",
+		"added email",
+		"removed tags[1]",
+	}
+	if got := changeSummary(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if c := d.Changes[1]; c.Old != 30.0 || c.New != 31.0 {
+		t.Errorf("unexpected change %+v", c)
+	}
+	expectPatchRoundTrip(t, old, new, d, nil)
+}
+
+func TestDiffData_NoChanges(t *testing.T) {
+	doc := `{"a": [1, {"b": null}], "c": true}`
+	d, err := builders.DiffData(decodeDoc(t, doc), decodeDoc(t, doc), nil)
+	if err != nil || d.HasChanges() || len(d.Patch()) != 0 {
+		t.Errorf("expected no changes, got %v, %v", changeSummary(d), err)
+	}
+
+	// Numbers compare by value across Go types
+	d, _ = builders.DiffData(map[string]interface{}{"n": 1}, map[string]interface{}{"n": 1.0}, nil)
+	if d.HasChanges() {
+		t.Errorf("expected no changes, got %v", changeSummary(d))
+	}
+}
+
+func TestDiffData_LargeNumbers(t *testing.T) {
+	// 2^53 and 2^53+1 are the same float64
+	const limit = 1 << 53
+	d, _ := builders.DiffData(map[string]interface{}{"n": int64(limit)}, map[string]interface{}{"n": int64(limit + 1)}, nil)
+	if got := changeSummary(d); !reflect.DeepEqual(got, []string{"changed n"}) {
+		t.Errorf("int64: got %v", got)
+	}
+	d, _ = builders.DiffData(decodeDoc(t, `{"n": 0.1}`), map[string]interface{}{"n": json.Number("0.10")}, nil)
+	if d.HasChanges() {
+		t.Errorf("json.Number: got %v", changeSummary(d))
+	}
+
+	schema := builders.Object().Field("items", builders.Array().IdentityKey("id"))
+	old := map[string]interface{}{"items": []interface{}{
+		map[string]interface{}{"id": int64(limit), "v": 1},
+		map[string]interface{}{"id": int64(limit + 1), "v": 2},
+	}}
+	new := map[string]interface{}{"items": []interface{}{
+		map[string]interface{}{"id": json.Number("9007199254740993"), "v": 2},
+		map[string]interface{}{"id": int64(limit), "v": 3},
+	}}
+	d, err := builders.DiffData(old, new, schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := changeSummary(d); !reflect.DeepEqual(got, []string{"changed items[1].v"}) {
+		t.Errorf("identity: got %v", got)
+	}
+}
+
+func TestDiffData_TypeChange(t *testing.T) {
+	old := decodeDoc(t, `{"v": {"x": 1}}`)
+	new := decodeDoc(t, `{"v": [1]}`)
+	d, _ := builders.DiffData(old, new, nil)
+	if got := changeSummary(d); !reflect.DeepEqual(got, []string{"changed v"}) {
+		t.Errorf("got %v", got)
+	}
+	expectPatchRoundTrip(t, old, new, d, nil)
+}
+
+func TestDiffData_NullValues(t *testing.T) {
+	old := decodeDoc(t, `{"a": 1, "b": null, "list": [1, 2]}`)
+	new := decodeDoc(t, `{"a": null, "b": 2, "list": [null, 2], "c": null}`)
+	d, _ := builders.DiffData(old, new, nil)
+	if !d.HasChanges() {
+		t.Fatal("expected changes")
+	}
+	expectPatchRoundTrip(t, old, new, d, nil)
+}
+
+func TestDiffData_OrderedArrays(t *testing.T) {
+	old := decodeDoc(t, `{"items": [{"id": 1}, {"id": 2}]}`)
+	new := decodeDoc(t, `{"items": [{"id": 2}, {"id": 1}, {"id": 3}]}`)
+	d, _ := builders.DiffData(old, new, nil)
+	want := []string{"changed items[0].id", "changed items[1].id", "added items[2]"}
+	if got := changeSummary(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	expectPatchRoundTrip(t, old, new, d, nil)
+}
+
+func TestDiffData_IdentityKey(t *testing.T) {
+	schema := builders.Object().
+		Field("orders", builders.Array().IdentityKey("id").Of(builders.Object().
+			Field("id", builders.Number()).
+			Field("lines", builders.Array().IdentityKey("sku"))))
+
+	old := decodeDoc(t, `{"orders": [
+		{"id": 1, "total": 10},
+		{"id": 2, "total": 20, "lines": [{"sku": "a", "qty": 1}, {"sku": "b", "qty": 1}]},
+		{"id": 3, "total": 30}
+	]}`)
+	new := decodeDoc(t, `{"orders": [
+		{"id": 3, "total": 30},
+		{"id": 2, "total": 25, "lines": [{"sku": "b", "qty": 2}]},
+		{"id": 4, "total": 40}
+	]}`)
+
+	d, err := builders.DiffData(old, new, schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		"removed orders[0]",
+		"removed orders[1].lines[0]",
+		"changed orders[1].lines[0].qty",
+		"changed orders[1].total",
+		"added orders[2]",
+	}
+	if got := changeSummary(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	expectPatchRoundTrip(t, old, new, d, schema)
+
+	// Reordering alone is not a change
+	reordered := decodeDoc(t, `{"orders": [{"id": 3, "total": 30}, {"id": 1, "total": 10}]}`)
+	original := decodeDoc(t, `{"orders": [{"id": 1, "total": 10}, {"id": 3, "total": 30}]}`)
+	if d, _ := builders.DiffData(original, reordered, schema); d.HasChanges() {
+		t.Errorf("reordering reported as %v", changeSummary(d))
+	}
+}
+
+func TestDiffData_IdentityKeyReorderedPaths(t *testing.T) {
+	schema := builders.Object().Field("items", builders.Array().IdentityKey("id"))
+	old := decodeDoc(t, `{"items": [
+		{"id": 1, "note": "x", "sub": [1, 2, 3], "v": 1},
+		{"id": 2},
+		{"id": 3}
+	]}`)
+	new := decodeDoc(t, `{"items": [
+		{"id": 3},
+		{"id": 2},
+		{"id": 1, "sub": [1, 2], "v": 2}
+	]}`)
+
+	d, err := builders.DiffData(old, new, schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Removals index the old document, changes the new one
+	want := []string{
+		"removed items[0].note",
+		"removed items[0].sub[2]",
+		"changed items[2].v",
+	}
+	if got := changeSummary(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	expectPatchRoundTrip(t, old, new, d, schema)
+}
+
+func TestDiffData_IdentityKeyErrors(t *testing.T) {
+	schema := builders.Object().Field("items", builders.Array().IdentityKey("id"))
+	tests := []struct {
+		doc, want string
+	}{
+		{`{"items": [{"id": 1}, {"name": "x"}]}`, `items[1]: element has no identity key "id"`},
+		{`{"items": [{"id": 1}, {"id": 1}]}`, "items[1]: duplicate identity key"},
+		{`{"items": [1]}`, "items[0]: element is not an object"},
+	}
+	for _, tt := range tests {
+		_, err := builders.DiffData(decodeDoc(t, `{"items": []}`), decodeDoc(t, tt.doc), schema)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.doc, tt.want, err)
+		}
+	}
+}
+
+func TestDiffData_UniqueItems(t *testing.T) {
+	schema := builders.Object().Field("tags", builders.Array().UniqueItems())
+	old := decodeDoc(t, `{"tags": ["a", "b", "c"]}`)
+	new := decodeDoc(t, `{"tags": ["c", "d", "a"]}`)
+
+	d, _ := builders.DiffData(old, new, schema)
+	want := []string{"removed tags[1]", "added tags[1]"}
+	if got := changeSummary(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if d.Changes[0].Old != "b" || d.Changes[1].New != "d" {
+		t.Errorf("unexpected changes %+v", d.Changes)
+	}
+	expectPatchRoundTrip(t, old, new, d, schema)
+}
+
+func TestDiffData_Patch(t *testing.T) {
+	old := decodeDoc(t, `{"a/b": 1, "list": [1, 2, 3]}`)
+	new := decodeDoc(t, `{"a/b": 2, "list": [1]}`)
+	d, _ := builders.DiffData(old, new, nil)
+
+	want := []patch.Operation{
+		{Op: "replace", Path: "/a~1b", Value: 2.0},
+		{Op: "remove", Path: "/list/2"},
+		{Op: "remove", Path: "/list/1"},
+	}
+	if got := d.Patch(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestArraySchema_IdentityKey(t *testing.T) {
+	s := builders.Array().IdentityKey("id")
+	if s.IdentityField() != "id" || builders.Array().IdentityField() != "" {
+		t.Error("IdentityField() does not return the identity key")
+	}
+}
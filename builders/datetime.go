@@ -165,7 +165,7 @@ func (s *DateTimeSchema) Future() *DateTimeSchema {
 			return nil
 		}
 		if !t.After(time.Now()) {
-			return fmt.Errorf("must be in the future")
+			return &queryfy.CodedError{Code: queryfy.CodeFuture}
 		}
 		return nil
 	})
@@ -181,7 +181,7 @@ func (s *DateTimeSchema) Past() *DateTimeSchema {
 			return nil
 		}
 		if !t.Before(time.Now()) {
-			return fmt.Errorf("must be in the past")
+			return &queryfy.CodedError{Code: queryfy.CodePast}
 		}
 		return nil
 	})
@@ -199,10 +199,10 @@ func (s *DateTimeSchema) Age(minAge, maxAge int) *DateTimeSchema {
 
 		age := calculateAge(t)
 		if age < minAge {
-			return fmt.Errorf("age must be at least %d years (current: %d)", minAge, age)
+			return &queryfy.CodedError{Code: queryfy.CodeMinAge, Params: queryfy.Params{"min": minAge, "age": age}}
 		}
 		if age > maxAge {
-			return fmt.Errorf("age must be at most %d years (current: %d)", maxAge, age)
+			return &queryfy.CodedError{Code: queryfy.CodeMaxAge, Params: queryfy.Params{"max": maxAge, "age": age}}
 		}
 		return nil
 	})
@@ -224,7 +224,7 @@ func (s *DateTimeSchema) Weekday(days ...time.Weekday) *DateTimeSchema {
 			}
 		}
 
-		return fmt.Errorf("must be on %s", formatWeekdays(days))
+		return &queryfy.CodedError{Code: queryfy.CodeWeekday, Params: queryfy.Params{"days": formatWeekdays(days)}}
 	})
 	return s
 }
@@ -257,7 +257,7 @@ func (s *DateTimeSchema) Validate(value interface{}, ctx *queryfy.ValidationCont
 				// In loose mode, try to convert to string
 				str, isString = queryfy.ConvertToString(value)
 				if !isString {
-					ctx.AddError(fmt.Sprintf("cannot convert %T to date/time", value), value)
+					ctx.AddCodedError(queryfy.CodeConversion, queryfy.Params{"type": fmt.Sprintf("%T", value), "target": "date/time"}, value)
 					return nil
 				}
 			}
@@ -267,21 +267,21 @@ func (s *DateTimeSchema) Validate(value interface{}, ctx *queryfy.ValidationCont
 			if err != nil {
 				// In strict format mode, only the specified format is accepted
 				if s.strictFormat {
-					ctx.AddError(fmt.Sprintf("invalid date/time format (expected %s): %s", s.format, err.Error()), str)
+					ctx.AddCodedError(queryfy.CodeDateTimeFormat, queryfy.Params{"format": s.format, "error": err.Error()}, str)
 					return nil
 				}
 				// Try some common formats if the specified format fails
 				if parsedAlt, err2 := tryCommonFormats(str); err2 == nil {
 					t = parsedAlt
 				} else {
-					ctx.AddError(fmt.Sprintf("invalid date/time format (expected %s): %s", s.format, err.Error()), str)
+					ctx.AddCodedError(queryfy.CodeDateTimeFormat, queryfy.Params{"format": s.format, "error": err.Error()}, str)
 					return nil
 				}
 			} else {
 				t = parsed
 			}
 		} else {
-			ctx.AddError(fmt.Sprintf("expected time.Time or string, got %T", value), value)
+			ctx.AddCodedError(queryfy.CodeType, queryfy.Params{"expected": "time.Time or string", "actual": fmt.Sprintf("%T", value)}, value)
 			return nil
 		}
 	}
@@ -290,17 +290,17 @@ func (s *DateTimeSchema) Validate(value interface{}, ctx *queryfy.ValidationCont
 
 	// Range validation
 	if s.minTime != nil && t.Before(*s.minTime) {
-		ctx.AddError(fmt.Sprintf("must be after %s", s.minTime.Format(s.format)), t.Format(s.format))
+		ctx.AddCodedError(queryfy.CodeAfter, queryfy.Params{"min": s.minTime.Format(s.format)}, t.Format(s.format))
 	}
 
 	if s.maxTime != nil && t.After(*s.maxTime) {
-		ctx.AddError(fmt.Sprintf("must be before %s", s.maxTime.Format(s.format)), t.Format(s.format))
+		ctx.AddCodedError(queryfy.CodeBefore, queryfy.Params{"max": s.maxTime.Format(s.format)}, t.Format(s.format))
 	}
 
 	// Custom validators - pass the parsed time
 	for _, validator := range s.validators {
 		if err := validator(t); err != nil {
-			ctx.AddError(err.Error(), t.Format(s.format))
+			ctx.AddValidatorError(err, t.Format(s.format))
 		}
 	}
 
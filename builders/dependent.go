@@ -2,8 +2,7 @@
 package builders
 
 import (
-	"fmt"
-	"reflect"
+	"sort"
 
 	"github.com/ha1tch/queryfy"
 )
@@ -11,18 +10,15 @@ import (
 // DependentSchema validates fields based on conditions from other fields.
 type DependentSchema struct {
 	queryfy.BaseSchema
-	fieldName     string              // The field this schema validates
-	dependsOn     []string            // Fields this validation depends on
-	conditionFunc DependencyCondition // Function that determines if validation should run
-	schema        queryfy.Schema      // The schema to apply when condition is met
-	elseSchema    queryfy.Schema      // Optional schema to apply when condition is not met
-	validators    []queryfy.ValidatorFunc
+	fieldName  string         // The field this schema validates
+	dependsOn  []string       // Fields this validation depends on
+	condition  *Condition     // Condition that determines if validation should run
+	ifSchema   queryfy.Schema // Schema the parent must match for validation to run
+	schema     queryfy.Schema // The schema to apply when condition is met
+	elseSchema queryfy.Schema // Optional schema to apply when condition is not met
+	validators []queryfy.ValidatorFunc
 }
 
-// DependencyCondition is a function that receives the parent object and returns whether
-// the dependent validation should be applied.
-type DependencyCondition func(parentData map[string]interface{}) bool
-
 // Dependent creates a new dependent field schema.
 func Dependent(fieldName string) *DependentSchema {
 	return &DependentSchema{
@@ -40,9 +36,22 @@ func (s *DependentSchema) On(fields ...string) *DependentSchema {
 	return s
 }
 
-// When sets the condition function that determines when validation applies.
-func (s *DependentSchema) When(condition DependencyCondition) *DependentSchema {
-	s.conditionFunc = condition
+// When sets the condition that determines when validation applies.
+// Pass a When* helper, or wrap a function with WhenFunc. It replaces any
+// If schema.
+func (s *DependentSchema) When(condition *Condition) *DependentSchema {
+	s.condition = condition
+	s.ifSchema = nil
+	return s
+}
+
+// If sets a schema as the condition: validation applies when the parent
+// object validates against it. Unlike a condition function, the schema
+// can be inspected, compared and exported; JSON Schema's if/then/else
+// and dependentSchemas import this way. It replaces any When condition.
+func (s *DependentSchema) If(condition queryfy.Schema) *DependentSchema {
+	s.ifSchema = condition
+	s.condition = nil
 	return s
 }
 
@@ -94,10 +103,7 @@ func (s *DependentSchema) Validate(value interface{}, ctx *queryfy.ValidationCon
 // ValidateWithParent validates the field considering the parent object context.
 func (s *DependentSchema) ValidateWithParent(value interface{}, parentData map[string]interface{}, ctx *queryfy.ValidationContext) error {
 	// Check if condition is met
-	conditionMet := false
-	if s.conditionFunc != nil {
-		conditionMet = s.conditionFunc(parentData)
-	}
+	conditionMet := s.conditionMet(parentData, ctx.Mode())
 
 	// Apply appropriate schema based on condition
 	if conditionMet && s.schema != nil {
@@ -109,13 +115,64 @@ func (s *DependentSchema) ValidateWithParent(value interface{}, parentData map[s
 	// Run custom validators
 	for _, validator := range s.validators {
 		if err := validator(value); err != nil {
-			ctx.AddError(err.Error(), value)
+			ctx.AddValidatorError(err, value)
 		}
 	}
 
 	return nil
 }
 
+// conditionMet evaluates the If schema or When condition against the
+// parent object. A rule with neither never applies.
+func (s *DependentSchema) conditionMet(parentData map[string]interface{}, mode queryfy.ValidationMode) bool {
+	if s.ifSchema != nil {
+		tempCtx := queryfy.NewValidationContext(mode)
+		s.ifSchema.Validate(parentData, tempCtx)
+		return !tempCtx.HasErrors()
+	}
+	if s.condition != nil {
+		return s.condition.Evaluate(parentData)
+	}
+	return false
+}
+
+// FieldName returns the name passed to Dependent.
+func (s *DependentSchema) FieldName() string {
+	return s.fieldName
+}
+
+// DependsOn returns the fields passed to On.
+func (s *DependentSchema) DependsOn() []string {
+	return append([]string(nil), s.dependsOn...)
+}
+
+// Condition returns the condition set with When, or nil if the rule uses
+// If or has no condition. Function conditions appear as OpFunc nodes.
+func (s *DependentSchema) Condition() *Condition {
+	return s.condition
+}
+
+// IfSchema returns the schema set with If, or nil if the rule uses a
+// When condition or has no condition.
+func (s *DependentSchema) IfSchema() queryfy.Schema {
+	return s.ifSchema
+}
+
+// ThenSchema returns the schema applied when the condition holds.
+func (s *DependentSchema) ThenSchema() queryfy.Schema {
+	return s.schema
+}
+
+// ElseSchema returns the schema applied when the condition does not hold.
+func (s *DependentSchema) ElseSchema() queryfy.Schema {
+	return s.elseSchema
+}
+
+// Validators returns the custom validator functions.
+func (s *DependentSchema) Validators() []queryfy.ValidatorFunc {
+	return s.validators
+}
+
 // Type implements the Schema interface.
 func (s *DependentSchema) Type() queryfy.SchemaType {
 	return queryfy.TypeDependent
@@ -124,125 +181,90 @@ func (s *DependentSchema) Type() queryfy.SchemaType {
 // Common dependency conditions
 
 // WhenEquals creates a condition that checks if a field equals a specific value.
-func WhenEquals(field string, value interface{}) DependencyCondition {
-	return func(data map[string]interface{}) bool {
-		if fieldValue, exists := data[field]; exists {
-			return reflect.DeepEqual(fieldValue, value)
-		}
-		return false
-	}
+func WhenEquals(field string, value interface{}) *Condition {
+	return &Condition{Op: OpEquals, Field: field, Value: value}
 }
 
 // WhenNotEquals creates a condition that checks if a field does not equal a specific value.
-func WhenNotEquals(field string, value interface{}) DependencyCondition {
-	return func(data map[string]interface{}) bool {
-		if fieldValue, exists := data[field]; exists {
-			return !reflect.DeepEqual(fieldValue, value)
-		}
-		return true
-	}
+func WhenNotEquals(field string, value interface{}) *Condition {
+	return &Condition{Op: OpNotEquals, Field: field, Value: value}
 }
 
 // WhenExists creates a condition that checks if a field exists and is not nil.
-func WhenExists(field string) DependencyCondition {
-	return func(data map[string]interface{}) bool {
-		value, exists := data[field]
-		return exists && value != nil
-	}
+func WhenExists(field string) *Condition {
+	return &Condition{Op: OpExists, Field: field}
 }
 
 // WhenNotExists creates a condition that checks if a field does not exist or is nil.
-func WhenNotExists(field string) DependencyCondition {
-	return func(data map[string]interface{}) bool {
-		value, exists := data[field]
-		return !exists || value == nil
-	}
+func WhenNotExists(field string) *Condition {
+	return &Condition{Op: OpNotExists, Field: field}
 }
 
 // WhenIn creates a condition that checks if a field's value is in a list.
-func WhenIn(field string, values ...interface{}) DependencyCondition {
-	return func(data map[string]interface{}) bool {
-		if fieldValue, exists := data[field]; exists {
-			for _, v := range values {
-				if reflect.DeepEqual(fieldValue, v) {
-					return true
-				}
-			}
-		}
-		return false
-	}
+func WhenIn(field string, values ...interface{}) *Condition {
+	return &Condition{Op: OpIn, Field: field, Values: values}
 }
 
 // WhenGreaterThan creates a condition for numeric comparisons.
-func WhenGreaterThan(field string, threshold float64) DependencyCondition {
-	return func(data map[string]interface{}) bool {
-		if fieldValue, exists := data[field]; exists {
-			if num, ok := dependentToFloat64(fieldValue); ok {
-				return num > threshold
-			}
-		}
-		return false
-	}
+func WhenGreaterThan(field string, threshold float64) *Condition {
+	return &Condition{Op: OpGreaterThan, Field: field, Value: threshold}
 }
 
 // WhenLessThan creates a condition for numeric comparisons.
-func WhenLessThan(field string, threshold float64) DependencyCondition {
-	return func(data map[string]interface{}) bool {
-		if fieldValue, exists := data[field]; exists {
-			if num, ok := dependentToFloat64(fieldValue); ok {
-				return num < threshold
-			}
-		}
-		return false
-	}
+func WhenLessThan(field string, threshold float64) *Condition {
+	return &Condition{Op: OpLessThan, Field: field, Value: threshold}
 }
 
 // WhenTrue creates a condition that checks if a boolean field is true.
-func WhenTrue(field string) DependencyCondition {
-	return func(data map[string]interface{}) bool {
-		if fieldValue, exists := data[field]; exists {
-			if boolVal, ok := fieldValue.(bool); ok {
-				return boolVal
-			}
-		}
-		return false
-	}
+func WhenTrue(field string) *Condition {
+	return &Condition{Op: OpTrue, Field: field}
 }
 
 // WhenFalse creates a condition that checks if a boolean field is false.
-func WhenFalse(field string) DependencyCondition {
-	return func(data map[string]interface{}) bool {
-		if fieldValue, exists := data[field]; exists {
-			if boolVal, ok := fieldValue.(bool); ok {
-				return !boolVal
-			}
-		}
-		return false
-	}
+func WhenFalse(field string) *Condition {
+	return &Condition{Op: OpFalse, Field: field}
 }
 
 // WhenAll creates a condition that requires all sub-conditions to be true.
-func WhenAll(conditions ...DependencyCondition) DependencyCondition {
-	return func(data map[string]interface{}) bool {
-		for _, condition := range conditions {
-			if !condition(data) {
-				return false
-			}
-		}
-		return true
-	}
+func WhenAll(conditions ...*Condition) *Condition {
+	return &Condition{Op: OpAll, Conditions: conditions}
 }
 
 // WhenAny creates a condition that requires at least one sub-condition to be true.
-func WhenAny(conditions ...DependencyCondition) DependencyCondition {
-	return func(data map[string]interface{}) bool {
-		for _, condition := range conditions {
-			if condition(data) {
-				return true
-			}
+func WhenAny(conditions ...*Condition) *Condition {
+	return &Condition{Op: OpAny, Conditions: conditions}
+}
+
+// WhenNot creates a condition that inverts another condition.
+func WhenNot(condition *Condition) *Condition {
+	return &Condition{Op: OpNot, Conditions: []*Condition{condition}}
+}
+
+// WhenFunc wraps a function as a condition, for logic the other helpers
+// cannot express. Such conditions are opaque: Equal and Hash tell them
+// apart only by identity, and they cannot be exported or serialised.
+func WhenFunc(fn DependencyCondition) *Condition {
+	return &Condition{Op: OpFunc, fn: fn}
+}
+
+// compareConditionNumbers compares two numbers exactly, as Number
+// schemas do, so json.Number, big values and integers beyond 2^53 are
+// not rounded. It returns false if either value is not a number.
+func compareConditionNumbers(a, b interface{}) (int, bool) {
+	if queryfy.ExactRat(a) == nil && queryfy.ExactRat(b) == nil {
+		fa, okA := dependentToFloat64(a)
+		fb, okB := dependentToFloat64(b)
+		if !okA || !okB {
+			return 0, false
 		}
-		return false
+		return queryfy.CompareNumber(fa, nil, fb), true
 	}
+	ra, okA := queryfy.ConvertToRat(a)
+	rb, okB := queryfy.ConvertToRat(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	return ra.Cmp(rb), true
 }
 
 // dependentToFloat64 converts to float64 for dependent field validation
@@ -258,12 +280,20 @@ func dependentToFloat64(value interface{}) (float64, bool) {
 		return float64(v), true
 	case int32:
 		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int8:
+		return float64(v), true
 	case uint:
 		return float64(v), true
 	case uint64:
 		return float64(v), true
 	case uint32:
 		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
 	default:
 		return 0, false
 	}
@@ -301,14 +331,35 @@ func (s *ObjectSchemaWithDependencies) RequiredFields(names ...string) *ObjectSc
 	return s
 }
 
-// DependentField adds a dependent field to the schema.
+// DependentField adds a dependent field to the schema. If name is also
+// a regular field, both apply: the regular schema always, the dependent
+// rule according to its condition.
 func (s *ObjectSchemaWithDependencies) DependentField(name string, dependent *DependentSchema) *ObjectSchemaWithDependencies {
 	s.dependentFields[name] = dependent
 	// Also add it as a regular field so it appears in the schema
-	s.fields[name] = dependent
+	if _, exists := s.fields[name]; !exists {
+		s.fields[name] = dependent
+	}
 	return s
 }
 
+// DependentFieldNames returns the names of all dependent fields, sorted.
+func (s *ObjectSchemaWithDependencies) DependentFieldNames() []string {
+	names := make([]string, 0, len(s.dependentFields))
+	for name := range s.dependentFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetDependentField returns the dependent rule for a field and whether
+// it exists.
+func (s *ObjectSchemaWithDependencies) GetDependentField(name string) (*DependentSchema, bool) {
+	dep, ok := s.dependentFields[name]
+	return dep, ok
+}
+
 // Custom adds a custom validator (override to return correct type).
 func (s *ObjectSchemaWithDependencies) Custom(fn queryfy.ValidatorFunc) *ObjectSchemaWithDependencies {
 	s.ObjectSchema.Custom(fn)
@@ -335,55 +386,85 @@ func (s *ObjectSchemaWithDependencies) Nullable() *ObjectSchemaWithDependencies
 
 // Validate overrides the base validate to handle dependent fields.
 func (s *ObjectSchemaWithDependencies) Validate(value interface{}, ctx *queryfy.ValidationContext) error {
-	// First convert to map
-	objMap, ok := convertToMap(value)
-	if !ok {
-		ctx.AddError(fmt.Sprintf("cannot convert %T to map", value), value)
-		return nil
-	}
-
 	// Validate regular fields first
 	if err := s.ObjectSchema.Validate(value, ctx); err != nil {
 		return err
 	}
 
 	// Now validate dependent fields with parent context
-	for fieldName, depSchema := range s.dependentFields {
+	if objMap, ok := convertToMap(value); ok && value != nil {
+		s.validateDependents(objMap, ctx)
+	}
+
+	return nil
+}
+
+// ValidateAndTransform runs the base object's transformation, then
+// checks dependent fields against the original object.
+func (s *ObjectSchemaWithDependencies) ValidateAndTransform(value interface{}, ctx *queryfy.ValidationContext) (interface{}, error) {
+	result, _ := s.ObjectSchema.ValidateAndTransform(value, ctx)
+	if objMap, ok := convertToMap(value); ok && value != nil {
+		s.validateDependents(objMap, ctx)
+	}
+	return result, ctx.Error()
+}
+
+// validateDependents applies each dependent rule, in field name order.
+func (s *ObjectSchemaWithDependencies) validateDependents(objMap map[string]interface{}, ctx *queryfy.ValidationContext) {
+	for _, fieldName := range s.DependentFieldNames() {
+		depSchema := s.dependentFields[fieldName]
 		fieldValue, exists := objMap[fieldName]
 
 		ctx.WithPath(fieldName, func() {
-			// If field doesn't exist, check if it's required based on condition
-			if !exists {
-				if depSchema.conditionFunc != nil && depSchema.conditionFunc(objMap) {
-					if depSchema.IsRequired() || (depSchema.schema != nil && isRequired(depSchema.schema)) {
-						ctx.AddError("field is required", nil)
-					}
-				}
-			} else {
+			if exists {
 				// Validate with parent context
 				depSchema.ValidateWithParent(fieldValue, objMap, ctx)
+				return
+			}
+			if s.baseRequires(fieldName) {
+				return // already reported by the base object
+			}
+			// If field doesn't exist, check if it's required based on condition
+			if depSchema.conditionMet(objMap, ctx.Mode()) {
+				if depSchema.IsRequired() || (depSchema.schema != nil && isRequired(depSchema.schema)) {
+					ctx.AddCodedError(queryfy.CodeRequired, nil, nil)
+				}
+			} else if depSchema.elseSchema != nil && isRequired(depSchema.elseSchema) {
+				ctx.AddCodedError(queryfy.CodeRequired, nil, nil)
 			}
 		})
 	}
+}
 
-	return nil
+// baseRequires reports whether the base object already requires a field
+// unconditionally.
+func (s *ObjectSchemaWithDependencies) baseRequires(name string) bool {
+	if s.requiredFields[name] {
+		return true
+	}
+	field, ok := s.fields[name]
+	if !ok {
+		return false
+	}
+	if _, dependent := field.(*DependentSchema); dependent {
+		return false
+	}
+	return isRequired(field)
 }
 
 // Helper functions for common patterns
 
 // RequiredWhen creates a schema that makes a field required when a condition is met.
-func RequiredWhen(condition DependencyCondition, schema queryfy.Schema) *DependentSchema {
+func RequiredWhen(condition *Condition, schema queryfy.Schema) *DependentSchema {
 	return Dependent("").
 		When(condition).
 		Then(markAsRequired(schema))
 }
 
 // RequiredUnless creates a schema that makes a field required unless a condition is met.
-func RequiredUnless(condition DependencyCondition, schema queryfy.Schema) *DependentSchema {
+func RequiredUnless(condition *Condition, schema queryfy.Schema) *DependentSchema {
 	return Dependent("").
-		When(func(data map[string]interface{}) bool {
-			return !condition(data)
-		}).
+		When(WhenNot(condition)).
 		Then(markAsRequired(schema))
 }
 
@@ -432,4 +513,16 @@ schema := builders.Object().WithDependencies().
             )).
             Then(builders.Bool().Required()).
             Else(builders.Bool().Optional()))
+
+// Custom condition logic
+schema := builders.Object().WithDependencies().
+    Field("items", builders.Array()).
+    DependentField("bulkDiscountCode",
+        builders.Dependent("bulkDiscountCode").
+            When(builders.WhenFunc(func(data map[string]interface{}) bool {
+                items, _ := data["items"].([]interface{})
+                return len(items) > 100
+            })).
+            Then(builders.Bool().Required()).
+            Else(builders.Bool().Optional()))
 */
@@ -115,6 +115,9 @@ func collectFields(schema queryfy.Schema) []fieldEntry {
 		switch s.(type) {
 		case *ObjectSchema, *ObjectSchemaWithDependencies:
 			return nil
+		case *RefSchema:
+			// The target is visited at the same path
+			return nil
 		case *ArraySchema:
 			// Include arrays only if they have no element schema
 			// (i.e., they're leaves). Arrays with elements are
@@ -168,6 +171,10 @@ func describeChange(old, new queryfy.Schema) string {
 	case *NumberSchema:
 		n := new.(*NumberSchema)
 		describeNumberChange(o, n, &details)
+	case *DependentSchema:
+		if n, ok := new.(*DependentSchema); ok {
+			describeDependentChange(o, n, &details)
+		}
 	}
 
 	if len(details) == 0 {
@@ -183,6 +190,40 @@ func describeChange(old, new queryfy.Schema) string {
 	return result
 }
 
+func describeDependentChange(old, new *DependentSchema, details *[]string) {
+	oldCond, newCond := conditionString(old.Condition()), conditionString(new.Condition())
+	if oldCond != newCond {
+		*details = append(*details, fmt.Sprintf("condition: %s -> %s", oldCond, newCond))
+	} else if !conditionsEqual(old.Condition(), new.Condition()) {
+		*details = append(*details, "condition: function condition replaced")
+	}
+	if !Equal(old.IfSchema(), new.IfSchema()) {
+		*details = append(*details, "if schema changed")
+	}
+	if !Equal(old.ThenSchema(), new.ThenSchema()) {
+		*details = append(*details, "then schema changed")
+	}
+	if !Equal(old.ElseSchema(), new.ElseSchema()) {
+		*details = append(*details, "else schema changed")
+	}
+}
+
+// conditionsEqual compares two conditions as Equal does. Function
+// conditions print alike, so their String forms cannot tell them apart.
+func conditionsEqual(a, b *Condition) bool {
+	ca, cb := &canonicalBuilder{}, &canonicalBuilder{}
+	canonicalCondition(ca, a)
+	canonicalCondition(cb, b)
+	return ca.String() == cb.String()
+}
+
+func conditionString(c *Condition) string {
+	if c == nil {
+		return "none"
+	}
+	return c.String()
+}
+
 func describeStringChange(old, new *StringSchema, details *[]string) {
 	if old.FormatType() != new.FormatType() {
 		*details = append(*details, fmt.Sprintf("format: %q -> %q", old.FormatType(), new.FormatType()))
@@ -122,6 +122,52 @@ func TestDiff_BecameRequired(t *testing.T) {
 	}
 }
 
+func TestDiff_DependentCondition(t *testing.T) {
+	old := builders.Object().WithDependencies().
+		DependentField("state", builders.Dependent("state").
+			When(builders.WhenEquals("country", "US")).
+			Then(builders.String().Required()))
+
+	new := builders.Object().WithDependencies().
+		DependentField("state", builders.Dependent("state").
+			When(builders.WhenIn("country", "US", "CA")).
+			Then(builders.String().Required()))
+
+	diff, err := builders.Diff(old, new)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diff.Changed) != 1 {
+		t.Fatalf("expected 1 change, got %v", diff.Changed)
+	}
+	want := `condition: country == "US" -> country in ["US", "CA"]`
+	if diff.Changed[0].Path != "state" || diff.Changed[0].Details != want {
+		t.Errorf("unexpected change %+v", diff.Changed[0])
+	}
+}
+
+func TestDiff_FunctionCondition(t *testing.T) {
+	build := func(cond *builders.Condition) *builders.ObjectSchemaWithDependencies {
+		return builders.Object().WithDependencies().
+			DependentField("state", builders.Dependent("state").
+				When(cond).
+				Then(builders.String().Required()))
+	}
+	old := build(builders.WhenFunc(func(map[string]interface{}) bool { return true }))
+	new := build(builders.WhenFunc(func(map[string]interface{}) bool { return false }))
+
+	diff, err := builders.Diff(old, new)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diff.Changed) != 1 {
+		t.Fatalf("expected 1 change, got %v", diff.Changed)
+	}
+	if want := "condition: function condition replaced"; diff.Changed[0].Details != want {
+		t.Errorf("unexpected change %+v", diff.Changed[0])
+	}
+}
+
 func TestDiff_NestedChanges(t *testing.T) {
 	old := builders.Object().
 		Field("user", builders.Object().
@@ -18,8 +18,12 @@ import (
 // Custom validators and async validators are excluded from the hash
 // because Go functions are not comparable or serialisable. Two schemas
 // that differ only in custom validators will produce the same hash.
+// Declarative conditions built with the When* helpers are included. A
+// WhenFunc condition is included by identity, so schemas holding
+// different function conditions never share a hash.
 //
-// The hash is stable across process restarts.
+// The hash is stable across process restarts, except for schemas with
+// WhenFunc conditions, whose identity differs from run to run.
 func Hash(schema queryfy.Schema) string {
 	h := sha256.New()
 	h.Write([]byte(canonicalise(schema)))
@@ -30,6 +34,8 @@ func Hash(schema queryfy.Schema) string {
 //
 // Like Hash, this excludes custom validators and async validators.
 // Two schemas that differ only in custom validators are considered equal.
+// Schemas with WhenFunc conditions are equal only if they share the
+// same condition values.
 func Equal(a, b queryfy.Schema) bool {
 	return canonicalise(a) == canonicalise(b)
 }
@@ -37,12 +43,20 @@ func Equal(a, b queryfy.Schema) bool {
 // canonicalise produces a deterministic string representation of a
 // schema's structure and constraints.
 func canonicalise(schema queryfy.Schema) string {
-	var b strings.Builder
-	canonicaliseNode(&b, schema)
+	b := &canonicalBuilder{active: make(map[*refDefinition]bool)}
+	canonicaliseNode(b, schema)
 	return b.String()
 }
 
-func canonicaliseNode(b *strings.Builder, schema queryfy.Schema) {
+// canonicalBuilder accumulates the canonical form. active holds the
+// references currently being expanded so recursive definitions are
+// written once and then referred to by name.
+type canonicalBuilder struct {
+	strings.Builder
+	active map[*refDefinition]bool
+}
+
+func canonicaliseNode(b *canonicalBuilder, schema queryfy.Schema) {
 	if schema == nil {
 		b.WriteString("null")
 		return
@@ -60,10 +74,20 @@ func canonicaliseNode(b *strings.Builder, schema queryfy.Schema) {
 	case *ObjectSchema:
 		canonicaliseObject(b, s)
 	case *ObjectSchemaWithDependencies:
-		// Dependent schemas canonicalise as their base object
-		// (dependency conditions involve functions — not comparable)
 		canonicaliseObject(b, s.ObjectSchema)
-		b.WriteString(";deps")
+		b.WriteString(";deps{")
+		for i, name := range s.DependentFieldNames() {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(name)
+			b.WriteString(":")
+			dep, _ := s.GetDependentField(name)
+			canonicaliseDependent(b, dep)
+		}
+		b.WriteString("}")
+	case *DependentSchema:
+		canonicaliseDependent(b, s)
 	case *ArraySchema:
 		canonicaliseArray(b, s)
 	case *CustomSchema:
@@ -83,6 +107,7 @@ func canonicaliseNode(b *strings.Builder, schema queryfy.Schema) {
 			canonicaliseNode(b, sub)
 		}
 		b.WriteString(")")
+		canonicaliseBase(b, &s.BaseSchema)
 	case *OrSchema:
 		b.WriteString("or(")
 		for i, sub := range s.Schemas() {
@@ -92,10 +117,32 @@ func canonicaliseNode(b *strings.Builder, schema queryfy.Schema) {
 			canonicaliseNode(b, sub)
 		}
 		b.WriteString(")")
+		canonicaliseBase(b, &s.BaseSchema)
+	case *OneOfSchema:
+		b.WriteString("oneof(")
+		for i, sub := range s.Schemas() {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			canonicaliseNode(b, sub)
+		}
+		b.WriteString(")")
+		canonicaliseBase(b, &s.BaseSchema)
 	case *NotSchema:
 		b.WriteString("not(")
 		canonicaliseNode(b, s.InnerSchema())
 		b.WriteString(")")
+		canonicaliseBase(b, &s.BaseSchema)
+	case *RefSchema:
+		b.WriteString(fmt.Sprintf("ref<%s>", s.Name()))
+		canonicaliseBase(b, &s.BaseSchema)
+		if target := s.Target(); target != nil && !b.active[s.def] {
+			b.active[s.def] = true
+			b.WriteString("(")
+			canonicaliseNode(b, target)
+			b.WriteString(")")
+			delete(b.active, s.def)
+		}
 	default:
 		// Unknown schema type — use type name
 		b.WriteString(fmt.Sprintf("unknown<%T>", schema))
@@ -103,7 +150,33 @@ func canonicaliseNode(b *strings.Builder, schema queryfy.Schema) {
 	}
 }
 
-func canonicaliseBase(b *strings.Builder, base *queryfy.BaseSchema) {
+// canonicaliseDependent writes a dependent rule: its condition (or If
+// schema) and both branches.
+func canonicaliseDependent(b *canonicalBuilder, s *DependentSchema) {
+	b.WriteString("dependent<")
+	b.WriteString(s.FieldName())
+	b.WriteString(">")
+	canonicaliseBase(b, &s.BaseSchema)
+	on := s.DependsOn()
+	sort.Strings(on)
+	b.WriteString(fmt.Sprintf(";on=%v", on))
+	if c := s.Condition(); c != nil {
+		b.WriteString(";when=")
+		canonicalCondition(b, c)
+	}
+	if s.IfSchema() != nil {
+		b.WriteString(";if=")
+		canonicaliseNode(b, s.IfSchema())
+	}
+	b.WriteString(";then=")
+	canonicaliseNode(b, s.ThenSchema())
+	if s.ElseSchema() != nil {
+		b.WriteString(";else=")
+		canonicaliseNode(b, s.ElseSchema())
+	}
+}
+
+func canonicaliseBase(b *canonicalBuilder, base *queryfy.BaseSchema) {
 	if base.IsRequired() {
 		b.WriteString(";req")
 	}
@@ -116,7 +189,7 @@ func canonicaliseBase(b *strings.Builder, base *queryfy.BaseSchema) {
 	}
 }
 
-func canonicaliseMeta(b *strings.Builder, meta map[string]interface{}) {
+func canonicaliseMeta(b *canonicalBuilder, meta map[string]interface{}) {
 	// Sort keys for determinism
 	keys := make([]string, 0, len(meta))
 	for k := range meta {
@@ -133,7 +206,7 @@ func canonicaliseMeta(b *strings.Builder, meta map[string]interface{}) {
 	b.WriteString("}")
 }
 
-func canonicaliseString(b *strings.Builder, s *StringSchema) {
+func canonicaliseString(b *canonicalBuilder, s *StringSchema) {
 	b.WriteString("string")
 	canonicaliseBase(b, &s.BaseSchema)
 
@@ -155,7 +228,7 @@ func canonicaliseString(b *strings.Builder, s *StringSchema) {
 	}
 }
 
-func canonicaliseNumber(b *strings.Builder, s *NumberSchema) {
+func canonicaliseNumber(b *canonicalBuilder, s *NumberSchema) {
 	b.WriteString("number")
 	canonicaliseBase(b, &s.BaseSchema)
 
@@ -172,14 +245,20 @@ func canonicaliseNumber(b *strings.Builder, s *NumberSchema) {
 	if m := s.MultipleOfValue(); m != nil {
 		b.WriteString(fmt.Sprintf(";mul=%g", *m))
 	}
+	if precision, scale, ok := s.DecimalConstraint(); ok {
+		b.WriteString(fmt.Sprintf(";dec=%d,%d", precision, scale))
+	}
 }
 
-func canonicaliseBool(b *strings.Builder, s *BoolSchema) {
+func canonicaliseBool(b *canonicalBuilder, s *BoolSchema) {
 	b.WriteString("bool")
 	canonicaliseBase(b, &s.BaseSchema)
+	if cv := s.ConstValue(); cv != nil {
+		b.WriteString(fmt.Sprintf(";const=%t", *cv))
+	}
 }
 
-func canonicaliseDateTime(b *strings.Builder, s *DateTimeSchema) {
+func canonicaliseDateTime(b *canonicalBuilder, s *DateTimeSchema) {
 	b.WriteString("datetime")
 	canonicaliseBase(b, &s.BaseSchema)
 
@@ -196,7 +275,7 @@ func canonicaliseDateTime(b *strings.Builder, s *DateTimeSchema) {
 	}
 }
 
-func canonicaliseObject(b *strings.Builder, s *ObjectSchema) {
+func canonicaliseObject(b *canonicalBuilder, s *ObjectSchema) {
 	b.WriteString("object")
 	canonicaliseBase(b, &s.BaseSchema)
 
@@ -220,7 +299,7 @@ func canonicaliseObject(b *strings.Builder, s *ObjectSchema) {
 	b.WriteString("}")
 }
 
-func canonicaliseArray(b *strings.Builder, s *ArraySchema) {
+func canonicaliseArray(b *canonicalBuilder, s *ArraySchema) {
 	b.WriteString("array")
 	canonicaliseBase(b, &s.BaseSchema)
 
@@ -133,6 +133,18 @@ func TestHash_Composites(t *testing.T) {
 	}
 }
 
+func TestHash_OneOfVsOr(t *testing.T) {
+	or := builders.Or(builders.String(), builders.Bool())
+	oneOf := builders.OneOf(builders.String(), builders.Bool())
+
+	if builders.Hash(or) == builders.Hash(oneOf) {
+		t.Error("Or and OneOf over the same schemas should hash differently")
+	}
+	if builders.Equal(oneOf, builders.OneOf(builders.String(), builders.Bool()).Required()) {
+		t.Error("required flag on a composite should matter")
+	}
+}
+
 func TestHash_DateTime(t *testing.T) {
 	s1 := builders.DateTime().DateOnly().StrictFormat()
 	s2 := builders.DateTime().DateOnly().StrictFormat()
@@ -216,3 +228,36 @@ func TestEqual_AllowAdditionalMatters(t *testing.T) {
 		t.Error("allow(true) vs default should differ")
 	}
 }
+
+func TestEqual_DependentConditionMatters(t *testing.T) {
+	build := func(cond *builders.Condition) *builders.ObjectSchemaWithDependencies {
+		return builders.Object().WithDependencies().
+			DependentField("state", builders.Dependent("state").
+				When(cond).
+				Then(builders.String().Required()))
+	}
+
+	if !builders.Equal(build(builders.WhenEquals("country", "US")), build(builders.WhenEquals("country", "US"))) {
+		t.Error("identical conditions should be equal")
+	}
+	if builders.Equal(build(builders.WhenEquals("country", "US")), build(builders.WhenEquals("country", "CA"))) {
+		t.Error("different condition values should not be equal")
+	}
+	if builders.Hash(build(builders.WhenTrue("a"))) == builders.Hash(build(builders.WhenFalse("a"))) {
+		t.Error("different condition ops should hash differently")
+	}
+
+	// Function conditions cannot be compared, so only the same condition
+	// is equal to itself
+	f1 := builders.WhenFunc(func(map[string]interface{}) bool { return true })
+	f2 := builders.WhenFunc(func(map[string]interface{}) bool { return false })
+	if !builders.Equal(build(f1), build(f1)) {
+		t.Error("a shared function condition should be equal to itself")
+	}
+	if builders.Equal(build(f1), build(f2)) {
+		t.Error("different function conditions should not be equal")
+	}
+	if builders.Hash(build(f1)) == builders.Hash(build(f2)) {
+		t.Error("different function conditions should hash differently")
+	}
+}
@@ -2,7 +2,6 @@
 package builders
 
 import (
-	"fmt"
 	"sync"
 
 	"github.com/ha1tch/queryfy"
@@ -78,7 +77,7 @@ func (s *StringSchema) FormatString(name string) *StringSchema {
 			if LookupFormat(name) != nil {
 				return LookupFormat(name)(value)
 			}
-			return fmt.Errorf("unknown format: %q", name)
+			return &queryfy.CodedError{Code: queryfy.CodeUnknownFormat, Params: queryfy.Params{"format": name}}
 		})
 	}
 	return s
@@ -0,0 +1,223 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ha1tch/queryfy/builders"
+	"github.com/ha1tch/queryfy/builders/jsonschema"
+)
+
+// ======================================================================
+// Combinator import
+// ======================================================================
+
+func TestFromJSON_AnyOfScalar(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"anyOf": [
+			{"type": "string", "maxLength": 3},
+			{"type": "integer"}
+		]
+	}`), nil)
+	assertNoErrors(t, errs)
+	if _, ok := schema.(*builders.OrSchema); !ok {
+		t.Fatalf("expected *OrSchema, got %T", schema)
+	}
+	assertValid(t, schema, "abc")
+	assertValid(t, schema, 42.0)
+	assertInvalid(t, schema, "abcd")
+	assertInvalid(t, schema, 1.5)
+}
+
+func TestFromJSON_OneOfExactlyOne(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"oneOf": [
+			{"type": "number", "multipleOf": 3},
+			{"type": "number", "multipleOf": 5}
+		]
+	}`), nil)
+	assertNoErrors(t, errs)
+	if _, ok := schema.(*builders.OneOfSchema); !ok {
+		t.Fatalf("expected *OneOfSchema, got %T", schema)
+	}
+	assertValid(t, schema, 9.0)
+	assertValid(t, schema, 10.0)
+	assertInvalid(t, schema, 15.0) // matches both
+	assertInvalid(t, schema, 7.0)  // matches neither
+}
+
+func TestFromJSON_AllOfObjects(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"allOf": [
+			{"type": "object", "properties": {"a": {"type": "string"}}, "required": ["a"]},
+			{"type": "object", "properties": {"b": {"type": "number"}}, "required": ["b"]}
+		]
+	}`), nil)
+	assertNoErrors(t, errs)
+	// Each branch sees the other's fields, as in JSON Schema
+	assertValid(t, schema, map[string]interface{}{"a": "x", "b": 1.0})
+	assertInvalid(t, schema, map[string]interface{}{"a": "x"})
+	assertInvalid(t, schema, map[string]interface{}{"a": 1.0, "b": 1.0})
+}
+
+func TestFromJSON_Not(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "string",
+		"not": {"enum": ["admin", "root"]}
+	}`), nil)
+	assertNoErrors(t, errs)
+	assertValid(t, schema, "alice")
+	assertInvalid(t, schema, "root")
+	assertInvalid(t, schema, 5.0)
+}
+
+func TestFromJSON_CompositeInheritsType(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {
+			"email": {"type": "string"},
+			"phone": {"type": "string"}
+		},
+		"oneOf": [
+			{"required": ["email"]},
+			{"required": ["phone"]}
+		]
+	}`), nil)
+	assertNoErrors(t, errs)
+	assertValid(t, schema, map[string]interface{}{"email": "a@example.com"})
+	assertValid(t, schema, map[string]interface{}{"phone": "555"})
+	assertInvalid(t, schema, map[string]interface{}{})
+	assertInvalid(t, schema, map[string]interface{}{"email": "a@example.com", "phone": "555"})
+}
+
+func TestFromJSON_CompositeNullBranch(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {
+			"v": {"anyOf": [{"type": "string"}, {"type": "null"}]}
+		}
+	}`), nil)
+	assertNoErrors(t, errs)
+	assertValid(t, schema, map[string]interface{}{"v": nil})
+	assertValid(t, schema, map[string]interface{}{"v": "x"})
+	assertInvalid(t, schema, map[string]interface{}{"v": 1.0})
+}
+
+func TestFromJSON_CompositeRequiredField(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {
+			"id": {"oneOf": [{"type": "string"}, {"type": "integer"}]}
+		},
+		"required": ["id"]
+	}`), nil)
+	assertNoErrors(t, errs)
+	assertValid(t, schema, map[string]interface{}{"id": "a"})
+	assertInvalid(t, schema, map[string]interface{}{})
+}
+
+func TestFromJSON_CompositeWithRef(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"anyOf": [{"$ref": "#/$defs/Code"}, {"type": "boolean"}],
+		"$defs": {"Code": {"type": "string", "pattern": "^[A-Z]+$"}}
+	}`), nil)
+	assertNoErrors(t, errs)
+	assertValid(t, schema, "ABC")
+	assertValid(t, schema, true)
+	assertInvalid(t, schema, "abc")
+}
+
+func TestFromJSON_CompositeRefCycle(t *testing.T) {
+	_, errs := jsonschema.FromJSON([]byte(`{
+		"$ref": "#/$defs/A",
+		"$defs": {
+			"A": {"allOf": [{"$ref": "#/$defs/A"}]}
+		}
+	}`), nil)
+	assertHasError(t, errs, "$ref")
+}
+
+func TestFromJSON_CompositeEmptyArray(t *testing.T) {
+	_, errs := jsonschema.FromJSON([]byte(`{"anyOf": []}`), nil)
+	assertHasError(t, errs, "anyOf")
+}
+
+// ======================================================================
+// Combinator export
+// ======================================================================
+
+func TestExport_Composites(t *testing.T) {
+	m := jsonschema.ToMap(builders.And(builders.String(), builders.String().MinLength(2)), nil)
+	if branches, ok := m["allOf"].([]interface{}); !ok || len(branches) != 2 {
+		t.Errorf("expected allOf with 2 branches, got %v", m)
+	}
+
+	m = jsonschema.ToMap(builders.Or(builders.String(), builders.Bool()), nil)
+	if branches, ok := m["anyOf"].([]interface{}); !ok || len(branches) != 2 {
+		t.Errorf("expected anyOf with 2 branches, got %v", m)
+	}
+
+	m = jsonschema.ToMap(builders.OneOf(builders.String(), builders.Bool()), nil)
+	if branches, ok := m["oneOf"].([]interface{}); !ok || len(branches) != 2 {
+		t.Errorf("expected oneOf with 2 branches, got %v", m)
+	}
+
+	m = jsonschema.ToMap(builders.Not(builders.String()), nil)
+	not, ok := m["not"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected not, got %v", m)
+	}
+	assertMapValue(t, not, "type", "string")
+}
+
+func TestExport_NullableComposite(t *testing.T) {
+	m := jsonschema.ToMap(builders.OneOf(builders.String(), builders.Bool()).Nullable(), nil)
+	branches := m["oneOf"].([]interface{})
+	if len(branches) != 3 {
+		t.Fatalf("expected null branch appended, got %v", branches)
+	}
+	assertMapValue(t, branches[2].(map[string]interface{}), "type", "null")
+
+	m = jsonschema.ToMap(builders.Not(builders.String()).Nullable(), nil)
+	wrapped, ok := m["anyOf"].([]interface{})
+	if !ok || len(wrapped) != 2 {
+		t.Fatalf("expected nullable not wrapped in anyOf, got %v", m)
+	}
+	if _, ok := wrapped[0].(map[string]interface{})["not"]; !ok {
+		t.Errorf("expected first branch to hold not, got %v", wrapped[0])
+	}
+}
+
+func TestRoundTrip_Composites(t *testing.T) {
+	original := `{
+		"type": "object",
+		"properties": {
+			"id": {"oneOf": [{"type": "string"}, {"type": "integer"}]},
+			"tag": {"anyOf": [{"type": "string"}, {"type": "boolean"}, {"type": "null"}]},
+			"code": {"allOf": [{"type": "string", "minLength": 2}, {"type": "string", "maxLength": 4}]},
+			"name": {"not": {"type": "string", "enum": ["root"]}}
+		}
+	}`
+	schema1, errs := jsonschema.FromJSON([]byte(original), nil)
+	assertNoErrors(t, errs)
+
+	exported, err := jsonschema.ToJSON(schema1, nil)
+	if err != nil {
+		t.Fatalf("export error: %v", err)
+	}
+
+	var orig, out map[string]interface{}
+	json.Unmarshal([]byte(original), &orig)
+	json.Unmarshal(exported, &out)
+	compareJSONMaps(t, orig, out, "")
+
+	schema2, errs := jsonschema.FromJSON(exported, nil)
+	assertNoErrors(t, errs)
+	if !builders.Equal(schema1, schema2) {
+		t.Errorf("round-tripped schema differs:\n%s", exported)
+	}
+
+	assertValid(t, schema2, map[string]interface{}{"id": 3.0, "tag": nil, "code": "abc", "name": "alice"})
+	assertInvalid(t, schema2, map[string]interface{}{"code": "abcde"})
+	assertInvalid(t, schema2, map[string]interface{}{"name": "root"})
+}
@@ -0,0 +1,577 @@
+package jsonschema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+)
+
+// Conditional keywords (if/then/else, dependentRequired, dependentSchemas)
+// are mapped onto ObjectSchemaWithDependencies. Each field named in a
+// then/else branch, a dependentSchemas schema or a dependentRequired list
+// becomes one DependentSchema whose condition is a schema set with If:
+//
+//   - if/then/else: the converted "if" schema
+//   - dependentRequired, dependentSchemas: an object requiring the
+//     trigger property
+//
+// Rules converted from the same keyword share one condition schema, which
+// is how the exporter groups them back together.
+//
+// A field can carry one rule. When several keywords constrain the same
+// field, rules that only make it required are merged into one whose
+// condition is an Or of theirs; any other rule goes on an extra open
+// object with dependencies, and the result is an And of the objects,
+// which is what allOf would say.
+
+// branch holds the per-field parts of a then/else/dependentSchemas
+// schema.
+type branch struct {
+	schemas  map[string]queryfy.Schema
+	required map[string]bool
+}
+
+// has reports whether the branch says anything about a field.
+func (b *branch) has(name string) bool {
+	if b == nil {
+		return false
+	}
+	_, declared := b.schemas[name]
+	return declared || b.required[name]
+}
+
+// schema returns the branch's schema for a field: the declared property,
+// or a schema accepting any value, marked required if the branch lists
+// the field in required.
+func (b *branch) schema(name string) queryfy.Schema {
+	s, ok := b.schemas[name]
+	if !ok {
+		s = builders.And()
+	}
+	if b.required[name] {
+		s = markRequired(s)
+	}
+	return s
+}
+
+// names returns every field the branches mention, sorted.
+func branchNames(branches ...*branch) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, b := range branches {
+		if b == nil {
+			continue
+		}
+		for name := range b.schemas {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+		for name := range b.required {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// convertConditionals adds the object's conditional keywords to base as
+// dependent fields. It returns nil if the object has none.
+func (c *converter) convertConditionals(raw map[string]interface{}, path string, base *builders.ObjectSchema) queryfy.Schema {
+	_, hasIf := raw["if"]
+	_, hasThen := raw["then"]
+	_, hasElse := raw["else"]
+	_, hasDepReq := raw["dependentRequired"]
+	_, hasDepSchemas := raw["dependentSchemas"]
+	if !hasIf && !hasDepReq && !hasDepSchemas {
+		if hasThen || hasElse {
+			c.addWarning(path, "if", "then/else without if has no effect")
+		}
+		return nil
+	}
+
+	deps := base.WithDependencies()
+	var extra []*builders.ObjectSchemaWithDependencies
+	add := func(name string, dep *builders.DependentSchema) {
+		targets := append([]*builders.ObjectSchemaWithDependencies{deps}, extra...)
+		for _, target := range targets {
+			prev, taken := target.GetDependentField(name)
+			if !taken {
+				target.DependentField(name, dep)
+				return
+			}
+			if requiredOnly(prev) && requiredOnly(dep) {
+				// Required when either condition holds
+				on := append(prev.DependsOn(), dep.DependsOn()...)
+				target.DependentField(name, builders.Dependent(name).On(on...).
+					If(builders.Or(prev.IfSchema(), dep.IfSchema())).Required())
+				return
+			}
+		}
+		extra = append(extra, builders.Object().AllowAdditional(true).WithDependencies().DependentField(name, dep))
+	}
+
+	if hasIf {
+		c.convertIfThenElse(raw, path, add)
+	}
+
+	presence := make(map[string]*builders.ObjectSchema)
+	hasField := func(trigger string) *builders.ObjectSchema {
+		if cond, ok := presence[trigger]; ok {
+			return cond
+		}
+		cond := builders.Object().RequiredFields(trigger).AllowAdditional(true)
+		presence[trigger] = cond
+		return cond
+	}
+
+	if hasDepSchemas {
+		keywordPath := appendPath(path, "dependentSchemas")
+		entries, ok := raw["dependentSchemas"].(map[string]interface{})
+		if !ok {
+			c.addError(keywordPath, "dependentSchemas", "expected object")
+		}
+		for _, trigger := range sortedKeys(entries) {
+			triggerPath := appendPath(keywordPath, trigger)
+			schemaRaw, ok := entries[trigger].(map[string]interface{})
+			if !ok {
+				c.addError(triggerPath, "dependentSchemas", "expected object")
+				continue
+			}
+			b := c.convertBranch(schemaRaw, triggerPath)
+			cond := hasField(trigger)
+			for _, name := range branchNames(b) {
+				add(name, builders.Dependent(name).On(trigger).If(cond).Then(b.schema(name)))
+			}
+		}
+	}
+
+	if hasDepReq {
+		keywordPath := appendPath(path, "dependentRequired")
+		entries, ok := raw["dependentRequired"].(map[string]interface{})
+		if !ok {
+			c.addError(keywordPath, "dependentRequired", "expected object")
+		}
+		for _, trigger := range sortedKeys(entries) {
+			triggerPath := appendPath(keywordPath, trigger)
+			names, ok := getStringSlice(entries, trigger)
+			if !ok {
+				c.addError(triggerPath, "dependentRequired", "expected array of strings")
+				continue
+			}
+			cond := hasField(trigger)
+			for _, name := range names {
+				add(name, builders.Dependent(name).On(trigger).If(cond).Required())
+			}
+		}
+	}
+
+	if len(extra) == 0 {
+		return deps
+	}
+	all := []queryfy.Schema{deps}
+	for _, more := range extra {
+		all = append(all, more)
+	}
+	return builders.And(all...)
+}
+
+// requiredOnly reports whether a rule only makes its field required
+// when its If schema matches, as dependentRequired rules do.
+func requiredOnly(dep *builders.DependentSchema) bool {
+	return dep.IfSchema() != nil && dep.IsRequired() && dep.ThenSchema() == nil && dep.ElseSchema() == nil
+}
+
+// convertIfThenElse converts if/then/else into one rule per field named
+// in then or else.
+func (c *converter) convertIfThenElse(raw map[string]interface{}, path string, add func(name string, dep *builders.DependentSchema)) {
+	ifPath := appendPath(path, "if")
+	ifRaw, ok := raw["if"].(map[string]interface{})
+	if !ok {
+		c.addError(ifPath, "if", "expected object")
+		return
+	}
+	cond := openObject(c.convertNode(inheritType(ifRaw, "object"), ifPath))
+
+	var thenBranch, elseBranch *branch
+	if v, ok := raw["then"]; ok {
+		thenBranch = c.convertBranchValue(v, appendPath(path, "then"), "then")
+	}
+	if v, ok := raw["else"]; ok {
+		elseBranch = c.convertBranchValue(v, appendPath(path, "else"), "else")
+	}
+
+	on := conditionFields(cond)
+	for _, name := range branchNames(thenBranch, elseBranch) {
+		dep := builders.Dependent(name).On(on...).If(cond)
+		if thenBranch.has(name) {
+			dep.Then(thenBranch.schema(name))
+		}
+		if elseBranch.has(name) {
+			dep.Else(elseBranch.schema(name))
+		}
+		add(name, dep)
+	}
+}
+
+// convertBranchValue checks that a then/else value is an object before
+// converting it.
+func (c *converter) convertBranchValue(value interface{}, path, keyword string) *branch {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		c.addError(path, keyword, "expected object")
+		return nil
+	}
+	return c.convertBranch(m, path)
+}
+
+// convertBranch splits a then/else/dependentSchemas schema into its
+// properties and required list. Other validation keywords cannot be
+// attached to a single field and are reported as unsupported.
+func (c *converter) convertBranch(raw map[string]interface{}, path string) *branch {
+	b := &branch{
+		schemas:  make(map[string]queryfy.Schema),
+		required: make(map[string]bool),
+	}
+
+	for _, key := range sortedKeys(raw) {
+		switch {
+		case key == "properties" || key == "required" || key == "type":
+		case refAnnotations[key], strings.HasPrefix(key, "x-"):
+		default:
+			c.reportUnsupported(path, key, "only properties and required are supported inside conditional schemas")
+		}
+	}
+
+	if t, ok := getString(raw, "type"); ok && t != "object" {
+		c.addError(path, "type", fmt.Sprintf("conditional schema must describe an object, got %q", t))
+	}
+
+	if names, ok := getStringSlice(raw, "required"); ok {
+		for _, name := range names {
+			b.required[name] = true
+		}
+	}
+
+	if props, ok := raw["properties"]; ok {
+		propsMap, ok := props.(map[string]interface{})
+		if !ok {
+			c.addError(path, "properties", "expected object")
+			return b
+		}
+		for _, name := range sortedKeys(propsMap) {
+			fieldPath := appendPath(path, "properties."+name)
+			fieldRaw, ok := propsMap[name].(map[string]interface{})
+			if !ok {
+				c.addError(fieldPath, "properties", "expected object for field definition")
+				continue
+			}
+			c.depth++
+			b.schemas[name] = c.convertNode(fieldRaw, fieldPath)
+			c.depth--
+		}
+	}
+	return b
+}
+
+// conditionFields lists the fields an object condition looks at, for
+// DependentSchema.On.
+func conditionFields(cond queryfy.Schema) []string {
+	obj, ok := cond.(*builders.ObjectSchema)
+	if !ok {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, name := range append(obj.FieldNames(), obj.RequiredFieldNames()...) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ======================================================================
+// Export
+// ======================================================================
+
+// conditionalGroup is the set of rules sharing one If schema or one
+// declarative When condition.
+type conditionalGroup struct {
+	cond  queryfy.Schema
+	when  *builders.Condition
+	names []string
+	rules []*builders.DependentSchema
+}
+
+// exportDependentObject exports the base object, then its dependent
+// rules as if/then/else, dependentRequired or dependentSchemas. Rules
+// sharing an If schema or an equivalent When condition are emitted
+// together. Rules that use a function condition cannot be exported and are
+// skipped.
+func (e *exporter) exportDependentObject(s *builders.ObjectSchemaWithDependencies) map[string]interface{} {
+	out := e.exportObject(s.ObjectSchema)
+
+	var groups []*conditionalGroup
+	byCond := make(map[queryfy.Schema]*conditionalGroup)
+	byWhen := make(map[string]*conditionalGroup)
+	for _, name := range s.DependentFieldNames() {
+		dep, _ := s.GetDependentField(name)
+		var g *conditionalGroup
+		if cond := dep.IfSchema(); cond != nil {
+			if g = byCond[cond]; g == nil {
+				g = &conditionalGroup{cond: cond}
+				byCond[cond] = g
+				groups = append(groups, g)
+			}
+		} else if when := dep.Condition(); when != nil && when.IsDeclarative() {
+			key := when.String()
+			if g = byWhen[key]; g == nil {
+				g = &conditionalGroup{when: when}
+				byWhen[key] = g
+				groups = append(groups, g)
+			}
+		} else {
+			continue
+		}
+		g.names = append(g.names, name)
+		g.rules = append(g.rules, dep)
+	}
+
+	var ifThenElse []map[string]interface{}
+	for _, g := range groups {
+		if g.when != nil {
+			entry := map[string]interface{}{"if": exportCondition(g.when)}
+			if then := e.exportBranch(g, false); len(then) > 1 {
+				entry["then"] = then
+			}
+			if g.hasElse() {
+				entry["else"] = e.exportBranch(g, true)
+			}
+			ifThenElse = append(ifThenElse, entry)
+			continue
+		}
+
+		if trigger, ok := presenceTrigger(g.cond); ok && !g.hasElse() {
+			if g.requiredOnly() {
+				depReq := subMap(out, "dependentRequired")
+				list, _ := depReq[trigger].([]interface{})
+				for _, name := range g.names {
+					list = append(list, name)
+				}
+				depReq[trigger] = list
+			} else {
+				subMap(out, "dependentSchemas")[trigger] = e.exportBranch(g, false)
+			}
+			continue
+		}
+
+		entry := map[string]interface{}{"if": e.exportNode(g.cond)}
+		if then := e.exportBranch(g, false); len(then) > 1 {
+			entry["then"] = then
+		}
+		if g.hasElse() {
+			entry["else"] = e.exportBranch(g, true)
+		}
+		ifThenElse = append(ifThenElse, entry)
+	}
+
+	switch len(ifThenElse) {
+	case 0:
+	case 1:
+		for k, v := range ifThenElse[0] {
+			out[k] = v
+		}
+	default:
+		// JSON Schema allows one if per schema; combine the rest
+		all := make([]interface{}, len(ifThenElse))
+		for i, entry := range ifThenElse {
+			all[i] = entry
+		}
+		out["allOf"] = all
+	}
+
+	return out
+}
+
+// exportCondition translates a declarative When condition into an
+// equivalent JSON Schema for "if". Field tests become property
+// constraints plus required, since the When helpers treat a missing
+// field as not matching.
+func exportCondition(c *builders.Condition) map[string]interface{} {
+	field := func(constraint map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"properties": map[string]interface{}{c.Field: constraint},
+			"required":   []interface{}{c.Field},
+		}
+	}
+	nested := func() []interface{} {
+		out := make([]interface{}, len(c.Conditions))
+		for i, sub := range c.Conditions {
+			out[i] = exportCondition(sub)
+		}
+		return out
+	}
+
+	switch c.Op {
+	case builders.OpEquals:
+		return field(map[string]interface{}{"const": c.Value})
+	case builders.OpNotEquals:
+		return map[string]interface{}{"not": field(map[string]interface{}{"const": c.Value})}
+	case builders.OpExists:
+		return field(map[string]interface{}{"not": map[string]interface{}{"type": "null"}})
+	case builders.OpNotExists:
+		return map[string]interface{}{"not": field(map[string]interface{}{"not": map[string]interface{}{"type": "null"}})}
+	case builders.OpIn:
+		constraint := map[string]interface{}{"enum": c.Values}
+		if allStrings(c.Values) {
+			constraint["type"] = "string"
+		}
+		return field(constraint)
+	case builders.OpGreaterThan:
+		return field(map[string]interface{}{"type": "number", "exclusiveMinimum": c.Value})
+	case builders.OpLessThan:
+		return field(map[string]interface{}{"type": "number", "exclusiveMaximum": c.Value})
+	case builders.OpTrue:
+		return field(map[string]interface{}{"const": true})
+	case builders.OpFalse:
+		return field(map[string]interface{}{"const": false})
+	case builders.OpAll:
+		return map[string]interface{}{"allOf": nested()}
+	case builders.OpAny:
+		return map[string]interface{}{"anyOf": nested()}
+	case builders.OpNot:
+		return map[string]interface{}{"not": nested()[0]}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// allStrings reports whether every value is a string.
+func allStrings(values []interface{}) bool {
+	for _, v := range values {
+		if _, ok := v.(string); !ok {
+			return false
+		}
+	}
+	return len(values) > 0
+}
+
+// exportBranch renders the then (or else) side of a group as an object
+// schema with properties and required.
+func (e *exporter) exportBranch(g *conditionalGroup, elseSide bool) map[string]interface{} {
+	out := map[string]interface{}{"type": "object"}
+	properties := make(map[string]interface{})
+	var required []interface{}
+
+	for i, dep := range g.rules {
+		name := g.names[i]
+		schema := dep.ThenSchema()
+		req := dep.IsRequired()
+		if elseSide {
+			schema = dep.ElseSchema()
+			req = false
+		}
+		if schema != nil {
+			req = req || isRequired(schema)
+			if !isAnyValue(schema) {
+				properties[name] = e.exportNode(schema)
+			}
+		}
+		if req {
+			required = append(required, name)
+		}
+	}
+
+	if len(properties) > 0 {
+		out["properties"] = properties
+	}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+// hasElse reports whether any rule in the group has an Else schema.
+func (g *conditionalGroup) hasElse() bool {
+	for _, dep := range g.rules {
+		if dep.ElseSchema() != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredOnly reports whether every rule only makes its field required,
+// which is what dependentRequired expresses.
+func (g *conditionalGroup) requiredOnly() bool {
+	for _, dep := range g.rules {
+		then := dep.ThenSchema()
+		switch {
+		case then == nil:
+			if !dep.IsRequired() {
+				return false
+			}
+		case isAnyValue(then):
+			if !isRequired(then) && !dep.IsRequired() {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// presenceTrigger recognises the condition dependentRequired and
+// dependentSchemas import to: an object that only requires one field.
+func presenceTrigger(cond queryfy.Schema) (string, bool) {
+	obj, ok := cond.(*builders.ObjectSchema)
+	if !ok || len(obj.FieldNames()) > 0 {
+		return "", false
+	}
+	required := obj.RequiredFieldNames()
+	if len(required) != 1 {
+		return "", false
+	}
+	if allow, _ := obj.AllowsAdditional(); !allow {
+		return "", false
+	}
+	return required[0], true
+}
+
+// isAnyValue reports whether a schema is the empty And used for fields
+// that a branch only lists as required.
+func isAnyValue(schema queryfy.Schema) bool {
+	and, ok := schema.(*builders.AndSchema)
+	return ok && len(and.Schemas()) == 0
+}
+
+// subMap returns out[key] as a map, creating it if needed.
+func subMap(out map[string]interface{}, key string) map[string]interface{} {
+	m, ok := out[key].(map[string]interface{})
+	if !ok {
+		m = make(map[string]interface{})
+		out[key] = m
+	}
+	return m
+}
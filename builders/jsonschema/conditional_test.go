@@ -0,0 +1,341 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+	"github.com/ha1tch/queryfy/builders/jsonschema"
+)
+
+// ======================================================================
+// Conditional import
+// ======================================================================
+
+func TestFromJSON_IfThenElseSemantics(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {
+			"type": {"type": "string", "enum": ["personal", "business"]},
+			"taxId": {"type": "string"}
+		},
+		"required": ["type"],
+		"if": {"properties": {"type": {"const": "business"}}},
+		"then": {
+			"properties": {"taxId": {"minLength": 5, "type": "string"}},
+			"required": ["taxId"]
+		},
+		"else": {
+			"properties": {"nickname": {"type": "string"}},
+			"required": ["nickname"]
+		}
+	}`), nil)
+	assertNoErrors(t, errs)
+
+	deps, ok := schema.(*builders.ObjectSchemaWithDependencies)
+	if !ok {
+		t.Fatalf("expected *ObjectSchemaWithDependencies, got %T", schema)
+	}
+	if names := deps.DependentFieldNames(); len(names) != 2 || names[0] != "nickname" || names[1] != "taxId" {
+		t.Errorf("unexpected dependent fields %v", names)
+	}
+
+	assertValid(t, schema, map[string]interface{}{"type": "business", "taxId": "12345"})
+	assertInvalid(t, schema, map[string]interface{}{"type": "business"})
+	assertInvalid(t, schema, map[string]interface{}{"type": "business", "taxId": "123"})
+	assertValid(t, schema, map[string]interface{}{"type": "personal", "nickname": "al"})
+	assertInvalid(t, schema, map[string]interface{}{"type": "personal"})
+	// then does not apply when the condition fails
+	assertValid(t, schema, map[string]interface{}{"type": "personal", "nickname": "al", "taxId": "1"})
+}
+
+func TestFromJSON_IfCompiled(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {"premium": {"type": "boolean"}},
+		"if": {"properties": {"premium": {"const": true}}, "required": ["premium"]},
+		"then": {"properties": {"limit": {"type": "number", "maximum": 100000}}},
+		"else": {"properties": {"limit": {"type": "number", "maximum": 1000}}}
+	}`), nil)
+	assertNoErrors(t, errs)
+
+	compiled := queryfy.Compile(schema)
+	if err := queryfy.Validate(map[string]interface{}{"premium": true, "limit": 5000.0}, compiled); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := queryfy.Validate(map[string]interface{}{"premium": false, "limit": 5000.0}, compiled); err == nil {
+		t.Error("expected else branch to reject limit")
+	}
+}
+
+func TestFromJSON_DependentRequiredSemantics(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {
+			"creditCard": {"type": "string"},
+			"billingAddress": {"type": "string"}
+		},
+		"dependentRequired": {"creditCard": ["billingAddress"]}
+	}`), nil)
+	assertNoErrors(t, errs)
+
+	assertValid(t, schema, map[string]interface{}{})
+	assertValid(t, schema, map[string]interface{}{"billingAddress": "x"})
+	assertValid(t, schema, map[string]interface{}{"creditCard": "4111", "billingAddress": "x"})
+	assertInvalid(t, schema, map[string]interface{}{"creditCard": "4111"})
+	// the base property still applies
+	assertInvalid(t, schema, map[string]interface{}{"creditCard": "4111", "billingAddress": 1.0})
+}
+
+func TestFromJSON_DependentSchemasSemantics(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {"creditCard": {"type": "string"}},
+		"dependentSchemas": {
+			"creditCard": {
+				"properties": {"cvv": {"type": "string", "pattern": "^[0-9]{3}$"}},
+				"required": ["cvv"]
+			}
+		}
+	}`), nil)
+	assertNoErrors(t, errs)
+
+	assertValid(t, schema, map[string]interface{}{})
+	assertValid(t, schema, map[string]interface{}{"creditCard": "4111", "cvv": "123"})
+	assertInvalid(t, schema, map[string]interface{}{"creditCard": "4111"})
+	assertInvalid(t, schema, map[string]interface{}{"creditCard": "4111", "cvv": "12"})
+}
+
+func TestFromJSON_ConditionalUnsupportedKeyword(t *testing.T) {
+	_, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"if": {"required": ["a"]},
+		"then": {"minProperties": 2}
+	}`), &jsonschema.Options{StrictMode: true})
+	assertHasError(t, errs, "minProperties")
+}
+
+func TestFromJSON_SeveralRulesPerField(t *testing.T) {
+	// Required by either trigger: one rule with an Or condition
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {"a": {"type": "number"}, "b": {"type": "number"}},
+		"dependentRequired": {"a": ["c"], "b": ["c"]}
+	}`), nil)
+	assertNoErrors(t, errs)
+	if _, ok := schema.(*builders.ObjectSchemaWithDependencies); !ok {
+		t.Errorf("expected *ObjectSchemaWithDependencies, got %T", schema)
+	}
+	assertValid(t, schema, map[string]interface{}{})
+	assertValid(t, schema, map[string]interface{}{"a": 1.0, "b": 1.0, "c": 1.0})
+	assertInvalid(t, schema, map[string]interface{}{"a": 1.0})
+	assertInvalid(t, schema, map[string]interface{}{"b": 1.0})
+
+	// if/then and dependentRequired on the same field both apply
+	schema, errs = jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {"kind": {"type": "string"}, "code": {"type": "string"}, "token": {"type": "string"}},
+		"if": {"properties": {"kind": {"const": "card"}}, "required": ["kind"]},
+		"then": {"properties": {"code": {"type": "string", "minLength": 3}}},
+		"dependentRequired": {"token": ["code"]}
+	}`), nil)
+	assertNoErrors(t, errs)
+	assertValid(t, schema, map[string]interface{}{"kind": "card", "code": "123"})
+	assertValid(t, schema, map[string]interface{}{"kind": "cash", "code": "1"})
+	assertInvalid(t, schema, map[string]interface{}{"kind": "card", "code": "1"})
+	assertInvalid(t, schema, map[string]interface{}{"kind": "cash", "token": "t"})
+	assertInvalid(t, schema, map[string]interface{}{"kind": "card", "token": "t", "code": "1"})
+	assertValid(t, schema, map[string]interface{}{"kind": "card", "token": "t", "code": "123"})
+
+	// The combination exports and imports back to the same rules
+	exported, err := jsonschema.ToJSON(schema, nil)
+	if err != nil {
+		t.Fatalf("export error: %v", err)
+	}
+	again, errs := jsonschema.FromJSON(exported, nil)
+	assertNoErrors(t, errs)
+	assertInvalid(t, again, map[string]interface{}{"kind": "cash", "token": "t"})
+	assertInvalid(t, again, map[string]interface{}{"kind": "card", "code": "1"})
+}
+
+func TestFromJSON_ThenWithoutIf(t *testing.T) {
+	_, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"then": {"required": ["a"]}
+	}`), nil)
+	assertHasWarning(t, errs, "if")
+}
+
+func TestFromJSON_Const(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {
+			"kind": {"const": "fixed"},
+			"version": {"const": 2},
+			"enabled": {"const": true}
+		}
+	}`), nil)
+	assertNoErrors(t, errs)
+	assertValid(t, schema, map[string]interface{}{"kind": "fixed", "version": 2.0, "enabled": true})
+	assertInvalid(t, schema, map[string]interface{}{"kind": "other"})
+	assertInvalid(t, schema, map[string]interface{}{"version": 3.0})
+	assertInvalid(t, schema, map[string]interface{}{"enabled": false})
+}
+
+// ======================================================================
+// Conditional export
+// ======================================================================
+
+func TestExport_IfThenElse(t *testing.T) {
+	cond := builders.Object().
+		Field("type", builders.String().Enum("business")).
+		AllowAdditional(true)
+	schema := builders.Object().
+		Field("type", builders.String()).
+		WithDependencies().
+		DependentField("taxId", builders.Dependent("taxId").On("type").If(cond).
+			Then(builders.String().MinLength(5).Required()))
+
+	m := jsonschema.ToMap(schema, nil)
+	props := m["properties"].(map[string]interface{})
+	if _, ok := props["taxId"]; ok {
+		t.Error("dependent-only field should not appear in properties")
+	}
+	if _, ok := m["if"].(map[string]interface{}); !ok {
+		t.Fatalf("expected if, got %v", m)
+	}
+	then := m["then"].(map[string]interface{})
+	thenProps := then["properties"].(map[string]interface{})
+	assertMapValue(t, thenProps["taxId"].(map[string]interface{}), "minLength", 5)
+	if req := then["required"].([]interface{}); len(req) != 1 || req[0] != "taxId" {
+		t.Errorf("expected then.required [taxId], got %v", req)
+	}
+	if _, ok := m["else"]; ok {
+		t.Error("expected no else")
+	}
+}
+
+func TestExport_WhenFuncSkipped(t *testing.T) {
+	schema := builders.Object().WithDependencies().
+		DependentField("b", builders.Dependent("b").
+			When(builders.WhenFunc(func(data map[string]interface{}) bool {
+				return data["a"] != nil
+			})).
+			Then(builders.String().Required()))
+
+	m := jsonschema.ToMap(schema, nil)
+	for _, key := range []string{"if", "then", "dependentRequired", "dependentSchemas"} {
+		if _, ok := m[key]; ok {
+			t.Errorf("did not expect %s for a When function", key)
+		}
+	}
+}
+
+func TestRoundTrip_Conditionals(t *testing.T) {
+	original := `{
+		"type": "object",
+		"properties": {
+			"type": {"type": "string"},
+			"creditCard": {"type": "string"},
+			"billingAddress": {"type": "string"},
+			"premium": {"type": "boolean"}
+		},
+		"if": {"properties": {"premium": {"type": "boolean", "const": true}}},
+		"then": {"properties": {"limit": {"type": "number", "maximum": 100000}}},
+		"else": {"properties": {"limit": {"type": "number", "maximum": 1000}}},
+		"dependentRequired": {"creditCard": ["billingAddress"]},
+		"dependentSchemas": {
+			"type": {"properties": {"typeNote": {"type": "string"}}, "required": ["typeNote"]}
+		}
+	}`
+	schema1, errs := jsonschema.FromJSON([]byte(original), nil)
+	assertNoErrors(t, errs)
+
+	exported, err := jsonschema.ToJSON(schema1, nil)
+	if err != nil {
+		t.Fatalf("export error: %v", err)
+	}
+
+	var orig, out map[string]interface{}
+	json.Unmarshal([]byte(original), &orig)
+	json.Unmarshal(exported, &out)
+	compareJSONMaps(t, orig, out, "")
+
+	schema2, errs := jsonschema.FromJSON(exported, nil)
+	assertNoErrors(t, errs)
+	if !builders.Equal(schema1, schema2) {
+		t.Errorf("round-tripped schema differs:\n%s", exported)
+	}
+
+	assertValid(t, schema2, map[string]interface{}{"premium": true, "limit": 5000.0})
+	assertInvalid(t, schema2, map[string]interface{}{"premium": false, "limit": 5000.0})
+	assertInvalid(t, schema2, map[string]interface{}{"creditCard": "4111"})
+	assertInvalid(t, schema2, map[string]interface{}{"type": "x"})
+}
+
+func TestExport_When(t *testing.T) {
+	schema := builders.Object().
+		Field("country", builders.String()).
+		Field("total", builders.Number()).
+		WithDependencies().
+		DependentField("state", builders.Dependent("state").
+			When(builders.WhenAll(
+				builders.WhenEquals("country", "US"),
+				builders.WhenGreaterThan("total", 100),
+			)).
+			Then(builders.String().Required()).
+			Else(builders.String()))
+
+	m := jsonschema.ToMap(schema, nil)
+	ifMap, ok := m["if"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected if, got %v", m)
+	}
+	if all, ok := ifMap["allOf"].([]interface{}); !ok || len(all) != 2 {
+		t.Fatalf("expected allOf with 2 conditions, got %v", ifMap)
+	}
+	if _, ok := m["else"]; !ok {
+		t.Error("expected else")
+	}
+
+	// The exported schema behaves like the original
+	data, err := jsonschema.ToJSON(schema, nil)
+	if err != nil {
+		t.Fatalf("export error: %v", err)
+	}
+	imported, errs := jsonschema.FromJSON(data, nil)
+	assertNoErrors(t, errs)
+	for _, s := range []queryfy.Schema{schema, imported} {
+		assertValid(t, s, map[string]interface{}{"country": "US", "total": 150.0, "state": "CA"})
+		assertInvalid(t, s, map[string]interface{}{"country": "US", "total": 150.0})
+		assertValid(t, s, map[string]interface{}{"country": "US", "total": 50.0})
+		assertValid(t, s, map[string]interface{}{"country": "FR", "total": 150.0})
+	}
+}
+
+func TestExport_WhenNegations(t *testing.T) {
+	schema := builders.Object().
+		Field("approved", builders.Bool().Nullable()).
+		Field("status", builders.String()).
+		WithDependencies().
+		DependentField("reason", builders.Dependent("reason").
+			When(builders.WhenAny(
+				builders.WhenNotExists("approved"),
+				builders.WhenNot(builders.WhenIn("status", "ok", "done")),
+			)).
+			Then(builders.String().Required()))
+
+	data, err := jsonschema.ToJSON(schema, nil)
+	if err != nil {
+		t.Fatalf("export error: %v", err)
+	}
+	imported, errs := jsonschema.FromJSON(data, nil)
+	assertNoErrors(t, errs)
+	for _, s := range []queryfy.Schema{schema, imported} {
+		assertInvalid(t, s, map[string]interface{}{"status": "ok"})
+		assertInvalid(t, s, map[string]interface{}{"approved": true, "status": "failed"})
+		assertValid(t, s, map[string]interface{}{"approved": true, "status": "done"})
+		assertValid(t, s, map[string]interface{}{"approved": nil, "status": "ok", "reason": "x"})
+	}
+}
@@ -2,7 +2,9 @@ package jsonschema
 
 import (
 	"encoding/json"
+	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/ha1tch/queryfy"
 	"github.com/ha1tch/queryfy/builders"
@@ -19,17 +21,34 @@ type ExportOptions struct {
 	// IncludeMeta includes stored metadata as extension keywords in the
 	// output (e.g., "x-custom": "value").
 	IncludeMeta bool
+
+	// InlineShared disables hoisting of object schemas that appear more
+	// than once into $defs; every copy is written out in full instead.
+	// References created with builders.Ref are always emitted as $ref,
+	// since recursive definitions cannot be inlined.
+	InlineShared bool
 }
 
 // ToJSON converts a queryfy schema to a JSON Schema document.
 // Returns the JSON bytes and any errors encountered during conversion.
 // A nil opts uses default settings.
+//
+// builders.RefSchema references and object schemas shared by pointer
+// between several fields are emitted once under $defs and referenced
+// with $ref.
 func ToJSON(schema queryfy.Schema, opts *ExportOptions) ([]byte, error) {
+	return json.MarshalIndent(ToMap(schema, opts), "", "  ")
+}
+
+// ToMap converts a queryfy schema to a map representation of JSON Schema.
+// Useful when you need to manipulate the output before serialising.
+func ToMap(schema queryfy.Schema, opts *ExportOptions) map[string]interface{} {
 	if opts == nil {
 		opts = &ExportOptions{}
 	}
 
-	raw := exportNode(schema, opts)
+	e := newExporter(opts)
+	raw := e.exportRoot(schema)
 
 	if opts.SchemaURI != "" {
 		raw["$schema"] = opts.SchemaURI
@@ -38,50 +57,247 @@ func ToJSON(schema queryfy.Schema, opts *ExportOptions) ([]byte, error) {
 		raw["$id"] = opts.ID
 	}
 
-	return json.MarshalIndent(raw, "", "  ")
+	return raw
 }
 
-// ToMap converts a queryfy schema to a map representation of JSON Schema.
-// Useful when you need to manipulate the output before serialising.
-func ToMap(schema queryfy.Schema, opts *ExportOptions) map[string]interface{} {
-	if opts == nil {
-		opts = &ExportOptions{}
-	}
+// exporter holds state during a single export.
+type exporter struct {
+	opts *ExportOptions
+
+	// defs collects hoisted definitions, keyed by name.
+	defs map[string]interface{}
+	// refs maps an already-hoisted schema to its $ref value.
+	refs map[queryfy.Schema]string
+	// uses counts how often each object schema pointer appears.
+	uses map[*builders.ObjectSchema]int
+	// hints holds the field name where a shared object first appears,
+	// used to name its definition.
+	hints map[*builders.ObjectSchema]string
+}
 
-	raw := exportNode(schema, opts)
+func newExporter(opts *ExportOptions) *exporter {
+	return &exporter{
+		opts:  opts,
+		defs:  make(map[string]interface{}),
+		refs:  make(map[queryfy.Schema]string),
+		uses:  make(map[*builders.ObjectSchema]int),
+		hints: make(map[*builders.ObjectSchema]string),
+	}
+}
 
-	if opts.SchemaURI != "" {
-		raw["$schema"] = opts.SchemaURI
+// exportRoot exports the root schema and attaches collected $defs.
+func (e *exporter) exportRoot(schema queryfy.Schema) map[string]interface{} {
+	if !e.opts.InlineShared {
+		e.countUses(schema, "", make(map[queryfy.Schema]bool))
 	}
-	if opts.ID != "" {
-		raw["$id"] = opts.ID
+
+	var raw map[string]interface{}
+	if obj, ok := schema.(*builders.ObjectSchema); ok && e.uses[obj] > 1 {
+		// The root refers to itself; inner uses point back at "#"
+		e.refs[obj] = "#"
+		raw = e.exportObject(obj)
+	} else {
+		raw = e.exportNode(schema)
 	}
 
+	if len(e.defs) > 0 {
+		raw["$defs"] = e.defs
+	}
 	return raw
 }
 
+// countUses records how many times each object schema is reachable, so
+// that shared objects can be hoisted into $defs. Shared objects and
+// reference targets are only descended into once.
+func (e *exporter) countUses(schema queryfy.Schema, hint string, seenRefs map[queryfy.Schema]bool) {
+	switch s := schema.(type) {
+	case *builders.ObjectSchema:
+		e.uses[s]++
+		if e.uses[s] > 1 {
+			return
+		}
+		e.hints[s] = hint
+		for _, name := range s.FieldNames() {
+			field, _ := s.GetField(name)
+			e.countUses(field, name, seenRefs)
+		}
+	case *builders.ArraySchema:
+		if elem := s.ElementSchema(); elem != nil {
+			e.countUses(elem, hint+"Item", seenRefs)
+		}
+	case *builders.TransformSchema:
+		e.countUses(s.InnerSchema(), hint, seenRefs)
+	case *builders.RefSchema:
+		target := s.Target()
+		if target == nil || seenRefs[target] {
+			return
+		}
+		seenRefs[target] = true
+		e.countUses(target, s.Name(), seenRefs)
+	case *builders.AndSchema:
+		for _, sub := range s.Schemas() {
+			e.countUses(sub, hint, seenRefs)
+		}
+	case *builders.OrSchema:
+		for _, sub := range s.Schemas() {
+			e.countUses(sub, hint, seenRefs)
+		}
+	case *builders.OneOfSchema:
+		for _, sub := range s.Schemas() {
+			e.countUses(sub, hint, seenRefs)
+		}
+	case *builders.NotSchema:
+		e.countUses(s.InnerSchema(), hint, seenRefs)
+	case *builders.ObjectSchemaWithDependencies:
+		e.countUses(s.ObjectSchema, hint, seenRefs)
+		// Rules sharing a condition export it once
+		seenConds := make(map[queryfy.Schema]bool)
+		for _, name := range s.DependentFieldNames() {
+			dep, _ := s.GetDependentField(name)
+			if cond := dep.IfSchema(); cond != nil && !seenConds[cond] {
+				seenConds[cond] = true
+				e.countUses(cond, hint, seenRefs)
+			}
+			for _, sub := range []queryfy.Schema{dep.ThenSchema(), dep.ElseSchema()} {
+				if sub != nil {
+					e.countUses(sub, name, seenRefs)
+				}
+			}
+		}
+	}
+}
+
 // exportNode converts a single schema node to its JSON Schema map form.
-func exportNode(schema queryfy.Schema, opts *ExportOptions) map[string]interface{} {
+func (e *exporter) exportNode(schema queryfy.Schema) map[string]interface{} {
 	switch s := schema.(type) {
 	case *builders.StringSchema:
-		return exportString(s, opts)
+		return e.exportString(s)
 	case *builders.NumberSchema:
-		return exportNumber(s, opts)
+		return e.exportNumber(s)
 	case *builders.BoolSchema:
-		return exportBool(s, opts)
+		return e.exportBool(s)
 	case *builders.ObjectSchema:
-		return exportObject(s, opts)
+		if e.uses[s] > 1 {
+			return e.hoist(s, e.hints[s], func() map[string]interface{} {
+				return e.exportObject(s)
+			})
+		}
+		return e.exportObject(s)
+	case *builders.ObjectSchemaWithDependencies:
+		return e.exportDependentObject(s)
 	case *builders.ArraySchema:
-		return exportArray(s, opts)
+		return e.exportArray(s)
+	case *builders.RefSchema:
+		return e.exportRef(s)
 	case *builders.TransformSchema:
 		// Export the inner schema — transforms are a queryfy concept
-		return exportNode(s.InnerSchema(), opts)
+		return e.exportNode(s.InnerSchema())
+	case *builders.AndSchema:
+		return e.exportComposite(s, "allOf", s.Schemas())
+	case *builders.OrSchema:
+		return e.exportComposite(s, "anyOf", s.Schemas())
+	case *builders.OneOfSchema:
+		return e.exportComposite(s, "oneOf", s.Schemas())
+	case *builders.NotSchema:
+		return e.exportComposite(s, "not", []queryfy.Schema{s.InnerSchema()})
 	default:
 		return map[string]interface{}{}
 	}
 }
 
-func exportString(s *builders.StringSchema, opts *ExportOptions) map[string]interface{} {
+// exportRef emits a $ref to the reference target, hoisting the target
+// into $defs on first use.
+func (e *exporter) exportRef(s *builders.RefSchema) map[string]interface{} {
+	target := s.Target()
+	if target == nil {
+		// Unresolved — nothing to define, keep the name for the reader
+		return map[string]interface{}{"$ref": "#/$defs/" + escapePointerToken(s.Name())}
+	}
+
+	out := e.hoist(target, s.Name(), func() map[string]interface{} {
+		if obj, ok := target.(*builders.ObjectSchema); ok {
+			// Bypass the shared-object check in exportNode, which
+			// would otherwise point the definition at itself
+			return e.exportObject(obj)
+		}
+		return e.exportNode(target)
+	})
+
+	// Nullability set on this use site only (the target's own
+	// nullability is part of its definition)
+	if s.BaseSchema.IsNullable() {
+		out["nullable"] = true
+	}
+	includeMeta(s, out, e.opts)
+	return out
+}
+
+// exportComposite emits a combinator. A nullable anyOf/oneOf gets an extra
+// {"type": "null"} branch; a nullable allOf/not is wrapped in an anyOf
+// with one, since adding a branch would change its meaning.
+func (e *exporter) exportComposite(schema queryfy.Schema, keyword string, schemas []queryfy.Schema) map[string]interface{} {
+	branches := make([]interface{}, 0, len(schemas)+1)
+	for _, sub := range schemas {
+		branches = append(branches, e.exportNode(sub))
+	}
+
+	out := map[string]interface{}{}
+	if keyword == "not" {
+		out["not"] = branches[0]
+	} else {
+		out[keyword] = branches
+	}
+
+	if isNullable(schema) {
+		null := map[string]interface{}{"type": "null"}
+		switch keyword {
+		case "anyOf", "oneOf":
+			out[keyword] = append(branches, null)
+		default:
+			out = map[string]interface{}{"anyOf": []interface{}{out, null}}
+		}
+	}
+
+	includeMeta(schema, out, e.opts)
+	return out
+}
+
+// hoist places schema's definition in $defs under a unique name derived
+// from name, and returns a $ref to it. The definition is registered
+// before body runs so that recursive uses resolve to the same $ref.
+func (e *exporter) hoist(schema queryfy.Schema, name string, body func() map[string]interface{}) map[string]interface{} {
+	if ref, ok := e.refs[schema]; ok {
+		return map[string]interface{}{"$ref": ref}
+	}
+
+	name = e.uniqueDefName(schema, name)
+	ref := "#/$defs/" + escapePointerToken(name)
+	e.refs[schema] = ref
+	e.defs[name] = map[string]interface{}{} // reserve the name
+	e.defs[name] = body()
+
+	return map[string]interface{}{"$ref": ref}
+}
+
+// uniqueDefName picks a $defs name for schema, preferring a "title"
+// metadata value, then the given name, with a numeric suffix if taken.
+func (e *exporter) uniqueDefName(schema queryfy.Schema, name string) string {
+	if title, ok := getMetaString(schema, "title"); ok && title != "" {
+		name = title
+	}
+	if name == "" {
+		name = "def"
+	}
+	candidate := name
+	for i := 2; ; i++ {
+		if _, taken := e.defs[candidate]; !taken {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+}
+
+func (e *exporter) exportString(s *builders.StringSchema) map[string]interface{} {
 	out := makeBase(s, "string")
 
 	minLen, maxLen := s.LengthConstraints()
@@ -108,11 +324,11 @@ func exportString(s *builders.StringSchema, opts *ExportOptions) map[string]inte
 		out["format"] = f
 	}
 
-	includeMeta(s, out, opts)
+	includeMeta(s, out, e.opts)
 	return out
 }
 
-func exportNumber(s *builders.NumberSchema, opts *ExportOptions) map[string]interface{} {
+func (e *exporter) exportNumber(s *builders.NumberSchema) map[string]interface{} {
 	typeName := "number"
 	if s.IsInteger() {
 		typeName = "integer"
@@ -138,17 +354,20 @@ func exportNumber(s *builders.NumberSchema, opts *ExportOptions) map[string]inte
 		out["multipleOf"] = *mul
 	}
 
-	includeMeta(s, out, opts)
+	includeMeta(s, out, e.opts)
 	return out
 }
 
-func exportBool(s *builders.BoolSchema, opts *ExportOptions) map[string]interface{} {
+func (e *exporter) exportBool(s *builders.BoolSchema) map[string]interface{} {
 	out := makeBase(s, "boolean")
-	includeMeta(s, out, opts)
+	if cv := s.ConstValue(); cv != nil {
+		out["const"] = *cv
+	}
+	includeMeta(s, out, e.opts)
 	return out
 }
 
-func exportObject(s *builders.ObjectSchema, opts *ExportOptions) map[string]interface{} {
+func (e *exporter) exportObject(s *builders.ObjectSchema) map[string]interface{} {
 	out := makeBase(s, "object")
 
 	fieldNames := s.FieldNames()
@@ -160,14 +379,20 @@ func exportObject(s *builders.ObjectSchema, opts *ExportOptions) map[string]inte
 
 		for _, name := range fieldNames {
 			fieldSchema, _ := s.GetField(name)
-			properties[name] = exportNode(fieldSchema, opts)
+			if _, dependent := fieldSchema.(*builders.DependentSchema); dependent {
+				// Emitted with the conditional keywords instead
+				continue
+			}
+			properties[name] = e.exportNode(fieldSchema)
 
 			if isRequired(fieldSchema) {
 				required = append(required, name)
 			}
 		}
 
-		out["properties"] = properties
+		if len(properties) > 0 {
+			out["properties"] = properties
+		}
 		if len(required) > 0 {
 			out["required"] = required
 		}
@@ -211,11 +436,11 @@ func exportObject(s *builders.ObjectSchema, opts *ExportOptions) map[string]inte
 		out["additionalProperties"] = allow
 	}
 
-	includeMeta(s, out, opts)
+	includeMeta(s, out, e.opts)
 	return out
 }
 
-func exportArray(s *builders.ArraySchema, opts *ExportOptions) map[string]interface{} {
+func (e *exporter) exportArray(s *builders.ArraySchema) map[string]interface{} {
 	out := makeBase(s, "array")
 
 	minItems, maxItems := s.ItemCountConstraints()
@@ -231,10 +456,10 @@ func exportArray(s *builders.ArraySchema, opts *ExportOptions) map[string]interf
 	}
 
 	if elem := s.ElementSchema(); elem != nil {
-		out["items"] = exportNode(elem, opts)
+		out["items"] = e.exportNode(elem)
 	}
 
-	includeMeta(s, out, opts)
+	includeMeta(s, out, e.opts)
 	return out
 }
 
@@ -306,3 +531,26 @@ func isNullable(schema queryfy.Schema) bool {
 	}
 	return false
 }
+
+// getMetaString returns a string metadata value, if the schema has one.
+func getMetaString(schema queryfy.Schema, key string) (string, bool) {
+	type metaGetter interface {
+		GetMeta(string) (interface{}, bool)
+	}
+	mg, ok := schema.(metaGetter)
+	if !ok {
+		return "", false
+	}
+	v, ok := mg.GetMeta(key)
+	if !ok {
+		return "", false
+	}
+	str, ok := v.(string)
+	return str, ok
+}
+
+// escapePointerToken escapes a $defs name for use in a JSON Pointer.
+func escapePointerToken(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	return strings.ReplaceAll(token, "/", "~1")
+}
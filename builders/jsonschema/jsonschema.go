@@ -17,8 +17,11 @@ package jsonschema
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/ha1tch/queryfy"
 	"github.com/ha1tch/queryfy/builders"
@@ -68,24 +71,12 @@ func (e *ConversionError) Error() string {
 
 // unsupported keywords that we explicitly reject
 var unsupportedKeywords = map[string]string{
-	"$ref":                    "schema references are not supported",
-	"$defs":                   "schema definitions are not supported",
-	"definitions":             "schema definitions are not supported",
-	"oneOf":                   "composite schemas are not supported; use queryfy builders directly",
-	"anyOf":                   "composite schemas are not supported; use queryfy builders directly",
-	"allOf":                   "composite schemas are not supported; use queryfy builders directly",
-	"not":                     "composite schemas are not supported; use queryfy builders directly",
-	"if":                      "conditional schemas are not supported",
-	"then":                    "conditional schemas are not supported",
-	"else":                    "conditional schemas are not supported",
-	"dependentRequired":       "dependent schemas are not supported; use queryfy builders directly",
-	"dependentSchemas":        "dependent schemas are not supported; use queryfy builders directly",
-	"patternProperties":       "pattern properties are not supported",
-	"unevaluatedProperties":   "unevaluated properties are not supported",
-	"unevaluatedItems":        "unevaluated items are not supported",
-	"prefixItems":             "tuple validation is not supported",
-	"contains":                "contains is not supported",
-	"additionalItems":         "additionalItems is not supported; use items",
+	"patternProperties":     "pattern properties are not supported",
+	"unevaluatedProperties": "unevaluated properties are not supported",
+	"unevaluatedItems":      "unevaluated items are not supported",
+	"prefixItems":           "tuple validation is not supported",
+	"contains":              "contains is not supported",
+	"additionalItems":       "additionalItems is not supported; use items",
 }
 
 // recognised keywords that we handle (not flagged as unknown)
@@ -100,6 +91,23 @@ var recognisedKeywords = map[string]bool{
 	"title": true, "description": true, "default": true,
 	"examples": true, "const": true,
 	"$schema": true, "$id": true, "$comment": true,
+	"$ref": true, "$defs": true, "definitions": true,
+	"allOf": true, "anyOf": true, "oneOf": true, "not": true,
+	"if": true, "then": true, "else": true,
+	"dependentRequired": true, "dependentSchemas": true,
+}
+
+// compositeKeywords are the JSON Schema combinators, in the order their
+// sub-schemas are combined.
+var compositeKeywords = []string{"allOf", "anyOf", "oneOf", "not"}
+
+// refAnnotations are keywords that may appear next to $ref without
+// changing what the reference validates.
+var refAnnotations = map[string]bool{
+	"$ref": true, "$defs": true, "definitions": true,
+	"$schema": true, "$id": true, "$comment": true,
+	"title": true, "description": true, "default": true,
+	"examples": true, "nullable": true,
 }
 
 // FromJSON parses a JSON Schema document and returns a queryfy schema.
@@ -118,7 +126,12 @@ func FromJSON(data []byte, opts *Options) (queryfy.Schema, []ConversionError) {
 		}}
 	}
 
-	c := &converter{opts: opts}
+	c := &converter{
+		opts:    opts,
+		root:    raw,
+		refs:    make(map[string]*builders.RefSchema),
+		pending: make(map[string]int),
+	}
 	schema := c.convertNode(raw, "")
 	return schema, c.errors
 }
@@ -127,6 +140,19 @@ func FromJSON(data []byte, opts *Options) (queryfy.Schema, []ConversionError) {
 type converter struct {
 	opts   *Options
 	errors []ConversionError
+
+	// root is the whole document, used to resolve local $ref pointers.
+	root map[string]interface{}
+	// refs holds one resolved reference per pointer. Each use site gets
+	// a Clone so that required/nullable stay local to the field.
+	refs map[string]*builders.RefSchema
+	// pending maps pointers currently being converted to the structural
+	// depth at which their conversion started. A $ref back to a pending
+	// pointer at the same depth is a cycle made only of references and
+	// combinators, which would never reach a value.
+	pending map[string]int
+	// depth counts the properties/items levels entered so far.
+	depth int
 }
 
 func (c *converter) addError(path, keyword, message string) {
@@ -152,17 +178,25 @@ func (c *converter) checkUnsupported(raw map[string]interface{}, path string) bo
 	fatal := false
 	for key, reason := range unsupportedKeywords {
 		if _, exists := raw[key]; exists {
-			if c.opts.StrictMode {
-				c.addError(path, key, reason)
+			if c.reportUnsupported(path, key, reason) {
 				fatal = true
-			} else {
-				c.addWarning(path, key, reason+" (skipped)")
 			}
 		}
 	}
 	return fatal
 }
 
+// reportUnsupported records a feature that could not be converted: an
+// error in StrictMode, otherwise a warning. Returns true if it was fatal.
+func (c *converter) reportUnsupported(path, keyword, reason string) bool {
+	if c.opts.StrictMode {
+		c.addError(path, keyword, reason)
+		return true
+	}
+	c.addWarning(path, keyword, reason+" (skipped)")
+	return false
+}
+
 // convertNode converts a single JSON Schema node to a queryfy schema.
 func (c *converter) convertNode(raw map[string]interface{}, path string) queryfy.Schema {
 	if c.checkUnsupported(raw, path) && c.opts.StrictMode {
@@ -170,7 +204,25 @@ func (c *converter) convertNode(raw map[string]interface{}, path string) queryfy
 		// the supported parts so the caller gets maximum information.
 	}
 
+	if _, hasRef := raw["$ref"]; hasRef {
+		if ref := c.convertRef(raw, path); ref != nil {
+			return ref
+		}
+		// Unresolvable reference — convert whatever else the node declares
+	}
+
 	typeName := c.resolveType(raw, path)
+	parts, nullBranch := c.convertComposites(raw, path, typeName)
+	if nullBranch {
+		raw["_nullable"] = true
+	}
+
+	if typeName == "" && len(parts) > 0 {
+		// Pure combinator: {"anyOf": [...]} with no type of its own
+		schema := combine(nil, parts)
+		c.applyNullable(raw, schema)
+		return schema
+	}
 
 	var schema queryfy.Schema
 	switch typeName {
@@ -192,9 +244,208 @@ func (c *converter) convertNode(raw map[string]interface{}, path string) queryfy
 		schema = builders.String()
 	}
 
+	if len(parts) > 0 {
+		// Properties may be declared in the branches
+		openObject(schema)
+		schema = combine(schema, parts)
+		c.applyNullable(raw, schema)
+	}
+
 	return schema
 }
 
+// convertComposites converts allOf/anyOf/oneOf/not into queryfy
+// composite schemas, in that order. Branches without a type of their own
+// inherit parentType, so {"type": "object", "anyOf": [{"required": ["a"]}]}
+// works as expected. A {"type": "null"} branch of anyOf/oneOf is not
+// converted; nullBranch reports that the node should be nullable instead.
+//
+// Object branches that do not set additionalProperties accept extra
+// fields regardless of validation mode, as they would in JSON Schema.
+// This does not reach objects behind a $ref, which are converted once
+// and shared.
+func (c *converter) convertComposites(raw map[string]interface{}, path, parentType string) (parts []queryfy.Schema, nullBranch bool) {
+	for _, keyword := range compositeKeywords {
+		value, ok := raw[keyword]
+		if !ok {
+			continue
+		}
+		keywordPath := appendPath(path, keyword)
+
+		if keyword == "not" {
+			branch, ok := value.(map[string]interface{})
+			if !ok {
+				c.addError(keywordPath, keyword, "expected object")
+				continue
+			}
+			if isNullType(branch) {
+				// Schemas reject null unless nullable
+				parts = append(parts, builders.And())
+				continue
+			}
+			parts = append(parts, builders.Not(openObject(c.convertNode(inheritType(branch, parentType), keywordPath))))
+			continue
+		}
+
+		items, ok := value.([]interface{})
+		if !ok || len(items) == 0 {
+			c.addError(keywordPath, keyword, "expected non-empty array")
+			continue
+		}
+
+		var branches []queryfy.Schema
+		for i, item := range items {
+			branchPath := fmt.Sprintf("%s.%d", keywordPath, i)
+			branch, ok := item.(map[string]interface{})
+			if !ok {
+				c.addError(branchPath, keyword, "expected object")
+				continue
+			}
+			if keyword != "allOf" && isNullType(branch) {
+				nullBranch = true
+				continue
+			}
+			branches = append(branches, openObject(c.convertNode(inheritType(branch, parentType), branchPath)))
+		}
+		if len(branches) == 0 {
+			continue
+		}
+
+		switch keyword {
+		case "allOf":
+			parts = append(parts, builders.And(branches...))
+		case "anyOf":
+			if len(branches) == 1 {
+				parts = append(parts, branches[0])
+			} else {
+				parts = append(parts, builders.Or(branches...))
+			}
+		case "oneOf":
+			if len(branches) == 1 {
+				parts = append(parts, branches[0])
+			} else {
+				parts = append(parts, builders.OneOf(branches...))
+			}
+		}
+	}
+	return parts, nullBranch
+}
+
+// combine joins a node's own schema with its combinator parts. A single
+// part with no base is returned as-is; allOf parts are flattened into the
+// surrounding And.
+func combine(base queryfy.Schema, parts []queryfy.Schema) queryfy.Schema {
+	var all []queryfy.Schema
+	if base != nil {
+		all = append(all, base)
+	}
+	for _, part := range parts {
+		if and, ok := part.(*builders.AndSchema); ok {
+			all = append(all, and.Schemas()...)
+		} else {
+			all = append(all, part)
+		}
+	}
+	if len(all) == 1 {
+		return all[0]
+	}
+	return builders.And(all...)
+}
+
+// inheritType returns branch with parentType filled in when the branch
+// has no type of its own and nothing to infer one from. The original map
+// is not modified.
+func inheritType(branch map[string]interface{}, parentType string) map[string]interface{} {
+	if parentType == "" {
+		return branch
+	}
+	for _, key := range []string{"type", "$ref", "properties", "items", "allOf", "anyOf", "oneOf", "not"} {
+		if _, ok := branch[key]; ok {
+			return branch
+		}
+	}
+	copied := make(map[string]interface{}, len(branch)+1)
+	for k, v := range branch {
+		copied[k] = v
+	}
+	copied["type"] = parentType
+	return copied
+}
+
+// openObject lets an object schema accept fields it does not declare,
+// unless additionalProperties was set explicitly. Each combinator branch
+// sees the whole value, including fields declared by its siblings.
+func openObject(schema queryfy.Schema) queryfy.Schema {
+	var obj *builders.ObjectSchema
+	switch s := schema.(type) {
+	case *builders.ObjectSchema:
+		obj = s
+	case *builders.ObjectSchemaWithDependencies:
+		obj = s.ObjectSchema
+	default:
+		return schema
+	}
+	if _, explicit := obj.AllowsAdditional(); !explicit {
+		obj.AllowAdditional(true)
+	}
+	return schema
+}
+
+// isNullType reports whether a branch is exactly {"type": "null"}.
+func isNullType(branch map[string]interface{}) bool {
+	t, ok := getString(branch, "type")
+	return ok && t == "null" && len(branch) == 1
+}
+
+// convertRef resolves a local $ref and returns a reference schema for
+// this use site. Returns nil if the reference cannot be resolved.
+func (c *converter) convertRef(raw map[string]interface{}, path string) queryfy.Schema {
+	ref, ok := getString(raw, "$ref")
+	if !ok {
+		c.addError(path, "$ref", "expected string")
+		return nil
+	}
+	if !strings.HasPrefix(ref, "#") {
+		c.reportUnsupported(path, "$ref", fmt.Sprintf("external reference %q is not supported", ref))
+		return nil
+	}
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		c.reportUnsupported(path, "$ref", fmt.Sprintf("anchor reference %q is not supported", ref))
+		return nil
+	}
+
+	def, exists := c.refs[ref]
+	if exists {
+		if depth, inProgress := c.pending[ref]; inProgress && depth == c.depth {
+			c.addError(path, "$ref", fmt.Sprintf("reference %q refers to itself without an intervening schema", ref))
+			return nil
+		}
+	} else {
+		target, err := resolvePointer(c.root, ref[1:])
+		if err != nil {
+			c.reportUnsupported(path, "$ref", fmt.Sprintf("cannot resolve %q: %s", ref, err.Error()))
+			return nil
+		}
+		def = builders.Ref(refName(ref))
+		c.refs[ref] = def
+		c.pending[ref] = c.depth
+		def.Resolve(c.convertNode(target, pointerToPath(ref[1:])))
+		delete(c.pending, ref)
+	}
+
+	for key := range raw {
+		if !refAnnotations[key] && !strings.HasPrefix(key, "x-") {
+			c.addWarning(path, key, "keywords alongside $ref are ignored")
+		}
+	}
+
+	use := def.Clone()
+	if nullable, ok := getBool(raw, "nullable"); ok && nullable {
+		use.Nullable()
+	}
+	return use
+}
+
 // resolveType determines the JSON Schema type, handling nullable arrays.
 func (c *converter) resolveType(raw map[string]interface{}, path string) string {
 	t, ok := raw["type"]
@@ -206,6 +457,15 @@ func (c *converter) resolveType(raw map[string]interface{}, path string) string
 		if _, hasItems := raw["items"]; hasItems {
 			return "array"
 		}
+		// {"const": "business"}, the usual shape inside an if
+		switch raw["const"].(type) {
+		case string:
+			return "string"
+		case float64:
+			return "number"
+		case bool:
+			return "boolean"
+		}
 		return ""
 	}
 
@@ -253,6 +513,9 @@ func (c *converter) convertString(raw map[string]interface{}, path string) query
 	if enumVals, ok := getStringSlice(raw, "enum"); ok {
 		s.Enum(enumVals...)
 	}
+	if constVal, ok := getString(raw, "const"); ok {
+		s.Enum(constVal)
+	}
 	if format, ok := getString(raw, "format"); ok {
 		c.applyStringFormat(s, format, path)
 	}
@@ -314,6 +577,9 @@ func (c *converter) convertNumber(raw map[string]interface{}, path string, integ
 	if mul, ok := getFloat(raw, "multipleOf"); ok {
 		s.MultipleOf(mul)
 	}
+	if constVal, ok := getFloat(raw, "const"); ok {
+		s.Min(constVal).Max(constVal)
+	}
 
 	c.applyNullable(raw, s)
 	c.storeUnknownOnSchema(raw, path, s)
@@ -323,6 +589,9 @@ func (c *converter) convertNumber(raw map[string]interface{}, path string, integ
 // convertBool handles boolean schemas.
 func (c *converter) convertBool(raw map[string]interface{}, path string) queryfy.Schema {
 	s := builders.Bool()
+	if constVal, ok := getBool(raw, "const"); ok {
+		s.Const(constVal)
+	}
 	c.applyNullable(raw, s)
 	c.storeUnknownOnSchema(raw, path, s)
 	return s
@@ -367,7 +636,9 @@ func (c *converter) convertObject(raw map[string]interface{}, path string) query
 					continue
 				}
 
+				c.depth++
 				fieldSchema := c.convertNode(fieldRaw, fieldPath)
+				c.depth--
 				if requiredSet[fieldName] {
 					fieldSchema = markRequired(fieldSchema)
 				}
@@ -376,6 +647,16 @@ func (c *converter) convertObject(raw map[string]interface{}, path string) query
 		}
 	}
 
+	// required names with no matching property still have to be present
+	var undeclared []string
+	for name := range requiredSet {
+		if _, declared := s.GetField(name); !declared {
+			undeclared = append(undeclared, name)
+		}
+	}
+	sort.Strings(undeclared)
+	s.RequiredFields(undeclared...)
+
 	// additionalProperties
 	if ap, ok := raw["additionalProperties"]; ok {
 		switch v := ap.(type) {
@@ -393,6 +674,10 @@ func (c *converter) convertObject(raw map[string]interface{}, path string) query
 
 	c.applyNullable(raw, s)
 	c.storeUnknownOnSchema(raw, path, s)
+
+	if deps := c.convertConditionals(raw, path, s); deps != nil {
+		return deps
+	}
 	return s
 }
 
@@ -416,7 +701,9 @@ func (c *converter) convertArray(raw map[string]interface{}, path string) queryf
 		if !ok {
 			c.addError(appendPath(path, "items"), "items", "expected object")
 		} else {
+			c.depth++
 			elemSchema := c.convertNode(itemsRaw, appendPath(path, "items"))
+			c.depth--
 			s.Of(elemSchema)
 		}
 	}
@@ -456,6 +743,20 @@ func (c *converter) applyNullable(raw map[string]interface{}, schema interface{}
 		s.Nullable()
 	case *builders.ArraySchema:
 		s.Nullable()
+	case *builders.RefSchema:
+		s.Nullable()
+	case *builders.AndSchema:
+		s.Nullable()
+	case *builders.OrSchema:
+		s.Nullable()
+	case *builders.OneOfSchema:
+		s.Nullable()
+	case *builders.NotSchema:
+		s.Nullable()
+	default:
+		if setter, ok := schema.(interface{ SetNullable(bool) }); ok {
+			setter.SetNullable(true)
+		}
 	}
 }
 
@@ -516,6 +817,18 @@ func markRequired(schema queryfy.Schema) queryfy.Schema {
 		return s.Required()
 	case *builders.CustomSchema:
 		return s.Required()
+	case *builders.RefSchema:
+		return s.Required()
+	case *builders.ObjectSchemaWithDependencies:
+		return s.Required()
+	case *builders.AndSchema:
+		return s.Required()
+	case *builders.OrSchema:
+		return s.Required()
+	case *builders.OneOfSchema:
+		return s.Required()
+	case *builders.NotSchema:
+		return s.Required()
 	default:
 		return schema
 	}
@@ -570,6 +883,68 @@ func getStringSlice(raw map[string]interface{}, key string) ([]string, bool) {
 	return result, true
 }
 
+// resolvePointer follows a JSON Pointer (RFC 6901) fragment, without the
+// leading '#', from the document root to a schema object.
+func resolvePointer(root map[string]interface{}, pointer string) (map[string]interface{}, error) {
+	var current interface{} = root
+	for _, token := range pointerTokens(pointer) {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%q not found", token)
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("invalid array index %q", token)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("cannot descend into %T at %q", current, token)
+		}
+	}
+	target, ok := current.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("target is %T, not a schema object", current)
+	}
+	return target, nil
+}
+
+// pointerTokens splits a JSON Pointer into unescaped reference tokens.
+// URI fragments may also be percent-encoded.
+func pointerTokens(pointer string) []string {
+	if pointer == "" {
+		return nil
+	}
+	if unescaped, err := url.PathUnescape(pointer); err == nil {
+		pointer = unescaped
+	}
+	parts := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, part := range parts {
+		part = strings.ReplaceAll(part, "~1", "/")
+		parts[i] = strings.ReplaceAll(part, "~0", "~")
+	}
+	return parts
+}
+
+// pointerToPath converts a JSON Pointer to the dot-separated document
+// path used in ConversionError.
+func pointerToPath(pointer string) string {
+	return strings.Join(pointerTokens(pointer), ".")
+}
+
+// refName derives a definition name from a reference: the last pointer
+// token, or "root" for a reference to the whole document.
+func refName(ref string) string {
+	tokens := pointerTokens(strings.TrimPrefix(ref, "#"))
+	if len(tokens) == 0 {
+		return "root"
+	}
+	return tokens[len(tokens)-1]
+}
+
 func appendPath(base, suffix string) string {
 	if base == "" {
 		return suffix
@@ -0,0 +1,295 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ha1tch/queryfy/builders"
+	"github.com/ha1tch/queryfy/builders/jsonschema"
+)
+
+// ======================================================================
+// $ref / $defs import
+// ======================================================================
+
+func TestFromJSON_RefDefs(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {
+			"billing": {"$ref": "#/$defs/Address"},
+			"shipping": {"$ref": "#/$defs/Address"}
+		},
+		"required": ["billing"],
+		"$defs": {
+			"Address": {
+				"type": "object",
+				"properties": {"city": {"type": "string", "minLength": 1}},
+				"required": ["city"]
+			}
+		}
+	}`), nil)
+	assertNoErrors(t, errs)
+
+	assertValid(t, schema, map[string]interface{}{
+		"billing": map[string]interface{}{"city": "Houston"},
+	})
+	assertInvalid(t, schema, map[string]interface{}{
+		"billing": map[string]interface{}{"city": ""},
+	})
+	assertInvalid(t, schema, map[string]interface{}{
+		"billing":  map[string]interface{}{"city": "Houston"},
+		"shipping": map[string]interface{}{},
+	})
+	// shipping is optional even though it shares billing's definition
+	assertInvalid(t, schema, map[string]interface{}{})
+}
+
+func TestFromJSON_RefDefinitions(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "array",
+		"items": {"$ref": "#/definitions/Code"},
+		"definitions": {
+			"Code": {"type": "string", "pattern": "^[A-Z]{3}$"}
+		}
+	}`), nil)
+	assertNoErrors(t, errs)
+	assertValid(t, schema, []interface{}{"ABC", "XYZ"})
+	assertInvalid(t, schema, []interface{}{"ABC", "xyz"})
+}
+
+func TestFromJSON_RefRecursive(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"$ref": "#/$defs/Node",
+		"$defs": {
+			"Node": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"children": {"type": "array", "items": {"$ref": "#/$defs/Node"}}
+				},
+				"required": ["name"]
+			}
+		}
+	}`), nil)
+	assertNoErrors(t, errs)
+
+	tree := map[string]interface{}{
+		"name": "root",
+		"children": []interface{}{
+			map[string]interface{}{
+				"name": "child",
+				"children": []interface{}{
+					map[string]interface{}{"name": "grandchild"},
+				},
+			},
+		},
+	}
+	assertValid(t, schema, tree)
+
+	bad := map[string]interface{}{
+		"name": "root",
+		"children": []interface{}{
+			map[string]interface{}{
+				"children": []interface{}{map[string]interface{}{}},
+			},
+		},
+	}
+	assertInvalid(t, schema, bad)
+}
+
+func TestFromJSON_RefRoot(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {
+			"value": {"type": "number"},
+			"next": {"$ref": "#"}
+		}
+	}`), nil)
+	assertNoErrors(t, errs)
+	assertValid(t, schema, map[string]interface{}{
+		"value": 1.0,
+		"next":  map[string]interface{}{"value": 2.0},
+	})
+	assertInvalid(t, schema, map[string]interface{}{
+		"next": map[string]interface{}{"value": "two"},
+	})
+}
+
+func TestFromJSON_RefEscapedPointer(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"$ref": "#/$defs/a~1b",
+		"$defs": {"a/b": {"type": "boolean"}}
+	}`), nil)
+	assertNoErrors(t, errs)
+	assertValid(t, schema, true)
+	assertInvalid(t, schema, "yes")
+}
+
+func TestFromJSON_RefNullable(t *testing.T) {
+	schema, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {
+			"tag": {"$ref": "#/$defs/Tag", "nullable": true}
+		},
+		"$defs": {"Tag": {"type": "string"}}
+	}`), nil)
+	assertNoErrors(t, errs)
+	assertValid(t, schema, map[string]interface{}{"tag": nil})
+}
+
+func TestFromJSON_RefMissingStrict(t *testing.T) {
+	_, errs := jsonschema.FromJSON([]byte(`{
+		"type": "object",
+		"properties": {"a": {"$ref": "#/$defs/Missing"}}
+	}`), &jsonschema.Options{StrictMode: true})
+	assertHasError(t, errs, "$ref")
+}
+
+func TestFromJSON_RefExternal(t *testing.T) {
+	_, errs := jsonschema.FromJSON([]byte(`{
+		"type": "string",
+		"$ref": "https://example.com/schemas/thing.json"
+	}`), nil)
+	assertHasWarning(t, errs, "$ref")
+}
+
+func TestFromJSON_RefCycle(t *testing.T) {
+	_, errs := jsonschema.FromJSON([]byte(`{
+		"$ref": "#/$defs/A",
+		"$defs": {
+			"A": {"$ref": "#/$defs/B"},
+			"B": {"$ref": "#/$defs/A"}
+		}
+	}`), nil)
+	assertHasError(t, errs, "$ref")
+}
+
+func TestFromJSON_RefSiblingsWarn(t *testing.T) {
+	_, errs := jsonschema.FromJSON([]byte(`{
+		"$ref": "#/$defs/S",
+		"minLength": 3,
+		"description": "ignored silently",
+		"$defs": {"S": {"type": "string"}}
+	}`), nil)
+	assertNoErrors(t, errs)
+	assertHasWarning(t, errs, "minLength")
+}
+
+// ======================================================================
+// $defs export
+// ======================================================================
+
+func TestExport_RefRecursive(t *testing.T) {
+	node := builders.Ref("Node")
+	node.Resolve(builders.Object().
+		Field("name", builders.String().Required()).
+		Field("children", builders.Array().Of(node.Clone())))
+
+	m := jsonschema.ToMap(node, nil)
+	assertMapValue(t, m, "$ref", "#/$defs/Node")
+
+	defs, ok := m["$defs"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected $defs, got %v", m)
+	}
+	def, ok := defs["Node"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected $defs.Node, got %v", defs)
+	}
+	children := def["properties"].(map[string]interface{})["children"].(map[string]interface{})
+	items := children["items"].(map[string]interface{})
+	assertMapValue(t, items, "$ref", "#/$defs/Node")
+}
+
+func TestExport_SharedObjectHoisted(t *testing.T) {
+	address := builders.Object().
+		Field("city", builders.String().Required())
+	schema := builders.Object().
+		Field("billing", address).
+		Field("shipping", address)
+
+	m := jsonschema.ToMap(schema, nil)
+	props := m["properties"].(map[string]interface{})
+	billing := props["billing"].(map[string]interface{})
+	shipping := props["shipping"].(map[string]interface{})
+	if billing["$ref"] == nil || billing["$ref"] != shipping["$ref"] {
+		t.Fatalf("expected both fields to share a $ref, got %v and %v", billing, shipping)
+	}
+	defs := m["$defs"].(map[string]interface{})
+	if len(defs) != 1 {
+		t.Errorf("expected one definition, got %d", len(defs))
+	}
+}
+
+func TestExport_SharedObjectTitleNamesDef(t *testing.T) {
+	address := builders.Object().
+		Field("city", builders.String()).
+		Meta("title", "Address")
+	schema := builders.Object().
+		Field("billing", address).
+		Field("shipping", address)
+
+	m := jsonschema.ToMap(schema, nil)
+	defs := m["$defs"].(map[string]interface{})
+	if _, ok := defs["Address"]; !ok {
+		t.Errorf("expected $defs.Address, got %v", defs)
+	}
+}
+
+func TestExport_InlineShared(t *testing.T) {
+	address := builders.Object().Field("city", builders.String())
+	schema := builders.Object().
+		Field("billing", address).
+		Field("shipping", address)
+
+	m := jsonschema.ToMap(schema, &jsonschema.ExportOptions{InlineShared: true})
+	if _, ok := m["$defs"]; ok {
+		t.Error("expected no $defs with InlineShared")
+	}
+	props := m["properties"].(map[string]interface{})
+	assertMapValue(t, props["billing"].(map[string]interface{}), "type", "object")
+}
+
+func TestRoundTrip_RefDefs(t *testing.T) {
+	original := `{
+		"type": "object",
+		"properties": {
+			"root": {"$ref": "#/$defs/Node"}
+		},
+		"$defs": {
+			"Node": {
+				"type": "object",
+				"properties": {
+					"id": {"type": "integer"},
+					"kids": {"type": "array", "items": {"$ref": "#/$defs/Node"}}
+				}
+			}
+		}
+	}`
+	schema1, errs := jsonschema.FromJSON([]byte(original), nil)
+	assertNoErrors(t, errs)
+
+	exported, err := jsonschema.ToJSON(schema1, nil)
+	if err != nil {
+		t.Fatalf("export error: %v", err)
+	}
+
+	var orig, out map[string]interface{}
+	json.Unmarshal([]byte(original), &orig)
+	json.Unmarshal(exported, &out)
+	compareJSONMaps(t, orig, out, "")
+
+	schema2, errs := jsonschema.FromJSON(exported, nil)
+	assertNoErrors(t, errs)
+	if !builders.Equal(schema1, schema2) {
+		t.Errorf("round-tripped schema differs:\n%s", exported)
+	}
+
+	data := map[string]interface{}{
+		"root": map[string]interface{}{
+			"id":   1.0,
+			"kids": []interface{}{map[string]interface{}{"id": 2.5}},
+		},
+	}
+	assertInvalid(t, schema2, data)
+}
@@ -0,0 +1,191 @@
+// jsonvalidate.go - Validation straight from JSON tokens
+package builders
+
+import (
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/superjsonic"
+)
+
+// ValidateJSON implements queryfy.JSONValidator. Each field is
+// validated as it is read and undeclared fields are skipped without
+// being decoded, unless the object is rejecting them. Only the last
+// value of a repeated key counts, as with json.Unmarshal. Objects with
+// custom validators, which need the whole map, are decoded and passed
+// to Validate.
+func (s *ObjectSchema) ValidateJSON(r *queryfy.JSONReader, ctx *queryfy.ValidationContext) error {
+	tok, err := r.Peek()
+	if err != nil {
+		return err
+	}
+	if tok.Type != superjsonic.ObjectStart || len(s.validators) > 0 || len(s.asyncValidators) > 0 {
+		return decodeAndValidate(s, r, ctx)
+	}
+	if _, err := r.Next(); err != nil {
+		return err
+	}
+
+	keys := queryfy.NewObjectKeys(ctx)
+	rejectExtra := s.rejectsExtra(ctx)
+	for {
+		key, err := r.Next()
+		if err != nil {
+			return err
+		}
+		if key.Type == superjsonic.ObjectEnd {
+			break
+		}
+		name, err := superjsonic.Unquote(r.Bytes(key))
+		if err != nil {
+			return err
+		}
+
+		fieldSchema, declared := s.fields[name]
+		_, dependent := fieldSchema.(*DependentSchema)
+		err = keys.Read(name, func() (err error) {
+			switch {
+			case declared && !dependent:
+				ctx.WithPath(name, func() {
+					err = r.Validate(fieldSchema, ctx)
+				})
+			case !declared && rejectExtra:
+				ctx.WithPath(name, func() {
+					err = rejectField(r, ctx)
+				})
+			default:
+				err = r.Skip()
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	keys.Done()
+
+	// Missing fields are reported at the object's offset
+	for fieldName, required := range s.requiredFields {
+		if required && !keys.Has(fieldName) {
+			ctx.WithPath(fieldName, func() {
+				ctx.AddCodedError(queryfy.CodeRequired, nil, nil)
+			})
+		}
+	}
+	for fieldName, fieldSchema := range s.fields {
+		if _, dependent := fieldSchema.(*DependentSchema); dependent {
+			continue
+		}
+		if !keys.Has(fieldName) && !s.requiredFields[fieldName] && isRequired(fieldSchema) {
+			ctx.WithPath(fieldName, func() {
+				ctx.AddCodedError(queryfy.CodeRequired, nil, nil)
+			})
+		}
+	}
+	return nil
+}
+
+// ValidateJSON implements queryfy.JSONValidator. Elements are validated
+// as they are read, so the array is never held in memory. Arrays that
+// need all their elements at once, for unique items or custom
+// validators, are decoded and passed to Validate.
+func (s *ArraySchema) ValidateJSON(r *queryfy.JSONReader, ctx *queryfy.ValidationContext) error {
+	tok, err := r.Peek()
+	if err != nil {
+		return err
+	}
+	if tok.Type != superjsonic.ArrayStart || s.uniqueItems || len(s.validators) > 0 || len(s.asyncValidators) > 0 {
+		return decodeAndValidate(s, r, ctx)
+	}
+	if _, err := r.Next(); err != nil {
+		return err
+	}
+
+	length := 0
+	for {
+		next, err := r.Peek()
+		if err != nil {
+			return err
+		}
+		if next.Type == superjsonic.ArrayEnd {
+			if _, err := r.Next(); err != nil {
+				return err
+			}
+			break
+		}
+		if s.elementSchema != nil {
+			ctx.WithIndex(length, func() {
+				err = r.Validate(s.elementSchema, ctx)
+			})
+		} else {
+			err = r.Skip()
+		}
+		if err != nil {
+			return err
+		}
+		length++
+	}
+
+	if s.minItems != nil && length < *s.minItems {
+		ctx.AddCodedError(queryfy.CodeMinItems, queryfy.Params{"min": *s.minItems, "actual": length}, nil)
+	}
+	if s.maxItems != nil && length > *s.maxItems {
+		ctx.AddCodedError(queryfy.CodeMaxItems, queryfy.Params{"max": *s.maxItems, "actual": length}, nil)
+	}
+	return nil
+}
+
+// ValidateJSON implements queryfy.JSONValidator, unquoting a string
+// token without going through interface{}.
+func (s *StringSchema) ValidateJSON(r *queryfy.JSONReader, ctx *queryfy.ValidationContext) error {
+	tok, err := r.Peek()
+	if err != nil {
+		return err
+	}
+	if tok.Type != superjsonic.String {
+		return decodeAndValidate(s, r, ctx)
+	}
+	if _, err := r.Next(); err != nil {
+		return err
+	}
+	str, err := superjsonic.Unquote(r.Bytes(tok))
+	if err != nil {
+		return err
+	}
+	return s.Validate(str, ctx)
+}
+
+// ValidateJSON implements queryfy.JSONValidator. Dependent fields are
+// checked against the whole object, so it is decoded and passed to
+// Validate.
+func (s *ObjectSchemaWithDependencies) ValidateJSON(r *queryfy.JSONReader, ctx *queryfy.ValidationContext) error {
+	return decodeAndValidate(s, r, ctx)
+}
+
+// rejectField reports an undeclared field at the current path, decoding
+// its value for the error.
+func rejectField(r *queryfy.JSONReader, ctx *queryfy.ValidationContext) error {
+	tok, err := r.Peek()
+	if err != nil {
+		return err
+	}
+	value, err := r.Decode()
+	if err != nil {
+		return err
+	}
+	ctx.AddFieldError(queryfy.FieldError{
+		Message: ctx.Message(queryfy.CodeUnexpectedField, nil),
+		Code:    queryfy.CodeUnexpectedField,
+		Value:   value,
+		Offset:  tok.Offset,
+	})
+	return nil
+}
+
+// decodeAndValidate decodes the next value and validates it with
+// schema.Validate, for values the token-level checks do not handle.
+func decodeAndValidate(schema queryfy.Schema, r *queryfy.JSONReader, ctx *queryfy.ValidationContext) error {
+	value, err := r.Decode()
+	if err != nil {
+		return err
+	}
+	return schema.Validate(value, ctx)
+}
@@ -0,0 +1,168 @@
+package builders_test
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+	"github.com/ha1tch/queryfy/superjsonic"
+)
+
+func jsonOrderSchema() *builders.ObjectSchema {
+	return builders.Object().
+		Field("id", builders.String().Required().MinLength(3)).
+		Field("customer", builders.Object().
+			Field("email", builders.String().Email().Required()).
+			Field("name", builders.String())).
+		Field("items", builders.Array().MinItems(1).Of(builders.Object().
+			Field("sku", builders.String().Required()).
+			Field("qty", builders.Number().Integer().Min(1)))).
+		Field("tags", builders.Array().Of(builders.String()).UniqueItems())
+}
+
+// fieldErrors returns the field errors of a validation result.
+func fieldErrors(t *testing.T, err error) []queryfy.FieldError {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var verr *queryfy.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error %T is not a *ValidationError: %v", err, err)
+	}
+	return verr.Errors
+}
+
+// ======================================================================
+// Agreement with Validate
+// ======================================================================
+
+func TestValidateJSON_MatchesValidate(t *testing.T) {
+	docs := []string{
+		`{"id":"A-100","customer":{"email":"a@b.co"},"items":[{"sku":"x","qty":2}]}`,
+		`{"id":"A","customer":{"email":"nope"},"items":[]}`,
+		`{"customer":{"name":7},"items":[{"qty":0.5},{"sku":1}],"extra":{"deep":[1,2]}}`,
+		`{"id":"A-100","customer":null,"items":"none","tags":["a","a"]}`,
+		`[]`,
+		`"string"`,
+	}
+	schema := jsonOrderSchema()
+
+	for _, mode := range []queryfy.ValidationMode{queryfy.Strict, queryfy.Loose} {
+		for _, doc := range docs {
+			var data interface{}
+			if err := json.Unmarshal([]byte(doc), &data); err != nil {
+				t.Fatal(err)
+			}
+			want := summarize(fieldErrors(t, queryfy.ValidateWithMode(data, schema, mode)))
+			got := summarize(fieldErrors(t, queryfy.ValidateJSONWithMode([]byte(doc), schema, mode)))
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("mode %v, %s:\nValidateJSON: %q\nValidate:     %q", mode, doc, got, want)
+			}
+		}
+	}
+}
+
+func summarize(errs []queryfy.FieldError) []string {
+	out := make([]string, len(errs))
+	for i, e := range errs {
+		out[i] = e.String()
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ======================================================================
+// Offsets
+// ======================================================================
+
+func TestValidateJSON_Offsets(t *testing.T) {
+	doc := `{"id":"A","customer":{"email":"nope"},"items":[{"sku":"x"},{"qty":0}],"bogus":1}`
+	errs := fieldErrors(t, queryfy.ValidateJSON([]byte(doc), jsonOrderSchema()))
+
+	at := func(s string) int { return strings.Index(doc, s) }
+	want := map[string]int{
+		"id":             at(`"A"`),
+		"customer.email": at(`"nope"`),
+		"items[1].sku":   at(`{"qty"`), // missing: reported at the object
+		"items[1].qty":   at(`0}`),
+		"bogus":          at(`1}`),
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for _, e := range errs {
+		offset, ok := want[e.Path]
+		if !ok {
+			t.Errorf("unexpected error %v", e)
+			continue
+		}
+		if e.Offset != offset {
+			t.Errorf("%s: offset %d, want %d", e.Path, e.Offset, offset)
+		}
+	}
+}
+
+func TestValidateJSON_ArrayLengthOffset(t *testing.T) {
+	doc := `{"items": []}`
+	errs := fieldErrors(t, queryfy.ValidateJSON([]byte(doc), builders.Object().
+		Field("items", builders.Array().MinItems(1))))
+	if len(errs) != 1 || errs[0].Path != "items" || errs[0].Offset != strings.Index(doc, "[") {
+		t.Fatalf("errors = %+v", errs)
+	}
+	if errs[0].Message != "must have at least 1 items, got 0" {
+		t.Errorf("message = %q", errs[0].Message)
+	}
+}
+
+// ======================================================================
+// Syntax errors and fallbacks
+// ======================================================================
+
+func TestValidateJSON_SyntaxError(t *testing.T) {
+	for _, doc := range []string{
+		`{"id":"A-100",}`,
+		`{"id":"A-100"} x`,
+		`{"bogus":[1,}`, // inside a skipped value
+	} {
+		err := queryfy.ValidateJSONWithMode([]byte(doc), jsonOrderSchema(), queryfy.Loose)
+		var syn *superjsonic.SyntaxError
+		if !errors.As(err, &syn) {
+			t.Errorf("%s: error = %v, want a *superjsonic.SyntaxError", doc, err)
+		}
+	}
+}
+
+func TestValidateJSON_CustomValidatorsSeeWholeObject(t *testing.T) {
+	schema := builders.Object().
+		Field("a", builders.Number()).
+		Field("b", builders.Number()).
+		Custom(func(v interface{}) error {
+			m := v.(map[string]interface{})
+			if m["a"].(float64) > m["b"].(float64) {
+				return errors.New("a must not exceed b")
+			}
+			return nil
+		})
+	errs := fieldErrors(t, queryfy.ValidateJSON([]byte(`{"a":2,"b":1}`), schema))
+	if len(errs) != 1 || errs[0].Message != "a must not exceed b" {
+		t.Fatalf("errors = %v", errs)
+	}
+}
+
+func TestValidateJSON_Dependencies(t *testing.T) {
+	schema := builders.Object().
+		Field("method", builders.String()).
+		WithDependencies().
+		DependentField("card", builders.Dependent("card").
+			When(builders.WhenEquals("method", "card")).
+			Then(builders.String().Required()))
+	errs := fieldErrors(t, queryfy.ValidateJSON([]byte(`{"method":"card"}`), schema))
+	if len(errs) != 1 || errs[0].Path != "card" {
+		t.Fatalf("errors = %v", errs)
+	}
+}
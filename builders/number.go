@@ -1,22 +1,38 @@
 package builders
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"math"
+	"math/big"
+	"strconv"
 
 	"github.com/ha1tch/queryfy"
 )
 
-// NumberSchema validates numeric values.
+// NumberSchema validates numeric values. Besides Go's numeric types it
+// accepts json.Number, *big.Int, *big.Float, *big.Rat and
+// queryfy.Rational decimal values. Min, Max, MultipleOf, Integer and
+// Decimal are checked exactly: integers beyond 2^53 are not rounded
+// through float64, and floats are taken as the decimal they print as,
+// so 0.3 is a multiple of 0.01.
 type NumberSchema struct {
 	queryfy.BaseSchema
 	min        *float64
 	max        *float64
 	multipleOf *float64
 	isInteger  bool
+	decimal    *decimalConstraint
 	validators []queryfy.ValidatorFunc
 }
 
+// decimalConstraint limits the digits of a number, as SQL's
+// DECIMAL(precision, scale) does.
+type decimalConstraint struct {
+	precision int
+	scale     int
+}
+
 // Number creates a new number schema builder.
 func Number() *NumberSchema {
 	return &NumberSchema{
@@ -72,13 +88,19 @@ func (s *NumberSchema) MultipleOf(value float64) *NumberSchema {
 // Integer validates that the number is an integer (no decimal part).
 func (s *NumberSchema) Integer() *NumberSchema {
 	s.isInteger = true
-	s.validators = append(s.validators, func(value interface{}) error {
-		num := toFloat64(value)
-		if num != math.Floor(num) {
-			return fmt.Errorf("must be an integer")
-		}
-		return nil
-	})
+	return s
+}
+
+// Decimal limits the number to precision significant digits, scale of
+// them after the decimal point, like SQL's DECIMAL(precision, scale).
+// Decimal(10, 2) accepts 12345678.90 but not 1.234 or 123456789. It is
+// meant for currency fields and is checked exactly, so it is best used
+// with json.Number or a decimal type rather than float64 values.
+func (s *NumberSchema) Decimal(precision, scale int) *NumberSchema {
+	if scale < 0 || precision < scale {
+		panic(fmt.Sprintf("builders: invalid Decimal(%d, %d): need 0 <= scale <= precision", precision, scale))
+	}
+	s.decimal = &decimalConstraint{precision: precision, scale: scale}
 	return s
 }
 
@@ -88,7 +110,7 @@ func (s *NumberSchema) Positive() *NumberSchema {
 	s.min = &zero
 	s.validators = append(s.validators, func(value interface{}) error {
 		if toFloat64(value) <= 0 {
-			return fmt.Errorf("must be positive")
+			return &queryfy.CodedError{Code: queryfy.CodePositive}
 		}
 		return nil
 	})
@@ -101,7 +123,7 @@ func (s *NumberSchema) Negative() *NumberSchema {
 	s.max = &zero
 	s.validators = append(s.validators, func(value interface{}) error {
 		if toFloat64(value) >= 0 {
-			return fmt.Errorf("must be negative")
+			return &queryfy.CodedError{Code: queryfy.CodeNegative}
 		}
 		return nil
 	})
@@ -123,30 +145,43 @@ func (s *NumberSchema) Validate(value interface{}, ctx *queryfy.ValidationContex
 	// Get numeric value
 	num, ok := toFloat64WithMode(value, ctx.Mode())
 	if !ok {
-		ctx.AddError(fmt.Sprintf("expected number, got %T", value), value)
+		ctx.AddCodedError(queryfy.CodeType, queryfy.Params{"expected": "number", "actual": fmt.Sprintf("%T", value)}, value)
 		return nil
 	}
 
+	// Exact value for values float64 cannot hold, nil otherwise
+	exact := queryfy.ExactRat(value)
+
 	// Range validation
-	if s.min != nil && num < *s.min {
-		ctx.AddError(fmt.Sprintf("must be >= %v", *s.min), num)
+	if s.min != nil && queryfy.CompareNumber(num, exact, *s.min) < 0 {
+		ctx.AddCodedError(queryfy.CodeMin, queryfy.Params{"min": *s.min}, num)
 	}
 
-	if s.max != nil && num > *s.max {
-		ctx.AddError(fmt.Sprintf("must be <= %v", *s.max), num)
+	if s.max != nil && queryfy.CompareNumber(num, exact, *s.max) > 0 {
+		ctx.AddCodedError(queryfy.CodeMax, queryfy.Params{"max": *s.max}, num)
 	}
 
 	// Multiple validation
 	if s.multipleOf != nil && *s.multipleOf != 0 {
-		if math.Mod(num, *s.multipleOf) != 0 {
-			ctx.AddError(fmt.Sprintf("must be a multiple of %v", *s.multipleOf), num)
+		if !queryfy.IsMultipleOf(num, exact, *s.multipleOf) {
+			ctx.AddCodedError(queryfy.CodeMultipleOf, queryfy.Params{"multiple_of": *s.multipleOf}, num)
+		}
+	}
+
+	if s.isInteger && !queryfy.IsWholeNumber(num, exact) {
+		ctx.AddCodedError(queryfy.CodeInteger, nil, num)
+	}
+
+	if s.decimal != nil {
+		if code, params := queryfy.CheckDecimal(num, exact, s.decimal.precision, s.decimal.scale); code != "" {
+			ctx.AddCodedError(code, params, num)
 		}
 	}
 
 	// Custom validators - pass the converted number
 	for _, validator := range s.validators {
 		if err := validator(num); err != nil {
-			ctx.AddError(err.Error(), num)
+			ctx.AddValidatorError(err, num)
 		}
 	}
 
@@ -164,6 +199,15 @@ func (s *NumberSchema) IsInteger() bool {
 	return s.isInteger
 }
 
+// DecimalConstraint returns the precision and scale set by Decimal, and
+// whether it was set.
+func (s *NumberSchema) DecimalConstraint() (precision, scale int, ok bool) {
+	if s.decimal == nil {
+		return 0, 0, false
+	}
+	return s.decimal.precision, s.decimal.scale, true
+}
+
 // MultipleOfValue returns the multipleOf constraint, or nil if not set.
 func (s *NumberSchema) MultipleOfValue() *float64 {
 	return s.multipleOf
@@ -213,7 +257,8 @@ func toFloat64(value interface{}) float64 {
 	case uint64:
 		return float64(v)
 	default:
-		return 0
+		f, _ := bigToFloat64(value)
+		return f
 	}
 }
 
@@ -246,6 +291,9 @@ func toFloat64WithMode(value interface{}, mode queryfy.ValidationMode) (float64,
 	case uint64:
 		return float64(v), true
 	}
+	if f, ok := bigToFloat64(value); ok {
+		return f, true
+	}
 
 	// In loose mode, try string conversion
 	if mode == queryfy.Loose {
@@ -256,3 +304,26 @@ func toFloat64WithMode(value interface{}, mode queryfy.ValidationMode) (float64,
 
 	return 0, false
 }
+
+// bigToFloat64 converts json.Number, big and decimal values to the
+// nearest float64.
+func bigToFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		// Out of range numbers are still numbers: ±Inf or 0
+		f, err := v.Float64()
+		return f, err == nil || errors.Is(err, strconv.ErrRange)
+	case *big.Float:
+		if v == nil {
+			return 0, false
+		}
+		f, _ := v.Float64()
+		return f, true
+	}
+	r, ok := queryfy.ConvertToRat(value)
+	if !ok {
+		return 0, false
+	}
+	f, _ := r.Float64()
+	return f, true
+}
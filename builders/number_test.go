@@ -0,0 +1,137 @@
+package builders_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+)
+
+// money is a decimal type outside the standard library, as
+// shopspring/decimal would be.
+type money struct{ cents int64 }
+
+func (m money) Rat() *big.Rat { return big.NewRat(m.cents, 100) }
+
+// bothPaths returns a schema as built and compiled.
+func bothPaths(s queryfy.Schema) []queryfy.Schema {
+	return []queryfy.Schema{s, queryfy.Compile(s)}
+}
+
+// ======================================================================
+// Exact comparisons
+// ======================================================================
+
+func TestNumber_LargeIntegersCompareExactly(t *testing.T) {
+	// 2^53 + 1 rounds to 2^53 as a float64
+	const limit = 1 << 53
+	for _, schema := range bothPaths(builders.Number().Max(limit)) {
+		if err := queryfy.Validate(int64(limit+1), schema); err == nil {
+			t.Errorf("%T: int64 2^53+1 accepted by Max(2^53)", schema)
+		}
+		if err := queryfy.Validate(json.Number("9007199254740993"), schema); err == nil {
+			t.Errorf("%T: json.Number 2^53+1 accepted by Max(2^53)", schema)
+		}
+		if err := queryfy.Validate(int64(limit), schema); err != nil {
+			t.Errorf("%T: %v", schema, err)
+		}
+	}
+}
+
+func TestNumber_MultipleOfDecimal(t *testing.T) {
+	for _, schema := range bothPaths(builders.Number().MultipleOf(0.01)) {
+		for _, v := range []interface{}{0.3, 19.99, 1.1, json.Number("100.07"), 5} {
+			if err := queryfy.Validate(v, schema); err != nil {
+				t.Errorf("%T: %v: %v", schema, v, err)
+			}
+		}
+		if err := queryfy.Validate(0.001, schema); err == nil {
+			t.Errorf("%T: 0.001 accepted as a multiple of 0.01", schema)
+		}
+	}
+}
+
+func TestNumber_BigAndRationalValues(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	schema := builders.Number().Min(0).Integer()
+
+	for _, v := range []interface{}{huge, new(big.Float).SetInt64(7), big.NewRat(8, 2), money{cents: 500}} {
+		for _, s := range bothPaths(schema) {
+			if err := queryfy.Validate(v, s); err != nil {
+				t.Errorf("%T: %v: %v", s, v, err)
+			}
+		}
+	}
+	for _, v := range []interface{}{new(big.Int).Neg(huge), big.NewRat(1, 3), money{cents: 250}} {
+		for _, s := range bothPaths(schema) {
+			if err := queryfy.Validate(v, s); err == nil {
+				t.Errorf("%T: %v accepted", s, v)
+			}
+		}
+	}
+}
+
+func TestNumber_IntegerSingleError(t *testing.T) {
+	for _, schema := range bothPaths(builders.Number().Integer()) {
+		err := queryfy.Validate(1.5, schema)
+		verr, ok := err.(*queryfy.ValidationError)
+		if !ok || len(verr.Errors) != 1 || verr.Errors[0].Message != "must be an integer" {
+			t.Errorf("%T: %v", schema, err)
+		}
+	}
+}
+
+// ======================================================================
+// Decimal
+// ======================================================================
+
+func TestNumber_Decimal(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{12345678.99, ""},
+		{-99999999.99, ""},
+		{json.Number("0.10"), ""},
+		{money{cents: 1999}, ""},
+		{0.125, "must have at most 2 decimal places"},
+		{json.Number("1.001"), "must have at most 2 decimal places"},
+		{100000000, "must have at most 8 digits before the decimal point"},
+		{-123456789.5, "must have at most 8 digits before the decimal point"},
+	}
+	for _, schema := range bothPaths(builders.Number().Decimal(10, 2)) {
+		for _, tt := range tests {
+			err := queryfy.Validate(tt.value, schema)
+			var got string
+			if verr, ok := err.(*queryfy.ValidationError); ok {
+				got = verr.Errors[0].Message
+			}
+			if got != tt.want {
+				t.Errorf("%T: %v: got %q, want %q", schema, tt.value, got, tt.want)
+			}
+		}
+	}
+
+	precision, scale, ok := builders.Number().Decimal(10, 2).DecimalConstraint()
+	if !ok || precision != 10 || scale != 2 {
+		t.Errorf("DecimalConstraint() = %d, %d, %v", precision, scale, ok)
+	}
+	if _, _, ok := builders.Number().DecimalConstraint(); ok {
+		t.Error("DecimalConstraint() set without Decimal")
+	}
+}
+
+func TestNumber_DecimalPanicsOnInvalidArguments(t *testing.T) {
+	for _, args := range [][2]int{{2, 3}, {5, -1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Decimal(%d, %d) did not panic", args[0], args[1])
+				}
+			}()
+			builders.Number().Decimal(args[0], args[1])
+		}()
+	}
+}
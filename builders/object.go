@@ -115,7 +115,7 @@ func (s *ObjectSchema) Validate(value interface{}, ctx *queryfy.ValidationContex
 	// Convert to map for validation
 	objMap, ok := convertToMap(value)
 	if !ok {
-		ctx.AddError(fmt.Sprintf("cannot convert %T to map", value), value)
+		ctx.AddCodedError(queryfy.CodeConversion, queryfy.Params{"type": fmt.Sprintf("%T", value), "target": "map"}, value)
 		return nil
 	}
 
@@ -124,7 +124,7 @@ func (s *ObjectSchema) Validate(value interface{}, ctx *queryfy.ValidationContex
 		if required {
 			if _, exists := objMap[fieldName]; !exists {
 				ctx.WithPath(fieldName, func() {
-					ctx.AddError("field is required", nil)
+					ctx.AddCodedError(queryfy.CodeRequired, nil, nil)
 				})
 			}
 		}
@@ -132,6 +132,10 @@ func (s *ObjectSchema) Validate(value interface{}, ctx *queryfy.ValidationContex
 
 	// Validate each defined field
 	for fieldName, fieldSchema := range s.fields {
+		if _, dependent := fieldSchema.(*DependentSchema); dependent {
+			// Checked against the parent by ObjectSchemaWithDependencies
+			continue
+		}
 		fieldValue, exists := objMap[fieldName]
 
 		ctx.WithPath(fieldName, func() {
@@ -142,7 +146,7 @@ func (s *ObjectSchema) Validate(value interface{}, ctx *queryfy.ValidationContex
 				// Already handled above, skip
 			} else if isRequired(fieldSchema) {
 				// Field schema itself says it's required
-				ctx.AddError("field is required", nil)
+				ctx.AddCodedError(queryfy.CodeRequired, nil, nil)
 			}
 		})
 	}
@@ -152,7 +156,7 @@ func (s *ObjectSchema) Validate(value interface{}, ctx *queryfy.ValidationContex
 		for key := range objMap {
 			if _, defined := s.fields[key]; !defined {
 				ctx.WithPath(key, func() {
-					ctx.AddError("unexpected field", objMap[key])
+					ctx.AddCodedError(queryfy.CodeUnexpectedField, nil, objMap[key])
 				})
 			}
 		}
@@ -161,7 +165,7 @@ func (s *ObjectSchema) Validate(value interface{}, ctx *queryfy.ValidationContex
 	// Custom validators
 	for _, validator := range s.validators {
 		if err := validator(objMap); err != nil {
-			ctx.AddError(err.Error(), objMap)
+			ctx.AddValidatorError(err, objMap)
 		}
 	}
 
@@ -187,6 +191,9 @@ func (s *ObjectSchema) RequiredFieldNames() []string {
 		if s.requiredFields[name] {
 			continue // already counted
 		}
+		if _, dependent := schema.(*DependentSchema); dependent {
+			continue // required only when its condition holds
+		}
 		if requirer, ok := schema.(interface{ IsRequired() bool }); ok && requirer.IsRequired() {
 			names = append(names, name)
 		}
@@ -258,7 +265,7 @@ func (s *ObjectSchema) ValidateAndTransform(value interface{}, ctx *queryfy.Vali
 	// Convert to map
 	objMap, ok := convertToMap(value)
 	if !ok {
-		ctx.AddError(fmt.Sprintf("cannot convert %T to map", value), value)
+		ctx.AddCodedError(queryfy.CodeConversion, queryfy.Params{"type": fmt.Sprintf("%T", value), "target": "map"}, value)
 		return value, ctx.Error()
 	}
 
@@ -277,7 +284,7 @@ func (s *ObjectSchema) ValidateAndTransform(value interface{}, ctx *queryfy.Vali
 		if required {
 			if _, exists := objMap[fieldName]; !exists {
 				ctx.WithPath(fieldName, func() {
-					ctx.AddError("field is required", nil)
+					ctx.AddCodedError(queryfy.CodeRequired, nil, nil)
 				})
 			}
 		}
@@ -287,12 +294,20 @@ func (s *ObjectSchema) ValidateAndTransform(value interface{}, ctx *queryfy.Vali
 	for fieldName, fieldSchema := range s.fields {
 		fieldValue, exists := objMap[fieldName]
 
+		if _, dependent := fieldSchema.(*DependentSchema); dependent {
+			// Checked against the parent by ObjectSchemaWithDependencies
+			if exists {
+				result[fieldName] = fieldValue
+			}
+			continue
+		}
+
 		if !exists {
 			if s.requiredFields[fieldName] {
 				// Already reported above
 			} else if isRequired(fieldSchema) {
 				ctx.WithPath(fieldName, func() {
-					ctx.AddError("field is required", nil)
+					ctx.AddCodedError(queryfy.CodeRequired, nil, nil)
 				})
 			}
 			continue
@@ -319,7 +334,7 @@ func (s *ObjectSchema) ValidateAndTransform(value interface{}, ctx *queryfy.Vali
 		for key := range objMap {
 			if _, defined := s.fields[key]; !defined {
 				ctx.WithPath(key, func() {
-					ctx.AddError("unexpected field", objMap[key])
+					ctx.AddCodedError(queryfy.CodeUnexpectedField, nil, objMap[key])
 				})
 			}
 		}
@@ -328,7 +343,7 @@ func (s *ObjectSchema) ValidateAndTransform(value interface{}, ctx *queryfy.Vali
 	// Custom validators run against the result map
 	for _, validator := range s.validators {
 		if err := validator(result); err != nil {
-			ctx.AddError(err.Error(), result)
+			ctx.AddValidatorError(err, result)
 		}
 	}
 
@@ -376,7 +391,7 @@ func (s *ObjectSchema) ValidateAndTransformAsync(goCtx context.Context, value in
 	// Convert to map
 	objMap, ok := convertToMap(value)
 	if !ok {
-		ctx.AddError(fmt.Sprintf("cannot convert %T to map", value), value)
+		ctx.AddCodedError(queryfy.CodeConversion, queryfy.Params{"type": fmt.Sprintf("%T", value), "target": "map"}, value)
 		return value, ctx.Error()
 	}
 
@@ -394,7 +409,7 @@ func (s *ObjectSchema) ValidateAndTransformAsync(goCtx context.Context, value in
 		if required {
 			if _, exists := objMap[fieldName]; !exists {
 				ctx.WithPath(fieldName, func() {
-					ctx.AddError("field is required", nil)
+					ctx.AddCodedError(queryfy.CodeRequired, nil, nil)
 				})
 			}
 		}
@@ -404,12 +419,20 @@ func (s *ObjectSchema) ValidateAndTransformAsync(goCtx context.Context, value in
 	for fieldName, fieldSchema := range s.fields {
 		fieldValue, exists := objMap[fieldName]
 
+		if _, dependent := fieldSchema.(*DependentSchema); dependent {
+			// Checked against the parent by ObjectSchemaWithDependencies
+			if exists {
+				result[fieldName] = fieldValue
+			}
+			continue
+		}
+
 		if !exists {
 			if s.requiredFields[fieldName] {
 				// Already reported above
 			} else if isRequired(fieldSchema) {
 				ctx.WithPath(fieldName, func() {
-					ctx.AddError("field is required", nil)
+					ctx.AddCodedError(queryfy.CodeRequired, nil, nil)
 				})
 			}
 			continue
@@ -434,7 +457,7 @@ func (s *ObjectSchema) ValidateAndTransformAsync(goCtx context.Context, value in
 		for key := range objMap {
 			if _, defined := s.fields[key]; !defined {
 				ctx.WithPath(key, func() {
-					ctx.AddError("unexpected field", objMap[key])
+					ctx.AddCodedError(queryfy.CodeUnexpectedField, nil, objMap[key])
 				})
 			}
 		}
@@ -443,7 +466,7 @@ func (s *ObjectSchema) ValidateAndTransformAsync(goCtx context.Context, value in
 	// Sync custom validators
 	for _, validator := range s.validators {
 		if err := validator(result); err != nil {
-			ctx.AddError(err.Error(), result)
+			ctx.AddValidatorError(err, result)
 		}
 	}
 
@@ -454,7 +477,7 @@ func (s *ObjectSchema) ValidateAndTransformAsync(goCtx context.Context, value in
 
 	// Check context cancellation before async phase
 	if goCtx.Err() != nil {
-		ctx.AddError(fmt.Sprintf("validation cancelled: %s", goCtx.Err()), result)
+		ctx.AddCodedError(queryfy.CodeCancelled, queryfy.Params{"error": goCtx.Err().Error()}, result)
 		return result, ctx.Error()
 	}
 
@@ -466,7 +489,7 @@ func (s *ObjectSchema) ValidateAndTransformAsync(goCtx context.Context, value in
 		}
 
 		if goCtx.Err() != nil {
-			ctx.AddError(fmt.Sprintf("validation cancelled: %s", goCtx.Err()), result)
+			ctx.AddCodedError(queryfy.CodeCancelled, queryfy.Params{"error": goCtx.Err().Error()}, result)
 			return result, ctx.Error()
 		}
 
@@ -483,12 +506,12 @@ func (s *ObjectSchema) ValidateAndTransformAsync(goCtx context.Context, value in
 	// Run object-level async validators
 	for _, asyncValidator := range s.asyncValidators {
 		if goCtx.Err() != nil {
-			ctx.AddError(fmt.Sprintf("validation cancelled: %s", goCtx.Err()), result)
+			ctx.AddCodedError(queryfy.CodeCancelled, queryfy.Params{"error": goCtx.Err().Error()}, result)
 			return result, ctx.Error()
 		}
 
 		if err := asyncValidator(goCtx, result); err != nil {
-			ctx.AddError(err.Error(), result)
+			ctx.AddValidatorError(err, result)
 		}
 	}
 
@@ -0,0 +1,177 @@
+// ref.go - Named schema references
+package builders
+
+import (
+	"fmt"
+
+	"github.com/ha1tch/queryfy"
+)
+
+// RefSchema is a named reference to another schema. References let a
+// single definition be reused in several places, and they are the only
+// way to express recursive structures (a tree node whose children are
+// tree nodes).
+//
+// A reference can be created before its target exists and resolved
+// later with Resolve. Every RefSchema produced by Clone shares the same
+// target, so resolving one resolves all of them.
+//
+// Required and Nullable are tracked per reference, not on the shared
+// target, so one definition can be required in one place and optional
+// in another.
+type RefSchema struct {
+	queryfy.BaseSchema
+	name string
+	def  *refDefinition
+}
+
+// refDefinition is the target shared by all clones of a reference.
+type refDefinition struct {
+	schema queryfy.Schema
+}
+
+// Ref creates a new, unresolved reference with the given name.
+// Call Resolve to attach the target schema.
+func Ref(name string) *RefSchema {
+	return &RefSchema{
+		BaseSchema: queryfy.BaseSchema{
+			SchemaType: queryfy.TypeAny,
+		},
+		name: name,
+		def:  &refDefinition{},
+	}
+}
+
+// Resolve sets the target schema for this reference and every clone
+// of it.
+func (s *RefSchema) Resolve(target queryfy.Schema) *RefSchema {
+	s.def.schema = target
+	return s
+}
+
+// Clone returns a new reference to the same target. The clone starts
+// out optional and non-nullable regardless of this reference's settings.
+func (s *RefSchema) Clone() *RefSchema {
+	return &RefSchema{
+		BaseSchema: queryfy.BaseSchema{
+			SchemaType: queryfy.TypeAny,
+		},
+		name: s.name,
+		def:  s.def,
+	}
+}
+
+// Required marks the field as required.
+func (s *RefSchema) Required() *RefSchema {
+	s.SetRequired(true)
+	return s
+}
+
+// Optional marks the field as optional (default).
+func (s *RefSchema) Optional() *RefSchema {
+	s.SetRequired(false)
+	return s
+}
+
+// Nullable allows the field to be null.
+func (s *RefSchema) Nullable() *RefSchema {
+	s.SetNullable(true)
+	return s
+}
+
+// Name returns the reference name.
+func (s *RefSchema) Name() string {
+	return s.name
+}
+
+// Target returns the referenced schema, or nil if the reference has not
+// been resolved.
+func (s *RefSchema) Target() queryfy.Schema {
+	return s.def.schema
+}
+
+// IsResolved reports whether Resolve has been called.
+func (s *RefSchema) IsResolved() bool {
+	return s.def.schema != nil
+}
+
+// SameTarget reports whether two references share a target, i.e. one
+// was cloned from the other or both were cloned from a common reference.
+func (s *RefSchema) SameTarget(other *RefSchema) bool {
+	return other != nil && s.def == other.def
+}
+
+// IsNullable returns true if either this reference or its target
+// accepts null.
+func (s *RefSchema) IsNullable() bool {
+	if s.BaseSchema.IsNullable() {
+		return true
+	}
+	if s.def.schema != nil {
+		return isNullable(s.def.schema)
+	}
+	return false
+}
+
+// Validate implements the Schema interface.
+func (s *RefSchema) Validate(value interface{}, ctx *queryfy.ValidationContext) error {
+	if !s.checkNil(value, ctx) {
+		return nil
+	}
+	if s.def.schema == nil {
+		ctx.AddCodedError(queryfy.CodeUnresolvedRef, queryfy.Params{"name": s.name}, value)
+		return nil
+	}
+	return s.def.schema.Validate(value, ctx)
+}
+
+// ValidateAndTransform validates the value against the target and returns
+// the target's transformed result when the target supports transformation.
+func (s *RefSchema) ValidateAndTransform(value interface{}, ctx *queryfy.ValidationContext) (interface{}, error) {
+	if !s.checkNil(value, ctx) {
+		return value, ctx.Error()
+	}
+	if s.def.schema == nil {
+		ctx.AddCodedError(queryfy.CodeUnresolvedRef, queryfy.Params{"name": s.name}, value)
+		return value, ctx.Error()
+	}
+	if ts, ok := s.def.schema.(queryfy.TransformableSchema); ok {
+		return ts.ValidateAndTransform(value, ctx)
+	}
+	s.def.schema.Validate(value, ctx)
+	return value, ctx.Error()
+}
+
+// checkNil mirrors BaseSchema.CheckRequired but honours the target's
+// nullability as well as the reference's own.
+func (s *RefSchema) checkNil(value interface{}, ctx *queryfy.ValidationContext) bool {
+	if value != nil {
+		return true
+	}
+	if s.IsRequired() {
+		ctx.AddCodedError(queryfy.CodeRequired, nil, nil)
+	} else if !s.IsNullable() {
+		ctx.AddCodedError(queryfy.CodeNotNull, nil, nil)
+	}
+	return false
+}
+
+// Meta attaches a key-value metadata pair to the schema.
+func (s *RefSchema) Meta(key string, value interface{}) *RefSchema {
+	s.SetMeta(key, value)
+	return s
+}
+
+// Type returns the target schema's type, or TypeAny if the reference is
+// unresolved.
+func (s *RefSchema) Type() queryfy.SchemaType {
+	if s.def.schema == nil {
+		return queryfy.TypeAny
+	}
+	return s.def.schema.Type()
+}
+
+// String returns a string representation of the reference.
+func (s *RefSchema) String() string {
+	return fmt.Sprintf("Ref(%s)", s.name)
+}
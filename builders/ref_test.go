@@ -0,0 +1,139 @@
+package builders_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+)
+
+// ======================================================================
+// Schema references
+// ======================================================================
+
+func newTreeRef() *builders.RefSchema {
+	node := builders.Ref("Node")
+	node.Resolve(builders.Object().
+		Field("name", builders.String().Required()).
+		Field("children", builders.Array().Of(node.Clone())))
+	return node
+}
+
+func TestRef_Recursive(t *testing.T) {
+	schema := newTreeRef()
+
+	valid := map[string]interface{}{
+		"name": "root",
+		"children": []interface{}{
+			map[string]interface{}{"name": "leaf"},
+		},
+	}
+	if err := queryfy.Validate(valid, schema); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	invalid := map[string]interface{}{
+		"name": "root",
+		"children": []interface{}{
+			map[string]interface{}{"children": []interface{}{}},
+		},
+	}
+	err := queryfy.Validate(invalid, schema)
+	if err == nil {
+		t.Fatal("expected error for missing nested name")
+	}
+	if !strings.Contains(err.Error(), "children[0].name") {
+		t.Errorf("expected nested path in error, got %v", err)
+	}
+}
+
+func TestRef_Unresolved(t *testing.T) {
+	err := queryfy.Validate("x", builders.Ref("Missing"))
+	if err == nil || !strings.Contains(err.Error(), `unresolved schema reference "Missing"`) {
+		t.Errorf("expected unresolved reference error, got %v", err)
+	}
+}
+
+func TestRef_CloneRequiredIsLocal(t *testing.T) {
+	def := builders.Ref("Name").Resolve(builders.String())
+	schema := builders.Object().
+		Field("first", def.Clone().Required()).
+		Field("nick", def.Clone())
+
+	if err := queryfy.Validate(map[string]interface{}{"first": "Ada"}, schema); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := queryfy.Validate(map[string]interface{}{"nick": "A"}, schema); err == nil {
+		t.Error("expected error for missing required ref field")
+	}
+	if !def.Clone().SameTarget(def) {
+		t.Error("expected clone to share target")
+	}
+}
+
+func TestRef_NullableTarget(t *testing.T) {
+	ref := builders.Ref("MaybeString").Resolve(builders.String().Nullable())
+	if err := queryfy.Validate(nil, ref); err != nil {
+		t.Errorf("expected nil accepted through nullable target, got %v", err)
+	}
+	if err := queryfy.Validate(nil, builders.Ref("S").Resolve(builders.String())); err == nil {
+		t.Error("expected nil rejected")
+	}
+}
+
+func TestRef_Compiled(t *testing.T) {
+	compiled := queryfy.Compile(newTreeRef())
+	err := queryfy.Validate(map[string]interface{}{
+		"name":     "root",
+		"children": []interface{}{map[string]interface{}{"name": 1}},
+	}, compiled)
+	if err == nil {
+		t.Error("expected error from compiled ref schema")
+	}
+}
+
+func TestRef_WalkTerminates(t *testing.T) {
+	var paths []string
+	err := builders.Walk(newTreeRef(), func(path string, s queryfy.Schema) error {
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"", "", "children", "children[*]", "name"}
+	if strings.Join(paths, "|") != strings.Join(want, "|") {
+		t.Errorf("expected paths %v, got %v", want, paths)
+	}
+}
+
+func TestRef_EqualAndHash(t *testing.T) {
+	a, b := newTreeRef(), newTreeRef()
+	if !builders.Equal(a, b) {
+		t.Error("expected structurally identical recursive refs to be equal")
+	}
+	if builders.Hash(a) != builders.Hash(b) {
+		t.Error("expected identical hashes")
+	}
+
+	c := builders.Ref("Node").Resolve(builders.Object().Field("name", builders.String()))
+	if builders.Equal(a, c) {
+		t.Error("expected refs with different targets to differ")
+	}
+}
+
+func TestRef_Diff(t *testing.T) {
+	old := builders.Object().Field("a", builders.Ref("A").Resolve(
+		builders.Object().Field("x", builders.String())))
+	new := builders.Object().Field("a", builders.Ref("A").Resolve(
+		builders.Object().Field("x", builders.String()).Field("y", builders.Number())))
+
+	diff, err := builders.Diff(old, new)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != "a.y" {
+		t.Errorf("expected a.y added, got %+v", diff)
+	}
+}
@@ -0,0 +1,219 @@
+// schemaquery.go - Queries checked against a schema
+package builders
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/query"
+)
+
+// SchemaQuery is a query whose path has been checked against a schema,
+// with typed accessors for its result. Build one at setup time so that
+// a misspelt field fails at startup rather than on the first request:
+//
+//	var customerEmail = builders.MustSchemaQuery(orderSchema, "customer.email")
+//
+//	email, err := customerEmail.String(order)
+//
+// A SchemaQuery is immutable and safe for concurrent use.
+type SchemaQuery struct {
+	query  *query.CompiledQuery
+	schema queryfy.Schema
+}
+
+// NewSchemaQuery parses a query and checks that its path exists in the
+// schema: every field must be declared by the object schema it is read
+// from, and every index, wildcard, filter, slice or union must apply to
+// an array schema. Below a recursive descent, a function call, or a
+// composite, custom or untyped array element schema the structure is
+// not known, and the rest of the path is not checked.
+func NewSchemaQuery(schema queryfy.Schema, queryStr string) (*SchemaQuery, error) {
+	compiled, err := query.Compile(queryStr)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := schemaAtPath(schema, compiled.Path())
+	if err != nil {
+		return nil, fmt.Errorf("query %q: %w", queryStr, err)
+	}
+	return &SchemaQuery{query: compiled, schema: leaf}, nil
+}
+
+// MustSchemaQuery is like NewSchemaQuery but panics if the query does
+// not parse or does not fit the schema. It is meant for package-level
+// variables.
+func MustSchemaQuery(schema queryfy.Schema, queryStr string) *SchemaQuery {
+	q, err := NewSchemaQuery(schema, queryStr)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// Query returns the query string.
+func (q *SchemaQuery) Query() string {
+	return q.query.String()
+}
+
+// Schema returns the schema the query's path leads to, or nil if it
+// could not be determined.
+func (q *SchemaQuery) Schema() queryfy.Schema {
+	return q.schema
+}
+
+// Execute runs the query against data.
+func (q *SchemaQuery) Execute(data interface{}) (interface{}, error) {
+	return q.query.Execute(data)
+}
+
+// String runs the query and returns its result as a string. See
+// queryfy.AsString.
+func (q *SchemaQuery) String(data interface{}) (string, error) {
+	value, err := q.execute(data)
+	if err != nil {
+		return "", err
+	}
+	s, err := queryfy.AsString(value)
+	return s, q.wrap(err)
+}
+
+// Int runs the query and returns its result as an int. See
+// queryfy.AsInt.
+func (q *SchemaQuery) Int(data interface{}) (int, error) {
+	value, err := q.execute(data)
+	if err != nil {
+		return 0, err
+	}
+	n, err := queryfy.AsInt(value)
+	return n, q.wrap(err)
+}
+
+// Float runs the query and returns its result as a float64. See
+// queryfy.AsFloat.
+func (q *SchemaQuery) Float(data interface{}) (float64, error) {
+	value, err := q.execute(data)
+	if err != nil {
+		return 0, err
+	}
+	f, err := queryfy.AsFloat(value)
+	return f, q.wrap(err)
+}
+
+// Bool runs the query and returns its result as a bool. See
+// queryfy.AsBool.
+func (q *SchemaQuery) Bool(data interface{}) (bool, error) {
+	value, err := q.execute(data)
+	if err != nil {
+		return false, err
+	}
+	b, err := queryfy.AsBool(value)
+	return b, q.wrap(err)
+}
+
+// Time runs the query and returns its result as a time.Time. When the
+// path leads to a DateTimeSchema, strings are parsed with its format;
+// otherwise as RFC 3339.
+func (q *SchemaQuery) Time(data interface{}) (time.Time, error) {
+	value, err := q.execute(data)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var layouts []string
+	if dt, ok := unwrapSchema(q.schema).(*DateTimeSchema); ok && dt.FormatString() != "" {
+		layouts = []string{dt.FormatString()}
+	}
+	t, err := queryfy.AsTime(value, layouts...)
+	return t, q.wrap(err)
+}
+
+// Strings runs the query and returns its result as a []string. See
+// queryfy.AsStrings.
+func (q *SchemaQuery) Strings(data interface{}) ([]string, error) {
+	value, err := q.execute(data)
+	if err != nil {
+		return nil, err
+	}
+	s, err := queryfy.AsStrings(value)
+	return s, q.wrap(err)
+}
+
+// execute runs the compiled query for a typed accessor.
+func (q *SchemaQuery) execute(data interface{}) (interface{}, error) {
+	value, err := q.query.Execute(data)
+	return value, q.wrap(err)
+}
+
+// wrap names the query in an error, as the queryfy.Query accessors do.
+func (q *SchemaQuery) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("query %q: %w", q.Query(), err)
+}
+
+// schemaAtPath follows a query path through a schema and returns the
+// schema of the values it reaches, or nil once the structure is no
+// longer known.
+func schemaAtPath(schema queryfy.Schema, path []interface{}) (queryfy.Schema, error) {
+	current := schema
+	for i, segment := range path {
+		current = unwrapSchema(current)
+		if current == nil || opaqueSchema(current) {
+			return nil, nil
+		}
+		at := query.Match{Path: path[:i+1]}.PathString()
+
+		switch seg := segment.(type) {
+		case string:
+			switch current.(type) {
+			case *ObjectSchema, *ObjectSchemaWithDependencies:
+			default:
+				return nil, fmt.Errorf("%s: schema is %s, not an object", at, current.Type())
+			}
+			field := fieldSchema(current, seg)
+			if field == nil {
+				return nil, fmt.Errorf("%s: field %q is not in the schema", at, seg)
+			}
+			current = field
+
+		case int, query.Wildcard, query.Filter, query.Slice, query.Union:
+			switch s := current.(type) {
+			case *ArraySchema:
+				current = s.ElementSchema()
+			default:
+				return nil, fmt.Errorf("%s: schema is %s, not an array", at, current.Type())
+			}
+
+		default:
+			// Recursive descent and function calls
+			return nil, nil
+		}
+	}
+	return current, nil
+}
+
+// opaqueSchema reports whether a schema does not describe the structure
+// of the values it accepts: composites, custom validators and
+// unresolved references.
+func opaqueSchema(schema queryfy.Schema) bool {
+	switch schema.(type) {
+	case *AndSchema, *OrSchema, *OneOfSchema, *NotSchema, *CustomSchema, *DependentSchema, *RefSchema:
+		return true
+	}
+	return false
+}
+
+// unwrapSchema follows references and transforms to the schema that
+// describes the structure of a value.
+func unwrapSchema(schema queryfy.Schema) queryfy.Schema {
+	for {
+		switch s := resolveSchema(schema).(type) {
+		case *TransformSchema:
+			schema = s.InnerSchema()
+		default:
+			return s
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package builders_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ha1tch/queryfy/builders"
+)
+
+func orderQuerySchema() *builders.ObjectSchema {
+	return builders.Object().
+		Field("id", builders.String()).
+		Field("placed", builders.DateTime().DateOnly()).
+		Field("customer", builders.Object().
+			Field("email", builders.String().Email())).
+		Field("items", builders.Array().Of(builders.Object().
+			Field("sku", builders.String()).
+			Field("qty", builders.Number().Integer()))).
+		Field("notes", builders.Array()).
+		Field("extra", builders.Or(builders.String(), builders.Number()))
+}
+
+// ======================================================================
+// SchemaQuery
+// ======================================================================
+
+func TestSchemaQuery_Valid(t *testing.T) {
+	schema := orderQuerySchema()
+	for _, q := range []string{
+		"id",
+		"customer.email",
+		"items[0].qty",
+		"items[*].sku",
+		"items[?(@.qty > 1)].sku",
+		"items..sku",
+		"count(items)",
+		"notes[0].anything",
+		"extra.anything",
+	} {
+		if _, err := builders.NewSchemaQuery(schema, q); err != nil {
+			t.Errorf("%s: unexpected error: %v", q, err)
+		}
+	}
+}
+
+func TestSchemaQuery_Invalid(t *testing.T) {
+	schema := orderQuerySchema()
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"customer.mail", `customer.mail: field "mail" is not in the schema`},
+		{"items[0].price", `items[0].price: field "price" is not in the schema`},
+		{"id[0]", "id[0]: schema is string, not an array"},
+		{"items.sku", "items.sku: schema is array, not an object"},
+		{"items[", "invalid query"},
+	}
+	for _, tt := range tests {
+		_, err := builders.NewSchemaQuery(schema, tt.query)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want error containing %q", tt.query, err, tt.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustSchemaQuery should panic")
+		}
+	}()
+	builders.MustSchemaQuery(schema, "customer.mail")
+}
+
+func TestSchemaQuery_Accessors(t *testing.T) {
+	schema := orderQuerySchema()
+	data := map[string]interface{}{
+		"id":       "A-1",
+		"placed":   "2024-03-01",
+		"customer": map[string]interface{}{"email": "ann@example.com"},
+		"items": []interface{}{
+			map[string]interface{}{"sku": "x", "qty": 2.0},
+			map[string]interface{}{"sku": "y", "qty": 1.0},
+		},
+	}
+
+	qty := builders.MustSchemaQuery(schema, "items[0].qty")
+	if _, ok := qty.Schema().(*builders.NumberSchema); !ok {
+		t.Errorf("expected a NumberSchema, got %T", qty.Schema())
+	}
+	if n, err := qty.Int(data); err != nil || n != 2 {
+		t.Errorf("Int: got %d, %v", n, err)
+	}
+	if s, err := builders.MustSchemaQuery(schema, "customer.email").String(data); err != nil || s != "ann@example.com" {
+		t.Errorf("String: got %q, %v", s, err)
+	}
+	if s, err := builders.MustSchemaQuery(schema, "items[*].sku").Strings(data); err != nil || len(s) != 2 {
+		t.Errorf("Strings: got %v, %v", s, err)
+	}
+
+	// json.Number results, as DecodeJSON produces
+	decoded := map[string]interface{}{"items": []interface{}{
+		map[string]interface{}{"qty": json.Number("9007199254740993")},
+	}}
+	if n, err := qty.Int(decoded); err != nil || n != 9007199254740993 {
+		t.Errorf("Int json.Number: got %d, %v", n, err)
+	}
+	if _, err := qty.String(decoded); err == nil || !strings.Contains(err.Error(), `query "items[0].qty": expected string`) {
+		t.Errorf("String: got %v", err)
+	}
+
+	// The DateTimeSchema's format is used to parse the string
+	placed, err := builders.MustSchemaQuery(schema, "placed").Time(data)
+	if err != nil || !placed.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Time: got %v, %v", placed, err)
+	}
+}
@@ -3,7 +3,6 @@ package builders
 import (
 	"fmt"
 	"regexp"
-	"strings"
 
 	"github.com/ha1tch/queryfy"
 )
@@ -124,41 +123,39 @@ func (s *StringSchema) Validate(value interface{}, ctx *queryfy.ValidationContex
 	if ctx.Mode() == queryfy.Loose {
 		str, ok = queryfy.ConvertToString(value)
 		if !ok {
-			ctx.AddError(fmt.Sprintf("cannot convert %T to string", value), value)
+			ctx.AddCodedError(queryfy.CodeConversion, queryfy.Params{"type": fmt.Sprintf("%T", value), "target": "string"}, value)
 			return nil
 		}
 	} else {
 		// Strict mode - must be a string
 		str, ok = value.(string)
 		if !ok {
-			ctx.AddError(fmt.Sprintf("expected string, got %T", value), value)
+			ctx.AddCodedError(queryfy.CodeType, queryfy.Params{"expected": "string", "actual": fmt.Sprintf("%T", value)}, value)
 			return nil
 		}
 	}
 
 	// Length validation
 	if s.minLength != nil && len(str) < *s.minLength {
-		ctx.AddError(fmt.Sprintf("length must be at least %d, got %d", *s.minLength, len(str)), str)
+		ctx.AddCodedError(queryfy.CodeMinLength, queryfy.Params{"min": *s.minLength, "actual": len(str)}, str)
 	}
 
 	if s.maxLength != nil && len(str) > *s.maxLength {
-		ctx.AddError(fmt.Sprintf("length must be at most %d, got %d", *s.maxLength, len(str)), str)
+		ctx.AddCodedError(queryfy.CodeMaxLength, queryfy.Params{"max": *s.maxLength, "actual": len(str)}, str)
 	}
 
 	// Pattern validation
 	if s.pattern != nil && !s.pattern.MatchString(str) {
-		var msg string
 		switch s.formatType {
 		case "email":
-			msg = "must be a valid email address"
+			ctx.AddCodedError(queryfy.CodeEmail, nil, str)
 		case "url":
-			msg = "must be a valid URL"
+			ctx.AddCodedError(queryfy.CodeURL, nil, str)
 		case "uuid":
-			msg = "must be a valid UUID"
+			ctx.AddCodedError(queryfy.CodeUUID, nil, str)
 		default:
-			msg = fmt.Sprintf("must match pattern %s", s.patternStr)
+			ctx.AddCodedError(queryfy.CodePattern, queryfy.Params{"pattern": s.patternStr}, str)
 		}
-		ctx.AddError(msg, str)
 	}
 
 	// Enum validation
@@ -171,14 +168,14 @@ func (s *StringSchema) Validate(value interface{}, ctx *queryfy.ValidationContex
 			}
 		}
 		if !found {
-			ctx.AddError(fmt.Sprintf("must be one of: %s", strings.Join(s.enum, ", ")), str)
+			ctx.AddCodedError(queryfy.CodeEnum, queryfy.Params{"values": s.enum}, str)
 		}
 	}
 
 	// Custom validators
 	for _, validator := range s.validators {
 		if err := validator(str); err != nil {
-			ctx.AddError(err.Error(), str)
+			ctx.AddValidatorError(err, str)
 		}
 	}
 
@@ -0,0 +1,417 @@
+// structschema.go - Deriving schemas from Go struct types
+package builders
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ha1tch/queryfy"
+)
+
+// structCache holds one derived schema (or error) per struct type.
+var structCache sync.Map // reflect.Type -> structCacheEntry
+
+type structCacheEntry struct {
+	schema *ObjectSchema
+	err    error
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	byteSliceType = reflect.TypeOf([]byte(nil))
+)
+
+// FromStruct derives an ObjectSchema from a struct type. v may be a
+// struct value, a pointer to a struct, or a reflect.Type for either.
+//
+// Field names follow the json tag, falling back to the Go field name.
+// Fields tagged `json:"-"` or `queryfy:"-"` and unexported fields are
+// skipped; untagged embedded structs are flattened. Go types map to
+// schemas as follows:
+//
+//   - string: String
+//   - bool: Bool
+//   - integer kinds: Number().Integer(), with Min(0) for unsigned kinds
+//   - float kinds: Number
+//   - time.Time: DateTime
+//   - []byte: String (base64, as encoding/json writes it)
+//   - slices and arrays: Array().Of(element)
+//   - structs: nested ObjectSchema; recursive types use Ref
+//   - map[string]T: Object().AllowAdditional(true)
+//   - interface{}: any value, including null
+//   - pointers: the element's schema, made Nullable
+//
+// Constraints come from the queryfy tag, a comma-separated list:
+//
+//	Email string `json:"email" queryfy:"required,format=email"`
+//	Age   int    `json:"age" queryfy:"min=0,max=150"`
+//	Tags  []string `queryfy:"min=1,unique"`
+//
+// Supported options are required, nullable, min, max, len, enum
+// (values separated by |), format (email, url, uuid, a registered
+// format name, or date/datetime for time.Time), integer, multipleOf,
+// unique and pattern. Because a pattern may contain commas, pattern
+// must be the last option. min, max and len apply to string length,
+// numeric value, array length or, for time.Time, an RFC 3339 or
+// date-only bound.
+//
+// The result is cached per type and shared between callers, so treat
+// it as read-only: Field, Required and the other builder methods would
+// change it for everyone. Combine it with And to add constraints.
+func FromStruct(v interface{}) (*ObjectSchema, error) {
+	t, ok := v.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(v)
+	}
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("FromStruct: expected a struct type, got %v", t)
+	}
+
+	if cached, ok := structCache.Load(t); ok {
+		entry := cached.(structCacheEntry)
+		return entry.schema, entry.err
+	}
+
+	d := &structDeriver{refs: make(map[reflect.Type]*RefSchema), active: make(map[reflect.Type]bool)}
+	schema, err := d.object(t, "")
+	actual, _ := structCache.LoadOrStore(t, structCacheEntry{schema: schema, err: err})
+	entry := actual.(structCacheEntry)
+	return entry.schema, entry.err
+}
+
+// MustFromStruct is like FromStruct but panics on error. It is intended
+// for package-level schema variables.
+func MustFromStruct(v interface{}) *ObjectSchema {
+	schema, err := FromStruct(v)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// structDeriver builds the schemas for one FromStruct call. active
+// holds the struct types being built, so that a type reached again
+// through its own fields becomes a reference.
+type structDeriver struct {
+	refs   map[reflect.Type]*RefSchema
+	active map[reflect.Type]bool
+}
+
+// object builds the ObjectSchema for a struct type.
+func (d *structDeriver) object(t reflect.Type, path string) (*ObjectSchema, error) {
+	d.active[t] = true
+	defer delete(d.active, t)
+
+	obj := Object()
+	if err := d.addFields(obj, t, path); err != nil {
+		return nil, err
+	}
+	if ref, ok := d.refs[t]; ok {
+		ref.Resolve(obj)
+	}
+	return obj, nil
+}
+
+// addFields adds the fields of t to obj, flattening embedded structs.
+func (d *structDeriver) addFields(obj *ObjectSchema, t reflect.Type, path string) error {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("queryfy")
+		jsonTag := sf.Tag.Get("json")
+		if tag == "-" || jsonTag == "-" {
+			continue
+		}
+
+		if sf.Anonymous && jsonTag == "" {
+			et := sf.Type
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				if err := d.addFields(obj, et, path); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := sf.Name
+		if tagName, _, _ := strings.Cut(jsonTag, ","); tagName != "" {
+			name = tagName
+		}
+		if _, exists := obj.GetField(name); exists {
+			// The outer field wins, as in encoding/json
+			continue
+		}
+
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		schema, err := d.field(sf.Type, tag, fieldPath)
+		if err != nil {
+			return err
+		}
+		obj.Field(name, schema)
+	}
+	return nil
+}
+
+// field builds the schema for a struct field and applies its tag.
+func (d *structDeriver) field(t reflect.Type, tag, path string) (queryfy.Schema, error) {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		nullable = true
+		t = t.Elem()
+	}
+
+	schema, err := d.schemaFor(t, path)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyStructTag(schema, tag, path); err != nil {
+		return nil, err
+	}
+	if nullable {
+		setNullable(schema)
+	}
+	return schema, nil
+}
+
+// schemaFor maps a Go type to an unconstrained schema.
+func (d *structDeriver) schemaFor(t reflect.Type, path string) (queryfy.Schema, error) {
+	switch {
+	case t == timeType:
+		return DateTime(), nil
+	case t == byteSliceType:
+		return String(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return String(), nil
+	case reflect.Bool:
+		return Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Number().Integer(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Number().Integer().Min(0), nil
+	case reflect.Float32, reflect.Float64:
+		return Number(), nil
+	case reflect.Slice, reflect.Array:
+		elem, err := d.field(t.Elem(), "", path+"[*]")
+		if err != nil {
+			return nil, err
+		}
+		arr := Array().Of(elem)
+		if t.Kind() == reflect.Array {
+			arr.Length(t.Len())
+		}
+		return arr, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("FromStruct: %s: map keys must be strings, got %s", path, t.Key())
+		}
+		return Object().AllowAdditional(true), nil
+	case reflect.Interface:
+		return And().Nullable(), nil
+	case reflect.Struct:
+		if d.active[t] {
+			ref, ok := d.refs[t]
+			if !ok {
+				ref = Ref(t.String())
+				d.refs[t] = ref
+			}
+			return ref.Clone(), nil
+		}
+		obj, err := d.object(t, path)
+		if err != nil {
+			return nil, err
+		}
+		if ref, ok := d.refs[t]; ok {
+			// Recursive type: the field gets its own reference so its
+			// Required and Nullable flags stay off the shared target
+			delete(d.refs, t)
+			return ref.Clone(), nil
+		}
+		return obj, nil
+	default:
+		return nil, fmt.Errorf("FromStruct: %s: unsupported type %s", path, t)
+	}
+}
+
+// applyStructTag applies the options of a queryfy tag to schema.
+func applyStructTag(schema queryfy.Schema, tag, path string) error {
+	if tag == "" {
+		return nil
+	}
+	options := strings.Split(tag, ",")
+	for i := 0; i < len(options); i++ {
+		key, value, hasValue := strings.Cut(strings.TrimSpace(options[i]), "=")
+		if key == "pattern" {
+			// The rest of the tag belongs to the pattern
+			value = strings.Join(append([]string{value}, options[i+1:]...), ",")
+			i = len(options)
+		}
+		if key == "" {
+			continue
+		}
+		if err := applyStructOption(schema, key, value, hasValue); err != nil {
+			return fmt.Errorf("FromStruct: %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+func applyStructOption(schema queryfy.Schema, key, value string, hasValue bool) error {
+	switch key {
+	case "required":
+		setRequired(schema)
+		return nil
+	case "optional":
+		return nil
+	case "nullable":
+		setNullable(schema)
+		return nil
+	}
+
+	needsValue := key != "integer" && key != "unique"
+	if needsValue && !hasValue {
+		return fmt.Errorf("option %q needs a value", key)
+	}
+
+	switch s := schema.(type) {
+	case *StringSchema:
+		switch key {
+		case "min", "max", "len":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("option %s: invalid length %q", key, value)
+			}
+			switch key {
+			case "min":
+				s.MinLength(n)
+			case "max":
+				s.MaxLength(n)
+			default:
+				s.Length(n)
+			}
+		case "enum":
+			s.Enum(strings.Split(value, "|")...)
+		case "format":
+			switch value {
+			case "email":
+				s.Email()
+			case "url":
+				s.URL()
+			case "uuid":
+				s.UUID()
+			default:
+				s.FormatString(value)
+			}
+		case "pattern":
+			s.Pattern(value)
+		default:
+			return fmt.Errorf("option %q does not apply to strings", key)
+		}
+	case *NumberSchema:
+		switch key {
+		case "min", "max", "multipleOf":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("option %s: invalid number %q", key, value)
+			}
+			switch key {
+			case "min":
+				s.Min(f)
+			case "max":
+				s.Max(f)
+			default:
+				s.MultipleOf(f)
+			}
+		case "integer":
+			s.Integer()
+		default:
+			return fmt.Errorf("option %q does not apply to numbers", key)
+		}
+	case *ArraySchema:
+		switch key {
+		case "min", "max", "len":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("option %s: invalid length %q", key, value)
+			}
+			switch key {
+			case "min":
+				s.MinItems(n)
+			case "max":
+				s.MaxItems(n)
+			default:
+				s.Length(n)
+			}
+		case "unique":
+			s.UniqueItems()
+		default:
+			return fmt.Errorf("option %q does not apply to arrays", key)
+		}
+	case *DateTimeSchema:
+		switch key {
+		case "min", "max":
+			t, err := parseTimeBound(value)
+			if err != nil {
+				return fmt.Errorf("option %s: invalid time %q", key, value)
+			}
+			if key == "min" {
+				s.Min(t)
+			} else {
+				s.Max(t)
+			}
+		case "format":
+			switch value {
+			case "date":
+				s.DateOnly()
+			case "datetime":
+				s.ISO8601()
+			default:
+				s.Format(value)
+			}
+		default:
+			return fmt.Errorf("option %q does not apply to time.Time", key)
+		}
+	default:
+		return fmt.Errorf("option %q does not apply to %s", key, schema.Type())
+	}
+	return nil
+}
+
+// parseTimeBound parses a min or max tag value for time.Time, in
+// RFC 3339 or date-only form.
+func parseTimeBound(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// setRequired and setNullable set the flags on any builder schema.
+func setRequired(schema queryfy.Schema) {
+	if s, ok := schema.(interface{ SetRequired(bool) }); ok {
+		s.SetRequired(true)
+	}
+}
+
+func setNullable(schema queryfy.Schema) {
+	if s, ok := schema.(interface{ SetNullable(bool) }); ok {
+		s.SetNullable(true)
+	}
+}
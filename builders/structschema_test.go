@@ -0,0 +1,161 @@
+package builders_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+)
+
+// ======================================================================
+// Schemas from struct types
+// ======================================================================
+
+type structAddress struct {
+	Street string `json:"street" queryfy:"required"`
+	Zip    string `json:"zip" queryfy:"pattern=^[0-9]{5}(,[0-9]{4})?$"`
+}
+
+type structAudit struct {
+	CreatedBy string `json:"createdBy"`
+}
+
+type structUser struct {
+	structAudit
+	Name     string            `json:"name" queryfy:"required,min=1,max=100"`
+	Email    string            `json:"email" queryfy:"required,format=email"`
+	Role     string            `json:"role" queryfy:"enum=admin|user"`
+	Age      int               `json:"age" queryfy:"min=0,max=150"`
+	Score    float64           `json:"score,omitempty"`
+	Count    uint8             `json:"count"`
+	Active   bool              `json:"active"`
+	Tags     []string          `json:"tags" queryfy:"min=1,unique"`
+	Address  structAddress     `json:"address"`
+	Manager  *structUser       `json:"manager"`
+	Nickname *string           `json:"nickname"`
+	Born     time.Time         `json:"born" queryfy:"format=date,min=1900-01-01"`
+	Labels   map[string]string `json:"labels"`
+	Extra    interface{}       `json:"extra"`
+	Secret   string            `json:"-"`
+	internal string
+}
+
+func TestFromStruct_Fields(t *testing.T) {
+	schema, err := builders.FromStruct(structUser{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"active", "address", "age", "born", "count", "createdBy", "email",
+		"extra", "labels", "manager", "name", "nickname", "role", "score", "tags"}
+	if got := schema.FieldNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("field names:\n got %v\nwant %v", got, want)
+	}
+	if got := schema.RequiredFieldNames(); !reflect.DeepEqual(got, []string{"email", "name"}) {
+		t.Errorf("unexpected required fields %v", got)
+	}
+
+	name, _ := schema.GetField("name")
+	if min, max := name.(*builders.StringSchema).LengthConstraints(); *min != 1 || *max != 100 {
+		t.Errorf("unexpected name length %d..%d", *min, *max)
+	}
+	email, _ := schema.GetField("email")
+	if email.(*builders.StringSchema).FormatType() != "email" {
+		t.Error("expected email format")
+	}
+	age, _ := schema.GetField("age")
+	if !age.(*builders.NumberSchema).IsInteger() {
+		t.Error("expected int field to be an integer")
+	}
+	born, _ := schema.GetField("born")
+	if _, ok := born.(*builders.DateTimeSchema); !ok {
+		t.Errorf("expected DateTimeSchema for time.Time, got %T", born)
+	}
+	nickname, _ := schema.GetField("nickname")
+	if !nickname.(*builders.StringSchema).IsNullable() {
+		t.Error("expected pointer field to be nullable")
+	}
+	manager, _ := schema.GetField("manager")
+	if ref, ok := manager.(*builders.RefSchema); !ok || ref.Target() != schema {
+		t.Errorf("expected recursive field to reference the root, got %T", manager)
+	}
+}
+
+func TestFromStruct_Validates(t *testing.T) {
+	schema := builders.MustFromStruct(&structUser{})
+
+	valid := map[string]interface{}{
+		"name":    "Ada",
+		"email":   "ada@example.com",
+		"role":    "admin",
+		"age":     36.0,
+		"tags":    []interface{}{"a"},
+		"address": map[string]interface{}{"street": "Main", "zip": "12345,6789"},
+		"manager": map[string]interface{}{"name": "Bob", "email": "bob@example.com", "manager": nil},
+		"born":    "1990-05-01",
+		"labels":  map[string]interface{}{"team": "core"},
+		"extra":   nil,
+	}
+	if err := queryfy.Validate(valid, schema); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	invalid := map[string]interface{}{
+		"name":    "",
+		"email":   "nope",
+		"role":    "root",
+		"age":     1.5,
+		"count":   -1.0,
+		"tags":    []interface{}{"a", "a"},
+		"address": map[string]interface{}{},
+		"manager": map[string]interface{}{"name": "Bob"},
+		"born":    "1800-01-01",
+	}
+	err := queryfy.Validate(invalid, schema)
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, path := range []string{"name", "email", "role", "age", "count", "tags", "address.street", "manager.email", "born"} {
+		if !strings.Contains(err.Error(), path+":") {
+			t.Errorf("expected an error at %s, got:\n%v", path, err)
+		}
+	}
+}
+
+func TestFromStruct_Cached(t *testing.T) {
+	a, _ := builders.FromStruct(structAddress{})
+	b, _ := builders.FromStruct(reflect.TypeOf(&structAddress{}))
+	if a != b {
+		t.Error("expected the same schema for the same type")
+	}
+}
+
+func TestFromStruct_Errors(t *testing.T) {
+	type badOption struct {
+		Name string `queryfy:"unique"`
+	}
+	type badValue struct {
+		Age int `queryfy:"min=abc"`
+	}
+	type badType struct {
+		Ch chan int
+	}
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{42, "expected a struct"},
+		{badOption{}, "Name"},
+		{badValue{}, "invalid number"},
+		{badType{}, "unsupported type"},
+	}
+	for _, tt := range tests {
+		_, err := builders.FromStruct(tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%T: expected error containing %q, got %v", tt.value, tt.want, err)
+		}
+	}
+}
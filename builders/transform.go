@@ -77,7 +77,7 @@ func (s *TransformSchema) Validate(value interface{}, ctx *queryfy.ValidationCon
 		original := transformed
 		result, err := transformer(transformed)
 		if err != nil {
-			ctx.AddError(fmt.Sprintf("transformation %d failed: %s", i+1, err.Error()), transformed)
+			ctx.AddCodedError(queryfy.CodeTransform, queryfy.Params{"step": i + 1, "error": err.Error()}, transformed)
 			return nil
 		}
 		// Record the transformation
@@ -97,7 +97,7 @@ func (s *TransformSchema) ValidateAndTransform(value interface{}, ctx *queryfy.V
 		original := transformed
 		result, err := transformer(transformed)
 		if err != nil {
-			ctx.AddError(fmt.Sprintf("transformation %d failed: %s", i+1, err.Error()), transformed)
+			ctx.AddCodedError(queryfy.CodeTransform, queryfy.Params{"step": i + 1, "error": err.Error()}, transformed)
 			return value, ctx.Error()
 		}
 		// Record the transformation
@@ -156,12 +156,12 @@ func (s *TransformSchema) ValidateAndTransformAsync(goCtx context.Context, value
 	for _, asyncValidator := range s.asyncValidators {
 		// Check context cancellation before each validator
 		if goCtx.Err() != nil {
-			ctx.AddError(fmt.Sprintf("validation cancelled: %s", goCtx.Err()), transformed)
+			ctx.AddCodedError(queryfy.CodeCancelled, queryfy.Params{"error": goCtx.Err().Error()}, transformed)
 			return transformed, ctx.Error()
 		}
 
 		if err := asyncValidator(goCtx, transformed); err != nil {
-			ctx.AddError(err.Error(), transformed)
+			ctx.AddValidatorError(err, transformed)
 		}
 	}
 
@@ -19,13 +19,18 @@ type FieldVisitor func(path string, schema queryfy.Schema) error
 //
 // The root schema itself is visited with an empty path "".
 //
+// A RefSchema is visited, followed by its target at the same path.
+// Recursive references are expanded once per branch.
+//
 // If the visitor returns a non-nil error, traversal stops and Walk
 // returns that error.
 func Walk(schema queryfy.Schema, visitor FieldVisitor) error {
-	return walkNode("", schema, visitor)
+	return walkNode("", schema, visitor, make(map[*refDefinition]bool))
 }
 
-func walkNode(path string, schema queryfy.Schema, visitor FieldVisitor) error {
+// walkNode visits a node and its children. active holds the references
+// currently being expanded so that recursive definitions terminate.
+func walkNode(path string, schema queryfy.Schema, visitor FieldVisitor, active map[*refDefinition]bool) error {
 	// Visit this node
 	if err := visitor(path, schema); err != nil {
 		return err
@@ -34,16 +39,61 @@ func walkNode(path string, schema queryfy.Schema, visitor FieldVisitor) error {
 	// Recurse into children based on type
 	switch s := schema.(type) {
 	case *ObjectSchema:
-		return walkObject(path, s, visitor)
+		return walkObject(path, s, visitor, active)
 
 	case *ObjectSchemaWithDependencies:
-		return walkObject(path, s.ObjectSchema, visitor)
+		if err := walkObject(path, s.ObjectSchema, visitor, active); err != nil {
+			return err
+		}
+		// A dependent rule for a field that also has a base schema is
+		// not reached through the fields, so visit it separately.
+		for _, name := range s.DependentFieldNames() {
+			dep, _ := s.GetDependentField(name)
+			if field, ok := s.GetField(name); ok && field == queryfy.Schema(dep) {
+				continue
+			}
+			childPath := appendPath(path, name) + "<dependent>"
+			if err := walkNode(childPath, dep, visitor, active); err != nil {
+				return err
+			}
+		}
+
+	case *DependentSchema:
+		// The If schema describes the parent object; Then and Else
+		// describe the field itself.
+		branches := []struct {
+			label  string
+			schema queryfy.Schema
+		}{{"if", s.IfSchema()}, {"then", s.ThenSchema()}, {"else", s.ElseSchema()}}
+		for _, br := range branches {
+			if br.schema == nil {
+				continue
+			}
+			childPath := fmt.Sprintf("%s<%s>", path, br.label)
+			if err := walkNode(childPath, br.schema, visitor, active); err != nil {
+				return err
+			}
+		}
+
+	case *RefSchema:
+		// A reference is visited, then its target at the same path.
+		// A reference that is already being expanded further up the
+		// tree is not expanded again.
+		target := s.Target()
+		if target != nil && !active[s.def] {
+			active[s.def] = true
+			err := walkNode(path, target, visitor, active)
+			delete(active, s.def)
+			if err != nil {
+				return err
+			}
+		}
 
 	case *ArraySchema:
 		elem := s.ElementSchema()
 		if elem != nil {
 			childPath := appendPath(path, "[*]")
-			if err := walkNode(childPath, elem, visitor); err != nil {
+			if err := walkNode(childPath, elem, visitor, active); err != nil {
 				return err
 			}
 		}
@@ -54,7 +104,7 @@ func walkNode(path string, schema queryfy.Schema, visitor FieldVisitor) error {
 		// the structural position.
 		inner := s.InnerSchema()
 		if inner != nil {
-			if err := walkNode(path, inner, visitor); err != nil {
+			if err := walkNode(path, inner, visitor, active); err != nil {
 				return err
 			}
 		}
@@ -63,7 +113,7 @@ func walkNode(path string, schema queryfy.Schema, visitor FieldVisitor) error {
 		// Composite: visit each sub-schema
 		for i, sub := range s.Schemas() {
 			childPath := fmt.Sprintf("%s<and[%d]>", path, i)
-			if err := walkNode(childPath, sub, visitor); err != nil {
+			if err := walkNode(childPath, sub, visitor, active); err != nil {
 				return err
 			}
 		}
@@ -71,7 +121,15 @@ func walkNode(path string, schema queryfy.Schema, visitor FieldVisitor) error {
 	case *OrSchema:
 		for i, sub := range s.Schemas() {
 			childPath := fmt.Sprintf("%s<or[%d]>", path, i)
-			if err := walkNode(childPath, sub, visitor); err != nil {
+			if err := walkNode(childPath, sub, visitor, active); err != nil {
+				return err
+			}
+		}
+
+	case *OneOfSchema:
+		for i, sub := range s.Schemas() {
+			childPath := fmt.Sprintf("%s<oneof[%d]>", path, i)
+			if err := walkNode(childPath, sub, visitor, active); err != nil {
 				return err
 			}
 		}
@@ -80,7 +138,7 @@ func walkNode(path string, schema queryfy.Schema, visitor FieldVisitor) error {
 		inner := s.InnerSchema()
 		if inner != nil {
 			childPath := fmt.Sprintf("%s<not>", path)
-			if err := walkNode(childPath, inner, visitor); err != nil {
+			if err := walkNode(childPath, inner, visitor, active); err != nil {
 				return err
 			}
 		}
@@ -89,11 +147,11 @@ func walkNode(path string, schema queryfy.Schema, visitor FieldVisitor) error {
 	return nil
 }
 
-func walkObject(path string, obj *ObjectSchema, visitor FieldVisitor) error {
+func walkObject(path string, obj *ObjectSchema, visitor FieldVisitor, active map[*refDefinition]bool) error {
 	for _, name := range obj.FieldNames() {
 		field, _ := obj.GetField(name)
 		childPath := appendPath(path, name)
-		if err := walkNode(childPath, field, visitor); err != nil {
+		if err := walkNode(childPath, field, visitor, active); err != nil {
 			return err
 		}
 	}
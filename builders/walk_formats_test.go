@@ -349,6 +349,33 @@ func TestWalk_CompositeAnd(t *testing.T) {
 	}
 }
 
+func TestWalk_Dependent(t *testing.T) {
+	schema := builders.Object().
+		Field("country", builders.String()).
+		Field("state", builders.String()).
+		WithDependencies().
+		DependentField("state", builders.Dependent("state").
+			When(builders.WhenEquals("country", "US")).
+			Then(builders.String().Required()).
+			Else(builders.String().MaxLength(0)))
+
+	var paths []string
+	builders.Walk(schema, func(path string, s queryfy.Schema) error {
+		paths = append(paths, path)
+		return nil
+	})
+
+	want := []string{"", "country", "state", "state<dependent>", "state<dependent><then>", "state<dependent><else>"}
+	if len(paths) != len(want) {
+		t.Fatalf("expected %v, got %v", want, paths)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("visit %d: expected %q, got %q", i, want[i], paths[i])
+		}
+	}
+}
+
 // ======================================================================
 // Composite introspection
 // ======================================================================
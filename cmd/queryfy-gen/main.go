@@ -0,0 +1,93 @@
+// Command queryfy-gen generates Go struct types and a schema builder
+// function from a JSON Schema document.
+//
+// Usage:
+//
+//	queryfy-gen [flags] [schema.json]
+//
+// The schema is read from the named file, or from standard input if no
+// file is given. Import warnings are printed to standard error; errors
+// stop generation.
+//
+// Flags:
+//
+//	-package name   package name of the generated file (default "schemas")
+//	-type name      name of the root struct type (default "Root")
+//	-func name      name of the schema function (default "New<type>Schema")
+//	-o file         write to file instead of standard output
+//
+// For example:
+//
+//	//go:generate queryfy-gen -package orders -type Order -o order_gen.go order.schema.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ha1tch/queryfy/builders/codegen"
+	"github.com/ha1tch/queryfy/builders/jsonschema"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "queryfy-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("queryfy-gen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	opts := &codegen.Options{}
+	fs.StringVar(&opts.Package, "package", "schemas", "package name of the generated file")
+	fs.StringVar(&opts.TypeName, "type", "Root", "name of the root struct type")
+	fs.StringVar(&opts.FuncName, "func", "", "name of the schema function (default \"New<type>Schema\")")
+	output := fs.String("o", "", "write to file instead of standard output")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: queryfy-gen [flags] [schema.json]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("expected at most one schema file, got %d", fs.NArg())
+	}
+
+	var data []byte
+	var err error
+	if fs.NArg() == 1 {
+		data, err = os.ReadFile(fs.Arg(0))
+	} else {
+		data, err = io.ReadAll(stdin)
+	}
+	if err != nil {
+		return err
+	}
+
+	schema, problems := jsonschema.FromJSON(data, nil)
+	failed := false
+	for _, p := range problems {
+		fmt.Fprintln(stderr, p.Error())
+		if !p.IsWarning {
+			failed = true
+		}
+	}
+	if failed || schema == nil {
+		return fmt.Errorf("could not import schema")
+	}
+
+	src, err := codegen.Generate(schema, opts)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*output, src, 0o644)
+}
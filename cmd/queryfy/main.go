@@ -0,0 +1,200 @@
+// Command queryfy validates and queries JSON documents from the shell.
+//
+// Usage:
+//
+//	queryfy validate -schema schema.json [-mode strict|loose] [-format text|json] [-lang en|es] [file...]
+//	queryfy query [-raw] [-ndjson] <query> [file...]
+//	queryfy diff [-format text|json] old.json new.json
+//	queryfy export [-schema-uri uri] [-id id] [-meta] schema.json
+//	queryfy hash schema.json...
+//
+// Schemas are JSON Schema documents, loaded with jsonschema.FromJSON.
+// Inputs are read from the named files, or from standard input when no
+// file (or "-") is given. Files ending in .ndjson or .jsonl are read as
+// one JSON document per line.
+//
+// The exit status is 0 on success, 1 when validation fails or diff finds
+// changes, and 2 for usage errors and unreadable files or schemas.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders/jsonschema"
+)
+
+// Exit statuses.
+const (
+	exitOK     = 0
+	exitFailed = 1
+	exitUsage  = 2
+)
+
+const usage = `usage: queryfy <command> [flags] [args]
+
+Commands:
+  validate  validate JSON or NDJSON files against a JSON Schema
+  query     evaluate a query path against JSON documents
+  diff      compare two JSON Schema files
+  export    normalise a JSON Schema file through queryfy
+  hash      print the structural hash of JSON Schema files
+
+Run "queryfy <command> -h" for the flags of a command.
+`
+
+// env is what a command reads from and writes to.
+type env struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+// commands maps each subcommand to its implementation. A command
+// returns the exit status.
+var commands = map[string]func(e *env, args []string) int{
+	"validate": runValidate,
+	"query":    runQuery,
+	"diff":     runDiff,
+	"export":   runExport,
+	"hash":     runHash,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}))
+}
+
+func run(args []string, e *env) int {
+	if len(args) == 0 {
+		fmt.Fprint(e.stderr, usage)
+		return exitUsage
+	}
+	switch args[0] {
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(e.stdout, usage)
+		return exitOK
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(e.stderr, "queryfy: unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+	return cmd(e, args[1:])
+}
+
+// fail reports an error that stops a command.
+func (e *env) fail(err error) int {
+	fmt.Fprintln(e.stderr, "queryfy:", err)
+	return exitUsage
+}
+
+// flagSet returns a flag set for a command, writing its help to stderr.
+func (e *env) flagSet(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: queryfy %s %s\n", name, synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses a command's flags. If the command should stop, it
+// returns false with the exit status to use.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	err := fs.Parse(args)
+	switch {
+	case err == nil:
+		return exitOK, true
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK, false
+	default:
+		return exitUsage, false
+	}
+}
+
+// loadSchema reads a JSON Schema file. Import warnings are written to
+// stderr; import errors fail the load.
+func (e *env) loadSchema(path string) (queryfy.Schema, error) {
+	data, err := e.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	schema, problems := jsonschema.FromJSON(data, nil)
+	var errs []string
+	for _, p := range problems {
+		if p.IsWarning {
+			fmt.Fprintf(e.stderr, "%s: %s\n", displayName(path), p.Error())
+			continue
+		}
+		errs = append(errs, p.Error())
+	}
+	if len(errs) > 0 || schema == nil {
+		return nil, fmt.Errorf("%s: invalid schema: %s", displayName(path), strings.Join(errs, "; "))
+	}
+	return schema, nil
+}
+
+func (e *env) readFile(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(e.stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// inputs returns the files named by args, or stdin if there are none.
+func inputs(args []string) []string {
+	if len(args) == 0 {
+		return []string{"-"}
+	}
+	return args
+}
+
+func displayName(path string) string {
+	if path == "-" {
+		return "<stdin>"
+	}
+	return path
+}
+
+func isNDJSON(path string) bool {
+	return strings.HasSuffix(path, ".ndjson") || strings.HasSuffix(path, ".jsonl")
+}
+
+// document is one JSON value read from an input. line is set for
+// NDJSON records and is 0 for whole-file documents.
+type document struct {
+	line  int
+	value interface{}
+	err   error
+}
+
+// readDocuments calls fn for each document in data: the whole of data,
+// or each non-blank line if ndjson is set.
+func readDocuments(data []byte, ndjson bool, fn func(document)) {
+	if !ndjson {
+		var v interface{}
+		err := json.Unmarshal(data, &v)
+		fn(document{value: v, err: err})
+		return
+	}
+	r := bufio.NewReader(bytes.NewReader(data))
+	for line := 1; ; line++ {
+		text, err := r.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(text); len(trimmed) > 0 {
+			var v interface{}
+			decodeErr := json.Unmarshal(trimmed, &v)
+			fn(document{line: line, value: v, err: decodeErr})
+		}
+		if err != nil {
+			return
+		}
+	}
+}
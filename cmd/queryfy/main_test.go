@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const userSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"age": {"type": "integer", "minimum": 0}
+	},
+	"required": ["name"]
+}`
+
+// runCLI runs the command with stdin and returns its exit status and
+// output.
+func runCLI(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	status := run(args, &env{stdin: strings.NewReader(stdin), stdout: &stdout, stderr: &stderr})
+	return status, stdout.String(), stderr.String()
+}
+
+// writeFiles writes name/content pairs to a temporary directory and
+// returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// ======================================================================
+// validate
+// ======================================================================
+
+func TestValidate(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schema.json": userSchema,
+		"good.json":   `{"name": "Ann", "age": 30}`,
+		"bad.json":    `{"age": -1}`,
+		"users.jsonl": "{\"name\": \"Ann\"}\n\n{\"name\": \"\"}\nnot json\n",
+	})
+	schema := filepath.Join(dir, "schema.json")
+
+	status, out, _ := runCLI(t, "", "validate", "-schema", schema, filepath.Join(dir, "good.json"))
+	if status != exitOK || out != "" {
+		t.Errorf("good.json: status %d, output %q", status, out)
+	}
+
+	status, out, _ = runCLI(t, "", "validate", "-schema", schema, filepath.Join(dir, "bad.json"))
+	if status != exitFailed {
+		t.Errorf("bad.json: expected status %d, got %d", exitFailed, status)
+	}
+	for _, want := range []string{"bad.json: name: ", "bad.json: age: "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("bad.json: output lacks %q:\n%s", want, out)
+		}
+	}
+
+	status, out, _ = runCLI(t, "", "validate", "-schema", schema, filepath.Join(dir, "users.jsonl"))
+	if status != exitFailed {
+		t.Errorf("users.jsonl: expected status %d, got %d", exitFailed, status)
+	}
+	if !strings.Contains(out, "users.jsonl:3: name: ") || !strings.Contains(out, "users.jsonl:4: invalid JSON") {
+		t.Errorf("users.jsonl: unexpected output:\n%s", out)
+	}
+	if strings.Contains(out, "users.jsonl:1") {
+		t.Errorf("users.jsonl: valid line reported:\n%s", out)
+	}
+}
+
+func TestValidate_JSONOutput(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"schema.json": userSchema})
+
+	status, out, _ := runCLI(t, "{\"name\": \"Ann\"}\n{\"age\": 1}\n",
+		"validate", "-schema", filepath.Join(dir, "schema.json"), "-ndjson", "-format", "json")
+	if status != exitFailed {
+		t.Errorf("expected status %d, got %d", exitFailed, status)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one result per record, got:\n%s", out)
+	}
+	var first, second result
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if !first.Valid || first.File != "<stdin>" || first.Line != 1 {
+		t.Errorf("unexpected first result %+v", first)
+	}
+	if second.Valid || second.Line != 2 || len(second.Errors) != 1 || second.Errors[0].Path != "name" {
+		t.Errorf("unexpected second result %+v", second)
+	}
+	if second.Errors[0].Code != "required" {
+		t.Errorf("code = %q, want required", second.Errors[0].Code)
+	}
+}
+
+func TestValidate_Lang(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"schema.json": userSchema})
+	schema := filepath.Join(dir, "schema.json")
+
+	status, out, _ := runCLI(t, `{"age": 1}`, "validate", "-schema", schema, "-lang", "es")
+	if status != exitFailed || !strings.Contains(out, "name: el campo es obligatorio") {
+		t.Errorf("status %d, output:\n%s", status, out)
+	}
+	if status, _, _ := runCLI(t, `{}`, "validate", "-schema", schema, "-lang", "xx"); status != exitUsage {
+		t.Errorf("unknown language: expected status %d, got %d", exitUsage, status)
+	}
+}
+
+func TestValidate_Mode(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"schema.json": userSchema})
+	schema := filepath.Join(dir, "schema.json")
+
+	if status, _, _ := runCLI(t, `{"name": "Ann", "age": "30"}`, "validate", "-schema", schema); status != exitFailed {
+		t.Errorf("strict: expected status %d, got %d", exitFailed, status)
+	}
+	if status, out, _ := runCLI(t, `{"name": "Ann", "age": "30"}`, "validate", "-schema", schema, "-mode", "loose"); status != exitOK {
+		t.Errorf("loose: expected status %d, got %d:\n%s", exitOK, status, out)
+	}
+	if status, out, _ := runCLI(t, `{"age": 1}`, "validate", "-schema", schema, "-q"); status != exitFailed || out != "" {
+		t.Errorf("quiet: status %d, output %q", status, out)
+	}
+}
+
+func TestValidate_UsageErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schema.json": userSchema,
+		"broken.json": `{"type": "object", "properties": {"a": {"type": "unknown"}}}`,
+	})
+
+	tests := [][]string{
+		{"validate"},
+		{"validate", "-schema", filepath.Join(dir, "missing.json")},
+		{"validate", "-schema", filepath.Join(dir, "broken.json")},
+		{"validate", "-schema", filepath.Join(dir, "schema.json"), "-mode", "lax"},
+		{"validate", "-schema", filepath.Join(dir, "schema.json"), filepath.Join(dir, "missing.json")},
+		{"frobnicate"},
+		{},
+	}
+	for _, args := range tests {
+		if status, _, _ := runCLI(t, "{}", args...); status != exitUsage {
+			t.Errorf("%v: expected status %d, got %d", args, exitUsage, status)
+		}
+	}
+}
+
+// ======================================================================
+// query
+// ======================================================================
+
+func TestQuery(t *testing.T) {
+	input := `{"items": [{"name": "a", "price": 1.5}, {"name": "b", "price": 2}]}`
+
+	status, out, _ := runCLI(t, input, "query", "items[*].price")
+	if status != exitOK || out != "[1.5,2]\n" {
+		t.Errorf("status %d, output %q", status, out)
+	}
+
+	status, out, _ = runCLI(t, input, "query", "-raw", "items[1].name")
+	if status != exitOK || out != "b\n" {
+		t.Errorf("raw: status %d, output %q", status, out)
+	}
+
+	status, _, errOut := runCLI(t, input, "query", "items[5].name")
+	if status != exitFailed || errOut == "" {
+		t.Errorf("missing path: status %d, stderr %q", status, errOut)
+	}
+
+	status, out, _ = runCLI(t, "{\"a\": 1}\n{\"a\": 2}\n", "query", "-ndjson", "a")
+	if status != exitOK || out != "1\n2\n" {
+		t.Errorf("ndjson: status %d, output %q", status, out)
+	}
+}
+
+// ======================================================================
+// diff, export and hash
+// ======================================================================
+
+func TestDiff(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"old.json": userSchema,
+		"new.json": `{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string", "minLength": 1},
+				"email": {"type": "string"}
+			},
+			"required": ["name"]
+		}`,
+	})
+	oldPath, newPath := filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")
+
+	status, out, _ := runCLI(t, "", "diff", oldPath, newPath)
+	if status != exitFailed {
+		t.Errorf("expected status %d, got %d", exitFailed, status)
+	}
+	if !strings.Contains(out, "+ email\n") || !strings.Contains(out, "- age\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	status, out, _ = runCLI(t, "", "diff", "-format", "json", oldPath, newPath)
+	var got struct {
+		Added, Removed []string
+	}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if status != exitFailed || len(got.Added) != 1 || len(got.Removed) != 1 {
+		t.Errorf("json: status %d, output %s", status, out)
+	}
+
+	if status, out, _ := runCLI(t, "", "diff", oldPath, oldPath); status != exitOK || out != "" {
+		t.Errorf("same schema: status %d, output %q", status, out)
+	}
+}
+
+func TestExportAndHash(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.json": userSchema,
+		"b.json": `{"required": ["name"], "properties": {"age": {"minimum": 0, "type": "integer"}, "name": {"minLength": 1, "type": "string"}}, "type": "object"}`,
+	})
+
+	status, out, _ := runCLI(t, "", "export", "-id", "urn:user", filepath.Join(dir, "a.json"))
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if status != exitOK || doc["$id"] != "urn:user" || doc["type"] != "object" {
+		t.Errorf("export: status %d, output %s", status, out)
+	}
+
+	status, out, _ = runCLI(t, "", "hash", filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json"))
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if status != exitOK || len(lines) != 2 {
+		t.Fatalf("hash: status %d, output %q", status, out)
+	}
+	hashA, _, _ := strings.Cut(lines[0], " ")
+	hashB, _, _ := strings.Cut(lines[1], " ")
+	if hashA == "" || hashA != hashB {
+		t.Errorf("equivalent schemas hash differently:\n%s", out)
+	}
+}
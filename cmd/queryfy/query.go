@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ha1tch/queryfy/query"
+)
+
+func runQuery(e *env, args []string) int {
+	fs := e.flagSet("query", "[flags] <query> [file...]")
+	raw := fs.Bool("raw", false, "print string results without JSON quotes")
+	ndjson := fs.Bool("ndjson", false, "read every input as NDJSON, whatever its extension")
+	if status, ok := parseFlags(fs, args); !ok {
+		return status
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	queryStr := fs.Arg(0)
+	if _, err := query.PathFromQuery(queryStr); err != nil && queryStr != "" {
+		return e.fail(fmt.Errorf("invalid query %q: %v", queryStr, err))
+	}
+
+	status := exitOK
+	for _, path := range inputs(fs.Args()[1:]) {
+		data, err := e.readFile(path)
+		if err != nil {
+			return e.fail(err)
+		}
+		readDocuments(data, *ndjson || isNDJSON(path), func(doc document) {
+			location := displayName(path)
+			if doc.line > 0 {
+				location = fmt.Sprintf("%s:%d", location, doc.line)
+			}
+			if doc.err != nil {
+				fmt.Fprintf(e.stderr, "%s: invalid JSON: %v\n", location, doc.err)
+				status = exitFailed
+				return
+			}
+			value, err := query.Execute(doc.value, queryStr)
+			if err != nil {
+				fmt.Fprintf(e.stderr, "%s: %v\n", location, err)
+				status = exitFailed
+				return
+			}
+			if s, ok := value.(string); ok && *raw {
+				fmt.Fprintln(e.stdout, s)
+				return
+			}
+			out, err := json.Marshal(value)
+			if err != nil {
+				fmt.Fprintf(e.stderr, "%s: %v\n", location, err)
+				status = exitFailed
+				return
+			}
+			fmt.Fprintln(e.stdout, string(out))
+		})
+	}
+	return status
+}
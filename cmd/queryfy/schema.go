@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ha1tch/queryfy/builders"
+	"github.com/ha1tch/queryfy/builders/jsonschema"
+)
+
+func runDiff(e *env, args []string) int {
+	fs := e.flagSet("diff", "[flags] old.json new.json")
+	format := fs.String("format", "text", "output format: text or json")
+	if status, ok := parseFlags(fs, args); !ok {
+		return status
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitUsage
+	}
+	if *format != "text" && *format != "json" {
+		return e.fail(fmt.Errorf("unknown format %q, want text or json", *format))
+	}
+
+	oldSchema, err := e.loadSchema(fs.Arg(0))
+	if err != nil {
+		return e.fail(err)
+	}
+	newSchema, err := e.loadSchema(fs.Arg(1))
+	if err != nil {
+		return e.fail(err)
+	}
+	diff, err := builders.Diff(oldSchema, newSchema)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	if *format == "json" {
+		type change struct {
+			Path    string `json:"path"`
+			OldType string `json:"oldType"`
+			NewType string `json:"newType"`
+			Details string `json:"details"`
+		}
+		out := struct {
+			Added   []string `json:"added"`
+			Removed []string `json:"removed"`
+			Changed []change `json:"changed"`
+		}{Added: diff.Added, Removed: diff.Removed, Changed: []change{}}
+		if out.Added == nil {
+			out.Added = []string{}
+		}
+		if out.Removed == nil {
+			out.Removed = []string{}
+		}
+		for _, c := range diff.Changed {
+			out.Changed = append(out.Changed, change{
+				Path: c.Path, OldType: string(c.OldType), NewType: string(c.NewType), Details: c.Details,
+			})
+		}
+		data, _ := json.MarshalIndent(out, "", "  ")
+		fmt.Fprintln(e.stdout, string(data))
+	} else {
+		for _, p := range diff.Added {
+			fmt.Fprintf(e.stdout, "+ %s\n", p)
+		}
+		for _, p := range diff.Removed {
+			fmt.Fprintf(e.stdout, "- %s\n", p)
+		}
+		for _, c := range diff.Changed {
+			path := c.Path
+			if path == "" {
+				path = "(root)"
+			}
+			fmt.Fprintf(e.stdout, "~ %s: %s\n", path, c.Details)
+		}
+	}
+
+	if diff.HasChanges() {
+		return exitFailed
+	}
+	return exitOK
+}
+
+func runExport(e *env, args []string) int {
+	fs := e.flagSet("export", "[flags] schema.json")
+	opts := &jsonschema.ExportOptions{}
+	fs.StringVar(&opts.SchemaURI, "schema-uri", "", "value of the $schema keyword")
+	fs.StringVar(&opts.ID, "id", "", "value of the $id keyword")
+	fs.BoolVar(&opts.IncludeMeta, "meta", false, "include metadata as x- extension keywords")
+	if status, ok := parseFlags(fs, args); !ok {
+		return status
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	schema, err := e.loadSchema(inputs(fs.Args())[0])
+	if err != nil {
+		return e.fail(err)
+	}
+	data, err := jsonschema.ToJSON(schema, opts)
+	if err != nil {
+		return e.fail(err)
+	}
+	fmt.Fprintln(e.stdout, string(data))
+	return exitOK
+}
+
+func runHash(e *env, args []string) int {
+	fs := e.flagSet("hash", "schema.json...")
+	if status, ok := parseFlags(fs, args); !ok {
+		return status
+	}
+	for _, path := range inputs(fs.Args()) {
+		schema, err := e.loadSchema(path)
+		if err != nil {
+			return e.fail(err)
+		}
+		fmt.Fprintf(e.stdout, "%s  %s\n", builders.Hash(schema), displayName(path))
+	}
+	return exitOK
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ha1tch/queryfy"
+)
+
+// result is the outcome of validating one document. It is written as
+// one JSON object per line with -format json.
+type result struct {
+	File   string        `json:"file"`
+	Line   int           `json:"line,omitempty"`
+	Valid  bool          `json:"valid"`
+	Errors []resultError `json:"errors,omitempty"`
+}
+
+type resultError struct {
+	Path    string         `json:"path"`
+	Message string         `json:"message"`
+	Code    string         `json:"code,omitempty"`
+	Params  queryfy.Params `json:"params,omitempty"`
+	Value   interface{}    `json:"value,omitempty"`
+}
+
+// catalogs are the message languages accepted by -lang.
+var catalogs = map[string]queryfy.MessageCatalog{
+	"en": queryfy.EnglishMessages,
+	"es": queryfy.SpanishMessages,
+}
+
+func runValidate(e *env, args []string) int {
+	fs := e.flagSet("validate", "-schema schema.json [flags] [file...]")
+	schemaPath := fs.String("schema", "", "JSON Schema file (required)")
+	modeName := fs.String("mode", "strict", "validation mode: strict or loose")
+	format := fs.String("format", "text", "output format: text or json")
+	ndjson := fs.Bool("ndjson", false, "read every input as NDJSON, whatever its extension")
+	quiet := fs.Bool("q", false, "print nothing; report through the exit status only")
+	lang := fs.String("lang", "en", "language of error messages: en or es")
+	if status, ok := parseFlags(fs, args); !ok {
+		return status
+	}
+
+	if *schemaPath == "" {
+		fs.Usage()
+		return exitUsage
+	}
+	var mode queryfy.ValidationMode
+	switch *modeName {
+	case "strict":
+		mode = queryfy.Strict
+	case "loose":
+		mode = queryfy.Loose
+	default:
+		return e.fail(fmt.Errorf("unknown mode %q, want strict or loose", *modeName))
+	}
+	catalog, ok := catalogs[*lang]
+	if !ok {
+		return e.fail(fmt.Errorf("unknown language %q, want en or es", *lang))
+	}
+	if *format != "text" && *format != "json" {
+		return e.fail(fmt.Errorf("unknown format %q, want text or json", *format))
+	}
+
+	schema, err := e.loadSchema(*schemaPath)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	status := exitOK
+	enc := json.NewEncoder(e.stdout)
+	for _, path := range inputs(fs.Args()) {
+		data, err := e.readFile(path)
+		if err != nil {
+			return e.fail(err)
+		}
+		readDocuments(data, *ndjson || isNDJSON(path), func(doc document) {
+			r := validateDocument(displayName(path), doc, schema, mode, catalog)
+			if !r.Valid {
+				status = exitFailed
+			}
+			switch {
+			case *quiet:
+			case *format == "json":
+				enc.Encode(r)
+			default:
+				writeResultText(e, r)
+			}
+		})
+	}
+	return status
+}
+
+func validateDocument(name string, doc document, schema queryfy.Schema, mode queryfy.ValidationMode, catalog queryfy.MessageCatalog) result {
+	r := result{File: name, Line: doc.line, Valid: true}
+	if doc.err != nil {
+		r.Valid = false
+		r.Errors = []resultError{{Message: "invalid JSON: " + doc.err.Error()}}
+		return r
+	}
+
+	err := queryfy.ValidateWithMode(doc.value, schema, mode)
+	if err == nil {
+		return r
+	}
+	r.Valid = false
+	var verr *queryfy.ValidationError
+	if !errors.As(err, &verr) {
+		r.Errors = []resultError{{Message: err.Error()}}
+		return r
+	}
+	for _, fe := range verr.Localize(catalog).Errors {
+		r.Errors = append(r.Errors, resultError{
+			Path:    fe.Path,
+			Message: fe.Message,
+			Code:    fe.Code,
+			Params:  fe.Params,
+			Value:   fe.Value,
+		})
+	}
+	return r
+}
+
+// writeResultText writes one line per error, in the file:line: form
+// editors and CI logs recognise.
+func writeResultText(e *env, r result) {
+	location := r.File
+	if r.Line > 0 {
+		location = fmt.Sprintf("%s:%d", r.File, r.Line)
+	}
+	for _, fe := range r.Errors {
+		if fe.Path == "" {
+			fmt.Fprintf(e.stdout, "%s: %s\n", location, fe.Message)
+		} else {
+			fmt.Fprintf(e.stdout, "%s: %s: %s\n", location, fe.Path, fe.Message)
+		}
+	}
+}
@@ -1,9 +1,9 @@
 package queryfy
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
-	"strings"
 )
 
 // checkFunc is a single validation check. It adds errors to ctx if the
@@ -22,6 +22,66 @@ type CompiledSchema struct {
 	inner      Schema
 	checks     []checkFunc
 	schemaType SchemaType
+
+	// Structure kept for DecodeJSON, which validates while it builds
+	// the value instead of walking it afterwards
+	integer bool            // a number schema with Integer()
+	object  *compiledObject // an object schema's fields
+	array   *compiledArray  // an array schema's element and item counts
+}
+
+// compiledField is an object field with its compiled schema.
+type compiledField struct {
+	name     string
+	schema   Schema
+	required bool
+}
+
+// compiledObject is the pre-computed structure of an object schema.
+type compiledObject struct {
+	fields   []compiledField
+	fieldSet map[string]int // field name to index in fields
+	allow    bool
+	explicit bool
+}
+
+// rejectsExtra reports whether fields not in the schema are errors.
+func (o *compiledObject) rejectsExtra(ctx *ValidationContext) bool {
+	if o.explicit {
+		return !o.allow
+	}
+	return ctx.Mode() == Strict
+}
+
+// compiledArray is the pre-computed structure of an array schema.
+type compiledArray struct {
+	elem     Schema // compiled, or nil
+	minItems *int
+	maxItems *int
+	unique   bool
+}
+
+// checkItems checks the item count and uniqueness of an array.
+func (a *compiledArray) checkItems(arr []interface{}, value interface{}, ctx *ValidationContext) {
+	length := len(arr)
+	if a.minItems != nil && length < *a.minItems {
+		ctx.AddCodedError(CodeMinItems, Params{"min": *a.minItems, "actual": length}, value)
+	}
+	if a.maxItems != nil && length > *a.maxItems {
+		ctx.AddCodedError(CodeMaxItems, Params{"max": *a.maxItems, "actual": length}, value)
+	}
+
+	if a.unique && length > 1 {
+		seen := make(map[string]bool, length)
+		for _, item := range arr {
+			key := fmt.Sprintf("%v", item)
+			if seen[key] {
+				ctx.AddCodedError(CodeUniqueItems, nil, value)
+				break
+			}
+			seen[key] = true
+		}
+	}
 }
 
 // compileInfo is extracted from concrete schema types via interface
@@ -46,12 +106,22 @@ type objectIntrospection interface {
 	AllowsAdditional() (bool, bool)
 }
 
+// decimalIntrospection is optional, so that numberIntrospection stays
+// satisfied by number schemas without it.
+type decimalIntrospection interface {
+	DecimalConstraint() (precision, scale int, ok bool)
+}
+
 type arrayIntrospection interface {
 	ElementSchema() Schema
 	ItemCountConstraints() (min, max *int)
 	IsUniqueItems() bool
 }
 
+type boolIntrospection interface {
+	ConstValue() *bool
+}
+
 type patternMatcher interface {
 	PatternMatch(string) bool
 }
@@ -83,6 +153,7 @@ func Compile(schema Schema) Schema {
 		cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
 			schema.Validate(value, ctx)
 		})
+		cs.compileStructure(schema)
 		return cs
 	}
 
@@ -157,11 +228,11 @@ func (cs *CompiledSchema) compileString(schema Schema) {
 	cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
 		if ctx.Mode() == Loose {
 			if _, ok := ConvertToString(value); !ok {
-				ctx.AddError(fmt.Sprintf("cannot convert %T to string", value), value)
+				ctx.AddCodedError(CodeConversion, Params{"type": fmt.Sprintf("%T", value), "target": "string"}, value)
 			}
 		} else {
 			if _, ok := value.(string); !ok {
-				ctx.AddError(fmt.Sprintf("expected string, got %T", value), value)
+				ctx.AddCodedError(CodeType, Params{"expected": "string", "actual": fmt.Sprintf("%T", value)}, value)
 			}
 		}
 	})
@@ -178,7 +249,7 @@ func (cs *CompiledSchema) compileString(schema Schema) {
 			str := toString(value, ctx)
 			if str != "" || value != nil {
 				if len(str) < min {
-					ctx.AddError(fmt.Sprintf("length must be at least %d, got %d", min, len(str)), str)
+					ctx.AddCodedError(CodeMinLength, Params{"min": min, "actual": len(str)}, str)
 				}
 			}
 		})
@@ -188,7 +259,7 @@ func (cs *CompiledSchema) compileString(schema Schema) {
 		cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
 			str := toString(value, ctx)
 			if len(str) > max {
-				ctx.AddError(fmt.Sprintf("length must be at most %d, got %d", max, len(str)), str)
+				ctx.AddCodedError(CodeMaxLength, Params{"max": max, "actual": len(str)}, str)
 			}
 		})
 	}
@@ -201,18 +272,16 @@ func (cs *CompiledSchema) compileString(schema Schema) {
 			cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
 				str := toString(value, ctx)
 				if !pm.PatternMatch(str) {
-					var msg string
 					switch formatType {
 					case "email":
-						msg = "must be a valid email address"
+						ctx.AddCodedError(CodeEmail, nil, str)
 					case "url":
-						msg = "must be a valid URL"
+						ctx.AddCodedError(CodeURL, nil, str)
 					case "uuid":
-						msg = "must be a valid UUID"
+						ctx.AddCodedError(CodeUUID, nil, str)
 					default:
-						msg = fmt.Sprintf("must match pattern %s", patStr)
+						ctx.AddCodedError(CodePattern, Params{"pattern": patStr}, str)
 					}
-					ctx.AddError(msg, str)
 				}
 			})
 		}
@@ -224,11 +293,10 @@ func (cs *CompiledSchema) compileString(schema Schema) {
 		for _, v := range vals {
 			set[v] = true
 		}
-		enumStr := strings.Join(vals, ", ")
 		cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
 			str := toString(value, ctx)
 			if !set[str] {
-				ctx.AddError(fmt.Sprintf("must be one of: %s", enumStr), str)
+				ctx.AddCodedError(CodeEnum, Params{"values": vals}, str)
 			}
 		})
 	}
@@ -240,7 +308,7 @@ func (cs *CompiledSchema) compileString(schema Schema) {
 			cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
 				str := toString(value, ctx)
 				if err := validator(str); err != nil {
-					ctx.AddError(err.Error(), str)
+					ctx.AddValidatorError(err, str)
 				}
 			})
 		}
@@ -263,10 +331,10 @@ func (cs *CompiledSchema) compileNumber(schema Schema) {
 	}
 
 	if ni.IsInteger() {
+		cs.integer = true
 		cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
-			f := toFloat(value)
-			if f != float64(int64(f)) {
-				ctx.AddError("must be an integer", value)
+			if !IsWholeNumber(toFloat(value), ExactRat(value)) {
+				ctx.AddCodedError(CodeInteger, nil, value)
 			}
 		})
 	}
@@ -275,16 +343,16 @@ func (cs *CompiledSchema) compileNumber(schema Schema) {
 	if min != nil {
 		minVal := *min
 		cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
-			if toFloat(value) < minVal {
-				ctx.AddError(fmt.Sprintf("must be at least %v", minVal), value)
+			if CompareNumber(toFloat(value), ExactRat(value), minVal) < 0 {
+				ctx.AddCodedError(CodeMin, Params{"min": minVal}, value)
 			}
 		})
 	}
 	if max != nil {
 		maxVal := *max
 		cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
-			if toFloat(value) > maxVal {
-				ctx.AddError(fmt.Sprintf("must be at most %v", maxVal), value)
+			if CompareNumber(toFloat(value), ExactRat(value), maxVal) > 0 {
+				ctx.AddCodedError(CodeMax, Params{"max": maxVal}, value)
 			}
 		})
 	}
@@ -292,20 +360,28 @@ func (cs *CompiledSchema) compileNumber(schema Schema) {
 	if mul := ni.MultipleOfValue(); mul != nil {
 		mulVal := *mul
 		cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
-			f := toFloat(value)
-			rem := f / mulVal
-			if rem != float64(int64(rem)) {
-				ctx.AddError(fmt.Sprintf("must be a multiple of %v", mulVal), value)
+			if !IsMultipleOf(toFloat(value), ExactRat(value), mulVal) {
+				ctx.AddCodedError(CodeMultipleOf, Params{"multiple_of": mulVal}, value)
 			}
 		})
 	}
 
+	if di, ok := schema.(decimalIntrospection); ok {
+		if precision, scale, set := di.DecimalConstraint(); set {
+			cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
+				if code, params := CheckDecimal(toFloat(value), ExactRat(value), precision, scale); code != "" {
+					ctx.AddCodedError(code, params, value)
+				}
+			})
+		}
+	}
+
 	if vp, ok := schema.(validatorProvider); ok {
 		for _, v := range vp.Validators() {
 			validator := v
 			cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
 				if err := validator(value); err != nil {
-					ctx.AddError(err.Error(), value)
+					ctx.AddValidatorError(err, value)
 				}
 			})
 		}
@@ -321,12 +397,27 @@ func (cs *CompiledSchema) compileBool(schema Schema) {
 		}
 	})
 
+	if bi, ok := schema.(boolIntrospection); ok {
+		if cv := bi.ConstValue(); cv != nil {
+			want := *cv
+			cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
+				b, ok := value.(bool)
+				if !ok {
+					b = value == "true"
+				}
+				if b != want {
+					ctx.AddCodedError(CodeConst, Params{"value": want}, value)
+				}
+			})
+		}
+	}
+
 	if vp, ok := schema.(validatorProvider); ok {
 		for _, v := range vp.Validators() {
 			validator := v
 			cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
 				if err := validator(value); err != nil {
-					ctx.AddError(err.Error(), value)
+					ctx.AddValidatorError(err, value)
 				}
 			})
 		}
@@ -352,70 +443,38 @@ func (cs *CompiledSchema) compileObject(schema Schema) {
 		return
 	}
 
-	// Pre-compute: required field set, compiled field schemas
-	fieldNames := oi.FieldNames()
-	type compiledField struct {
-		name     string
-		schema   Schema
-		required bool
-	}
-
-	reqSet := make(map[string]bool)
-	for _, name := range oi.RequiredFieldNames() {
-		reqSet[name] = true
-	}
-
-	fields := make([]compiledField, 0, len(fieldNames))
-	fieldSet := make(map[string]bool, len(fieldNames))
-	for _, name := range fieldNames {
-		fieldSchema, _ := oi.GetField(name)
-		required := reqSet[name] || isSchemaRequired(fieldSchema)
-		fields = append(fields, compiledField{
-			name:     name,
-			schema:   Compile(fieldSchema), // recursively compile
-			required: required,
-		})
-		fieldSet[name] = true
-	}
-
-	allow, explicit := oi.AllowsAdditional()
+	obj := newCompiledObject(oi)
+	cs.object = obj
 
 	// Single check for the whole object — this replaces the entire
 	// ObjectSchema.Validate method with pre-resolved field iteration
 	cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
 		objMap, ok := toMap(value)
 		if !ok {
-			ctx.AddError(fmt.Sprintf("cannot convert %T to map", value), value)
+			ctx.AddCodedError(CodeConversion, Params{"type": fmt.Sprintf("%T", value), "target": "map"}, value)
 			return
 		}
 
 		// Validate each pre-compiled field
-		for i := range fields {
-			f := &fields[i]
+		for i := range obj.fields {
+			f := &obj.fields[i]
 			fieldValue, exists := objMap[f.name]
 
 			ctx.WithPath(f.name, func() {
 				if exists {
 					f.schema.Validate(fieldValue, ctx)
 				} else if f.required {
-					ctx.AddError("field is required", nil)
+					ctx.AddCodedError(CodeRequired, nil, nil)
 				}
 			})
 		}
 
 		// Extra field check
-		rejectExtra := false
-		if explicit {
-			rejectExtra = !allow
-		} else {
-			rejectExtra = ctx.Mode() == Strict
-		}
-
-		if rejectExtra {
+		if obj.rejectsExtra(ctx) {
 			for key := range objMap {
-				if !fieldSet[key] {
+				if _, defined := obj.fieldSet[key]; !defined {
 					ctx.WithPath(key, func() {
-						ctx.AddError("unexpected field", objMap[key])
+						ctx.AddCodedError(CodeUnexpectedField, nil, objMap[key])
 					})
 				}
 			}
@@ -428,7 +487,7 @@ func (cs *CompiledSchema) compileObject(schema Schema) {
 			validator := v
 			cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
 				if err := validator(value); err != nil {
-					ctx.AddError(err.Error(), value)
+					ctx.AddValidatorError(err, value)
 				}
 			})
 		}
@@ -452,15 +511,8 @@ func (cs *CompiledSchema) compileArray(schema Schema) {
 		return
 	}
 
-	minItems, maxItems := ai.ItemCountConstraints()
-	elemSchema := ai.ElementSchema()
-	unique := ai.IsUniqueItems()
-
-	// Compile element schema if present
-	var compiledElem Schema
-	if elemSchema != nil {
-		compiledElem = Compile(elemSchema)
-	}
+	arrSchema := newCompiledArray(ai)
+	cs.array = arrSchema
 
 	// Single check for the whole array
 	cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
@@ -469,30 +521,12 @@ func (cs *CompiledSchema) compileArray(schema Schema) {
 			return // type check already reported error
 		}
 
-		length := len(arr)
-		if minItems != nil && length < *minItems {
-			ctx.AddError(fmt.Sprintf("must have at least %d items, got %d", *minItems, length), value)
-		}
-		if maxItems != nil && length > *maxItems {
-			ctx.AddError(fmt.Sprintf("must have at most %d items, got %d", *maxItems, length), value)
-		}
-
-		if unique && length > 1 {
-			seen := make(map[string]bool, length)
-			for _, item := range arr {
-				key := fmt.Sprintf("%v", item)
-				if seen[key] {
-					ctx.AddError("items must be unique", value)
-					break
-				}
-				seen[key] = true
-			}
-		}
+		arrSchema.checkItems(arr, value, ctx)
 
-		if compiledElem != nil {
+		if arrSchema.elem != nil {
 			for i, item := range arr {
 				ctx.WithPath(fmt.Sprintf("[%d]", i), func() {
-					compiledElem.Validate(item, ctx)
+					arrSchema.elem.Validate(item, ctx)
 				})
 			}
 		}
@@ -503,13 +537,73 @@ func (cs *CompiledSchema) compileArray(schema Schema) {
 			validator := v
 			cs.checks = append(cs.checks, func(value interface{}, ctx *ValidationContext) {
 				if err := validator(value); err != nil {
-					ctx.AddError(err.Error(), value)
+					ctx.AddValidatorError(err, value)
 				}
 			})
 		}
 	}
 }
 
+// ---- structure ----
+
+// compileStructure records the fields or element schema of an object or
+// array schema whose validation is delegated, for DecodeJSON. Objects
+// with dependent fields are left out, since those are checked against
+// the whole object.
+func (cs *CompiledSchema) compileStructure(schema Schema) {
+	if _, dependent := schema.(interface{ DependentFieldNames() []string }); dependent {
+		return
+	}
+	switch schema.Type() {
+	case TypeObject:
+		if oi, ok := schema.(objectIntrospection); ok {
+			cs.object = newCompiledObject(oi)
+		}
+	case TypeArray:
+		if ai, ok := schema.(arrayIntrospection); ok {
+			cs.array = newCompiledArray(ai)
+		}
+	}
+}
+
+// newCompiledObject pre-computes the required field set and compiled
+// field schemas of an object schema.
+func newCompiledObject(oi objectIntrospection) *compiledObject {
+	fieldNames := oi.FieldNames()
+	reqSet := make(map[string]bool)
+	for _, name := range oi.RequiredFieldNames() {
+		reqSet[name] = true
+	}
+
+	obj := &compiledObject{
+		fields:   make([]compiledField, 0, len(fieldNames)),
+		fieldSet: make(map[string]int, len(fieldNames)),
+	}
+	for _, name := range fieldNames {
+		fieldSchema, _ := oi.GetField(name)
+		required := reqSet[name] || isSchemaRequired(fieldSchema)
+		obj.fieldSet[name] = len(obj.fields)
+		obj.fields = append(obj.fields, compiledField{
+			name:     name,
+			schema:   Compile(fieldSchema), // recursively compile
+			required: required,
+		})
+	}
+	obj.allow, obj.explicit = oi.AllowsAdditional()
+	return obj
+}
+
+// newCompiledArray pre-computes the item constraints and compiled
+// element schema of an array schema.
+func newCompiledArray(ai arrayIntrospection) *compiledArray {
+	arr := &compiledArray{unique: ai.IsUniqueItems()}
+	arr.minItems, arr.maxItems = ai.ItemCountConstraints()
+	if elemSchema := ai.ElementSchema(); elemSchema != nil {
+		arr.elem = Compile(elemSchema)
+	}
+	return arr
+}
+
 // ---- helpers ----
 
 // extractBase copies BaseSchema fields from the original.
@@ -560,7 +654,16 @@ func toFloat(value interface{}) float64 {
 		return float64(v)
 	case float32:
 		return float64(v)
+	case json.Number:
+		// Out of range numbers are ±Inf or 0
+		f, _ := v.Float64()
+		return f
 	default:
+		// Other integer types and big numbers
+		if r, ok := ConvertToRat(value); ok {
+			f, _ := r.Float64()
+			return f
+		}
 		return 0
 	}
 }
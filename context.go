@@ -1,23 +1,48 @@
 package queryfy
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/ha1tch/queryfy/query"
+)
+
+// PathFormat selects how a ValidationContext writes the paths of
+// errors and transformations.
+type PathFormat int
+
+const (
+	// QueryPaths writes paths in query notation: items[0].price.
+	QueryPaths PathFormat = iota
+	// JSONPointerPaths writes paths as JSON Pointers (RFC 6901):
+	// /items/0/price. The root is the empty string.
+	JSONPointerPaths
 )
 
 // ValidationContext maintains state during validation.
 // It tracks the current path and accumulates errors.
 type ValidationContext struct {
-	path            []string
+	path            []pathSegment
 	errors          []FieldError
 	mode            ValidationMode
+	pathFormat      PathFormat
 	transformations []TransformationRecord
+	offset          int // input offset of the value being validated, for ValidateJSON
+	messages        MessageCatalog
+}
+
+// pathSegment is a field name, or an array index when isIndex is set.
+type pathSegment struct {
+	name    string
+	index   int
+	isIndex bool
 }
 
 // NewValidationContext creates a new validation context.
 func NewValidationContext(mode ValidationMode) *ValidationContext {
 	return &ValidationContext{
-		path:            make([]string, 0, 8), // Pre-allocate for typical nesting depth
+		path:            make([]pathSegment, 0, 8), // Pre-allocate for typical nesting depth
 		errors:          make([]FieldError, 0),
 		mode:            mode,
 		transformations: make([]TransformationRecord, 0),
@@ -29,23 +54,75 @@ func (c *ValidationContext) Mode() ValidationMode {
 	return c.mode
 }
 
+// SetPathFormat sets how paths are written in the errors and
+// transformations recorded from now on. The default is QueryPaths.
+//
+//	ctx := queryfy.NewValidationContext(queryfy.Strict)
+//	ctx.SetPathFormat(queryfy.JSONPointerPaths)
+//	schema.Validate(data, ctx) // errors at "/items/0/price"
+func (c *ValidationContext) SetPathFormat(format PathFormat) {
+	c.pathFormat = format
+}
+
+// PathFormat returns the format paths are written in.
+func (c *ValidationContext) PathFormat() PathFormat {
+	return c.pathFormat
+}
+
+// SetMessages sets the catalog error messages are taken from, such as
+// SpanishMessages. Codes the catalog does not know, and a nil catalog,
+// use EnglishMessages.
+//
+//	ctx := queryfy.NewValidationContext(queryfy.Strict)
+//	ctx.SetMessages(queryfy.SpanishMessages)
+//	schema.Validate(data, ctx) // "email: el campo es obligatorio"
+func (c *ValidationContext) SetMessages(catalog MessageCatalog) {
+	c.messages = catalog
+}
+
+// Messages returns the context's message catalog, or nil for the
+// default.
+func (c *ValidationContext) Messages() MessageCatalog {
+	return c.messages
+}
+
+// Message returns the message for an error code from the context's
+// catalog, falling back to EnglishMessages and then to the code itself.
+func (c *ValidationContext) Message(code string, params Params) string {
+	if msg, ok := c.lookupMessage(code, params); ok {
+		return msg
+	}
+	return code
+}
+
+func (c *ValidationContext) lookupMessage(code string, params Params) (string, bool) {
+	if c.messages != nil {
+		if msg, ok := c.messages.Message(code, params); ok {
+			return msg, true
+		}
+	}
+	return EnglishMessages.Message(code, params)
+}
+
 // Reset clears accumulated errors, path state, and transformation records,
 // allowing the context to be reused across multiple validations without
-// reallocating. The validation mode is preserved.
+// reallocating. The validation mode, path format and message catalog
+// are preserved.
 func (c *ValidationContext) Reset() {
 	c.path = c.path[:0]
 	c.errors = c.errors[:0]
 	c.transformations = c.transformations[:0]
+	c.offset = 0
 }
 
 // PushPath adds a path segment to the current path.
 func (c *ValidationContext) PushPath(segment string) {
-	c.path = append(c.path, segment)
+	c.path = append(c.path, pathSegment{name: segment})
 }
 
 // PushIndex adds an array index to the current path.
 func (c *ValidationContext) PushIndex(index int) {
-	c.path = append(c.path, fmt.Sprintf("[%d]", index))
+	c.path = append(c.path, pathSegment{index: index, isIndex: true})
 }
 
 // PopPath removes the last path segment.
@@ -55,36 +132,101 @@ func (c *ValidationContext) PopPath() {
 	}
 }
 
-// CurrentPath returns the current field path as a string.
+// CurrentPath returns the current field path as a string, in the
+// context's PathFormat.
 func (c *ValidationContext) CurrentPath() string {
 	if len(c.path) == 0 {
 		return ""
 	}
 
+	if c.pathFormat == JSONPointerPaths {
+		path := make([]interface{}, len(c.path))
+		for i, segment := range c.path {
+			if segment.isIndex {
+				path[i] = segment.index
+			} else {
+				path[i] = segment.name
+			}
+		}
+		pointer, _ := query.PointerFromPath(path)
+		return pointer
+	}
+
 	var result strings.Builder
 	for i, segment := range c.path {
-		if i > 0 && !strings.HasPrefix(segment, "[") {
+		if segment.isIndex {
+			fmt.Fprintf(&result, "[%d]", segment.index)
+			continue
+		}
+		if i > 0 && !strings.HasPrefix(segment.name, "[") {
 			result.WriteString(".")
 		}
-		result.WriteString(segment)
+		result.WriteString(segment.name)
 	}
 	return result.String()
 }
 
-// AddError adds an error at the current path.
+// AddError adds an error with a plain message at the current path. Its
+// code is CodeCustom.
 func (c *ValidationContext) AddError(message string, value interface{}) {
 	c.errors = append(c.errors, FieldError{
 		Path:    c.CurrentPath(),
 		Message: message,
+		Code:    CodeCustom,
+		Value:   value,
+		Offset:  c.offset,
+	})
+}
+
+// AddCodedError adds an error at the current path, with its message
+// taken from the context's catalog.
+func (c *ValidationContext) AddCodedError(code string, params Params, value interface{}) {
+	c.errors = append(c.errors, FieldError{
+		Path:    c.CurrentPath(),
+		Message: c.Message(code, params),
+		Code:    code,
+		Params:  params,
 		Value:   value,
+		Offset:  c.offset,
 	})
 }
 
-// AddFieldError adds a pre-constructed field error.
+// AddValidatorError adds the error returned by a custom validator. A
+// *CodedError keeps its code and parameters; other errors are added
+// with AddError.
+func (c *ValidationContext) AddValidatorError(err error, value interface{}) {
+	var coded *CodedError
+	if !errors.As(err, &coded) {
+		c.AddError(err.Error(), value)
+		return
+	}
+	msg, ok := c.lookupMessage(coded.Code, coded.Params)
+	if !ok {
+		msg = coded.Error()
+	}
+	c.errors = append(c.errors, FieldError{
+		Path:    c.CurrentPath(),
+		Message: msg,
+		Code:    coded.Code,
+		Params:  coded.Params,
+		Value:   value,
+		Offset:  c.offset,
+	})
+}
+
+// AddFieldError adds a pre-constructed field error. An empty Path or
+// zero Offset is filled in from the context, and an empty Code is set
+// to CodeCustom.
 func (c *ValidationContext) AddFieldError(err FieldError) {
 	if err.Path == "" {
 		err.Path = c.CurrentPath()
 	}
+	if err.Offset == 0 {
+		err.Offset = c.offset
+	}
+	if err.Code == "" {
+		err.Code = CodeCustom
+	}
 	c.errors = append(c.errors, err)
 }
 
@@ -0,0 +1,220 @@
+package queryfy
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/ha1tch/queryfy/superjsonic"
+)
+
+// DecodeJSON decodes a JSON document and validates it against schema in
+// the same pass, replacing json.Unmarshal followed by Validate. The
+// schema is compiled (see Compile), and objects and arrays are checked
+// against its pre-computed fields and element schemas as they are
+// built, so the decoded tree is not walked a second time. The errors
+// are those Validate reports for the same document.
+//
+// The result uses the types of json.Unmarshal into interface{}, with one
+// difference: a number read for a schema with Integer() is an int64, or
+// a json.Number when it is too large for int64, so large IDs keep their
+// precision. Other numbers are float64. As with json.Unmarshal, the
+// last value of a repeated object key wins, and only it is validated.
+//
+// The decoded value is returned along with a *ValidationError when the
+// document does not match the schema, whose FieldErrors carry byte
+// offsets as with ValidateJSON. Malformed JSON returns nil and a
+// *superjsonic.SyntaxError.
+func DecodeJSON(data []byte, schema Schema) (interface{}, error) {
+	return DecodeJSONWithMode(data, schema, Strict)
+}
+
+// DecodeJSONWithMode is DecodeJSON with a specific validation mode.
+func DecodeJSONWithMode(data []byte, schema Schema, mode ValidationMode) (interface{}, error) {
+	if schema != nil {
+		schema = Compile(schema)
+	}
+	ctx := NewValidationContext(mode)
+	r := NewJSONReader(data)
+	value, err := decodeValue(r, schema, ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Reject trailing data after the document
+	if _, err := r.Next(); err != nil {
+		return nil, err
+	}
+	return value, ctx.Error()
+}
+
+// decodeValue decodes the next value, validating it against schema,
+// which may be nil.
+func decodeValue(r *JSONReader, schema Schema, ctx *ValidationContext) (interface{}, error) {
+	tok, err := r.Peek()
+	if err != nil {
+		return nil, err
+	}
+
+	saved := ctx.offset
+	ctx.offset = tok.Offset
+	defer func() { ctx.offset = saved }()
+
+	cs, _ := schema.(*CompiledSchema)
+	var value interface{}
+	switch {
+	case cs != nil && cs.object != nil && tok.Type == superjsonic.ObjectStart:
+		r.Next()
+		return cs.decodeObject(r, ctx)
+	case cs != nil && cs.array != nil && tok.Type == superjsonic.ArrayStart:
+		r.Next()
+		return cs.decodeArray(r, ctx)
+	case cs != nil && cs.integer && tok.Type == superjsonic.Number:
+		r.Next()
+		value, err = decodeInteger(r.Bytes(tok), tok.Offset)
+	default:
+		value, err = r.Decode()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Values without a decoded structure are checked by the schema as
+	// written, so results match Validate
+	if cs != nil {
+		schema = cs.inner
+	}
+	if schema != nil {
+		if err := schema.Validate(value, ctx); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// decodeObject decodes the fields of an object whose '{' has been read.
+// The last value of a repeated key wins, as with json.Unmarshal.
+func (cs *CompiledSchema) decodeObject(r *JSONReader, ctx *ValidationContext) (interface{}, error) {
+	obj := cs.object
+	result := make(map[string]interface{}, len(obj.fields))
+	seen := make([]bool, len(obj.fields))
+	rejectExtra := obj.rejectsExtra(ctx)
+	keys := NewObjectKeys(ctx)
+
+	for {
+		key, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if key.Type == superjsonic.ObjectEnd {
+			break
+		}
+		name, err := superjsonic.Unquote(r.Bytes(key))
+		if err != nil {
+			return nil, err
+		}
+
+		var value interface{}
+		if i, defined := obj.fieldSet[name]; defined {
+			seen[i] = true
+			err = keys.Read(name, func() (err error) {
+				ctx.WithPath(name, func() {
+					value, err = decodeValue(r, obj.fields[i].schema, ctx)
+				})
+				return err
+			})
+		} else {
+			err = keys.Read(name, func() (err error) {
+				ctx.WithPath(name, func() {
+					var next superjsonic.Token
+					if next, err = r.Peek(); err != nil {
+						return
+					}
+					if value, err = r.Decode(); err == nil && rejectExtra {
+						ctx.AddFieldError(FieldError{
+							Message: ctx.Message(CodeUnexpectedField, nil),
+							Code:    CodeUnexpectedField,
+							Value:   value,
+							Offset:  next.Offset,
+						})
+					}
+				})
+				return err
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
+		result[name] = value
+	}
+	keys.Done()
+
+	for i, f := range obj.fields {
+		if !seen[i] && f.required {
+			ctx.WithPath(f.name, func() {
+				ctx.AddCodedError(CodeRequired, nil, nil)
+			})
+		}
+	}
+	cs.runValidators(result, ctx)
+	return result, nil
+}
+
+// decodeArray decodes the elements of an array whose '[' has been read.
+func (cs *CompiledSchema) decodeArray(r *JSONReader, ctx *ValidationContext) (interface{}, error) {
+	result := make([]interface{}, 0)
+	for {
+		next, err := r.Peek()
+		if err != nil {
+			return nil, err
+		}
+		if next.Type == superjsonic.ArrayEnd {
+			r.Next()
+			break
+		}
+
+		var value interface{}
+		ctx.WithIndex(len(result), func() {
+			value, err = decodeValue(r, cs.array.elem, ctx)
+		})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+
+	cs.array.checkItems(result, result, ctx)
+	cs.runValidators(result, ctx)
+	return result, nil
+}
+
+// runValidators runs the custom validators of the inner schema.
+func (cs *CompiledSchema) runValidators(value interface{}, ctx *ValidationContext) {
+	vp, ok := cs.inner.(validatorProvider)
+	if !ok {
+		return
+	}
+	for _, validator := range vp.Validators() {
+		if err := validator(value); err != nil {
+			ctx.AddValidatorError(err, value)
+		}
+	}
+}
+
+// decodeInteger decodes a number for an Integer() schema: an int64 when
+// it fits, a json.Number for whole numbers beyond int64, and a float64
+// for numbers with a fraction or exponent, which validation then checks.
+func decodeInteger(raw []byte, offset int) (interface{}, error) {
+	s := string(raw)
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err == nil {
+		return n, nil
+	}
+	if errors.Is(err, strconv.ErrRange) {
+		return json.Number(s), nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, &superjsonic.SyntaxError{Offset: offset, Msg: "number " + s + " out of range"}
+	}
+	return f, nil
+}
@@ -0,0 +1,234 @@
+package queryfy_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+	"github.com/ha1tch/queryfy/superjsonic"
+)
+
+func decodeSchema() queryfy.Schema {
+	return builders.Object().
+		Field("id", builders.Number().Integer().Required()).
+		Field("name", builders.String().Required().MinLength(2)).
+		Field("score", builders.Number().Max(100)).
+		Field("tags", builders.Array().Of(builders.String()).MaxItems(2)).
+		Field("owner", builders.Object().
+			Field("id", builders.Number().Integer()).
+			Field("email", builders.String().Email()))
+}
+
+func errorStrings(err error) []string {
+	var verr *queryfy.ValidationError
+	if !errors.As(err, &verr) {
+		return nil
+	}
+	out := make([]string, len(verr.Errors))
+	for i, e := range verr.Errors {
+		out[i] = e.String()
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ======================================================================
+// Decoding
+// ======================================================================
+
+func TestDecodeJSON_Valid(t *testing.T) {
+	doc := `{"id": 9007199254740993, "name": "Ann", "score": 12.5,
+		"tags": ["a"], "owner": {"id": 12345678901234567890, "email": "a@b.co"}}`
+	got, err := queryfy.DecodeJSON([]byte(doc), decodeSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"id":    int64(9007199254740993), // not representable as float64
+		"name":  "Ann",
+		"score": 12.5,
+		"tags":  []interface{}{"a"},
+		"owner": map[string]interface{}{
+			"id":    json.Number("12345678901234567890"), // beyond int64
+			"email": "a@b.co",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeJSON =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestValidate_JSONNumber(t *testing.T) {
+	big := json.Number("12345678901234567890")
+	for _, schema := range []queryfy.Schema{
+		builders.Number().Integer().Min(0),
+		queryfy.Compile(builders.Number().Integer().Min(0)),
+	} {
+		if err := queryfy.Validate(big, schema); err != nil {
+			t.Errorf("%T: %v", schema, err)
+		}
+		if err := queryfy.Validate(json.Number("1.5"), schema); err == nil {
+			t.Errorf("%T: 1.5 accepted as an integer", schema)
+		}
+	}
+}
+
+func TestDecodeJSON_MatchesUnmarshalAndValidate(t *testing.T) {
+	docs := []string{
+		`{"id": 1.5, "name": "A", "score": 101, "tags": ["a", 2, "c"], "extra": {"x": [1]}}`,
+		`{"name": 7, "owner": {"id": "x", "email": "nope"}}`,
+		`{"id": 2, "name": "Bo", "tags": null, "owner": []}`,
+		`[1, 2]`,
+		`null`,
+	}
+	schema := decodeSchema()
+
+	for _, mode := range []queryfy.ValidationMode{queryfy.Strict, queryfy.Loose} {
+		for _, doc := range docs {
+			var data interface{}
+			if err := json.Unmarshal([]byte(doc), &data); err != nil {
+				t.Fatal(err)
+			}
+			want := errorStrings(queryfy.ValidateWithMode(data, schema, mode))
+			_, err := queryfy.DecodeJSONWithMode([]byte(doc), schema, mode)
+			got := errorStrings(err)
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("mode %v, %s:\nDecodeJSON: %q\nValidate:   %q", mode, doc, got, want)
+			}
+		}
+	}
+}
+
+func TestDecodeJSON_DuplicateKeysLastWins(t *testing.T) {
+	schema := builders.Object().
+		Field("a", builders.Number().Max(5)).
+		Field("b", builders.Object().Field("c", builders.String().Required()))
+	docs := []string{
+		`{"a": 10, "a": 1}`,
+		`{"a": 1, "a": 10}`,
+		`{"a": 10, "b": {"c": 1}, "a": 1, "b": {"c": "x"}}`,
+		`{"b": {"c": "x", "c": 2}, "a": 6, "b": {}}`,
+		`{"x": 1, "x": 2}`,
+	}
+	for _, doc := range docs {
+		var data interface{}
+		if err := json.Unmarshal([]byte(doc), &data); err != nil {
+			t.Fatal(err)
+		}
+		want := strings.Join(errorStrings(queryfy.Validate(data, schema)), "\n")
+
+		decoded, err := queryfy.DecodeJSON([]byte(doc), schema)
+		if got := strings.Join(errorStrings(err), "\n"); got != want {
+			t.Errorf("%s:\nDecodeJSON:   %q\nValidate:     %q", doc, got, want)
+		}
+		if !reflect.DeepEqual(decoded, data) {
+			t.Errorf("%s: DecodeJSON = %v, want %v", doc, decoded, data)
+		}
+		if got := strings.Join(errorStrings(queryfy.ValidateJSON([]byte(doc), schema)), "\n"); got != want {
+			t.Errorf("%s:\nValidateJSON: %q\nValidate:     %q", doc, got, want)
+		}
+	}
+}
+
+func TestDecodeJSON_ReturnsDataWithErrors(t *testing.T) {
+	doc := `{"id": 1, "name": "A", "extra": true}`
+	got, err := queryfy.DecodeJSON([]byte(doc), decodeSchema())
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	m, ok := got.(map[string]interface{})
+	if !ok || m["extra"] != true || m["id"] != int64(1) {
+		t.Errorf("decoded = %#v", got)
+	}
+
+	var verr *queryfy.ValidationError
+	errors.As(err, &verr)
+	for _, e := range verr.Errors {
+		var want int
+		switch e.Path {
+		case "name":
+			want = strings.Index(doc, `"A"`)
+		case "extra":
+			want = strings.Index(doc, "true")
+		default:
+			t.Errorf("unexpected error %v", e)
+		}
+		if e.Offset != want {
+			t.Errorf("%s: offset %d, want %d", e.Path, e.Offset, want)
+		}
+	}
+}
+
+func TestDecodeJSON_CustomValidators(t *testing.T) {
+	schema := builders.Object().
+		Field("min", builders.Number().Integer()).
+		Field("max", builders.Number().Integer()).
+		Custom(func(v interface{}) error {
+			m := v.(map[string]interface{})
+			if m["min"].(int64) > m["max"].(int64) {
+				return errors.New("min must not exceed max")
+			}
+			return nil
+		})
+	_, err := queryfy.DecodeJSON([]byte(`{"min": 5, "max": 1}`), schema)
+	if got := errorStrings(err); len(got) != 1 || got[0] != "min must not exceed max" {
+		t.Errorf("errors = %q", got)
+	}
+}
+
+func TestDecodeJSON_NilSchema(t *testing.T) {
+	got, err := queryfy.DecodeJSON([]byte(`{"a": [1, "b"]}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"a": []interface{}{1.0, "b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeJSON = %#v", got)
+	}
+}
+
+func TestDecodeJSON_SyntaxErrors(t *testing.T) {
+	for _, doc := range []string{`{"id": 1,}`, `{"id": 1} []`, `{"id": 1e999}`, ``} {
+		got, err := queryfy.DecodeJSON([]byte(doc), decodeSchema())
+		var syn *superjsonic.SyntaxError
+		if !errors.As(err, &syn) || got != nil {
+			t.Errorf("%q: %v, %v", doc, got, err)
+		}
+	}
+}
+
+// ======================================================================
+// Benchmarks
+// ======================================================================
+
+var decodeBenchDoc = []byte(`{"id": 123456789012, "name": "widget", "score": 42.5,
+	"tags": ["a", "b"], "owner": {"id": 7, "email": "owner@example.com"}}`)
+
+func BenchmarkDecodeJSON(b *testing.B) {
+	schema := queryfy.Compile(decodeSchema())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := queryfy.DecodeJSON(decodeBenchDoc, schema); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalThenValidate(b *testing.B) {
+	schema := queryfy.Compile(decodeSchema())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var data interface{}
+		if err := json.Unmarshal(decodeBenchDoc, &data); err != nil {
+			b.Fatal(err)
+		}
+		if err := queryfy.Validate(data, schema); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
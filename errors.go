@@ -18,8 +18,17 @@ type FieldError struct {
 	Path string
 	// Message describes what validation failed
 	Message string
+	// Code identifies the kind of failure, such as CodeMinLength; see
+	// the Code constants. Errors with a plain message have CodeCustom
+	Code string
+	// Params holds the parameters of Code, such as {"min": 3} for
+	// CodeMinLength (optional)
+	Params Params
 	// Value is the actual value that failed validation (optional)
 	Value interface{}
+	// Offset is the byte offset in the input of the value that failed,
+	// for errors reported by ValidateJSON; otherwise zero
+	Offset int
 }
 
 // Error returns a string representation of all validation errors.
@@ -47,6 +56,7 @@ func (e *ValidationError) Add(path, message string, value interface{}) {
 	e.Errors = append(e.Errors, FieldError{
 		Path:    path,
 		Message: message,
+		Code:    CodeCustom,
 		Value:   value,
 	})
 }
@@ -61,6 +71,21 @@ func (e *ValidationError) HasErrors() bool {
 	return len(e.Errors) > 0
 }
 
+// Localize returns a copy of the error with messages taken from catalog,
+// for errors returned by functions that do not take a
+// ValidationContext, such as Validate or DecodeJSON. Errors whose code
+// the catalog does not know keep their message.
+func (e *ValidationError) Localize(catalog MessageCatalog) *ValidationError {
+	localized := &ValidationError{Errors: make([]FieldError, len(e.Errors))}
+	for i, fieldErr := range e.Errors {
+		if msg, ok := catalog.Message(fieldErr.Code, fieldErr.Params); ok {
+			fieldErr.Message = msg
+		}
+		localized.Errors[i] = fieldErr
+	}
+	return localized
+}
+
 // String returns a string representation of the field error.
 func (e FieldError) String() string {
 	if e.Path == "" {
@@ -84,6 +109,7 @@ func NewFieldError(path, message string, value interface{}) FieldError {
 	return FieldError{
 		Path:    path,
 		Message: message,
+		Code:    CodeCustom,
 		Value:   value,
 	}
 }
@@ -370,7 +370,7 @@ func complexDependencyExample() {
 		DependentField("smokerDetails",
 			builders.Dependent("smokerDetails").
 				On("insuranceType", "primaryHolder").
-				When(func(data map[string]interface{}) bool {
+				When(builders.WhenFunc(func(data map[string]interface{}) bool {
 					if data["insuranceType"] != "health" {
 						return false
 					}
@@ -380,7 +380,7 @@ func complexDependencyExample() {
 						}
 					}
 					return false
-				}).
+				})).
 				Then(builders.Object().
 					Field("yearsSmoked", builders.Number().Min(0).Required()).
 					Field("packsPerDay", builders.Number().Min(0).Required()).
@@ -388,10 +388,10 @@ func complexDependencyExample() {
 		// Premium calculation factors - depends on multiple fields
 		DependentField("premiumFactors",
 			builders.Dependent("premiumFactors").
-				When(func(data map[string]interface{}) bool {
+				When(builders.WhenFunc(func(data map[string]interface{}) bool {
 					// Always calculate premium factors
 					return true
-				}).
+				})).
 				Then(builders.Object().
 					Field("basePremium", builders.Number().Min(0).Required()).
 					Field("riskMultiplier", builders.Number().Min(0.5).Max(5.0)).
@@ -33,6 +33,26 @@ func Each(data interface{}, queryStr string, fn func(index int, value interface{
 	return nil
 }
 
+// EachWithPath is Each with the concrete path of every matched element
+// in place of its position in the results, such as
+// "customers[3].orders[1].total". The path of the root is the empty
+// string.
+//
+// Return a non-nil error from the callback to stop iteration early.
+func EachWithPath(data interface{}, queryStr string, fn func(path string, value interface{}) error) error {
+	matches, err := query.ExecuteWithPaths(data, queryStr)
+	if err != nil {
+		return fmt.Errorf("query %q: %w", queryStr, err)
+	}
+
+	for _, m := range matches {
+		if err := fn(m.PathString(), m.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Collect executes a query path and applies a transform function to each
 // matched element, returning the collected results. The path may include
 // wildcards.
@@ -1,7 +1,9 @@
 package queryfy_test
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/ha1tch/queryfy"
@@ -200,3 +202,92 @@ func TestValidateEach_BadQuery(t *testing.T) {
 		t.Error("expected error for bad query")
 	}
 }
+
+// ======================================================================
+// Filters
+// ======================================================================
+
+func TestEach_Filter(t *testing.T) {
+	var names []string
+	err := queryfy.Each(iterData, "items[?(@.price > 5)].name", func(i int, v interface{}) error {
+		names = append(names, v.(string))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(names) != 2 || names[0] != "Widget" || names[1] != "Gadget" {
+		t.Errorf("unexpected names: %v", names)
+	}
+}
+
+func TestCollect_Filter(t *testing.T) {
+	results, err := queryfy.Collect(iterData, `items[?name == "Gadget"].price`, func(v interface{}) (interface{}, error) {
+		return v.(float64) * 2, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A filter always yields a list, even with one match
+	if len(results) != 1 || results[0] != 49.98 {
+		t.Errorf("unexpected results: %v", results)
+	}
+}
+
+func TestValidateEach_Filter(t *testing.T) {
+	schema := builders.Object().
+		Field("name", builders.String().Required()).
+		Field("price", builders.Number().Max(10).Required())
+
+	if err := queryfy.ValidateEach(iterData, "items[?(@.price < 10)]", schema, queryfy.Strict); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := queryfy.ValidateEach(iterData, "items[?(@.price >= 5)]", schema, queryfy.Strict); err == nil {
+		t.Error("expected the Gadget to fail validation")
+	}
+}
+
+func TestEachWithPath(t *testing.T) {
+	data := map[string]interface{}{
+		"customers": []interface{}{
+			map[string]interface{}{"orders": []interface{}{
+				map[string]interface{}{"total": 10.0},
+				map[string]interface{}{"total": 120.0},
+			}},
+			map[string]interface{}{"orders": []interface{}{}},
+			map[string]interface{}{"orders": []interface{}{
+				map[string]interface{}{"total": 300.0},
+			}},
+		},
+	}
+
+	got := map[string]interface{}{}
+	err := queryfy.EachWithPath(data, "customers[*].orders[?(@.total > 100)].total", func(path string, value interface{}) error {
+		got[path] = value
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]interface{}{
+		"customers[0].orders[1].total": 120.0,
+		"customers[2].orders[0].total": 300.0,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = queryfy.EachWithPath(data, "customers[*]", func(string, interface{}) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("expected to stop after one call, got %d calls and %v", calls, err)
+	}
+
+	if err := queryfy.EachWithPath(data, "missing[*]", func(string, interface{}) error { return nil }); err == nil {
+		t.Error("expected an error for a missing field")
+	}
+}
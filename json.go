@@ -0,0 +1,132 @@
+package queryfy
+
+import (
+	"sort"
+
+	"github.com/ha1tch/queryfy/superjsonic"
+)
+
+// JSONValidator is implemented by schemas that can validate a value
+// straight from JSON tokens, without decoding it into a map first.
+// ValidateJSON must consume exactly one value from r, reporting errors
+// to ctx as Validate does. Schemas that do not implement it are
+// validated by decoding the value and calling Validate.
+type JSONValidator interface {
+	ValidateJSON(r *JSONReader, ctx *ValidationContext) error
+}
+
+// JSONReader reads the tokens of a JSON document for schemas that
+// implement JSONValidator. It embeds the tokenizer, so its Next, Peek,
+// Skip, Decode and Bytes methods are available.
+type JSONReader struct {
+	*superjsonic.Tokenizer
+}
+
+// NewJSONReader returns a reader for data.
+func NewJSONReader(data []byte) *JSONReader {
+	return &JSONReader{Tokenizer: superjsonic.NewTokenizer(data)}
+}
+
+// Validate consumes the next value and validates it against schema.
+// Errors reported while it runs carry the value's byte offset. Schemas
+// implementing JSONValidator validate from the tokens; others get the
+// decoded value. A nil schema skips the value.
+func (r *JSONReader) Validate(schema Schema, ctx *ValidationContext) error {
+	if schema == nil {
+		return r.Skip()
+	}
+	tok, err := r.Peek()
+	if err != nil {
+		return err
+	}
+
+	saved := ctx.offset
+	ctx.offset = tok.Offset
+	defer func() { ctx.offset = saved }()
+
+	if v, ok := schema.(JSONValidator); ok {
+		return v.ValidateJSON(r, ctx)
+	}
+	value, err := r.Decode()
+	if err != nil {
+		return err
+	}
+	return schema.Validate(value, ctx)
+}
+
+// ObjectKeys tracks the keys of an object read token by token, for
+// JSONValidator implementations. A key may appear more than once in a
+// document and json.Unmarshal keeps its last value, so Done drops the
+// errors reported for the earlier values: the object is judged on the
+// value it decodes to.
+type ObjectKeys struct {
+	ctx      *ValidationContext
+	spans    map[string]errorSpan
+	replaced []errorSpan
+}
+
+// errorSpan is the range of ctx.errors reported for one value.
+type errorSpan struct{ start, end int }
+
+// NewObjectKeys starts tracking the keys of an object.
+func NewObjectKeys(ctx *ValidationContext) *ObjectKeys {
+	return &ObjectKeys{ctx: ctx, spans: make(map[string]errorSpan)}
+}
+
+// Read calls fn, which consumes the value of key, and records the
+// errors it reports.
+func (k *ObjectKeys) Read(key string, fn func() error) error {
+	start := len(k.ctx.errors)
+	err := fn()
+	if prev, dup := k.spans[key]; dup {
+		k.replaced = append(k.replaced, prev)
+	}
+	k.spans[key] = errorSpan{start, len(k.ctx.errors)}
+	return err
+}
+
+// Has reports whether key has been read.
+func (k *ObjectKeys) Has(key string) bool {
+	_, ok := k.spans[key]
+	return ok
+}
+
+// Done drops the errors of values replaced by a later value of the same
+// key. Call it after the object's last key.
+func (k *ObjectKeys) Done() {
+	// Spans do not overlap; drop from the last so earlier ones stay put
+	sort.Slice(k.replaced, func(i, j int) bool { return k.replaced[i].start > k.replaced[j].start })
+	for _, s := range k.replaced {
+		k.ctx.errors = append(k.ctx.errors[:s.start], k.ctx.errors[s.end:]...)
+	}
+	k.replaced = nil
+}
+
+// ValidateJSON validates a JSON document against a schema without
+// unmarshaling it first. Objects, arrays and strings are checked as
+// their tokens are read, so an invalid document is rejected without
+// building its map tree; other values are decoded one at a time as
+// encoding/json would decode them. Each FieldError carries the byte
+// offset of the failing value in data as well as its path. When a key
+// appears twice in an object, the last value wins, as with
+// json.Unmarshal: earlier values are checked for syntax only.
+//
+// Malformed JSON is reported as a *superjsonic.SyntaxError and schema
+// violations as a *ValidationError.
+func ValidateJSON(data []byte, schema Schema) error {
+	return ValidateJSONWithMode(data, schema, Strict)
+}
+
+// ValidateJSONWithMode is ValidateJSON with a specific validation mode.
+func ValidateJSONWithMode(data []byte, schema Schema, mode ValidationMode) error {
+	ctx := NewValidationContext(mode)
+	r := NewJSONReader(data)
+	if err := r.Validate(schema, ctx); err != nil {
+		return err
+	}
+	// Reject trailing data after the document
+	if _, err := r.Next(); err != nil {
+		return err
+	}
+	return ctx.Error()
+}
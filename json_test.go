@@ -0,0 +1,91 @@
+package queryfy_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+	"github.com/ha1tch/queryfy/superjsonic"
+)
+
+// countingSchema validates values from tokens and counts the calls.
+type countingSchema struct {
+	calls int
+}
+
+func (s *countingSchema) Validate(value interface{}, ctx *queryfy.ValidationContext) error {
+	return errors.New("Validate called")
+}
+
+func (s *countingSchema) Type() queryfy.SchemaType { return queryfy.TypeAny }
+
+func (s *countingSchema) ValidateJSON(r *queryfy.JSONReader, ctx *queryfy.ValidationContext) error {
+	s.calls++
+	tok, err := r.Peek()
+	if err != nil {
+		return err
+	}
+	if tok.Type != superjsonic.Number {
+		ctx.AddError("expected a number token", nil)
+	}
+	return r.Skip()
+}
+
+func TestValidateJSON_UsesJSONValidator(t *testing.T) {
+	schema := &countingSchema{}
+	err := queryfy.ValidateJSON([]byte(` "x"`), schema)
+	if schema.calls != 1 {
+		t.Fatalf("ValidateJSON called %d times", schema.calls)
+	}
+	var verr *queryfy.ValidationError
+	if !errors.As(err, &verr) || verr.Errors[0].Offset != 1 {
+		t.Fatalf("error = %#v", err)
+	}
+}
+
+func TestValidateJSON_DecodesForOtherSchemas(t *testing.T) {
+	schema := builders.Object().Field("n", builders.Number().Max(10))
+	err := queryfy.ValidateJSON([]byte(`{"n": 11}`), schema)
+	var verr *queryfy.ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 1 {
+		t.Fatalf("error = %v", err)
+	}
+	if e := verr.Errors[0]; e.Path != "n" || e.Offset != 6 || e.Value != 11.0 {
+		t.Errorf("error = %+v", e)
+	}
+}
+
+func TestValidateJSON_ErrorKinds(t *testing.T) {
+	schema := builders.String()
+
+	if err := queryfy.ValidateJSON([]byte(`"ok"`), schema); err != nil {
+		t.Errorf("valid document: %v", err)
+	}
+
+	var syn *superjsonic.SyntaxError
+	if err := queryfy.ValidateJSON([]byte(`"ok" "again"`), schema); !errors.As(err, &syn) {
+		t.Errorf("trailing data: error = %v", err)
+	}
+	if err := queryfy.ValidateJSON(nil, schema); !errors.As(err, &syn) {
+		t.Errorf("empty input: error = %v", err)
+	}
+
+	err := queryfy.ValidateJSON([]byte(`42`), schema)
+	if err == nil || !strings.Contains(err.Error(), "expected string") {
+		t.Errorf("wrong type: error = %v", err)
+	}
+	if err := queryfy.ValidateJSONWithMode([]byte(`42`), schema, queryfy.Loose); err != nil {
+		t.Errorf("loose mode: %v", err)
+	}
+}
+
+func TestFieldError_OffsetZeroOutsideJSON(t *testing.T) {
+	err := queryfy.Validate(map[string]interface{}{"n": "x"},
+		builders.Object().Field("n", builders.Number()))
+	var verr *queryfy.ValidationError
+	if !errors.As(err, &verr) || verr.Errors[0].Offset != 0 {
+		t.Fatalf("error = %#v", err)
+	}
+}
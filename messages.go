@@ -0,0 +1,257 @@
+package queryfy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error codes identify the kind of a validation failure. Every
+// FieldError carries one in Code, with its parameters in Params, so
+// errors can be matched and translated without parsing messages. The
+// parameters of each code are listed next to it.
+const (
+	CodeRequired        = "required"
+	CodeNotNull         = "not_null"
+	CodeType            = "type"         // expected, actual
+	CodeConversion      = "conversion"   // type, target
+	CodeUnknownType     = "unknown_type" // type
+	CodeUnexpectedField = "unexpected_field"
+
+	CodeOverflow    = "overflow"     // value, target
+	CodeParse       = "parse"        // target, error
+	CodeArrayLength = "array_length" // length, actual
+
+	CodeMinLength = "min_length" // min, actual
+	CodeMaxLength = "max_length" // max, actual
+	CodePattern   = "pattern"    // pattern
+	CodeEmail     = "email"
+	CodeURL       = "url"
+	CodeUUID      = "uuid"
+	CodeEnum      = "enum" // values
+
+	CodeMin              = "min"         // min
+	CodeMax              = "max"         // max
+	CodeMultipleOf       = "multiple_of" // multiple_of
+	CodeInteger          = "integer"
+	CodePositive         = "positive"
+	CodeNegative         = "negative"
+	CodeDecimalScale     = "decimal_scale"     // scale
+	CodeDecimalPrecision = "decimal_precision" // digits
+
+	CodeMinItems    = "min_items" // min, actual
+	CodeMaxItems    = "max_items" // max, actual
+	CodeUniqueItems = "unique_items"
+
+	CodeConst = "const" // value
+
+	CodeDateTimeFormat = "datetime_format" // format, error
+	CodeAfter          = "after"           // min
+	CodeBefore         = "before"          // max
+	CodeFuture         = "future"
+	CodePast           = "past"
+	CodeMinAge         = "min_age" // min, age
+	CodeMaxAge         = "max_age" // max, age
+	CodeWeekday        = "weekday" // days
+
+	CodeAnyOf = "any_of"
+	CodeOneOf = "one_of" // matched
+	CodeNot   = "not"
+
+	CodeUnresolvedRef = "unresolved_ref" // name
+	CodeUnknownFormat = "unknown_format" // format
+	CodeCancelled     = "cancelled"      // error
+	CodeTransform     = "transform"      // step, error
+
+	// CodeCustom marks errors added with a plain message, such as those
+	// returned by custom validators. Their message is used as is.
+	CodeCustom = "custom"
+)
+
+// Params holds the parameters of an error code, such as the minimum
+// length for CodeMinLength.
+type Params map[string]interface{}
+
+// MessageCatalog turns error codes into messages. Message returns false
+// for codes the catalog does not know, and the English message is used
+// instead.
+type MessageCatalog interface {
+	Message(code string, params Params) (string, bool)
+}
+
+// MessageBundle is a MessageCatalog of message templates keyed by error
+// code. A template refers to parameters by name in braces, as in
+// "length must be at least {min}". Slices of strings are joined with
+// ", "; other values are formatted with fmt.Sprint. Placeholders without
+// a parameter are left as written.
+type MessageBundle map[string]string
+
+// Message fills in the template for code.
+func (b MessageBundle) Message(code string, params Params) (string, bool) {
+	tmpl, ok := b[code]
+	if !ok {
+		return "", false
+	}
+	if !strings.Contains(tmpl, "{") {
+		return tmpl, true
+	}
+
+	var out strings.Builder
+	for {
+		open := strings.IndexByte(tmpl, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[open:], '}')
+		if end < 0 {
+			break
+		}
+		out.WriteString(tmpl[:open])
+		if value, ok := params[tmpl[open+1:open+end]]; ok {
+			out.WriteString(formatParam(value))
+		} else {
+			out.WriteString(tmpl[open : open+end+1])
+		}
+		tmpl = tmpl[open+end+1:]
+	}
+	out.WriteString(tmpl)
+	return out.String(), true
+}
+
+func formatParam(value interface{}) string {
+	if values, ok := value.([]string); ok {
+		return strings.Join(values, ", ")
+	}
+	return fmt.Sprint(value)
+}
+
+// EnglishMessages is the default message catalog.
+var EnglishMessages = MessageBundle{
+	CodeRequired:        "field is required",
+	CodeNotNull:         "field cannot be null",
+	CodeType:            "expected {expected}, got {actual}",
+	CodeConversion:      "cannot convert {type} to {target}",
+	CodeUnknownType:     "unknown schema type: {type}",
+	CodeUnexpectedField: "unexpected field",
+
+	CodeOverflow:    "value {value} overflows {target}",
+	CodeParse:       "cannot parse {target}: {error}",
+	CodeArrayLength: "expected {length} items, got {actual}",
+
+	CodeMinLength: "length must be at least {min}, got {actual}",
+	CodeMaxLength: "length must be at most {max}, got {actual}",
+	CodePattern:   "must match pattern {pattern}",
+	CodeEmail:     "must be a valid email address",
+	CodeURL:       "must be a valid URL",
+	CodeUUID:      "must be a valid UUID",
+	CodeEnum:      "must be one of: {values}",
+
+	CodeMin:              "must be >= {min}",
+	CodeMax:              "must be <= {max}",
+	CodeMultipleOf:       "must be a multiple of {multiple_of}",
+	CodeInteger:          "must be an integer",
+	CodePositive:         "must be positive",
+	CodeNegative:         "must be negative",
+	CodeDecimalScale:     "must have at most {scale} decimal places",
+	CodeDecimalPrecision: "must have at most {digits} digits before the decimal point",
+
+	CodeMinItems:    "must have at least {min} items, got {actual}",
+	CodeMaxItems:    "must have at most {max} items, got {actual}",
+	CodeUniqueItems: "items must be unique",
+
+	CodeConst: "must be {value}",
+
+	CodeDateTimeFormat: "invalid date/time format (expected {format}): {error}",
+	CodeAfter:          "must be after {min}",
+	CodeBefore:         "must be before {max}",
+	CodeFuture:         "must be in the future",
+	CodePast:           "must be in the past",
+	CodeMinAge:         "age must be at least {min} years (current: {age})",
+	CodeMaxAge:         "age must be at most {max} years (current: {age})",
+	CodeWeekday:        "must be on {days}",
+
+	CodeAnyOf: "none of the validators passed",
+	CodeOneOf: "must match exactly one schema, matched {matched}",
+	CodeNot:   "value must not match the validation",
+
+	CodeUnresolvedRef: `unresolved schema reference "{name}"`,
+	CodeUnknownFormat: `unknown format: "{format}"`,
+	CodeCancelled:     "validation cancelled: {error}",
+	CodeTransform:     "transformation {step} failed: {error}",
+}
+
+// SpanishMessages is a Spanish message catalog. Parameters such as
+// type names are not translated.
+var SpanishMessages = MessageBundle{
+	CodeRequired:        "el campo es obligatorio",
+	CodeNotNull:         "el campo no puede ser nulo",
+	CodeType:            "se esperaba {expected}, se recibió {actual}",
+	CodeConversion:      "no se puede convertir {type} a {target}",
+	CodeUnknownType:     "tipo de esquema desconocido: {type}",
+	CodeUnexpectedField: "campo no permitido",
+
+	CodeOverflow:    "el valor {value} no cabe en {target}",
+	CodeParse:       "no se puede interpretar {target}: {error}",
+	CodeArrayLength: "se esperaban {length} elementos, se recibieron {actual}",
+
+	CodeMinLength: "la longitud debe ser de al menos {min}, se recibió {actual}",
+	CodeMaxLength: "la longitud debe ser de como máximo {max}, se recibió {actual}",
+	CodePattern:   "debe coincidir con el patrón {pattern}",
+	CodeEmail:     "debe ser una dirección de correo electrónico válida",
+	CodeURL:       "debe ser una URL válida",
+	CodeUUID:      "debe ser un UUID válido",
+	CodeEnum:      "debe ser uno de: {values}",
+
+	CodeMin:              "debe ser >= {min}",
+	CodeMax:              "debe ser <= {max}",
+	CodeMultipleOf:       "debe ser múltiplo de {multiple_of}",
+	CodeInteger:          "debe ser un número entero",
+	CodePositive:         "debe ser positivo",
+	CodeNegative:         "debe ser negativo",
+	CodeDecimalScale:     "debe tener como máximo {scale} decimales",
+	CodeDecimalPrecision: "debe tener como máximo {digits} dígitos antes del separador decimal",
+
+	CodeMinItems:    "debe tener al menos {min} elementos, se recibieron {actual}",
+	CodeMaxItems:    "debe tener como máximo {max} elementos, se recibieron {actual}",
+	CodeUniqueItems: "los elementos deben ser únicos",
+
+	CodeConst: "debe ser {value}",
+
+	CodeDateTimeFormat: "formato de fecha/hora no válido (se esperaba {format}): {error}",
+	CodeAfter:          "debe ser posterior a {min}",
+	CodeBefore:         "debe ser anterior a {max}",
+	CodeFuture:         "debe ser una fecha futura",
+	CodePast:           "debe ser una fecha pasada",
+	CodeMinAge:         "la edad debe ser de al menos {min} años (actual: {age})",
+	CodeMaxAge:         "la edad debe ser de como máximo {max} años (actual: {age})",
+	CodeWeekday:        "debe caer en {days}",
+
+	CodeAnyOf: "no se cumplió ninguna de las validaciones",
+	CodeOneOf: "debe coincidir con exactamente un esquema, coincidió con {matched}",
+	CodeNot:   "el valor no debe cumplir la validación",
+
+	CodeUnresolvedRef: `referencia de esquema no resuelta "{name}"`,
+	CodeUnknownFormat: `formato desconocido: "{format}"`,
+	CodeCancelled:     "validación cancelada: {error}",
+	CodeTransform:     "la transformación {step} falló: {error}",
+}
+
+// CodedError is an error with a code and parameters. Custom validators
+// can return one so that their error is reported with the code and its
+// message comes from the context's catalog. Message is used when no
+// catalog knows the code.
+type CodedError struct {
+	Code    string
+	Params  Params
+	Message string
+}
+
+// Error returns Message, or the English message for the code.
+func (e *CodedError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if msg, ok := EnglishMessages.Message(e.Code, e.Params); ok {
+		return msg
+	}
+	return e.Code
+}
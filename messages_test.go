@@ -0,0 +1,208 @@
+package queryfy_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+)
+
+func messagesSchema() queryfy.Schema {
+	return builders.Object().
+		Field("name", builders.String().MinLength(3).Required()).
+		Field("email", builders.String().Email().Required()).
+		Field("age", builders.Number().Min(18)).
+		Field("role", builders.String().Enum("admin", "user")).
+		Field("tags", builders.Array().Of(builders.String()).MaxItems(1))
+}
+
+var messagesDoc = map[string]interface{}{
+	"name": "Al",
+	"age":  16,
+	"role": "root",
+	"tags": []interface{}{"a", 2},
+}
+
+// byPath indexes the errors of a validation by path.
+func byPath(t *testing.T, err error) map[string]queryfy.FieldError {
+	t.Helper()
+	var verr *queryfy.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	out := make(map[string]queryfy.FieldError, len(verr.Errors))
+	for _, e := range verr.Errors {
+		out[e.Path] = e
+	}
+	return out
+}
+
+// ======================================================================
+// Codes and parameters
+// ======================================================================
+
+func TestFieldError_CodesAndParams(t *testing.T) {
+	want := map[string]struct {
+		code   string
+		params queryfy.Params
+	}{
+		"name":    {queryfy.CodeMinLength, queryfy.Params{"min": 3, "actual": 2}},
+		"email":   {queryfy.CodeRequired, nil},
+		"age":     {queryfy.CodeMin, queryfy.Params{"min": 18.0}},
+		"role":    {queryfy.CodeEnum, queryfy.Params{"values": []string{"admin", "user"}}},
+		"tags":    {queryfy.CodeMaxItems, queryfy.Params{"max": 1, "actual": 2}},
+		"tags[1]": {queryfy.CodeType, queryfy.Params{"expected": "string", "actual": "int"}},
+	}
+
+	schema := messagesSchema()
+	for _, s := range []queryfy.Schema{schema, queryfy.Compile(schema)} {
+		errs := byPath(t, queryfy.Validate(messagesDoc, s))
+		if len(errs) != len(want) {
+			t.Errorf("%T: %d errors, want %d: %v", s, len(errs), len(want), errs)
+		}
+		for path, w := range want {
+			e := errs[path]
+			if e.Code != w.code || !reflect.DeepEqual(e.Params, w.params) {
+				t.Errorf("%T: %s: code %q, params %v; want %q, %v", s, path, e.Code, e.Params, w.code, w.params)
+			}
+		}
+	}
+}
+
+func TestFieldError_CustomValidators(t *testing.T) {
+	schema := builders.Object().
+		Field("plain", builders.String().Custom(func(interface{}) error {
+			return errors.New("not allowed")
+		})).
+		Field("coded", builders.String().Custom(func(interface{}) error {
+			return &queryfy.CodedError{Code: "reserved", Params: queryfy.Params{"word": "admin"}, Message: "admin is reserved"}
+		})).
+		Field("positive", builders.Number().Positive())
+
+	ctx := queryfy.NewValidationContext(queryfy.Strict)
+	schema.Validate(map[string]interface{}{"plain": "x", "coded": "admin", "positive": -1}, ctx)
+	errs := byPath(t, ctx.Error())
+
+	if e := errs["plain"]; e.Code != queryfy.CodeCustom || e.Message != "not allowed" {
+		t.Errorf("plain: %+v", e)
+	}
+	if e := errs["coded"]; e.Code != "reserved" || e.Params["word"] != "admin" || e.Message != "admin is reserved" {
+		t.Errorf("coded: %+v", e)
+	}
+	if e := errs["positive"]; e.Code != queryfy.CodePositive || e.Message != "must be positive" {
+		t.Errorf("positive: %+v", e)
+	}
+}
+
+// ======================================================================
+// Catalogs
+// ======================================================================
+
+func TestValidationContext_SpanishMessages(t *testing.T) {
+	schema := messagesSchema()
+	for _, s := range []queryfy.Schema{schema, queryfy.Compile(schema)} {
+		ctx := queryfy.NewValidationContext(queryfy.Strict)
+		ctx.SetMessages(queryfy.SpanishMessages)
+		s.Validate(messagesDoc, ctx)
+		errs := byPath(t, ctx.Error())
+
+		want := map[string]string{
+			"name":  "la longitud debe ser de al menos 3, se recibió 2",
+			"email": "el campo es obligatorio",
+			"role":  "debe ser uno de: admin, user",
+		}
+		for path, msg := range want {
+			if got := errs[path].Message; got != msg {
+				t.Errorf("%T: %s: %q, want %q", s, path, got, msg)
+			}
+		}
+
+		// Reset keeps the catalog
+		ctx.Reset()
+		s.Validate(map[string]interface{}{}, ctx)
+		if got := byPath(t, ctx.Error())["name"].Message; got != "el campo es obligatorio" {
+			t.Errorf("%T: after Reset: %q", s, got)
+		}
+	}
+}
+
+func TestMessageBundles_CoverEveryCode(t *testing.T) {
+	for code := range queryfy.EnglishMessages {
+		if _, ok := queryfy.SpanishMessages[code]; !ok {
+			t.Errorf("SpanishMessages has no message for %q", code)
+		}
+	}
+	if len(queryfy.SpanishMessages) != len(queryfy.EnglishMessages) {
+		t.Errorf("bundles differ in size: %d and %d", len(queryfy.SpanishMessages), len(queryfy.EnglishMessages))
+	}
+}
+
+// upperCatalog overrides one message and leaves the rest to English.
+type upperCatalog struct{}
+
+func (upperCatalog) Message(code string, params queryfy.Params) (string, bool) {
+	if code == queryfy.CodeRequired {
+		return "REQUIRED", true
+	}
+	return "", false
+}
+
+func TestValidationContext_CustomCatalogFallsBack(t *testing.T) {
+	ctx := queryfy.NewValidationContext(queryfy.Strict)
+	ctx.SetMessages(upperCatalog{})
+	messagesSchema().Validate(map[string]interface{}{"name": "Al"}, ctx)
+	errs := byPath(t, ctx.Error())
+
+	if got := errs["email"].Message; got != "REQUIRED" {
+		t.Errorf("email: %q", got)
+	}
+	if got := errs["name"].Message; got != "length must be at least 3, got 2" {
+		t.Errorf("name: %q", got)
+	}
+}
+
+func TestValidationError_Localize(t *testing.T) {
+	var verr *queryfy.ValidationError
+	errors.As(queryfy.Validate(messagesDoc, messagesSchema()), &verr)
+	verr.AddError(queryfy.NewFieldError("x", "custom message", nil))
+
+	localized := verr.Localize(queryfy.SpanishMessages)
+	errs := byPath(t, localized)
+	if got := errs["tags[1]"].Message; got != "se esperaba string, se recibió int" {
+		t.Errorf("tags[1]: %q", got)
+	}
+	if got := errs["x"].Message; got != "custom message" {
+		t.Errorf("custom error changed: %q", got)
+	}
+	if got := byPath(t, verr)["email"].Message; got != "field is required" {
+		t.Errorf("original changed: %q", got)
+	}
+}
+
+func TestMessageBundle_Templates(t *testing.T) {
+	bundle := queryfy.MessageBundle{
+		"range":   "between {min} and {max}",
+		"list":    "one of {values}!",
+		"unknown": "{missing} and {unclosed",
+	}
+	tests := []struct {
+		code   string
+		params queryfy.Params
+		want   string
+	}{
+		{"range", queryfy.Params{"min": 1, "max": 2.5}, "between 1 and 2.5"},
+		{"list", queryfy.Params{"values": []string{"a", "b"}}, "one of a, b!"},
+		{"unknown", nil, "{missing} and {unclosed"},
+	}
+	for _, tt := range tests {
+		got, ok := bundle.Message(tt.code, tt.params)
+		if !ok || got != tt.want {
+			t.Errorf("%s: %q, %v; want %q", tt.code, got, ok, tt.want)
+		}
+	}
+	if _, ok := bundle.Message("absent", nil); ok {
+		t.Error("Message found an absent code")
+	}
+}
@@ -0,0 +1,211 @@
+package queryfy
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Rational is implemented by decimal types that can report their exact
+// value, such as shopspring/decimal's Decimal. Number schemas accept
+// values of such types and check them exactly.
+type Rational interface {
+	Rat() *big.Rat
+}
+
+// ConvertToRat returns the exact value of a number as a *big.Rat. It
+// accepts Go's integer and float types, json.Number, *big.Int,
+// *big.Float, *big.Rat and Rational values. A float is taken as the
+// shortest decimal that converts back to it, so float64(0.1) is 1/10
+// rather than its binary approximation; this is how the number was
+// most likely written. NaN, infinities and nil pointers are not
+// numbers, nor are json.Number values with an exponent or fraction of
+// more than 1000 digits, whose exact value is too costly to compute.
+//
+// A *big.Rat is returned as is, not copied, and must not be modified.
+func ConvertToRat(value interface{}) (*big.Rat, bool) {
+	switch v := value.(type) {
+	case int:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int8:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int16:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int32:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int64:
+		return new(big.Rat).SetInt64(v), true
+	case uint:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint8:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint16:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint32:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint64:
+		return new(big.Rat).SetUint64(v), true
+	case float32:
+		return floatToRat(float64(v), 32)
+	case float64:
+		return floatToRat(v, 64)
+	case json.Number:
+		return numberToRat(string(v))
+	case *big.Int:
+		if v == nil {
+			return nil, false
+		}
+		return new(big.Rat).SetInt(v), true
+	case *big.Float:
+		if v == nil || v.IsInf() {
+			return nil, false
+		}
+		r, _ := v.Rat(nil)
+		return r, true
+	case *big.Rat:
+		return v, v != nil
+	case Rational:
+		if r := v.Rat(); r != nil {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// maxRatDigits bounds the exponent and the number of fraction digits of
+// a json.Number that ConvertToRat converts. SetString computes a power
+// of ten of that size, which for input such as "1e999999" takes far
+// longer than validating the rest of a document.
+const maxRatDigits = 1000
+
+// numberToRat converts a json.Number, rejecting those beyond
+// maxRatDigits.
+func numberToRat(s string) (*big.Rat, bool) {
+	mantissa := s
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp, err := strconv.Atoi(s[i+1:])
+		if err != nil || exp > maxRatDigits || exp < -maxRatDigits {
+			return nil, false
+		}
+		mantissa = s[:i]
+	}
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 && len(mantissa)-i-1 > maxRatDigits {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// floatToRat converts a float through its shortest decimal form.
+func floatToRat(f float64, bitSize int) (*big.Rat, bool) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, bitSize))
+}
+
+// ExactRat returns the exact value of a number that float64 may not
+// represent: big and decimal values, json.Number and integers beyond
+// 2^53. It returns nil for floats and small integers, which compare
+// exactly as float64, and for values that are not numbers. The result
+// is the exact argument taken by CompareNumber and the other number
+// checks, which schema implementations outside this package share.
+func ExactRat(value interface{}) *big.Rat {
+	switch v := value.(type) {
+	case float64, float32, int8, int16, int32, uint8, uint16, uint32:
+		return nil
+	case int:
+		if v > -1<<53 && v < 1<<53 {
+			return nil
+		}
+	case int64:
+		if v > -1<<53 && v < 1<<53 {
+			return nil
+		}
+	case uint:
+		if v < 1<<53 {
+			return nil
+		}
+	case uint64:
+		if v < 1<<53 {
+			return nil
+		}
+	}
+	r, _ := ConvertToRat(value)
+	return r
+}
+
+// CompareNumber compares a number with a float64 bound exactly and
+// returns -1, 0 or 1. num is the value as a float64 and exact its exact
+// value from ExactRat, or nil when num is exact.
+func CompareNumber(num float64, exact *big.Rat, bound float64) int {
+	if exact == nil {
+		switch {
+		case num < bound:
+			return -1
+		case num > bound:
+			return 1
+		}
+		return 0
+	}
+	b, ok := floatToRat(bound, 64)
+	if !ok {
+		if bound > 0 {
+			return -1
+		}
+		return 1
+	}
+	return exact.Cmp(b)
+}
+
+// IsWholeNumber reports whether a number has no fractional part.
+func IsWholeNumber(num float64, exact *big.Rat) bool {
+	if exact != nil {
+		return exact.IsInt()
+	}
+	return num == math.Trunc(num) && !math.IsInf(num, 0)
+}
+
+// IsMultipleOf reports whether a number is an exact multiple of m, both
+// taken in decimal, so 0.3 is a multiple of 0.01.
+func IsMultipleOf(num float64, exact *big.Rat, m float64) bool {
+	if exact == nil {
+		var ok bool
+		if exact, ok = floatToRat(num, 64); !ok {
+			return false
+		}
+	}
+	mr, ok := floatToRat(m, 64)
+	if !ok || mr.Sign() == 0 {
+		return false
+	}
+	return new(big.Rat).Quo(exact, mr).IsInt()
+}
+
+// CheckDecimal checks a number against a DECIMAL(precision, scale)
+// constraint and returns the error code and parameters of the first
+// violation, or "".
+func CheckDecimal(num float64, exact *big.Rat, precision, scale int) (string, Params) {
+	intDigits := precision - scale
+	r := exact
+	if r == nil {
+		if math.IsInf(num, 0) {
+			// A json.Number too large to convert exactly
+			return CodeDecimalPrecision, Params{"digits": intDigits}
+		}
+		var ok bool
+		if r, ok = floatToRat(num, 64); !ok {
+			return "", nil
+		}
+	}
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	if !new(big.Rat).Mul(r, new(big.Rat).SetInt(pow)).IsInt() {
+		return CodeDecimalScale, Params{"scale": scale}
+	}
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(intDigits)), nil)
+	if new(big.Int).Quo(r.Num(), r.Denom()).CmpAbs(limit) >= 0 {
+		return CodeDecimalPrecision, Params{"digits": intDigits}
+	}
+	return "", nil
+}
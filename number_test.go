@@ -0,0 +1,83 @@
+package queryfy_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+)
+
+func TestConvertToRat(t *testing.T) {
+	huge, _ := new(big.Int).SetString("-98765432109876543210", 10)
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{int64(9007199254740993), "9007199254740993/1"},
+		{uint64(math.MaxUint64), "18446744073709551615/1"},
+		{0.1, "1/10"},
+		{float32(0.1), "1/10"},
+		{json.Number("1e-3"), "1/1000"},
+		{huge, "-98765432109876543210/1"},
+		{big.NewFloat(0.5), "1/2"},
+		{big.NewRat(6, 4), "3/2"},
+	}
+	for _, tt := range tests {
+		r, ok := queryfy.ConvertToRat(tt.value)
+		if !ok || r.String() != tt.want {
+			t.Errorf("ConvertToRat(%v) = %v, %v, want %s", tt.value, r, ok, tt.want)
+		}
+	}
+
+	for _, v := range []interface{}{"1", math.NaN(), math.Inf(1), json.Number("x"), (*big.Int)(nil), nil} {
+		if r, ok := queryfy.ConvertToRat(v); ok {
+			t.Errorf("ConvertToRat(%v) = %v, want not a number", v, r)
+		}
+	}
+}
+
+func TestConvertToRat_HugeExponents(t *testing.T) {
+	for _, s := range []string{"1e999999", "-1E-999999", "0." + strings.Repeat("0", 2000) + "1"} {
+		start := time.Now()
+		if r, ok := queryfy.ConvertToRat(json.Number(s)); ok {
+			t.Errorf("ConvertToRat(%.20s) = %v, want not converted", s, r)
+		}
+		if d := time.Since(start); d > 10*time.Millisecond {
+			t.Errorf("ConvertToRat(%.20s) took %v", s, d)
+		}
+	}
+	if r, ok := queryfy.ConvertToRat(json.Number("1e400")); !ok || r.Num().BitLen() < 1300 {
+		t.Errorf("ConvertToRat(1e400) = %v, %v", r, ok)
+	}
+}
+
+func TestNumber_HugeJSONNumbers(t *testing.T) {
+	tests := []struct {
+		schema queryfy.Schema
+		value  string
+		code   string
+	}{
+		{builders.Number().Max(1e300), "1e999999", queryfy.CodeMax},
+		{builders.Number().Decimal(10, 2), "1e999999", queryfy.CodeDecimalPrecision},
+		{builders.Number().Min(-1).Max(0), "-1e-999999", ""},
+	}
+	for _, tt := range tests {
+		for _, s := range []queryfy.Schema{tt.schema, queryfy.Compile(tt.schema)} {
+			err := queryfy.Validate(json.Number(tt.value), s)
+			if tt.code == "" {
+				if err != nil {
+					t.Errorf("%T: %s: %v", s, tt.value, err)
+				}
+				continue
+			}
+			if e := byPath(t, err)[""]; e.Code != tt.code {
+				t.Errorf("%T: %s: %v, want %s", s, tt.value, err, tt.code)
+			}
+		}
+	}
+}
@@ -0,0 +1,466 @@
+// Package patch applies JSON Patch (RFC 6902) and JSON Merge Patch
+// (RFC 7386) documents to decoded JSON, and validates the result
+// against a queryfy schema.
+//
+// Documents are the values encoding/json produces when decoding into
+// interface{}: map[string]interface{}, []interface{}, string, float64,
+// bool and nil. Patches never modify the document they are given; a
+// patched copy is returned.
+//
+// Example:
+//
+//	ops, err := patch.Decode(body)
+//	if err != nil {
+//		return err
+//	}
+//	updated, err := patch.ApplyAndValidate(current, ops, orderSchema, queryfy.Strict)
+//	if err != nil {
+//		return err // *queryfy.ValidationError
+//	}
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/query"
+)
+
+// Operation is one JSON Patch operation. Op is add, remove, replace,
+// move, copy or test; Path and From are JSON Pointers.
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value"`
+}
+
+// MarshalJSON encodes an operation with the members its op uses: value
+// for add, replace and test, even when it is null, and from for move
+// and copy.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	type withValue struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}
+	type withFrom struct {
+		Op   string `json:"op"`
+		Path string `json:"path"`
+		From string `json:"from"`
+	}
+	switch o.Op {
+	case "add", "replace", "test":
+		return json.Marshal(withValue{Op: o.Op, Path: o.Path, Value: o.Value})
+	case "move", "copy":
+		return json.Marshal(withFrom{Op: o.Op, Path: o.Path, From: o.From})
+	}
+	return json.Marshal(struct {
+		Op   string `json:"op"`
+		Path string `json:"path"`
+	}{o.Op, o.Path})
+}
+
+// UnmarshalJSON decodes an operation, rejecting unknown operations and
+// missing members, which the RFC requires.
+func (o *Operation) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Op    string          `json:"op"`
+		Path  *string         `json:"path"`
+		From  *string         `json:"from"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	*o = Operation{Op: raw.Op}
+	switch raw.Op {
+	case "add", "remove", "replace", "move", "copy", "test":
+	case "":
+		return fmt.Errorf("operation has no \"op\"")
+	default:
+		return fmt.Errorf("unknown operation %q", raw.Op)
+	}
+	if raw.Path == nil {
+		return fmt.Errorf("%s operation has no \"path\"", raw.Op)
+	}
+	o.Path = *raw.Path
+
+	switch raw.Op {
+	case "move", "copy":
+		if raw.From == nil {
+			return fmt.Errorf("%s operation has no \"from\"", raw.Op)
+		}
+		o.From = *raw.From
+	case "add", "replace", "test":
+		if raw.Value == nil {
+			return fmt.Errorf("%s operation has no \"value\"", raw.Op)
+		}
+		if err := json.Unmarshal(raw.Value, &o.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode parses a JSON Patch document: an array of operations.
+func Decode(data []byte) ([]Operation, error) {
+	var ops []Operation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("invalid JSON Patch: %w", err)
+	}
+	return ops, nil
+}
+
+// OpError reports the operation that stopped a patch.
+type OpError struct {
+	// Index is the position of the operation in the patch.
+	Index int
+	Op    Operation
+	Err   error
+}
+
+// Error returns a description of the failure.
+func (e *OpError) Error() string {
+	return fmt.Sprintf("operation %d (%s %s): %v", e.Index, e.Op.Op, e.Op.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// Apply applies the operations in order to a copy of doc and returns
+// it. If an operation fails, or a test operation does not match, Apply
+// returns an *OpError and none of the operations take effect.
+func Apply(doc interface{}, ops []Operation) (interface{}, error) {
+	result := deepCopy(doc)
+	for i, op := range ops {
+		var err error
+		if result, err = applyOp(result, op); err != nil {
+			return nil, &OpError{Index: i, Op: op, Err: err}
+		}
+	}
+	return result, nil
+}
+
+// ApplyAndValidate applies the operations and validates the result
+// against schema. Failures are returned as a *queryfy.ValidationError:
+// a failed operation is reported at the path it targets, in queryfy
+// notation, and schema violations at the paths of the offending values.
+func ApplyAndValidate(doc interface{}, ops []Operation, schema queryfy.Schema, mode queryfy.ValidationMode) (interface{}, error) {
+	result, err := Apply(doc, ops)
+	if err != nil {
+		opErr := err.(*OpError)
+		return nil, queryfy.NewValidationError(queryfy.FieldError{
+			Path:    displayPath(opErr.Op.Path),
+			Message: fmt.Sprintf("patch operation %d (%s) failed: %v", opErr.Index, opErr.Op.Op, opErr.Err),
+			Code:    queryfy.CodeCustom,
+			Value:   opErr.Op.Value,
+		})
+	}
+	return validated(result, schema, mode)
+}
+
+// Merge applies a JSON Merge Patch to a copy of doc and returns it.
+// Objects in the patch are merged member by member, a null member
+// removes the member, and any other value replaces the target.
+func Merge(doc, mergePatch interface{}) interface{} {
+	return merge(deepCopy(doc), mergePatch)
+}
+
+// MergeAndValidate applies a JSON Merge Patch and validates the result
+// against schema, returning a *queryfy.ValidationError on failure.
+func MergeAndValidate(doc, mergePatch interface{}, schema queryfy.Schema, mode queryfy.ValidationMode) (interface{}, error) {
+	return validated(Merge(doc, mergePatch), schema, mode)
+}
+
+func validated(result interface{}, schema queryfy.Schema, mode queryfy.ValidationMode) (interface{}, error) {
+	if err := queryfy.ValidateWithMode(result, schema, mode); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func merge(target, mergePatch interface{}) interface{} {
+	patchObj, ok := mergePatch.(map[string]interface{})
+	if !ok {
+		return deepCopy(mergePatch)
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+			continue
+		}
+		targetObj[k] = merge(targetObj[k], v)
+	}
+	return targetObj
+}
+
+// applyOp applies one operation to doc, which it may modify.
+func applyOp(doc interface{}, op Operation) (interface{}, error) {
+	switch op.Op {
+	case "add":
+		return add(doc, op.Path, deepCopy(op.Value))
+
+	case "remove":
+		path, _, err := resolve(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return nil, fmt.Errorf("cannot remove the whole document")
+		}
+		if _, err := query.ExecutePath(doc, path); err != nil {
+			return nil, err
+		}
+		return query.DeletePath(doc, path)
+
+	case "replace":
+		path, _, err := resolve(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := query.ExecutePath(doc, path); err != nil {
+			return nil, err
+		}
+		return query.SetPath(doc, path, deepCopy(op.Value))
+
+	case "move":
+		if op.From == op.Path {
+			return doc, nil
+		}
+		if isPrefix(op.From, op.Path) {
+			return nil, fmt.Errorf("cannot move %s into itself", op.From)
+		}
+		value, err := get(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		from, _, _ := resolve(doc, op.From)
+		if doc, err = query.DeletePath(doc, from); err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, value)
+
+	case "copy":
+		value, err := get(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, deepCopy(value))
+
+	case "test":
+		value, err := get(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(value, op.Value) {
+			return nil, fmt.Errorf("test failed: value is %v", value)
+		}
+		return doc, nil
+
+	default:
+		return nil, fmt.Errorf("unknown operation %q", op.Op)
+	}
+}
+
+// add inserts value at pointer. An array index inserts before the
+// element there, and "-" appends.
+func add(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+	path, parent, err := resolve(doc, pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	arr, ok := parent.([]interface{})
+	if !ok {
+		if _, ok := parent.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("cannot add a member to %s", typeName(parent))
+		}
+		return query.SetPath(doc, path, value)
+	}
+
+	index := path[len(path)-1].(int)
+	if index > len(arr) {
+		return nil, fmt.Errorf("index %d out of bounds (length %d)", index, len(arr))
+	}
+	grown := make([]interface{}, 0, len(arr)+1)
+	grown = append(grown, arr[:index]...)
+	grown = append(grown, value)
+	grown = append(grown, arr[index:]...)
+	return query.SetPath(doc, path[:len(path)-1], grown)
+}
+
+// get returns the value at pointer, which must exist.
+func get(doc interface{}, pointer string) (interface{}, error) {
+	path, _, err := resolve(doc, pointer)
+	if err != nil {
+		return nil, err
+	}
+	return query.ExecutePath(doc, path)
+}
+
+// resolve converts a JSON Pointer into a queryfy path against doc. A
+// token addressing an array becomes an index, with "-" standing for
+// the length of the array, and any other token a field name. The
+// parent of the target must exist; the target itself need not. The
+// parent is returned alongside the path.
+func resolve(doc interface{}, pointer string) ([]interface{}, interface{}, error) {
+	tokens, err := query.ParsePointer(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	path := make([]interface{}, 0, len(tokens))
+	var parent interface{}
+	current := doc
+	for i, token := range tokens {
+		parent = current
+		switch node := current.(type) {
+		case map[string]interface{}:
+			path = append(path, token)
+			current = node[token]
+			if _, exists := node[token]; !exists && i < len(tokens)-1 {
+				return nil, nil, fmt.Errorf("path %s not found", displayPath(pointer))
+			}
+		case []interface{}:
+			index, err := arrayIndex(token, len(node))
+			if err != nil {
+				return nil, nil, err
+			}
+			path = append(path, index)
+			if index < len(node) {
+				current = node[index]
+			} else if i < len(tokens)-1 {
+				return nil, nil, fmt.Errorf("path %s not found", displayPath(pointer))
+			}
+		default:
+			return nil, nil, fmt.Errorf("cannot address %q in %s", token, typeName(current))
+		}
+	}
+	return path, parent, nil
+}
+
+// arrayIndex parses an array token: a decimal index without leading
+// zeros, or "-" for the position after the last element.
+func arrayIndex(token string, n int) (int, error) {
+	if token == "-" {
+		return n, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index := 0
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("invalid array index %q", token)
+		}
+		index = index*10 + int(r-'0')
+	}
+	return index, nil
+}
+
+// displayPath writes a JSON Pointer in queryfy notation, as used in
+// FieldError.Path: /items/0/price becomes items[0].price. An invalid
+// pointer is returned as is.
+func displayPath(pointer string) string {
+	path, err := query.QueryFromPointer(pointer)
+	if err != nil {
+		return pointer
+	}
+	return path
+}
+
+// isPrefix reports whether pointer b lies inside pointer a.
+func isPrefix(a, b string) bool {
+	return len(b) > len(a) && b[:len(a)] == a && b[len(a)] == '/'
+}
+
+// equal compares JSON values, treating numbers of any Go type by value.
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, exists := y[k]
+			if !exists || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// deepCopy copies the maps and slices of a decoded JSON value.
+func deepCopy(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(x))
+		for k, elem := range x {
+			out[k] = deepCopy(elem)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(x))
+		for i, elem := range x {
+			out[i] = deepCopy(elem)
+		}
+		return out
+	}
+	return v
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	}
+	return fmt.Sprintf("%T", v)
+}
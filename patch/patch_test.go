@@ -0,0 +1,289 @@
+package patch_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+	"github.com/ha1tch/queryfy/patch"
+)
+
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", s, err)
+	}
+	return v
+}
+
+func decodePatch(t *testing.T, s string) []patch.Operation {
+	t.Helper()
+	ops, err := patch.Decode([]byte(s))
+	if err != nil {
+		t.Fatalf("invalid patch %s: %v", s, err)
+	}
+	return ops
+}
+
+// ======================================================================
+// JSON Patch: the examples from RFC 6902, appendix A
+// ======================================================================
+
+func TestApply_RFCExamples(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add member", `{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			`{"baz": "qux", "foo": "bar"}`},
+		{"add element", `{"foo": ["bar", "baz"]}`,
+			`[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			`{"foo": ["bar", "qux", "baz"]}`},
+		{"remove member", `{"baz": "qux", "foo": "bar"}`,
+			`[{"op": "remove", "path": "/baz"}]`,
+			`{"foo": "bar"}`},
+		{"remove element", `{"foo": ["bar", "qux", "baz"]}`,
+			`[{"op": "remove", "path": "/foo/1"}]`,
+			`{"foo": ["bar", "baz"]}`},
+		{"replace", `{"baz": "qux", "foo": "bar"}`,
+			`[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			`{"baz": "boo", "foo": "bar"}`},
+		{"move member", `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			`{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`},
+		{"move element", `{"foo": ["all", "grass", "cows", "eat"]}`,
+			`[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			`{"foo": ["all", "cows", "eat", "grass"]}`},
+		{"test", `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			`[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`},
+		{"add nested object", `{"foo": "bar"}`,
+			`[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			`{"foo": "bar", "child": {"grandchild": {}}}`},
+		{"escaped keys", `{"/": 9, "~1": 10}`,
+			`[{"op": "test", "path": "/~01", "value": 10}, {"op": "add", "path": "/a~1b", "value": 1}]`,
+			`{"/": 9, "~1": 10, "a/b": 1}`},
+		{"add to array end", `{"foo": ["bar"]}`,
+			`[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			`{"foo": ["bar", ["abc", "def"]]}`},
+		{"copy", `{"a": {"b": 1}}`,
+			`[{"op": "copy", "from": "/a", "path": "/c"}, {"op": "replace", "path": "/c/b", "value": 2}]`,
+			`{"a": {"b": 1}, "c": {"b": 2}}`},
+		{"replace root", `{"a": 1}`,
+			`[{"op": "add", "path": "", "value": [1]}]`,
+			`[1]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := patch.Apply(decodeJSON(t, tt.doc), decodePatch(t, tt.patch))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	tests := []struct {
+		name, doc, patch string
+	}{
+		{"test mismatch", `{"baz": "qux"}`, `[{"op": "test", "path": "/baz", "value": "bar"}]`},
+		{"missing parent", `{"foo": "bar"}`, `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`},
+		{"remove missing", `{"foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`},
+		{"replace missing", `{"foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": 1}]`},
+		{"index past end", `{"foo": [1]}`, `[{"op": "add", "path": "/foo/2", "value": 1}]`},
+		{"leading zero", `{"foo": [1, 2]}`, `[{"op": "replace", "path": "/foo/01", "value": 1}]`},
+		{"move into itself", `{"a": {"b": {}}}`, `[{"op": "move", "from": "/a", "path": "/a/b/c"}]`},
+		{"field of a string", `{"foo": "bar"}`, `[{"op": "add", "path": "/foo/x", "value": 1}]`},
+		{"bad pointer", `{}`, `[{"op": "add", "path": "foo", "value": 1}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := decodeJSON(t, tt.doc)
+			_, err := patch.Apply(doc, decodePatch(t, tt.patch))
+			var opErr *patch.OpError
+			if !errors.As(err, &opErr) || opErr.Index != 0 {
+				t.Errorf("expected an OpError for operation 0, got %v", err)
+			}
+		})
+	}
+}
+
+func TestApply_Atomic(t *testing.T) {
+	doc := decodeJSON(t, `{"a": 1, "list": [1, 2]}`)
+	ops := decodePatch(t, `[
+		{"op": "replace", "path": "/a", "value": 2},
+		{"op": "remove", "path": "/list/0"},
+		{"op": "test", "path": "/a", "value": 3}
+	]`)
+	_, err := patch.Apply(doc, ops)
+	if err == nil || !strings.Contains(err.Error(), "operation 2 (test /a)") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if want := decodeJSON(t, `{"a": 1, "list": [1, 2]}`); !reflect.DeepEqual(doc, want) {
+		t.Errorf("document was modified: %v", doc)
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	bad := []string{
+		`{"op": "add"}`,
+		`[{"op": "add", "path": "/a"}]`,
+		`[{"op": "move", "path": "/a"}]`,
+		`[{"path": "/a"}]`,
+		`[{"op": "frob", "path": "/a"}]`,
+		`[{"op": "remove"}]`,
+	}
+	for _, s := range bad {
+		if _, err := patch.Decode([]byte(s)); err == nil {
+			t.Errorf("%s: expected an error", s)
+		}
+	}
+
+	// A null value is a value
+	ops, err := patch.Decode([]byte(`[{"op": "add", "path": "/a", "value": null}]`))
+	if err != nil || len(ops) != 1 || ops[0].Value != nil {
+		t.Errorf("unexpected result %v, %v", ops, err)
+	}
+}
+
+func TestOperation_MarshalRoundTrip(t *testing.T) {
+	ops := []patch.Operation{
+		{Op: "add", Path: "/a", Value: nil},
+		{Op: "replace", Path: "/b", Value: nil},
+		{Op: "test", Path: "/c", Value: nil},
+		{Op: "replace", Path: "/d", Value: "x"},
+		{Op: "remove", Path: "/e"},
+		{Op: "move", From: "/f", Path: "/g"},
+		{Op: "copy", From: "/h", Path: "/i"},
+	}
+	data, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"op":"add","path":"/a","value":null},{"op":"replace","path":"/b","value":null},` +
+		`{"op":"test","path":"/c","value":null},{"op":"replace","path":"/d","value":"x"},` +
+		`{"op":"remove","path":"/e"},{"op":"move","path":"/g","from":"/f"},{"op":"copy","path":"/i","from":"/h"}]`
+	if string(data) != want {
+		t.Errorf("Marshal =\n%s\nwant\n%s", data, want)
+	}
+
+	decoded, err := patch.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, ops) {
+		t.Errorf("round trip = %+v", decoded)
+	}
+}
+
+// ======================================================================
+// JSON Merge Patch: the examples from RFC 7386, appendix A
+// ======================================================================
+
+func TestMerge_RFCExamples(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		doc := decodeJSON(t, tt.doc)
+		got := patch.Merge(doc, decodeJSON(t, tt.patch))
+		if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s + %s: got %v, want %v", tt.doc, tt.patch, got, want)
+		}
+		if original := decodeJSON(t, tt.doc); !reflect.DeepEqual(doc, original) {
+			t.Errorf("%s + %s: document was modified", tt.doc, tt.patch)
+		}
+	}
+}
+
+// ======================================================================
+// Validation
+// ======================================================================
+
+var orderSchema = builders.Object().
+	Field("status", builders.String().Enum("open", "shipped").Required()).
+	Field("items", builders.Array().Of(builders.Object().
+		Field("qty", builders.Number().Min(1).Required())).Required())
+
+func TestApplyAndValidate(t *testing.T) {
+	doc := decodeJSON(t, `{"status": "open", "items": [{"qty": 1}]}`)
+
+	got, err := patch.ApplyAndValidate(doc, decodePatch(t, `[
+		{"op": "replace", "path": "/status", "value": "shipped"},
+		{"op": "add", "path": "/items/-", "value": {"qty": 2}}
+	]`), orderSchema, queryfy.Strict)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := decodeJSON(t, `{"status": "shipped", "items": [{"qty": 1}, {"qty": 2}]}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v", got)
+	}
+
+	_, err = patch.ApplyAndValidate(doc, decodePatch(t, `[
+		{"op": "replace", "path": "/items/0/qty", "value": 0}
+	]`), orderSchema, queryfy.Strict)
+	var ve *queryfy.ValidationError
+	if !errors.As(err, &ve) || len(ve.Errors) != 1 || ve.Errors[0].Path != "items[0].qty" {
+		t.Errorf("expected a validation error at items[0].qty, got %v", err)
+	}
+}
+
+func TestApplyAndValidate_OpFailure(t *testing.T) {
+	doc := decodeJSON(t, `{"status": "open", "items": []}`)
+	_, err := patch.ApplyAndValidate(doc, decodePatch(t, `[
+		{"op": "test", "path": "/status", "value": "open"},
+		{"op": "replace", "path": "/items/3/qty", "value": 5}
+	]`), orderSchema, queryfy.Strict)
+
+	var ve *queryfy.ValidationError
+	if !errors.As(err, &ve) || len(ve.Errors) != 1 {
+		t.Fatalf("expected one validation error, got %v", err)
+	}
+	fe := ve.Errors[0]
+	if fe.Path != "items[3].qty" || !strings.Contains(fe.Message, "patch operation 1 (replace)") || fe.Value != 5.0 {
+		t.Errorf("unexpected error %+v", fe)
+	}
+}
+
+func TestMergeAndValidate(t *testing.T) {
+	doc := decodeJSON(t, `{"status": "open", "items": [{"qty": 1}]}`)
+
+	got, err := patch.MergeAndValidate(doc, decodeJSON(t, `{"status": "shipped"}`), orderSchema, queryfy.Strict)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.(map[string]interface{})["status"] != "shipped" {
+		t.Errorf("got %v", got)
+	}
+
+	_, err = patch.MergeAndValidate(doc, decodeJSON(t, `{"status": null}`), orderSchema, queryfy.Strict)
+	var ve *queryfy.ValidationError
+	if !errors.As(err, &ve) || ve.Errors[0].Path != "status" {
+		t.Errorf("expected a validation error at status, got %v", err)
+	}
+}
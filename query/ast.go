@@ -1,5 +1,10 @@
 package query
 
+import (
+	"strconv"
+	"strings"
+)
+
 // NodeType represents the type of AST node.
 type NodeType int
 
@@ -14,6 +19,20 @@ const (
 	NodeRoot
 	// NodeWildcard represents a wildcard array access [*]
 	NodeWildcard
+	// NodeFilter represents a filter expression [?(...)]
+	NodeFilter
+	// NodeSlice represents an array slice [start:end:step]
+	NodeSlice
+	// NodeUnion represents several array indexes [0,2,5]
+	NodeUnion
+	// NodeRecursiveDescent represents the recursive descent operator ..
+	NodeRecursiveDescent
+	// NodeCall represents a function call such as sum(items[*].price)
+	NodeCall
+	// NodeLiteral represents a literal function argument
+	NodeLiteral
+	// NodeCurrent represents @, the value a function is applied to
+	NodeCurrent
 )
 
 // Node represents a node in the query AST.
@@ -32,12 +51,17 @@ func (n *IdentifierNode) Type() NodeType {
 	return NodeIdentifier
 }
 
-// String returns the string representation.
+// String returns the string representation. A name that is not an
+// identifier is quoted: ["content-type"].
 func (n *IdentifierNode) String() string {
+	if !isIdentifier(n.Name) {
+		return quoteField(n.Name)
+	}
 	return n.Name
 }
 
-// IndexNode represents an array index access.
+// IndexNode represents an array index access. A negative index counts
+// from the end of the array.
 type IndexNode struct {
 	Index int
 }
@@ -49,7 +73,7 @@ func (n *IndexNode) Type() NodeType {
 
 // String returns the string representation.
 func (n *IndexNode) String() string {
-	return "[" + string(rune(n.Index+'0')) + "]"
+	return "[" + strconv.Itoa(n.Index) + "]"
 }
 
 // DotNode represents a dot notation access.
@@ -63,11 +87,36 @@ func (n *DotNode) Type() NodeType {
 	return NodeDot
 }
 
-// String returns the string representation.
+// String returns the string representation. Bracketed segments such as
+// [0] and ["a.b"] follow the left side without a dot, except after "..".
 func (n *DotNode) String() string {
+	if isBracketed(n.Right) && !endsWithDescent(n.Left) {
+		return n.Left.String() + n.Right.String()
+	}
 	return n.Left.String() + "." + n.Right.String()
 }
 
+// isBracketed reports whether a node is written in brackets.
+func isBracketed(n Node) bool {
+	switch n := n.(type) {
+	case *IndexNode, *WildcardNode, *FilterNode, *SliceNode, *UnionNode:
+		return true
+	case *IdentifierNode:
+		return !isIdentifier(n.Name)
+	}
+	return false
+}
+
+// endsWithDescent reports whether a node ends in recursive descent.
+func endsWithDescent(n Node) bool {
+	dot, ok := n.(*DotNode)
+	if !ok {
+		return false
+	}
+	_, ok = dot.Right.(*RecursiveDescentNode)
+	return ok
+}
+
 // RootNode represents the root of a query.
 type RootNode struct {
 	Child Node
@@ -104,9 +153,125 @@ func (n *WildcardNode) String() string {
 	return "[*]"
 }
 
+// FilterNode represents a filter expression [?(...)].
+type FilterNode struct {
+	Expr FilterExpr
+}
+
+// Type returns the node type.
+func (n *FilterNode) Type() NodeType {
+	return NodeFilter
+}
+
+// String returns the string representation.
+func (n *FilterNode) String() string {
+	return Filter{Expr: n.Expr}.String()
+}
+
+// SliceNode represents an array slice [start:end:step].
+type SliceNode struct {
+	Start, End, Step *int
+}
+
+// Type returns the node type.
+func (n *SliceNode) Type() NodeType {
+	return NodeSlice
+}
+
+// String returns the string representation.
+func (n *SliceNode) String() string {
+	return Slice{Start: n.Start, End: n.End, Step: n.Step}.String()
+}
+
+// UnionNode represents several array indexes [0,2,5].
+type UnionNode struct {
+	Indexes []int
+}
+
+// Type returns the node type.
+func (n *UnionNode) Type() NodeType {
+	return NodeUnion
+}
+
+// String returns the string representation.
+func (n *UnionNode) String() string {
+	return Union{Indexes: n.Indexes}.String()
+}
+
+// RecursiveDescentNode represents the recursive descent operator, as in
+// order..id.
+type RecursiveDescentNode struct{}
+
+// Type returns the node type.
+func (n *RecursiveDescentNode) Type() NodeType {
+	return NodeRecursiveDescent
+}
+
+// String returns the string representation. It is empty: the DotNodes
+// around it supply the two dots.
+func (n *RecursiveDescentNode) String() string {
+	return ""
+}
+
+// CallNode represents a function call. Each argument is a query node,
+// a LiteralNode or a CurrentNode.
+type CallNode struct {
+	Name string
+	Args []Node
+}
+
+// Type returns the node type.
+func (n *CallNode) Type() NodeType {
+	return NodeCall
+}
+
+// String returns the string representation.
+func (n *CallNode) String() string {
+	args := make([]string, len(n.Args))
+	for i, arg := range n.Args {
+		args[i] = arg.String()
+	}
+	return n.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// LiteralNode represents a string, number, boolean or null function
+// argument.
+type LiteralNode struct {
+	Value interface{}
+}
+
+// Type returns the node type.
+func (n *LiteralNode) Type() NodeType {
+	return NodeLiteral
+}
+
+// String returns the string representation.
+func (n *LiteralNode) String() string {
+	return (&LiteralOperand{Value: n.Value}).String()
+}
+
+// CurrentNode represents @ as a function argument: the value the
+// function is applied to.
+type CurrentNode struct{}
+
+// Type returns the node type.
+func (n *CurrentNode) Type() NodeType {
+	return NodeCurrent
+}
+
+// String returns the string representation.
+func (n *CurrentNode) String() string {
+	return "@"
+}
+
 // Wildcard is a sentinel value used in path segments to represent [*].
 type Wildcard struct{}
 
+// RecursiveDescent is a sentinel value used in path segments to
+// represent "..". The rest of the path is applied to the value and to
+// every value nested in it.
+type RecursiveDescent struct{}
+
 // String returns the string representation of the query.
 func (q *Query) String() string {
 	if q.Root != nil {
@@ -0,0 +1,323 @@
+package query
+
+import "fmt"
+
+// CompiledQuery is a parsed query prepared for repeated execution. It
+// holds no state that changes between runs, so one CompiledQuery can be
+// shared by any number of goroutines.
+//
+// Example:
+//
+//	prices := query.MustCompile("items[*].price")
+//	for _, order := range orders {
+//		result, err := prices.Execute(order)
+//		...
+//	}
+type CompiledQuery struct {
+	source  string
+	path    []interface{}
+	steps   []step
+	expands bool
+}
+
+// Compile parses a query and prepares it for execution. The empty query
+// returns the data it is given.
+func Compile(queryStr string) (*CompiledQuery, error) {
+	path := []interface{}{}
+	if queryStr != "" {
+		var err error
+		if path, err = PathFromQuery(queryStr); err != nil {
+			return nil, fmt.Errorf("invalid query: %w", err)
+		}
+	}
+
+	c, err := CompilePath(path)
+	if err != nil {
+		return nil, err
+	}
+	c.source = queryStr
+	return c, nil
+}
+
+// MustCompile is like Compile but panics if the query does not parse.
+// It is meant for queries fixed at init time.
+func MustCompile(queryStr string) *CompiledQuery {
+	c, err := Compile(queryStr)
+	if err != nil {
+		panic(fmt.Sprintf("query: Compile(%q): %v", queryStr, err))
+	}
+	return c
+}
+
+// CompilePath prepares a parsed path for execution.
+func CompilePath(path []interface{}) (*CompiledQuery, error) {
+	steps, expands, err := compileSteps(path)
+	if err != nil {
+		return nil, err
+	}
+	return &CompiledQuery{
+		source:  formatQuery(path),
+		path:    append([]interface{}(nil), path...),
+		steps:   steps,
+		expands: expands,
+	}, nil
+}
+
+// Execute runs the query against data. The result is the same as
+// query.Execute would return for the query string.
+func (c *CompiledQuery) Execute(data interface{}) (interface{}, error) {
+	return runSteps(data, c.steps, c.expands)
+}
+
+// ExecuteWithPaths runs the query against data and returns every value
+// it reaches with its concrete path, as query.ExecuteWithPaths does.
+func (c *CompiledQuery) ExecuteWithPaths(data interface{}) ([]Match, error) {
+	return runMatches(data, c.steps)
+}
+
+// Path returns a copy of the query's path segments.
+func (c *CompiledQuery) Path() []interface{} {
+	return append([]interface{}(nil), c.path...)
+}
+
+// Expands reports whether the query has a wildcard, filter, slice,
+// union or recursive descent, and so returns a []interface{} of results.
+func (c *CompiledQuery) Expands() bool {
+	return c.expands
+}
+
+// String returns the query the CompiledQuery was compiled from.
+func (c *CompiledQuery) String() string {
+	return c.source
+}
+
+// stepKind says what a step does.
+type stepKind uint8
+
+const (
+	stepField   stepKind = iota // map key or struct field
+	stepIndex                   // array index
+	stepSelect                  // Wildcard, Filter, Slice or Union
+	stepDescent                 // RecursiveDescent
+	stepCall                    // function call
+)
+
+// step is a path segment resolved ahead of execution, so that running
+// a query switches on a small kind rather than on the segment's type.
+type step struct {
+	kind    stepKind
+	field   string
+	index   int
+	call    Call
+	segment interface{} // the path segment, for selection and errors
+}
+
+// compileSteps resolves path into steps and reports whether any step
+// expands to several results.
+func compileSteps(path []interface{}) ([]step, bool, error) {
+	steps := make([]step, len(path))
+	expands := false
+	for i, segment := range path {
+		s := step{segment: segment}
+		switch seg := segment.(type) {
+		case string:
+			s.kind, s.field = stepField, seg
+		case int:
+			s.kind, s.index = stepIndex, seg
+		case Wildcard, Filter, Slice, Union:
+			s.kind, expands = stepSelect, true
+		case RecursiveDescent:
+			s.kind, expands = stepDescent, true
+		case Call:
+			s.kind, s.call = stepCall, seg
+		default:
+			return nil, false, fmt.Errorf("unexpected path segment type: %T", segment)
+		}
+		steps[i] = s
+	}
+	return steps, expands, nil
+}
+
+// runSteps executes steps against data. Without expanding steps it
+// returns the single value reached; otherwise a []interface{} of every
+// value reached, which is never nil.
+func runSteps(data interface{}, steps []step, expands bool) (interface{}, error) {
+	r := &runner{steps: steps, resolved: make([]int, len(steps))}
+	if !expands {
+		return r.single(data)
+	}
+	out := make([]interface{}, 0)
+	err := r.collect(data, nil, 0, func(v interface{}, _ []interface{}) {
+		out = append(out, v)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// runMatches executes steps against data and returns every value
+// reached with its concrete path.
+func runMatches(data interface{}, steps []step) ([]Match, error) {
+	r := &runner{steps: steps, resolved: make([]int, len(steps)), track: true}
+	matches := make([]Match, 0, 1)
+	err := r.collect(data, []interface{}{}, 0, func(v interface{}, at []interface{}) {
+		matches = append(matches, Match{Path: at, Value: v})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// runner executes steps. For error messages it records the array index
+// each index or selection step resolved to, from which the concrete
+// path to a failure is rebuilt. With track set it also builds the
+// concrete path to each value reached.
+type runner struct {
+	steps    []step
+	resolved []int
+	skip     bool // below a descent: skip elements the rest fails for
+	track    bool // build concrete paths for ExecuteWithPaths
+}
+
+// single follows steps that do not expand.
+func (r *runner) single(value interface{}) (interface{}, error) {
+	current := value
+	for i := range r.steps {
+		var err error
+		if current, err = r.apply(current, i); err != nil {
+			return nil, err
+		}
+	}
+	return current, nil
+}
+
+// collect follows steps from step i, passing every value reached to
+// emit. at is the concrete path to value when tracking, and nil
+// otherwise.
+func (r *runner) collect(value interface{}, at []interface{}, i int, emit func(v interface{}, at []interface{})) error {
+	current := value
+	for ; i < len(r.steps); i++ {
+		s := &r.steps[i]
+		switch s.kind {
+		case stepSelect:
+			if current == nil {
+				return fmt.Errorf("cannot access %v on nil value", s.segment)
+			}
+			items, indexes, err := selectElements(current, s.segment)
+			if err != nil {
+				return fmt.Errorf("at %s: %w", r.errorPath(i), err)
+			}
+			for j, item := range items {
+				r.resolved[i] = indexes[j]
+				if r.skip {
+					r.collectOrSkip(item, r.extend(at, indexes[j]), i+1, emit)
+					continue
+				}
+				if err := r.collect(item, r.extend(at, indexes[j]), i+1, emit); err != nil {
+					return err
+				}
+			}
+			return nil
+
+		case stepDescent:
+			if current == nil {
+				return fmt.Errorf("cannot access %v on nil value", s.segment)
+			}
+			// Descendants the rest of the path does not apply to are
+			// skipped, so their errors are never reported, and so are
+			// elements the rest fails for within a selection
+			rest := &runner{steps: r.steps[i+1:], resolved: make([]int, len(r.steps)-i-1), skip: true, track: r.track}
+			descendants(current, at, func(v interface{}, vPath []interface{}) {
+				rest.collectOrSkip(v, vPath, 0, emit)
+			})
+			return nil
+
+		default:
+			var err error
+			if current, err = r.apply(current, i); err != nil {
+				return err
+			}
+			if s.kind == stepIndex {
+				at = r.extend(at, r.resolved[i])
+			} else {
+				at = r.extend(at, s.segment)
+			}
+		}
+	}
+
+	emit(current, at)
+	return nil
+}
+
+// collectOrSkip is collect for a value below a descent: the values
+// reached from it are emitted only if the rest of the path succeeds.
+func (r *runner) collectOrSkip(value interface{}, at []interface{}, i int, emit func(v interface{}, at []interface{})) {
+	var found []Match
+	err := r.collect(value, at, i, func(v interface{}, at []interface{}) {
+		found = append(found, Match{Path: at, Value: v})
+	})
+	if err != nil {
+		return
+	}
+	for _, m := range found {
+		emit(m.Value, m.Path)
+	}
+}
+
+// extend appends segment to at when tracking paths.
+func (r *runner) extend(at []interface{}, segment interface{}) []interface{} {
+	if !r.track {
+		return nil
+	}
+	return extend(at, segment)
+}
+
+// apply performs a field, index or call step.
+func (r *runner) apply(current interface{}, i int) (interface{}, error) {
+	s := &r.steps[i]
+	if current == nil && s.kind != stepCall {
+		return nil, fmt.Errorf("cannot access %v on nil value", s.segment)
+	}
+
+	var next interface{}
+	var err error
+	switch s.kind {
+	case stepField:
+		next, err = getField(current, s.field)
+	case stepIndex:
+		next, r.resolved[i], err = getIndexResolved(current, s.index)
+	case stepCall:
+		next, err = applyCall(current, s.call)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("at %s: %w", r.errorPath(i), err)
+	}
+	return next, nil
+}
+
+// errorPath formats the concrete path to step i: earlier index and
+// selection steps show the index they resolved to, and step i itself
+// is shown as written.
+func (r *runner) errorPath(i int) string {
+	path := make([]interface{}, i+1)
+	for j := 0; j < i; j++ {
+		switch r.steps[j].kind {
+		case stepIndex, stepSelect:
+			path[j] = r.resolved[j]
+		default:
+			path[j] = r.steps[j].segment
+		}
+	}
+	path[i] = r.steps[i].segment
+	return formatPath(path)
+}
+
+// formatQuery formats a path in query syntax; the empty path is "".
+func formatQuery(path []interface{}) string {
+	if len(path) == 0 {
+		return ""
+	}
+	return formatPath(path)
+}
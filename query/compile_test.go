@@ -0,0 +1,187 @@
+package query_test
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ha1tch/queryfy/query"
+)
+
+// ======================================================================
+// Compile
+// ======================================================================
+
+func TestCompile_MatchesExecute(t *testing.T) {
+	queries := []string{
+		"",
+		"customers[0].name",
+		"customers[-1].orders[0].total",
+		"customers[*].name",
+		"customers[*].orders[*].total",
+		"customers[?(@.name == \"Ben\")].name",
+		"customers[0:1].orders[1:].total",
+		"customers[0,1].name",
+		"customers..total",
+		"sum(customers[*].orders[*].total)",
+		"customers[*].count(orders)",
+	}
+	for _, q := range queries {
+		compiled, err := query.Compile(q)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", q, err)
+		}
+		want, err := query.Execute(pathsData, q)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", q, err)
+		}
+		got, err := compiled.Execute(pathsData)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", q, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", q, got, want)
+		}
+
+		// The matches reach the same values
+		matches, err := compiled.ExecuteWithPaths(pathsData)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", q, err)
+		}
+		values := make([]interface{}, len(matches))
+		for i, m := range matches {
+			values[i] = m.Value
+		}
+		if !compiled.Expands() {
+			want = []interface{}{want}
+		}
+		if !reflect.DeepEqual(values, want) {
+			t.Errorf("%s: ExecuteWithPaths reached %v, want %v", q, values, want)
+		}
+		if compiled.String() != q {
+			t.Errorf("String() = %q, want %q", compiled.String(), q)
+		}
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	if _, err := query.Compile("items[?("); err == nil {
+		t.Error("expected parse error")
+	}
+
+	compiled := query.MustCompile("customers[*].orders[5].total")
+	_, err := compiled.Execute(pathsData)
+	if err == nil {
+		t.Fatal("expected error for out-of-range index")
+	}
+	if !strings.Contains(err.Error(), "customers[0].orders[5]") {
+		t.Errorf("error should name the concrete path, got: %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustCompile should panic on a bad query")
+		}
+	}()
+	query.MustCompile("items[")
+}
+
+func TestCompile_ExecuteWithPaths(t *testing.T) {
+	compiled := query.MustCompile("customers[*].orders[*].total")
+	if !compiled.Expands() {
+		t.Error("expected Expands() to be true")
+	}
+	matches, err := compiled.ExecuteWithPaths(pathsData)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matches) != 3 || matches[2].PathString() != "customers[1].orders[0].total" {
+		t.Errorf("unexpected matches: %v", matches)
+	}
+}
+
+func TestCompile_Concurrent(t *testing.T) {
+	compiled := query.MustCompile("customers[*].orders[*].total")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				result, err := compiled.Execute(pathsData)
+				if err != nil || len(result.([]interface{})) != 3 {
+					t.Errorf("unexpected result %v, %v", result, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// ======================================================================
+// Query cache
+// ======================================================================
+
+func TestCacheStats(t *testing.T) {
+	query.ClearCache()
+	defer query.SetCacheSize(query.DefaultCacheSize)
+
+	query.ExecuteCached(pathsData, "customers[0].name")
+	query.ExecuteCached(pathsData, "customers[0].name")
+	query.ExecuteCached(pathsData, "customers[1].name")
+
+	stats := query.GetCacheStats()
+	if stats.Hits != 1 || stats.Misses != 2 || stats.Size != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.Capacity != query.DefaultCacheSize {
+		t.Errorf("expected capacity %d, got %d", query.DefaultCacheSize, stats.Capacity)
+	}
+
+	// Shrinking evicts the least recently used query
+	query.SetCacheSize(1)
+	stats = query.GetCacheStats()
+	if stats.Size != 1 || stats.Evictions != 1 {
+		t.Errorf("unexpected stats after shrinking: %+v", stats)
+	}
+	query.ExecuteCached(pathsData, "customers[1].name")
+	if stats = query.GetCacheStats(); stats.Hits != 2 {
+		t.Errorf("most recent query should still be cached: %+v", stats)
+	}
+
+	query.ClearCache()
+	if stats = query.GetCacheStats(); stats != (query.CacheStats{Capacity: 1}) {
+		t.Errorf("unexpected stats after ClearCache: %+v", stats)
+	}
+}
+
+func TestCache_ParseErrorsNotCached(t *testing.T) {
+	query.ClearCache()
+	if _, err := query.ExecuteCached(pathsData, "items[?("); err == nil {
+		t.Fatal("expected parse error")
+	}
+	if size := query.GetCacheStats().Size; size != 0 {
+		t.Errorf("expected empty cache, got %d entries", size)
+	}
+}
+
+// ======================================================================
+// Benchmarks
+// ======================================================================
+
+func BenchmarkCompiledQuery(b *testing.B) {
+	compiled := query.MustCompile("customers[*].orders[*].total")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		compiled.Execute(pathsData)
+	}
+}
+
+func BenchmarkExecuteWithPaths(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		query.ExecuteWithPaths(pathsData, "customers[*].orders[*].total")
+	}
+}
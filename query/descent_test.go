@@ -0,0 +1,114 @@
+package query_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ha1tch/queryfy/query"
+)
+
+var descentData = map[string]interface{}{
+	"order": map[string]interface{}{
+		"id": "o1",
+		"customer": map[string]interface{}{
+			"id":   "c1",
+			"name": "Alice",
+		},
+		"items": []interface{}{
+			map[string]interface{}{"id": "i1", "price": 5.0},
+			map[string]interface{}{"id": "i2", "price": 15.0, "options": []interface{}{
+				map[string]interface{}{"id": "x1"},
+			}},
+			map[string]interface{}{"sku": "no-id"},
+		},
+	},
+	"other": map[string]interface{}{"id": "not under order"},
+}
+
+func expectDescent(t *testing.T, data interface{}, queryStr string, want interface{}) {
+	t.Helper()
+	got, err := query.Execute(data, queryStr)
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %v", queryStr, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s:\n got %v\nwant %v", queryStr, got, want)
+	}
+}
+
+// ======================================================================
+// Recursive descent: order..id
+// ======================================================================
+
+func TestDescent_Field(t *testing.T) {
+	// Depth first, map keys sorted: customer before items
+	expectDescent(t, descentData, "order..id", []interface{}{"o1", "c1", "i1", "i2", "x1"})
+	expectDescent(t, descentData, "order.items..id", []interface{}{"i1", "i2", "x1"})
+	expectDescent(t, descentData, "order..name", []interface{}{"Alice"})
+	expectDescent(t, descentData, "order..missing", []interface{}{})
+}
+
+func TestDescent_Deterministic(t *testing.T) {
+	first, _ := query.Execute(descentData, "order..id")
+	for i := 0; i < 20; i++ {
+		again, _ := query.Execute(descentData, "order..id")
+		if !reflect.DeepEqual(first, again) {
+			t.Fatalf("order changed between runs: %v vs %v", first, again)
+		}
+	}
+}
+
+func TestDescent_ThenPath(t *testing.T) {
+	expectDescent(t, descentData, "order..items[:2].price", []interface{}{5.0, 15.0})
+	// The third item has no price; only that element is skipped
+	expectDescent(t, descentData, "order..items[*].price", []interface{}{5.0, 15.0})
+	matches, err := query.ExecuteWithPaths(descentData, "order..items[*].price")
+	if err != nil || len(matches) != 2 || matches[1].PathString() != "order.items[1].price" {
+		t.Errorf("ExecuteWithPaths: got %v, %v", matches, err)
+	}
+	// Without a descent the missing field is still an error
+	if _, err := query.Execute(descentData, "order.items[*].price"); err == nil {
+		t.Error("expected an error for order.items[*].price")
+	}
+	expectDescent(t, descentData, "order..items[-1].sku", []interface{}{"no-id"})
+	expectDescent(t, descentData, "order..options[0].id", []interface{}{"x1"})
+	expectDescent(t, descentData, "order..[?(@.price > 10)].id", []interface{}{"i2"})
+	// Every array's first element
+	expectDescent(t, descentData, "order..[0].id", []interface{}{"i1", "x1"})
+}
+
+func TestDescent_ReflectedValues(t *testing.T) {
+	type item struct {
+		ID   string
+		Tags map[string]string
+	}
+	data := map[string]interface{}{
+		"root": map[string][]item{
+			"b": {{ID: "b1", Tags: map[string]string{"ID": "tag"}}},
+			"a": {{ID: "a1"}, {ID: "a2"}},
+		},
+	}
+	expectDescent(t, data, "root..ID", []interface{}{"a1", "a2", "b1", "tag"})
+}
+
+func TestDescent_Parse(t *testing.T) {
+	path, err := query.PathFromQuery("order..items[0]")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []interface{}{"order", query.RecursiveDescent{}, "items", 0}
+	if !reflect.DeepEqual(path, want) {
+		t.Errorf("got %v, want %v", path, want)
+	}
+
+	q, _ := query.ParseQuery("order..id")
+	if q.String() != "order..id" {
+		t.Errorf("unexpected string %q", q.String())
+	}
+
+	for _, bad := range []string{"order..", "order...id", "..id"} {
+		if _, err := query.ParseQuery(bad); err == nil {
+			t.Errorf("%s: expected a parse error", bad)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package query
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 // Execute executes a query against data and returns the result.
@@ -21,106 +23,198 @@ func Execute(data interface{}, queryStr string) (interface{}, error) {
 	return ExecutePath(data, path)
 }
 
+// Match is a value reached by a query, with the concrete path to it.
+// Path holds field names and non-negative indexes only.
+type Match struct {
+	Path  []interface{}
+	Value interface{}
+}
+
+// PathString formats the match's path in query syntax, such as
+// "customers[3].orders[1].total". The root is the empty string.
+func (m Match) PathString() string {
+	if len(m.Path) == 0 {
+		return ""
+	}
+	return formatPath(m.Path)
+}
+
 // ExecutePath executes a path against data.
+//
+// A path without expanding segments (Wildcard, Filter, Slice, Union or
+// RecursiveDescent) returns the single value it reaches; otherwise the
+// result is a flat []interface{} of every value reached.
 func ExecutePath(data interface{}, path []interface{}) (interface{}, error) {
-	current := data
+	steps, expands, err := compileSteps(path)
+	if err != nil {
+		return nil, err
+	}
+	return runSteps(data, steps, expands)
+}
 
-	for i, segment := range path {
-		if current == nil {
-			return nil, fmt.Errorf("cannot access %v on nil value", segment)
-		}
+// ExecuteWithPaths executes a query and returns every value it reaches
+// together with the concrete path to it, so that "customers[*].total"
+// yields paths such as customers[3].total.
+func ExecuteWithPaths(data interface{}, queryStr string) ([]Match, error) {
+	if queryStr == "" {
+		return []Match{{Path: []interface{}{}, Value: data}}, nil
+	}
 
-		switch seg := segment.(type) {
-		case string:
-			// Field access
-			next, err := getField(current, seg)
-			if err != nil {
-				return nil, fmt.Errorf("at %s: %w", formatPath(path[:i+1]), err)
-			}
-			current = next
+	path, err := PathFromQuery(queryStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	return ExecutePathWithPaths(data, path)
+}
 
-		case int:
-			// Array index access
-			next, err := getIndex(current, seg)
-			if err != nil {
-				return nil, fmt.Errorf("at %s: %w", formatPath(path[:i+1]), err)
-			}
-			current = next
+// ExecutePathWithPaths executes a path against data and returns every
+// value it reaches with its concrete path. It fails in the same cases
+// as ExecutePath.
+func ExecutePathWithPaths(data interface{}, path []interface{}) ([]Match, error) {
+	steps, _, err := compileSteps(path)
+	if err != nil {
+		return nil, err
+	}
+	return runMatches(data, steps)
+}
 
-		case Wildcard:
-			// Wildcard: apply remaining path to each element
-			remaining := path[i+1:]
-			return executeWildcard(current, remaining, path[:i+1])
+// extend returns path with segment appended, never sharing the backing
+// array of path, since sibling matches extend the same prefix.
+func extend(path []interface{}, segment interface{}) []interface{} {
+	out := make([]interface{}, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, segment)
+}
 
+// selectElements returns the elements of an array chosen by a Wildcard,
+// Filter, Slice or Union segment, with their indexes.
+func selectElements(arr interface{}, segment interface{}) ([]interface{}, []int, error) {
+	items, ok := toInterfaceSlice(derefValue(arr))
+	if !ok {
+		rv := reflect.ValueOf(arr)
+		switch segment.(type) {
+		case Wildcard:
+			if rv.Kind() == reflect.Ptr && rv.IsNil() {
+				return nil, nil, fmt.Errorf("cannot expand wildcard on nil pointer")
+			}
+			return nil, nil, fmt.Errorf("cannot expand wildcard on %v", reflect.Indirect(rv).Kind())
+		case Filter:
+			return nil, nil, fmt.Errorf("cannot filter %T", arr)
 		default:
-			return nil, fmt.Errorf("unexpected path segment type: %T", segment)
+			return nil, nil, fmt.Errorf("cannot index %T", arr)
+		}
+	}
+
+	var indexes []int
+	switch seg := segment.(type) {
+	case Wildcard:
+		indexes = make([]int, len(items))
+		for i := range items {
+			indexes[i] = i
+		}
+	case Filter:
+		for i, item := range items {
+			if seg.Expr.Match(item) {
+				indexes = append(indexes, i)
+			}
+		}
+	case Slice:
+		indexes = seg.Indexes(len(items))
+	case Union:
+		// Like slice bounds, indexes past the end select nothing
+		for _, index := range seg.Indexes {
+			if resolved, ok := resolveIndex(index, len(items)); ok {
+				indexes = append(indexes, resolved)
+			}
 		}
 	}
 
-	return current, nil
+	selected := make([]interface{}, len(indexes))
+	for i, index := range indexes {
+		selected[i] = items[index]
+	}
+	return selected, indexes, nil
 }
 
-// executeWildcard expands [*] by applying the remaining path to each element.
-func executeWildcard(arr interface{}, remaining []interface{}, prefix []interface{}) (interface{}, error) {
-	// Handle []interface{} directly
-	if a, ok := arr.([]interface{}); ok {
-		results := make([]interface{}, 0, len(a))
-		for i, elem := range a {
-			if len(remaining) == 0 {
-				results = append(results, elem)
-			} else {
-				result, err := ExecutePath(elem, remaining)
-				if err != nil {
-					return nil, fmt.Errorf("at %s[%d]: %w", formatPath(prefix), i, err)
-				}
-				// If the result is itself a wildcard expansion (slice), flatten it
-				if expanded, ok := result.([]interface{}); ok && containsWildcard(remaining) {
-					results = append(results, expanded...)
-				} else {
-					results = append(results, result)
-				}
-			}
+// descendants calls fn for value and then, depth first, for every value
+// nested in it, with the concrete path to each. Map keys are visited in
+// sorted order, slices in index order and struct fields in declaration
+// order, so the order is deterministic. A nil at leaves paths
+// untracked, and fn is passed nil paths.
+func descendants(value interface{}, at []interface{}, fn func(v interface{}, path []interface{})) {
+	fn(value, at)
+
+	extend := extend
+	if at == nil {
+		extend = func([]interface{}, interface{}) []interface{} { return nil }
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			descendants(v[k], extend(at, k), fn)
+		}
+		return
+	case []interface{}:
+		for i, elem := range v {
+			descendants(elem, extend(at, i), fn)
 		}
-		return results, nil
+		return
 	}
 
-	// Use reflection for other slice/array types
-	rv := reflect.ValueOf(arr)
-	for rv.Kind() == reflect.Ptr {
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
 		if rv.IsNil() {
-			return nil, fmt.Errorf("cannot expand wildcard on nil pointer")
+			return
 		}
 		rv = rv.Elem()
 	}
-
-	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
-		return nil, fmt.Errorf("cannot expand wildcard on %v", rv.Kind())
-	}
-
-	results := make([]interface{}, 0, rv.Len())
-	for i := 0; i < rv.Len(); i++ {
-		elem := rv.Index(i).Interface()
-		if len(remaining) == 0 {
-			results = append(results, elem)
-		} else {
-			result, err := ExecutePath(elem, remaining)
-			if err != nil {
-				return nil, fmt.Errorf("at %s[%d]: %w", formatPath(prefix), i, err)
-			}
-			if expanded, ok := result.([]interface{}); ok && containsWildcard(remaining) {
-				results = append(results, expanded...)
-			} else {
-				results = append(results, result)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return
+		}
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, k := range keys {
+			descendants(rv.MapIndex(k).Interface(), extend(at, k.String()), fn)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			descendants(rv.Index(i).Interface(), extend(at, i), fn)
+		}
+	case reflect.Struct:
+		for i := 0; i < rv.NumField(); i++ {
+			if f := rv.Type().Field(i); f.IsExported() {
+				descendants(rv.Field(i).Interface(), extend(at, f.Name), fn)
 			}
 		}
 	}
-	return results, nil
 }
 
-// containsWildcard checks if a path contains a Wildcard segment.
+// derefValue follows non-nil pointers.
+func derefValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr {
+		return v
+	}
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	return rv.Interface()
+}
+
+// containsWildcard checks if a path contains a segment that expands to
+// several results: a Wildcard, Filter, Slice, Union or RecursiveDescent.
 func containsWildcard(path []interface{}) bool {
 	for _, seg := range path {
-		if _, ok := seg.(Wildcard); ok {
+		switch seg.(type) {
+		case Wildcard, Filter, Slice, Union, RecursiveDescent:
 			return true
 		}
 	}
@@ -176,14 +270,23 @@ func getField(obj interface{}, field string) (interface{}, error) {
 	}
 }
 
-// getIndex gets an element from an array/slice.
+// getIndex gets an element from an array/slice. A negative index
+// counts from the end.
 func getIndex(arr interface{}, index int) (interface{}, error) {
+	value, _, err := getIndexResolved(arr, index)
+	return value, err
+}
+
+// getIndexResolved is getIndex, also returning the non-negative index
+// of the element.
+func getIndexResolved(arr interface{}, index int) (interface{}, int, error) {
 	// Handle []interface{} directly (most common case)
 	if a, ok := arr.([]interface{}); ok {
-		if index < 0 || index >= len(a) {
-			return nil, fmt.Errorf("index %d out of bounds (length %d)", index, len(a))
+		i, ok := resolveIndex(index, len(a))
+		if !ok {
+			return nil, 0, fmt.Errorf("index %d out of bounds (length %d)", index, len(a))
 		}
-		return a[index], nil
+		return a[i], i, nil
 	}
 
 	// Use reflection for other types
@@ -192,24 +295,26 @@ func getIndex(arr interface{}, index int) (interface{}, error) {
 	// Dereference pointers
 	for rv.Kind() == reflect.Ptr {
 		if rv.IsNil() {
-			return nil, fmt.Errorf("cannot index nil pointer")
+			return nil, 0, fmt.Errorf("cannot index nil pointer")
 		}
 		rv = rv.Elem()
 	}
 
 	switch rv.Kind() {
 	case reflect.Slice, reflect.Array:
-		if index < 0 || index >= rv.Len() {
-			return nil, fmt.Errorf("index %d out of bounds (length %d)", index, rv.Len())
+		i, ok := resolveIndex(index, rv.Len())
+		if !ok {
+			return nil, 0, fmt.Errorf("index %d out of bounds (length %d)", index, rv.Len())
 		}
-		return rv.Index(index).Interface(), nil
+		return rv.Index(i).Interface(), i, nil
 
 	default:
-		return nil, fmt.Errorf("cannot index %v", rv.Kind())
+		return nil, 0, fmt.Errorf("cannot index %v", rv.Kind())
 	}
 }
 
-// formatPath formats a path for error messages.
+// formatPath formats a path in query syntax, for error messages and
+// match paths. Field names that are not identifiers are quoted.
 func formatPath(path []interface{}) string {
 	if len(path) == 0 {
 		return "<root>"
@@ -219,15 +324,32 @@ func formatPath(path []interface{}) string {
 	for _, segment := range path {
 		switch seg := segment.(type) {
 		case string:
-			if result == "" {
-				result = seg
-			} else {
+			switch {
+			case !isIdentifier(seg):
+				result += quoteField(seg)
+			case result == "" || strings.HasSuffix(result, ".."):
+				result += seg
+			default:
 				result += "." + seg
 			}
 		case int:
 			result += fmt.Sprintf("[%d]", seg)
 		case Wildcard:
 			result += "[*]"
+		case Filter:
+			result += seg.String()
+		case Slice:
+			result += seg.String()
+		case Union:
+			result += seg.String()
+		case RecursiveDescent:
+			result += ".."
+		case Call:
+			if result == "" || strings.HasSuffix(result, "..") {
+				result += seg.String()
+			} else {
+				result += "." + seg.String()
+			}
 		}
 	}
 
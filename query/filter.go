@@ -0,0 +1,349 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Filter is a path segment that keeps the array elements matching Expr,
+// written [?(expr)] or [?expr] in a query. Like Wildcard, it expands to
+// a slice of results.
+type Filter struct {
+	Expr FilterExpr
+}
+
+// String returns the filter in query syntax.
+func (f Filter) String() string {
+	return "[?(" + f.Expr.String() + ")]"
+}
+
+// FilterExpr is a boolean expression evaluated against one element.
+type FilterExpr interface {
+	// Match reports whether elem satisfies the expression.
+	Match(elem interface{}) bool
+	String() string
+}
+
+// Operand is one side of a comparison.
+type Operand interface {
+	// Resolve returns the operand's value for elem, and false if it
+	// refers to something elem does not have.
+	Resolve(elem interface{}) (interface{}, bool)
+	String() string
+}
+
+// PathOperand is a path relative to the element: @, @.name, @.tags[0],
+// or a bare name such as status.
+type PathOperand struct {
+	Path []interface{}
+}
+
+// Resolve follows the path from elem.
+func (o *PathOperand) Resolve(elem interface{}) (interface{}, bool) {
+	current := elem
+	for _, segment := range o.Path {
+		var err error
+		switch seg := segment.(type) {
+		case string:
+			current, err = getField(current, seg)
+		case int:
+			current, err = getIndex(current, seg)
+		}
+		if err != nil {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// String returns the operand in query syntax.
+func (o *PathOperand) String() string {
+	if len(o.Path) == 0 {
+		return "@"
+	}
+	var b strings.Builder
+	b.WriteString("@")
+	for _, segment := range o.Path {
+		switch seg := segment.(type) {
+		case string:
+			if isIdentifier(seg) {
+				b.WriteString("." + seg)
+			} else {
+				b.WriteString(quoteField(seg))
+			}
+		case int:
+			fmt.Fprintf(&b, "[%d]", seg)
+		}
+	}
+	return b.String()
+}
+
+// LiteralOperand is a string, number, boolean or null literal. Numbers
+// are float64.
+type LiteralOperand struct {
+	Value interface{}
+}
+
+// Resolve returns the literal.
+func (o *LiteralOperand) Resolve(interface{}) (interface{}, bool) {
+	return o.Value, true
+}
+
+// String returns the literal in query syntax.
+func (o *LiteralOperand) String() string {
+	switch v := o.Value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// CompareExpr compares two operands. Op is one of ==, !=, <, <=, >, >=,
+// =~ (regular expression match), contains, startsWith or endsWith.
+//
+// Numbers of any Go numeric type compare by value; strings compare
+// lexically. A comparison with an operand the element lacks is false,
+// except != which is true.
+type CompareExpr struct {
+	Op          string
+	Left, Right Operand
+
+	re *regexp.Regexp // compiled pattern for =~
+}
+
+// Match evaluates the comparison for elem.
+func (e *CompareExpr) Match(elem interface{}) bool {
+	left, okLeft := e.Left.Resolve(elem)
+	right, okRight := e.Right.Resolve(elem)
+	if !okLeft || !okRight {
+		return e.Op == "!="
+	}
+
+	switch e.Op {
+	case "==":
+		return valuesEqual(left, right)
+	case "!=":
+		return !valuesEqual(left, right)
+	case "<", "<=", ">", ">=":
+		c, ok := compareValues(left, right)
+		if !ok {
+			return false
+		}
+		switch e.Op {
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		default:
+			return c >= 0
+		}
+	case "=~":
+		s, ok := left.(string)
+		return ok && e.re.MatchString(s)
+	case "contains":
+		if s, ok := left.(string); ok {
+			sub, ok := right.(string)
+			return ok && strings.Contains(s, sub)
+		}
+		if items, ok := toInterfaceSlice(left); ok {
+			for _, item := range items {
+				if valuesEqual(item, right) {
+					return true
+				}
+			}
+		}
+		return false
+	case "startsWith", "endsWith":
+		s, okS := left.(string)
+		affix, okA := right.(string)
+		if !okS || !okA {
+			return false
+		}
+		if e.Op == "startsWith" {
+			return strings.HasPrefix(s, affix)
+		}
+		return strings.HasSuffix(s, affix)
+	}
+	return false
+}
+
+// String returns the comparison in query syntax.
+func (e *CompareExpr) String() string {
+	return e.Left.String() + " " + e.Op + " " + e.Right.String()
+}
+
+// ExistsExpr is true when the element has the path, even if its value
+// is null. It is written exists(@.field), or as the bare path.
+type ExistsExpr struct {
+	Path *PathOperand
+}
+
+// Match reports whether the path resolves for elem.
+func (e *ExistsExpr) Match(elem interface{}) bool {
+	_, ok := e.Path.Resolve(elem)
+	return ok
+}
+
+// String returns the expression in query syntax.
+func (e *ExistsExpr) String() string {
+	return "exists(" + e.Path.String() + ")"
+}
+
+// AndExpr is true when both sides are.
+type AndExpr struct {
+	Left, Right FilterExpr
+}
+
+// Match evaluates the conjunction for elem.
+func (e *AndExpr) Match(elem interface{}) bool {
+	return e.Left.Match(elem) && e.Right.Match(elem)
+}
+
+// String returns the expression in query syntax.
+func (e *AndExpr) String() string {
+	return groupOr(e.Left) + " && " + groupOr(e.Right)
+}
+
+// OrExpr is true when either side is.
+type OrExpr struct {
+	Left, Right FilterExpr
+}
+
+// Match evaluates the disjunction for elem.
+func (e *OrExpr) Match(elem interface{}) bool {
+	return e.Left.Match(elem) || e.Right.Match(elem)
+}
+
+// String returns the expression in query syntax.
+func (e *OrExpr) String() string {
+	return e.Left.String() + " || " + e.Right.String()
+}
+
+// NotExpr negates an expression.
+type NotExpr struct {
+	Expr FilterExpr
+}
+
+// Match evaluates the negation for elem.
+func (e *NotExpr) Match(elem interface{}) bool {
+	return !e.Expr.Match(elem)
+}
+
+// String returns the expression in query syntax.
+func (e *NotExpr) String() string {
+	switch e.Expr.(type) {
+	case *ExistsExpr:
+		return "!" + e.Expr.String()
+	}
+	return "!(" + e.Expr.String() + ")"
+}
+
+// groupOr parenthesises an OrExpr inside an AndExpr, where precedence
+// would otherwise change its meaning.
+func groupOr(e FilterExpr) string {
+	if _, ok := e.(*OrExpr); ok {
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+// valuesEqual compares numbers by value and everything else with
+// reflect.DeepEqual.
+func valuesEqual(a, b interface{}) bool {
+	if fa, ok := toNumber(a); ok {
+		fb, ok := toNumber(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compareValues orders two numbers or two strings. The second result is
+// false if the values cannot be ordered.
+func compareValues(a, b interface{}) (int, bool) {
+	if fa, ok := toNumber(a); ok {
+		fb, ok := toNumber(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		}
+		return 0, true
+	}
+	sa, okA := a.(string)
+	sb, okB := b.(string)
+	if !okA || !okB {
+		return 0, false
+	}
+	return strings.Compare(sa, sb), true
+}
+
+// toNumber converts a number to float64. It accepts the numeric kinds,
+// json.Number and the math/big types, as the builders package does.
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		// Out of range numbers are still numbers: ±Inf or 0
+		f, err := strconv.ParseFloat(string(n), 64)
+		return f, err == nil || errors.Is(err, strconv.ErrRange)
+	case *big.Int:
+		if n == nil {
+			return 0, false
+		}
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return f, true
+	case *big.Float:
+		if n == nil {
+			return 0, false
+		}
+		f, _ := n.Float64()
+		return f, true
+	case *big.Rat:
+		if n == nil {
+			return 0, false
+		}
+		f, _ := n.Float64()
+		return f, true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func toInterfaceSlice(v interface{}) ([]interface{}, bool) {
+	if s, ok := v.([]interface{}); ok {
+		return s, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	out := make([]interface{}, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out, true
+}
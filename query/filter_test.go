@@ -0,0 +1,182 @@
+package query_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ha1tch/queryfy/query"
+)
+
+var filterData = map[string]interface{}{
+	"users": []interface{}{
+		map[string]interface{}{"name": "Alice", "status": "active", "age": 34.0, "email": "alice@example.com", "roles": []interface{}{"admin"}},
+		map[string]interface{}{"name": "Bob", "status": "inactive", "age": 19.0, "roles": []interface{}{}},
+		map[string]interface{}{"name": "Carol", "status": "active", "age": 27.0, "email": nil, "roles": []interface{}{"editor", "admin"}},
+		map[string]interface{}{"name": "Dan", "status": "banned", "age": 45},
+	},
+	"scores": []interface{}{3.0, 8.0, 12.5, 1.0},
+}
+
+func expectQuery(t *testing.T, queryStr string, want interface{}) {
+	t.Helper()
+	got, err := query.Execute(filterData, queryStr)
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %v", queryStr, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s:\n got %#v\nwant %#v", queryStr, got, want)
+	}
+}
+
+// ======================================================================
+// Filters: users[?(@.field op value)]
+// ======================================================================
+
+func TestFilter_Comparisons(t *testing.T) {
+	expectQuery(t, `users[?(@.status == "active")].name`, []interface{}{"Alice", "Carol"})
+	expectQuery(t, `users[?status=="active"].name`, []interface{}{"Alice", "Carol"})
+	expectQuery(t, `users[?(@.status != 'active')].name`, []interface{}{"Bob", "Dan"})
+	expectQuery(t, `users[?(@.age > 30)].name`, []interface{}{"Alice", "Dan"})
+	expectQuery(t, `users[?(@.age >= 27)].name`, []interface{}{"Alice", "Carol", "Dan"})
+	expectQuery(t, `users[?(@.age < 20)].name`, []interface{}{"Bob"})
+	expectQuery(t, `users[?(@.age <= 19)].name`, []interface{}{"Bob"})
+	// An int in the data compares equal to the literal
+	expectQuery(t, `users[?(@.age == 45)].name`, []interface{}{"Dan"})
+	expectQuery(t, `users[?(@.name > "B")].name`, []interface{}{"Bob", "Carol", "Dan"})
+	expectQuery(t, `scores[?(@ > 2.5)]`, []interface{}{3.0, 8.0, 12.5})
+	expectQuery(t, `scores[?(@ > -1)]`, []interface{}{3.0, 8.0, 12.5, 1.0})
+}
+
+func TestFilter_Logic(t *testing.T) {
+	expectQuery(t, `users[?(@.status == "active" && @.age < 30)].name`, []interface{}{"Carol"})
+	expectQuery(t, `users[?(@.age < 20 || @.status == "banned")].name`, []interface{}{"Bob", "Dan"})
+	expectQuery(t, `users[?(!(@.status == "active"))].name`, []interface{}{"Bob", "Dan"})
+	// && binds tighter than ||
+	expectQuery(t, `users[?(@.age > 40 || @.status == "active" && @.age < 30)].name`, []interface{}{"Carol", "Dan"})
+	expectQuery(t, `users[?((@.age > 40 || @.status == "active") && @.age < 40)].name`, []interface{}{"Alice", "Carol"})
+}
+
+func TestFilter_Exists(t *testing.T) {
+	// A null value still exists
+	expectQuery(t, `users[?(exists(@.email))].name`, []interface{}{"Alice", "Carol"})
+	expectQuery(t, `users[?(@.email)].name`, []interface{}{"Alice", "Carol"})
+	expectQuery(t, `users[?(!exists(@.roles))].name`, []interface{}{"Dan"})
+	expectQuery(t, `users[?(@.email != null)].name`, []interface{}{"Alice", "Bob", "Dan"})
+	expectQuery(t, `users[?(@.email == null)].name`, []interface{}{"Carol"})
+	expectQuery(t, `users[?(exists(@.roles[1]))].name`, []interface{}{"Carol"})
+}
+
+func TestFilter_StringMatching(t *testing.T) {
+	expectQuery(t, `users[?(@.name =~ "^[AB]")].name`, []interface{}{"Alice", "Bob"})
+	expectQuery(t, `users[?(@.email contains "@example")].name`, []interface{}{"Alice"})
+	expectQuery(t, `users[?(@.name startsWith "Ca")].name`, []interface{}{"Carol"})
+	expectQuery(t, `users[?(@.name endsWith "n")].name`, []interface{}{"Dan"})
+	// contains also tests array membership
+	expectQuery(t, `users[?(@.roles contains "admin")].name`, []interface{}{"Alice", "Carol"})
+}
+
+func TestFilter_NoMatches(t *testing.T) {
+	expectQuery(t, `users[?(@.age > 100)].name`, []interface{}{})
+}
+
+func TestFilter_Standalone(t *testing.T) {
+	result, err := query.Execute(filterData, `users[?(@.name == "Bob")]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	users := result.([]interface{})
+	if len(users) != 1 || users[0].(map[string]interface{})["status"] != "inactive" {
+		t.Errorf("unexpected result %v", result)
+	}
+}
+
+func TestFilter_ThenWildcard(t *testing.T) {
+	expectQuery(t, `users[?(@.status == "active")].roles[*]`, []interface{}{"admin", "editor", "admin"})
+}
+
+func TestFilter_TypedSlice(t *testing.T) {
+	data := map[string]interface{}{"n": []int{1, 5, 10}}
+	result, err := query.Execute(data, "n[?(@ >= 5)]")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result, []interface{}{5, 10}) {
+		t.Errorf("unexpected result %#v", result)
+	}
+}
+
+func TestFilter_BigAndJSONNumbers(t *testing.T) {
+	data := map[string]interface{}{
+		"n": []interface{}{
+			json.Number("3"),
+			json.Number("12.5"),
+			big.NewInt(7),
+			big.NewFloat(20),
+			big.NewRat(1, 2),
+			json.Number("1e400"),
+		},
+	}
+	tests := []struct {
+		query string
+		want  []interface{}
+	}{
+		{"n[?(@ > 5)]", []interface{}{json.Number("12.5"), big.NewInt(7), big.NewFloat(20), json.Number("1e400")}},
+		{"n[?(@ == 3)]", []interface{}{json.Number("3")}},
+		{"n[?(@ == 7)]", []interface{}{big.NewInt(7)}},
+		{"n[?(@ < 1)]", []interface{}{big.NewRat(1, 2)}},
+	}
+	for _, tt := range tests {
+		result, err := query.Execute(data, tt.query)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.query, err)
+		}
+		if !reflect.DeepEqual(result, tt.want) {
+			t.Errorf("%s:\n got %#v\nwant %#v", tt.query, result, tt.want)
+		}
+	}
+}
+
+func TestFilter_Errors(t *testing.T) {
+	bad := []string{
+		`users[?(@.age > 30]`,
+		`users[?(@.age > )]`,
+		`users[?("x")]`,
+		`users[?(@.name =~ "[")]`,
+		`users[?(@.name =~ 3)]`,
+		`users[?(@.name == "open)]`,
+		`users[?(@.age & 1)]`,
+	}
+	for _, q := range bad {
+		if _, err := query.ParseQuery(q); err == nil {
+			t.Errorf("%s: expected a parse error", q)
+		}
+	}
+
+	if _, err := query.Execute(filterData, `users[0][?(@ == 1)]`); err == nil {
+		t.Error("expected an error filtering an object")
+	}
+}
+
+func TestFilter_String(t *testing.T) {
+	q, err := query.ParseQuery(`users[?status=="active" && (@.age > 30 || !exists(@.email))].name`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `[?(@.status == "active" && (@.age > 30 || !exists(@.email)))]`
+	if got := q.String(); !strings.Contains(got, want) {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+
+	// The printed filter parses back to the same filter
+	path, _ := query.PathFromQuery(`users[?(@.age > 30)]`)
+	f := path[1].(query.Filter)
+	if f.String() != `[?(@.age > 30)]` {
+		t.Errorf("unexpected filter string %s", f.String())
+	}
+	if _, err := query.ParseQuery("users" + f.String()); err != nil {
+		t.Errorf("printed filter does not parse: %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Call is a path segment that applies a registered function, written
+// name(arg, ...) in a query. Its arguments are evaluated against the
+// value the call is applied to: the root for "sum(items[*].price)", or
+// each order for "orders[*].sum(items[*].price)".
+//
+// Each argument is either a path ([]interface{}), whose query result is
+// passed to the function, or a literal: a string, float64, bool or nil.
+// The empty path, written @, passes the value itself.
+type Call struct {
+	Name string
+	Args []interface{}
+}
+
+// String returns the call in query syntax.
+func (c Call) String() string {
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		path, ok := arg.([]interface{})
+		switch {
+		case !ok:
+			args[i] = (&LiteralOperand{Value: arg}).String()
+		case len(path) == 0:
+			args[i] = "@"
+		default:
+			args[i] = formatPath(path)
+		}
+	}
+	return c.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// Func is a query function. It receives its evaluated arguments: the
+// result of a path argument, which is a []interface{} when the path has
+// wildcards, filters, slices, unions or recursive descent, or the value
+// of a literal.
+type Func func(args ...interface{}) (interface{}, error)
+
+// funcRegistry stores the functions queries can call.
+var funcRegistry = &funcStore{
+	funcs: map[string]Func{
+		"sum":      sumFunc,
+		"count":    countFunc,
+		"min":      extremeFunc(-1),
+		"max":      extremeFunc(1),
+		"avg":      avgFunc,
+		"distinct": distinctFunc,
+		"length":   lengthFunc,
+	},
+}
+
+// funcStore is a thread-safe registry of named query functions.
+type funcStore struct {
+	mu    sync.RWMutex
+	funcs map[string]Func
+}
+
+// RegisterFunc adds a function that queries can call by name:
+//
+//	func init() {
+//	    query.RegisterFunc("first", func(args ...interface{}) (interface{}, error) {
+//	        // return the first element of args[0]...
+//	    })
+//	}
+//
+// Functions are looked up when a query runs, so a query may be parsed
+// before its functions are registered. Registering a name that already
+// exists, including a built-in, overwrites the previous function.
+func RegisterFunc(name string, fn Func) {
+	funcRegistry.mu.Lock()
+	defer funcRegistry.mu.Unlock()
+	funcRegistry.funcs[name] = fn
+}
+
+// LookupFunc returns a registered function, or nil if there is none.
+func LookupFunc(name string) Func {
+	funcRegistry.mu.RLock()
+	defer funcRegistry.mu.RUnlock()
+	return funcRegistry.funcs[name]
+}
+
+// RegisteredFuncs returns the names of all registered functions, in
+// sorted order.
+func RegisteredFuncs() []string {
+	funcRegistry.mu.RLock()
+	defer funcRegistry.mu.RUnlock()
+	names := make([]string, 0, len(funcRegistry.funcs))
+	for name := range funcRegistry.funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyCall evaluates the arguments of call against current and calls
+// the function.
+func applyCall(current interface{}, call Call) (interface{}, error) {
+	fn := LookupFunc(call.Name)
+	if fn == nil {
+		return nil, fmt.Errorf("unknown function %q", call.Name)
+	}
+
+	args := make([]interface{}, len(call.Args))
+	for i, arg := range call.Args {
+		path, ok := arg.([]interface{})
+		if !ok {
+			args[i] = arg
+			continue
+		}
+		value, err := ExecutePath(current, path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", call.Name, err)
+		}
+		args[i] = value
+	}
+
+	result, err := fn(args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", call.Name, err)
+	}
+	return result, nil
+}
+
+// aggregateArg returns the single argument of an aggregate function as
+// a list: the elements of an array, no elements for null, or the value
+// alone.
+func aggregateArg(args []interface{}) ([]interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	if items, ok := toInterfaceSlice(args[0]); ok {
+		return items, nil
+	}
+	return []interface{}{args[0]}, nil
+}
+
+// numbers returns the numeric values of items, skipping nulls. Numbers
+// of any Go numeric type, json.Number and the math/big types are
+// accepted; anything else is an error.
+func numbers(items []interface{}) ([]float64, error) {
+	out := make([]float64, 0, len(items))
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		f, ok := toNumber(item)
+		if !ok {
+			return nil, fmt.Errorf("%v (%T) is not a number", item, item)
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// sumFunc adds up numbers. The sum of no numbers is 0.
+func sumFunc(args ...interface{}) (interface{}, error) {
+	items, err := aggregateArg(args)
+	if err != nil {
+		return nil, err
+	}
+	values, err := numbers(items)
+	if err != nil {
+		return nil, err
+	}
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total, nil
+}
+
+// avgFunc averages numbers. The average of no numbers is null.
+func avgFunc(args ...interface{}) (interface{}, error) {
+	items, err := aggregateArg(args)
+	if err != nil {
+		return nil, err
+	}
+	values, err := numbers(items)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values)), nil
+}
+
+// countFunc counts values, including nulls inside an array.
+func countFunc(args ...interface{}) (interface{}, error) {
+	items, err := aggregateArg(args)
+	if err != nil {
+		return nil, err
+	}
+	return len(items), nil
+}
+
+// extremeFunc returns min (sign -1) or max (sign 1). Numbers and
+// strings can be compared; nulls are skipped and the result for no
+// values is null. The value is returned as it is in the data.
+func extremeFunc(sign int) Func {
+	return func(args ...interface{}) (interface{}, error) {
+		items, err := aggregateArg(args)
+		if err != nil {
+			return nil, err
+		}
+		var best interface{}
+		for _, item := range items {
+			if item == nil {
+				continue
+			}
+			if _, isNumber := toNumber(item); !isNumber {
+				if _, isString := item.(string); !isString {
+					return nil, fmt.Errorf("cannot compare %v (%T)", item, item)
+				}
+			}
+			if best == nil {
+				best = item
+				continue
+			}
+			c, ok := compareValues(item, best)
+			if !ok {
+				return nil, fmt.Errorf("cannot compare %v and %v", item, best)
+			}
+			if c*sign > 0 {
+				best = item
+			}
+		}
+		return best, nil
+	}
+}
+
+// distinctFunc removes repeated values, keeping the first of each.
+// Numbers are equal by value.
+func distinctFunc(args ...interface{}) (interface{}, error) {
+	items, err := aggregateArg(args)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		seen := false
+		for _, kept := range out {
+			if valuesEqual(item, kept) {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+// lengthFunc returns the length of a string in characters, or of an
+// array or object. The length of null is 0.
+func lengthFunc(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+	}
+	switch v := args[0].(type) {
+	case nil:
+		return 0, nil
+	case string:
+		return utf8.RuneCountInString(v), nil
+	}
+	rv := reflect.ValueOf(args[0])
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len(), nil
+	}
+	return nil, fmt.Errorf("%v (%T) has no length", args[0], args[0])
+}
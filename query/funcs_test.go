@@ -0,0 +1,187 @@
+package query_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ha1tch/queryfy/query"
+)
+
+var funcData = map[string]interface{}{
+	"orders": []interface{}{
+		map[string]interface{}{
+			"id":       "A1",
+			"customer": "ann",
+			"items": []interface{}{
+				map[string]interface{}{"sku": "pen", "price": 1.5, "qty": 4},
+				map[string]interface{}{"sku": "ink", "price": 7.0, "qty": 1},
+			},
+		},
+		map[string]interface{}{
+			"id":       "B2",
+			"customer": "bob",
+			"items": []interface{}{
+				map[string]interface{}{"sku": "pad", "price": 3.0, "qty": 2},
+			},
+		},
+		map[string]interface{}{
+			"id":       "C3",
+			"customer": "ann",
+			"items":    []interface{}{},
+			"note":     nil,
+		},
+	},
+}
+
+func expectFunc(t *testing.T, queryStr string, want interface{}) {
+	t.Helper()
+	got, err := query.Execute(funcData, queryStr)
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %v", queryStr, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s:\n got %#v\nwant %#v", queryStr, got, want)
+	}
+}
+
+// ======================================================================
+// Built-in functions
+// ======================================================================
+
+func TestFuncs_Aggregates(t *testing.T) {
+	expectFunc(t, "sum(orders[*].items[*].price)", 11.5)
+	expectFunc(t, "count(orders[*])", 3)
+	expectFunc(t, "count(orders[*].items[*])", 3)
+	expectFunc(t, "min(orders[*].items[*].price)", 1.5)
+	expectFunc(t, "max(orders[*].items[*].price)", 7.0)
+	expectFunc(t, "avg(orders[0].items[*].price)", 4.25)
+	// ints and floats mix
+	expectFunc(t, "sum(orders[*].items[*].qty)", 7.0)
+	expectFunc(t, "max(orders[*].items[*].qty)", 4)
+	expectFunc(t, "distinct(orders[*].customer)", []interface{}{"ann", "bob"})
+	expectFunc(t, "max(orders[*].customer)", "bob")
+}
+
+func TestFuncs_Empty(t *testing.T) {
+	expectFunc(t, "sum(orders[2].items[*].price)", 0.0)
+	expectFunc(t, "count(orders[2].items)", 0)
+	expectFunc(t, "avg(orders[2].items[*].price)", nil)
+	expectFunc(t, "min(orders[2].items[*].price)", nil)
+	expectFunc(t, "distinct(orders[2].items)", []interface{}{})
+	// A null value has nothing to count
+	expectFunc(t, "count(orders[2].note)", 0)
+}
+
+func TestFuncs_Length(t *testing.T) {
+	expectFunc(t, "length(orders)", 3)
+	expectFunc(t, "length(orders[0])", 3)
+	expectFunc(t, "length(orders[1].customer)", 3)
+	expectFunc(t, `length("héllo")`, 5)
+	expectFunc(t, "orders[*].length(items)", []interface{}{2, 1, 0})
+}
+
+func TestFuncs_PerElement(t *testing.T) {
+	expectFunc(t, "orders[*].sum(items[*].price)", []interface{}{8.5, 3.0, 0.0})
+	expectFunc(t, "orders[?(@.customer == 'ann')].count(items[*])", []interface{}{2, 0})
+	expectFunc(t, "orders[0].items[*].length(@)", []interface{}{3, 3})
+	// The result of a call can be navigated further
+	expectFunc(t, "distinct(orders[*].customer)[-1]", "bob")
+	expectFunc(t, "max(orders[*].sum(items[*].price))", 8.5)
+}
+
+func TestFuncs_TypedData(t *testing.T) {
+	data := map[string]interface{}{"n": []int{3, 1, 2}, "u": []uint8{1, 1}}
+	got, err := query.Execute(data, "sum(n)")
+	if err != nil || got != 6.0 {
+		t.Errorf("sum(n): got %v, %v", got, err)
+	}
+	got, err = query.Execute(data, "distinct(u)")
+	if err != nil || !reflect.DeepEqual(got, []interface{}{uint8(1)}) {
+		t.Errorf("distinct(u): got %v, %v", got, err)
+	}
+}
+
+func TestFuncs_Errors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"sum(orders[*].customer)", `sum: ann (string) is not a number`},
+		{"sum(orders[*].items, 2)", "sum: expected 1 argument, got 2"},
+		{"nosuch(orders)", `unknown function "nosuch"`},
+		{"length(orders[0].items[0].qty)", "has no length"},
+		{"max(orders[*].items[*])", "cannot compare"},
+		{"sum(orders[*].missing)", `sum: at orders[0].missing: field "missing" not found`},
+	}
+	for _, tt := range tests {
+		_, err := query.Execute(funcData, tt.query)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.query, tt.want, err)
+		}
+	}
+
+	for _, bad := range []string{"sum(", "sum(a b)", "sum(a,)", "sum(,a)", "sum(])"} {
+		if _, err := query.ParseQuery(bad); err == nil {
+			t.Errorf("%s: expected a parse error", bad)
+		}
+	}
+}
+
+func TestFuncs_Parse(t *testing.T) {
+	path, err := query.PathFromQuery(`orders[*].pick(items, 2, "x", true, null, @)`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	call, ok := path[2].(query.Call)
+	if !ok {
+		t.Fatalf("expected a Call segment, got %#v", path[2])
+	}
+	want := []interface{}{[]interface{}{"items"}, 2.0, "x", true, nil, []interface{}{}}
+	if call.Name != "pick" || !reflect.DeepEqual(call.Args, want) {
+		t.Errorf("unexpected call %#v", call)
+	}
+	if s := call.String(); s != `pick(items, 2, "x", true, null, @)` {
+		t.Errorf("unexpected string %s", s)
+	}
+
+	// A name followed by a call is still a field name
+	if path, _ := query.PathFromQuery("sum.total"); !reflect.DeepEqual(path, []interface{}{"sum", "total"}) {
+		t.Errorf("unexpected path %#v", path)
+	}
+}
+
+// ======================================================================
+// Registry
+// ======================================================================
+
+func TestRegisterFunc(t *testing.T) {
+	query.RegisterFunc("join", func(args ...interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expected 2 arguments, got %d", len(args))
+		}
+		items, _ := args[0].([]interface{})
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, args[1].(string)), nil
+	})
+
+	expectFunc(t, `join(orders[*].id, "+")`, "A1+B2+C3")
+
+	if query.LookupFunc("join") == nil || query.LookupFunc("nosuch") != nil {
+		t.Error("LookupFunc does not reflect the registry")
+	}
+	names := query.RegisteredFuncs()
+	for _, name := range []string{"avg", "count", "distinct", "join", "length", "max", "min", "sum"} {
+		found := false
+		for _, n := range names {
+			found = found || n == name
+		}
+		if !found {
+			t.Errorf("RegisteredFuncs() lacks %s: %v", name, names)
+		}
+	}
+}
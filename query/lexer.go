@@ -26,6 +26,22 @@ const (
 	TokenStar
 	// TokenError represents a lexing error
 	TokenError
+	// TokenQuestion represents '?', which starts a filter
+	TokenQuestion
+	// TokenAt represents '@', the element a filter is applied to
+	TokenAt
+	// TokenLeftParen represents '('
+	TokenLeftParen
+	// TokenRightParen represents ')'
+	TokenRightParen
+	// TokenString represents a quoted string; Value holds it unquoted
+	TokenString
+	// TokenOperator represents a filter operator: == != < <= > >= =~ && || !
+	TokenOperator
+	// TokenColon represents ':' in a slice
+	TokenColon
+	// TokenComma represents ',' in a union
+	TokenComma
 )
 
 // Token represents a lexical token.
@@ -85,8 +101,30 @@ func (l *Lexer) Next() Token {
 	case '*':
 		l.pos++
 		return Token{Type: TokenStar, Value: "*", Pos: l.pos - 1}
+	case '?':
+		l.pos++
+		return Token{Type: TokenQuestion, Value: "?", Pos: l.pos - 1}
+	case '@':
+		l.pos++
+		return Token{Type: TokenAt, Value: "@", Pos: l.pos - 1}
+	case '(':
+		l.pos++
+		return Token{Type: TokenLeftParen, Value: "(", Pos: l.pos - 1}
+	case ')':
+		l.pos++
+		return Token{Type: TokenRightParen, Value: ")", Pos: l.pos - 1}
+	case ':':
+		l.pos++
+		return Token{Type: TokenColon, Value: ":", Pos: l.pos - 1}
+	case ',':
+		l.pos++
+		return Token{Type: TokenComma, Value: ",", Pos: l.pos - 1}
+	case '"', '\'':
+		return l.lexString(ch)
+	case '=', '!', '<', '>', '&', '|':
+		return l.lexOperator()
 	default:
-		if unicode.IsDigit(rune(ch)) {
+		if isDigit(ch) || (ch == '-' && l.pos+1 < len(l.input) && isDigit(l.input[l.pos+1])) {
 			return l.lexNumber()
 		}
 		if unicode.IsLetter(rune(ch)) || ch == '_' {
@@ -127,13 +165,56 @@ func (l *Lexer) lexIdentifier() Token {
 	}
 }
 
-// lexNumber lexes a number.
+// isIdentifier reports whether name can be written bare in a query.
+// Other field names are written quoted in brackets, as ["content-type"].
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, ch := range name {
+		switch {
+		case ch == '_', ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z':
+		case ch >= '0' && ch <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// quoteField writes a field name as a bracketed, double-quoted segment,
+// escaping backslashes and double quotes.
+func quoteField(name string) string {
+	var b strings.Builder
+	b.WriteString(`["`)
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' || name[i] == '"' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(name[i])
+	}
+	b.WriteString(`"]`)
+	return b.String()
+}
+
+// lexNumber lexes a number: an optional minus sign, digits, and an
+// optional fraction.
 func (l *Lexer) lexNumber() Token {
 	start := l.pos
 
-	for l.pos < len(l.input) && unicode.IsDigit(rune(l.input[l.pos])) {
+	if l.input[l.pos] == '-' {
 		l.pos++
 	}
+	for l.pos < len(l.input) && isDigit(l.input[l.pos]) {
+		l.pos++
+	}
+	// A fraction needs a digit after the dot; "items[0].name" has none
+	if l.pos+1 < len(l.input) && l.input[l.pos] == '.' && isDigit(l.input[l.pos+1]) {
+		l.pos++
+		for l.pos < len(l.input) && isDigit(l.input[l.pos]) {
+			l.pos++
+		}
+	}
 
 	return Token{
 		Type:  TokenNumber,
@@ -142,6 +223,57 @@ func (l *Lexer) lexNumber() Token {
 	}
 }
 
+// lexString lexes a string quoted with quote. Backslash escapes the
+// next character.
+func (l *Lexer) lexString(quote byte) Token {
+	start := l.pos
+	l.pos++ // opening quote
+
+	var b strings.Builder
+	for l.pos < len(l.input) {
+		ch := l.input[l.pos]
+		switch {
+		case ch == quote:
+			l.pos++
+			return Token{Type: TokenString, Value: b.String(), Pos: start}
+		case ch == '\\' && l.pos+1 < len(l.input):
+			b.WriteByte(l.input[l.pos+1])
+			l.pos += 2
+		default:
+			b.WriteByte(ch)
+			l.pos++
+		}
+	}
+
+	return Token{
+		Type:  TokenError,
+		Value: "unterminated string",
+		Pos:   start,
+	}
+}
+
+// operators lists the filter operators, two-character ones first.
+var operators = []string{"==", "!=", "<=", ">=", "=~", "&&", "||", "<", ">", "!"}
+
+// lexOperator lexes a filter operator.
+func (l *Lexer) lexOperator() Token {
+	for _, op := range operators {
+		if strings.HasPrefix(l.input[l.pos:], op) {
+			l.pos += len(op)
+			return Token{Type: TokenOperator, Value: op, Pos: l.pos - len(op)}
+		}
+	}
+	return Token{
+		Type:  TokenError,
+		Value: fmt.Sprintf("unexpected character: %c", l.input[l.pos]),
+		Pos:   l.pos,
+	}
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
 // skipWhitespace skips whitespace characters.
 func (l *Lexer) skipWhitespace() {
 	for l.pos < len(l.input) && unicode.IsSpace(rune(l.input[l.pos])) {
@@ -177,6 +309,14 @@ func TokenTypeName(t TokenType) string {
 		"Number",
 		"Star",
 		"Error",
+		"Question",
+		"At",
+		"LeftParen",
+		"RightParen",
+		"String",
+		"Operator",
+		"Colon",
+		"Comma",
 	}
 
 	if int(t) < len(names) {
@@ -0,0 +1,588 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// Set stores value at the path a query names and returns the updated
+// data. Maps and slices are updated in place, but the root itself may
+// be replaced (setting "a.b" on nil returns a new map), so callers
+// should always use the result.
+//
+// Missing intermediate containers are created: a map before a field
+// name and a []interface{} before an index. Setting an index past the
+// end of a slice grows it, filling the gap with nil. The query must
+// name a single location; use SetAll for wildcards and filters.
+func Set(data interface{}, queryStr string, value interface{}) (interface{}, error) {
+	path, err := PathFromQuery(queryStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	return SetPath(data, path, value)
+}
+
+// SetPath is Set for a parsed path.
+func SetPath(data interface{}, path []interface{}, value interface{}) (interface{}, error) {
+	return setPath(data, path, 0, value)
+}
+
+// SetAll stores value at every location a query with wildcards, filters,
+// slices, unions or recursive descent reaches, such as
+// "items[*].currency", and returns the updated data. The part of the
+// path after the last expanding segment is created where missing, as
+// with Set; the part up to it must already exist.
+func SetAll(data interface{}, queryStr string, value interface{}) (interface{}, error) {
+	path, err := PathFromQuery(queryStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	return SetAllPath(data, path, value)
+}
+
+// SetAllPath is SetAll for a parsed path.
+func SetAllPath(data interface{}, path []interface{}, value interface{}) (interface{}, error) {
+	targets, err := concreteTargets(data, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, target := range targets {
+		if data, err = SetPath(data, target, value); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// Delete removes the value at the path a query names and returns the
+// updated data. A map entry is deleted and a slice element is removed,
+// shifting the elements after it. Deleting something that does not
+// exist is not an error.
+//
+// Queries with wildcards, filters, slices, unions or recursive descent
+// delete every location they reach: Delete(data, "items[?(@.qty == 0)]")
+// removes the matching elements.
+func Delete(data interface{}, queryStr string) (interface{}, error) {
+	path, err := PathFromQuery(queryStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	return DeletePath(data, path)
+}
+
+// DeletePath is Delete for a parsed path.
+func DeletePath(data interface{}, path []interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot delete the root")
+	}
+
+	targets, err := concreteTargets(data, path)
+	if err != nil {
+		return nil, err
+	}
+	// Remove later elements first so earlier indexes stay valid
+	sort.SliceStable(targets, func(i, j int) bool {
+		return comparePaths(targets[i], targets[j]) > 0
+	})
+	for i, target := range targets {
+		if i > 0 && comparePaths(target, targets[i-1]) == 0 {
+			continue
+		}
+		if data, err = deletePath(data, target, 0); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// concreteTargets expands path into the concrete paths it addresses.
+// The expanding segments are resolved against data; the rest of the
+// path is appended to each result unresolved, so it may name
+// locations that do not exist yet.
+func concreteTargets(data interface{}, path []interface{}) ([][]interface{}, error) {
+	split := 0
+	for i, segment := range path {
+		switch segment.(type) {
+		case Wildcard, Filter, Slice, Union:
+			split = i + 1
+		case RecursiveDescent:
+			// The descent selects the values holding the next segment
+			split = i + 2
+		}
+	}
+	if split == 0 {
+		return [][]interface{}{path}, nil
+	}
+	if split > len(path) {
+		split = len(path)
+	}
+
+	matches, err := ExecutePathWithPaths(data, path[:split])
+	if err != nil {
+		return nil, err
+	}
+	targets := make([][]interface{}, len(matches))
+	for i, m := range matches {
+		target := make([]interface{}, 0, len(m.Path)+len(path)-split)
+		target = append(target, m.Path...)
+		targets[i] = append(target, path[split:]...)
+	}
+	return targets, nil
+}
+
+// setPath stores value at path[i:] below current, returning current or
+// its replacement.
+func setPath(current interface{}, path []interface{}, i int, value interface{}) (interface{}, error) {
+	if i == len(path) {
+		return value, nil
+	}
+
+	segment := path[i]
+	switch segment.(type) {
+	case string, int:
+	default:
+		return nil, fmt.Errorf("at %s: cannot set through %s; use SetAll", formatPath(path[:i+1]), formatPath(path[i:i+1]))
+	}
+
+	if current == nil {
+		current = containerFor(segment)
+	}
+	old, _, err := child(current, segment)
+	if err != nil {
+		return nil, fmt.Errorf("at %s: %w", formatPath(path[:i+1]), err)
+	}
+	next, err := setPath(old, path, i+1, value)
+	if err != nil {
+		return nil, err
+	}
+	if sameContainer(old, next) {
+		return current, nil
+	}
+	current, err = replaceChild(current, segment, next)
+	if err != nil {
+		return nil, fmt.Errorf("at %s: %w", formatPath(path[:i+1]), err)
+	}
+	return current, nil
+}
+
+// deletePath removes path[i:] below current, returning current or its
+// replacement.
+func deletePath(current interface{}, path []interface{}, i int) (interface{}, error) {
+	if current == nil {
+		return nil, nil
+	}
+
+	segment := path[i]
+	if i == len(path)-1 {
+		next, err := removeChild(current, segment)
+		if err != nil {
+			return nil, fmt.Errorf("at %s: %w", formatPath(path[:i+1]), err)
+		}
+		return next, nil
+	}
+
+	old, found, err := child(current, segment)
+	if err != nil {
+		return nil, fmt.Errorf("at %s: %w", formatPath(path[:i+1]), err)
+	}
+	if !found {
+		return current, nil
+	}
+	next, err := deletePath(old, path, i+1)
+	if err != nil {
+		return nil, err
+	}
+	if sameContainer(old, next) {
+		return current, nil
+	}
+	current, err = replaceChild(current, segment, next)
+	if err != nil {
+		return nil, fmt.Errorf("at %s: %w", formatPath(path[:i+1]), err)
+	}
+	return current, nil
+}
+
+// containerFor returns an empty container that segment can address.
+func containerFor(segment interface{}) interface{} {
+	if _, ok := segment.(int); ok {
+		return []interface{}{}
+	}
+	return map[string]interface{}{}
+}
+
+// child returns the value at segment in current. The second result is
+// false when a map lacks the key or a slice the index; other failures,
+// such as a field name on a slice, are errors.
+func child(current interface{}, segment interface{}) (interface{}, bool, error) {
+	switch seg := segment.(type) {
+	case string:
+		if m, ok := current.(map[string]interface{}); ok {
+			value, exists := m[seg]
+			return value, exists, nil
+		}
+		if rv := reflect.Indirect(reflect.ValueOf(current)); rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+			value := rv.MapIndex(reflect.ValueOf(seg).Convert(rv.Type().Key()))
+			if !value.IsValid() {
+				return nil, false, nil
+			}
+			return value.Interface(), true, nil
+		}
+		value, err := getField(current, seg)
+		return value, err == nil, err
+
+	case int:
+		if a, ok := current.([]interface{}); ok {
+			i, ok := resolveIndex(seg, len(a))
+			if !ok {
+				return nil, false, nil
+			}
+			return a[i], true, nil
+		}
+		if rv := reflect.Indirect(reflect.ValueOf(current)); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			i, ok := resolveIndex(seg, rv.Len())
+			if !ok {
+				return nil, false, nil
+			}
+			return rv.Index(i).Interface(), true, nil
+		}
+		value, err := getIndex(current, seg)
+		return value, err == nil, err
+	}
+	return nil, false, fmt.Errorf("unexpected path segment type: %T", segment)
+}
+
+// replaceChild stores value at segment in current, growing a slice if
+// the index is past its end, and returns current or its replacement.
+func replaceChild(current interface{}, segment interface{}, value interface{}) (interface{}, error) {
+	switch seg := segment.(type) {
+	case string:
+		if m, ok := current.(map[string]interface{}); ok {
+			m[seg] = value
+			return m, nil
+		}
+
+		rv, err := indirect(current)
+		if err != nil {
+			return nil, err
+		}
+		switch rv.Kind() {
+		case reflect.Map:
+			if rv.Type().Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("cannot set field %q on non-string keyed map", seg)
+			}
+			if rv.IsNil() {
+				return nil, fmt.Errorf("cannot set field %q on nil map", seg)
+			}
+			v, err := assignable(value, rv.Type().Elem())
+			if err != nil {
+				return nil, err
+			}
+			rv.SetMapIndex(reflect.ValueOf(seg).Convert(rv.Type().Key()), v)
+			return current, nil
+
+		case reflect.Struct:
+			field := rv.FieldByName(seg)
+			if !field.IsValid() {
+				return nil, fmt.Errorf("field %q not found in struct", seg)
+			}
+			if !field.CanSet() {
+				return nil, fmt.Errorf("cannot set field %q: pass a pointer to the struct", seg)
+			}
+			v, err := assignable(value, field.Type())
+			if err != nil {
+				return nil, err
+			}
+			field.Set(v)
+			return current, nil
+
+		default:
+			return nil, fmt.Errorf("cannot set field %q on %v", seg, rv.Kind())
+		}
+
+	case int:
+		if a, ok := current.([]interface{}); ok {
+			i, err := settableIndex(seg, len(a))
+			if err != nil {
+				return nil, err
+			}
+			if i >= len(a) {
+				a = append(a, make([]interface{}, i-len(a)+1)...)
+			}
+			a[i] = value
+			return a, nil
+		}
+
+		rv, err := indirect(current)
+		if err != nil {
+			return nil, err
+		}
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			i, err := settableIndex(seg, rv.Len())
+			if err != nil {
+				return nil, err
+			}
+			v, err := assignable(value, rv.Type().Elem())
+			if err != nil {
+				return nil, err
+			}
+			if i >= rv.Len() {
+				if rv.Kind() == reflect.Array {
+					return nil, fmt.Errorf("index %d out of bounds (length %d)", seg, rv.Len())
+				}
+				rv = reflect.AppendSlice(rv, reflect.MakeSlice(rv.Type(), i-rv.Len()+1, i-rv.Len()+1))
+			}
+			if !rv.Index(i).CanSet() {
+				return nil, fmt.Errorf("cannot set index %d: pass a pointer to the array", seg)
+			}
+			rv.Index(i).Set(v)
+			return store(current, rv), nil
+
+		default:
+			return nil, fmt.Errorf("cannot index %v", rv.Kind())
+		}
+	}
+	return nil, fmt.Errorf("unexpected path segment type: %T", segment)
+}
+
+// removeChild deletes segment from current and returns current or its
+// replacement. A missing key or index is left alone.
+func removeChild(current interface{}, segment interface{}) (interface{}, error) {
+	switch seg := segment.(type) {
+	case string:
+		if m, ok := current.(map[string]interface{}); ok {
+			delete(m, seg)
+			return m, nil
+		}
+
+		rv, err := indirect(current)
+		if err != nil {
+			return nil, err
+		}
+		switch rv.Kind() {
+		case reflect.Map:
+			if rv.Type().Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("cannot delete field %q from non-string keyed map", seg)
+			}
+			if !rv.IsNil() {
+				rv.SetMapIndex(reflect.ValueOf(seg).Convert(rv.Type().Key()), reflect.Value{})
+			}
+			return current, nil
+		case reflect.Struct:
+			return nil, fmt.Errorf("cannot delete field %q from a struct", seg)
+		default:
+			return nil, fmt.Errorf("cannot delete field %q from %v", seg, rv.Kind())
+		}
+
+	case int:
+		if a, ok := current.([]interface{}); ok {
+			i, ok := resolveIndex(seg, len(a))
+			if !ok {
+				return a, nil
+			}
+			return append(a[:i], a[i+1:]...), nil
+		}
+
+		rv, err := indirect(current)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("cannot delete an element from %v", rv.Kind())
+		}
+		i, ok := resolveIndex(seg, rv.Len())
+		if !ok {
+			return current, nil
+		}
+		return store(current, reflect.AppendSlice(rv.Slice(0, i), rv.Slice(i+1, rv.Len()))), nil
+
+	default:
+		return nil, fmt.Errorf("cannot delete through %s", formatPath([]interface{}{segment}))
+	}
+}
+
+// indirect follows pointers from v, failing on a nil pointer.
+func indirect(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Value{}, fmt.Errorf("cannot modify nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	return rv, nil
+}
+
+// store returns the slice rv as the new value of current. When current
+// points to the slice, the slice is stored through the pointer and the
+// pointer returned instead.
+func store(current interface{}, rv reflect.Value) interface{} {
+	target := reflect.ValueOf(current)
+	if target.Kind() != reflect.Ptr {
+		return rv.Interface()
+	}
+	for target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+	target.Set(rv)
+	return current
+}
+
+// settableIndex resolves a negative index, which must name an existing
+// element. A non-negative index may be past the end.
+func settableIndex(index, n int) (int, error) {
+	if index >= 0 {
+		return index, nil
+	}
+	i, ok := resolveIndex(index, n)
+	if !ok {
+		return 0, fmt.Errorf("index %d out of bounds (length %d)", index, n)
+	}
+	return i, nil
+}
+
+// assignable converts value for storing in a location of type t.
+// Numbers convert between numeric types, so a float64 decoded from
+// JSON can be stored in an int field, as long as the value fits: a
+// fraction stored in an integer or a value out of the type's range is
+// an error.
+func assignable(value interface{}, t reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot assign nil to %v", t)
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Type().AssignableTo(t) {
+		return rv, nil
+	}
+	if isNumberKind(rv.Kind()) && isNumberKind(t.Kind()) {
+		return convertNumber(rv, t)
+	}
+	return reflect.Value{}, fmt.Errorf("cannot assign %T to %v", value, t)
+}
+
+// convertNumber converts a number to the numeric type t, rejecting
+// values t cannot hold.
+func convertNumber(rv reflect.Value, t reflect.Type) (reflect.Value, error) {
+	out := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = rv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if rv.Uint() > math.MaxInt64 {
+				return reflect.Value{}, overflowError(rv, t)
+			}
+			n = int64(rv.Uint())
+		default:
+			f := rv.Float()
+			if f != math.Trunc(f) {
+				return reflect.Value{}, fmt.Errorf("cannot assign %v to %v: not a whole number", f, t)
+			}
+			if f < math.MinInt64 || f >= math.MaxInt64 {
+				return reflect.Value{}, overflowError(rv, t)
+			}
+			n = int64(f)
+		}
+		if out.OverflowInt(n) {
+			return reflect.Value{}, overflowError(rv, t)
+		}
+		out.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if rv.Int() < 0 {
+				return reflect.Value{}, overflowError(rv, t)
+			}
+			n = uint64(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = rv.Uint()
+		default:
+			f := rv.Float()
+			if f != math.Trunc(f) {
+				return reflect.Value{}, fmt.Errorf("cannot assign %v to %v: not a whole number", f, t)
+			}
+			if f < 0 || f >= math.MaxUint64 {
+				return reflect.Value{}, overflowError(rv, t)
+			}
+			n = uint64(f)
+		}
+		if out.OverflowUint(n) {
+			return reflect.Value{}, overflowError(rv, t)
+		}
+		out.SetUint(n)
+
+	default:
+		f := rv.Convert(reflect.TypeOf(float64(0))).Float()
+		if out.OverflowFloat(f) {
+			return reflect.Value{}, overflowError(rv, t)
+		}
+		out.SetFloat(f)
+	}
+	return out, nil
+}
+
+func overflowError(rv reflect.Value, t reflect.Type) error {
+	return fmt.Errorf("cannot assign %v to %v: out of range", rv.Interface(), t)
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// sameContainer reports whether next is old updated in place: the same
+// map, pointer, or slice of the same length, which the parent does not
+// need to store again.
+func sameContainer(old, next interface{}) bool {
+	ro, rn := reflect.ValueOf(old), reflect.ValueOf(next)
+	if !ro.IsValid() || !rn.IsValid() || ro.Type() != rn.Type() {
+		return false
+	}
+	switch ro.Kind() {
+	case reflect.Map, reflect.Ptr:
+		return ro.Pointer() == rn.Pointer()
+	case reflect.Slice:
+		return ro.Pointer() == rn.Pointer() && ro.Len() == rn.Len()
+	}
+	return false
+}
+
+// comparePaths orders concrete paths segment by segment, indexes
+// numerically and field names lexically. A prefix sorts first.
+func comparePaths(a, b []interface{}) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		switch x := a[i].(type) {
+		case int:
+			if y, ok := b[i].(int); ok && x != y {
+				if x < y {
+					return -1
+				}
+				return 1
+			}
+		case string:
+			if y, ok := b[i].(string); ok && x != y {
+				if x < y {
+					return -1
+				}
+				return 1
+			}
+		}
+	}
+	return len(a) - len(b)
+}
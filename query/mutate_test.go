@@ -0,0 +1,319 @@
+package query_test
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ha1tch/queryfy/query"
+)
+
+// mutateData builds fresh test data for each mutation.
+func mutateData() map[string]interface{} {
+	return map[string]interface{}{
+		"user": map[string]interface{}{"name": "Ann"},
+		"items": []interface{}{
+			map[string]interface{}{"sku": "a", "qty": 0.0},
+			map[string]interface{}{"sku": "b", "qty": 2.0},
+			map[string]interface{}{"sku": "c", "qty": 0.0},
+		},
+	}
+}
+
+func mustQuery(t *testing.T, data interface{}, queryStr string) interface{} {
+	t.Helper()
+	got, err := query.Execute(data, queryStr)
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %v", queryStr, err)
+	}
+	return got
+}
+
+// ======================================================================
+// Set
+// ======================================================================
+
+func TestSet(t *testing.T) {
+	data := mutateData()
+
+	out, err := query.Set(data, "user.name", "Bea")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mustQuery(t, out, "user.name"); got != "Bea" {
+		t.Errorf("user.name: got %v", got)
+	}
+	// Maps are updated in place
+	if data["user"].(map[string]interface{})["name"] != "Bea" {
+		t.Error("expected the original map to be updated")
+	}
+
+	out, err = query.Set(out, "items[-1].qty", 5.0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mustQuery(t, out, "items[2].qty"); got != 5.0 {
+		t.Errorf("items[2].qty: got %v", got)
+	}
+}
+
+func TestSet_CreatesIntermediates(t *testing.T) {
+	out, err := query.Set(mutateData(), "user.address.city", "Lisbon")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mustQuery(t, out, "user.address.city"); got != "Lisbon" {
+		t.Errorf("got %v", got)
+	}
+
+	out, err = query.Set(nil, "tags[2].name", "x")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]interface{}{
+		"tags": []interface{}{nil, nil, map[string]interface{}{"name": "x"}},
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("got %v, want %v", out, want)
+	}
+}
+
+func TestSet_GrowsSlices(t *testing.T) {
+	data := map[string]interface{}{"n": []interface{}{1.0}}
+	out, err := query.Set(data, "n[3]", 4.0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mustQuery(t, out, "n"); !reflect.DeepEqual(got, []interface{}{1.0, nil, nil, 4.0}) {
+		t.Errorf("got %v", got)
+	}
+
+	if _, err := query.Set(data, "n[-9]", 0.0); err == nil {
+		t.Error("expected an error for a negative index out of bounds")
+	}
+}
+
+func TestSet_Errors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"items[*].qty", "use SetAll"},
+		{"user.name.first", `at user.name.first: cannot access field "first" on string`},
+		{"items.sku", "at items.sku: "},
+		{"user[", "invalid query"},
+	}
+	for _, tt := range tests {
+		_, err := query.Set(mutateData(), tt.query, 1.0)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.query, tt.want, err)
+		}
+	}
+}
+
+func TestSet_Structs(t *testing.T) {
+	type address struct {
+		City string
+		Zip  int
+	}
+	type user struct {
+		Address *address
+		Tags    []string
+		Meta    map[string]int
+	}
+	u := &user{Address: &address{}, Meta: map[string]int{}}
+
+	var err error
+	for q, v := range map[string]interface{}{
+		"Address.City": "Porto",
+		"Address.Zip":  4000.0, // numbers convert to the field type
+		"Tags[1]":      "b",
+		"Meta.hits":    3.0,
+	} {
+		if _, err = query.Set(u, q, v); err != nil {
+			t.Fatalf("%s: unexpected error: %v", q, err)
+		}
+	}
+	want := &user{
+		Address: &address{City: "Porto", Zip: 4000},
+		Tags:    []string{"", "b"},
+		Meta:    map[string]int{"hits": 3},
+	}
+	if !reflect.DeepEqual(u, want) {
+		t.Errorf("got %+v, want %+v", u, want)
+	}
+
+	if _, err := query.Set(u, "Address.City", 1.0); err == nil {
+		t.Error("expected an error assigning a number to a string field")
+	}
+	if _, err := query.Set(*u.Address, "City", "x"); err == nil || !strings.Contains(err.Error(), "pointer") {
+		t.Errorf("expected an error setting a field on a struct value, got %v", err)
+	}
+}
+
+func TestSet_NumberConversions(t *testing.T) {
+	type numbers struct {
+		N uint8
+		I int
+		U uint
+		F float32
+	}
+	v := &numbers{}
+
+	for q, value := range map[string]interface{}{"N": 255.0, "I": -7.0, "U": int64(9), "F": 1.5} {
+		if _, err := query.Set(v, q, value); err != nil {
+			t.Errorf("%s = %v: unexpected error: %v", q, value, err)
+		}
+	}
+	if want := (numbers{N: 255, I: -7, U: 9, F: 1.5}); *v != want {
+		t.Errorf("got %+v, want %+v", *v, want)
+	}
+
+	tests := []struct {
+		query string
+		value interface{}
+		want  string
+	}{
+		{"N", 300.0, "out of range"},
+		{"N", -1, "out of range"},
+		{"I", 3.7, "not a whole number"},
+		{"I", 1e300, "out of range"},
+		{"U", -1.0, "out of range"},
+		{"I", uint64(math.MaxUint64), "out of range"},
+		{"F", 1e300, "out of range"},
+	}
+	for _, tt := range tests {
+		_, err := query.Set(v, tt.query, tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s = %v: error %v, want %q", tt.query, tt.value, err, tt.want)
+		}
+	}
+	if want := (numbers{N: 255, I: -7, U: 9, F: 1.5}); *v != want {
+		t.Errorf("rejected values were stored: %+v", *v)
+	}
+}
+
+// ======================================================================
+// SetAll
+// ======================================================================
+
+func TestSetAll(t *testing.T) {
+	out, err := query.SetAll(mutateData(), "items[*].currency", "EUR")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mustQuery(t, out, "items[*].currency"); !reflect.DeepEqual(got, []interface{}{"EUR", "EUR", "EUR"}) {
+		t.Errorf("got %v", got)
+	}
+
+	out, err = query.SetAll(mutateData(), "items[?(@.qty == 0)].meta.empty", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mustQuery(t, out, "items[?(@.meta)].sku"); !reflect.DeepEqual(got, []interface{}{"a", "c"}) {
+		t.Errorf("got %v", got)
+	}
+
+	out, err = query.SetAll(mutateData(), "items..qty", 1.0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mustQuery(t, out, "items[*].qty"); !reflect.DeepEqual(got, []interface{}{1.0, 1.0, 1.0}) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestSetAll_WithoutWildcards(t *testing.T) {
+	out, err := query.SetAll(mutateData(), "user.role", "admin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mustQuery(t, out, "user.role"); got != "admin" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestSetAll_Errors(t *testing.T) {
+	if _, err := query.SetAll(mutateData(), "missing[*].x", 1.0); err == nil {
+		t.Error("expected an error expanding a missing field")
+	}
+}
+
+// ======================================================================
+// Delete
+// ======================================================================
+
+func TestDelete(t *testing.T) {
+	out, err := query.Delete(mutateData(), "user.name")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mustQuery(t, out, "user"); !reflect.DeepEqual(got, map[string]interface{}{}) {
+		t.Errorf("got %v", got)
+	}
+
+	out, err = query.Delete(mutateData(), "items[1]")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mustQuery(t, out, "items[*].sku"); !reflect.DeepEqual(got, []interface{}{"a", "c"}) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestDelete_Missing(t *testing.T) {
+	for _, q := range []string{"user.email", "user.address.city", "items[9]", "items[9].sku"} {
+		out, err := query.Delete(mutateData(), q)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", q, err)
+		}
+		if !reflect.DeepEqual(out, mutateData()) {
+			t.Errorf("%s: data changed to %v", q, out)
+		}
+	}
+
+	if _, err := query.Delete(mutateData(), "user.name.first"); err == nil {
+		t.Error("expected an error deleting a field from a string")
+	}
+}
+
+func TestDelete_Wildcards(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []interface{}
+	}{
+		{"items[?(@.qty == 0)]", []interface{}{"b"}},
+		{"items[::-2]", []interface{}{"b"}},
+		{"items[0,0,1]", []interface{}{"c"}},
+		{"items[*]", []interface{}{}},
+	}
+	for _, tt := range tests {
+		out, err := query.Delete(mutateData(), tt.query)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.query, err)
+		}
+		if got := mustQuery(t, out, "items[*].sku"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	out, err := query.Delete(mutateData(), "items[*].qty")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mustQuery(t, out, "items[?(@.qty)]"); len(got.([]interface{})) != 0 {
+		t.Errorf("qty left in %v", got)
+	}
+}
+
+func TestDelete_TypedSlice(t *testing.T) {
+	data := map[string]interface{}{"n": []int{1, 2, 3}}
+	out, err := query.Delete(data, "n[0]")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mustQuery(t, out, "n"); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Errorf("got %v", got)
+	}
+}
@@ -2,6 +2,7 @@ package query
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 )
 
@@ -54,6 +55,22 @@ func (p *Parser) parseExpression() (Node, error) {
 	for p.current.Type == TokenDot {
 		p.advance() // consume dot
 
+		if p.current.Type == TokenDot {
+			// Recursive descent: ".." then a name or a bracket selector
+			p.advance() // consume second dot
+			left = &DotNode{
+				Left:  left,
+				Right: &RecursiveDescentNode{},
+			}
+			if p.current.Type == TokenLeftBracket {
+				left, err = p.parseSelectors(left)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
 		right, err := p.parsePrimary()
 		if err != nil {
 			return nil, err
@@ -70,16 +87,100 @@ func (p *Parser) parseExpression() (Node, error) {
 
 // parsePrimary parses a primary expression (identifier or identifier[index]).
 func (p *Parser) parsePrimary() (Node, error) {
+	if p.current.Type == TokenLeftBracket && p.peek.Type == TokenString {
+		// Quoted field name: ["content-type"]
+		p.advance() // consume '['
+		name, err := p.parseQuotedField()
+		if err != nil {
+			return nil, err
+		}
+		return p.parseSelectors(&IdentifierNode{Name: name})
+	}
+
 	if p.current.Type != TokenIdentifier {
 		return nil, fmt.Errorf("expected identifier at position %d, got %s",
 			p.current.Pos, TokenTypeName(p.current.Type))
 	}
 
+	if p.peek.Type == TokenLeftParen {
+		call, err := p.parseCall()
+		if err != nil {
+			return nil, err
+		}
+		return p.parseSelectors(call)
+	}
+
 	// Change from *IdentifierNode to Node interface type
 	var node Node = &IdentifierNode{Name: p.current.Value}
 	p.advance()
 
-	// Check for array index or wildcard
+	return p.parseSelectors(node)
+}
+
+// parseCall parses a function call: name(arg, ...). Each argument is a
+// query, @, or a string, number, boolean or null literal.
+func (p *Parser) parseCall() (Node, error) {
+	call := &CallNode{Name: p.current.Value}
+	p.advance() // consume name
+	p.advance() // consume '('
+
+	for p.current.Type != TokenRightParen {
+		if len(call.Args) > 0 {
+			if p.current.Type != TokenComma {
+				return nil, fmt.Errorf("expected ',' or ')' at position %d, got %s",
+					p.current.Pos, TokenTypeName(p.current.Type))
+			}
+			p.advance() // consume ','
+		}
+		arg, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+	}
+	p.advance() // consume ')'
+
+	return call, nil
+}
+
+// parseArgument parses one function argument.
+func (p *Parser) parseArgument() (Node, error) {
+	tok := p.current
+	switch tok.Type {
+	case TokenString:
+		p.advance()
+		return &LiteralNode{Value: tok.Value}, nil
+	case TokenNumber:
+		f, err := strconv.ParseFloat(tok.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number at position %d: %s", tok.Pos, tok.Value)
+		}
+		p.advance()
+		return &LiteralNode{Value: f}, nil
+	case TokenAt:
+		p.advance()
+		return &CurrentNode{}, nil
+	case TokenIdentifier:
+		if p.peek.Type == TokenComma || p.peek.Type == TokenRightParen {
+			switch tok.Value {
+			case "true", "false":
+				p.advance()
+				return &LiteralNode{Value: tok.Value == "true"}, nil
+			case "null":
+				p.advance()
+				return &LiteralNode{Value: nil}, nil
+			}
+		}
+		return p.parseExpression()
+	case TokenLeftBracket:
+		return p.parseExpression()
+	}
+	return nil, fmt.Errorf("expected a function argument at position %d, got %s", tok.Pos, TokenTypeName(tok.Type))
+}
+
+// parseSelectors parses the bracket selectors following node: indexes,
+// slices, unions, wildcards, filters and quoted field names.
+func (p *Parser) parseSelectors(node Node) (Node, error) {
 	for p.current.Type == TokenLeftBracket {
 		p.advance() // consume '['
 
@@ -96,15 +197,12 @@ func (p *Parser) parsePrimary() (Node, error) {
 				Left:  node,
 				Right: &WildcardNode{},
 			}
-		} else if p.current.Type == TokenNumber {
-			index, err := strconv.Atoi(p.current.Value)
+		} else if p.current.Type == TokenNumber || p.current.Type == TokenColon {
+			selector, err := p.parseIndexSelector()
 			if err != nil {
-				return nil, fmt.Errorf("invalid array index at position %d: %s",
-					p.current.Pos, p.current.Value)
+				return nil, err
 			}
 
-			p.advance() // consume number
-
 			if p.current.Type != TokenRightBracket {
 				return nil, fmt.Errorf("expected ']' at position %d", p.current.Pos)
 			}
@@ -114,16 +212,313 @@ func (p *Parser) parsePrimary() (Node, error) {
 			// Create a composite node for array access
 			node = &DotNode{
 				Left:  node,
-				Right: &IndexNode{Index: index},
+				Right: selector,
+			}
+		} else if p.current.Type == TokenQuestion {
+			p.advance() // consume '?'
+
+			expr, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if p.current.Type != TokenRightBracket {
+				return nil, fmt.Errorf("expected ']' after filter at position %d", p.current.Pos)
+			}
+			p.advance() // consume ']'
+
+			node = &DotNode{
+				Left:  node,
+				Right: &FilterNode{Expr: expr},
+			}
+		} else if p.current.Type == TokenString {
+			// Quoted field name: ["content-type"] or ['a.b']
+			name, err := p.parseQuotedField()
+			if err != nil {
+				return nil, err
+			}
+
+			node = &DotNode{
+				Left:  node,
+				Right: &IdentifierNode{Name: name},
 			}
 		} else {
-			return nil, fmt.Errorf("expected number or '*' after '[' at position %d", p.current.Pos)
+			return nil, fmt.Errorf("expected index, slice, field name, '*' or '?' after '[' at position %d", p.current.Pos)
 		}
 	}
 
 	return node, nil
 }
 
+// parseQuotedField parses the quoted name and closing bracket of a
+// ["field"] segment, the '[' having been consumed.
+func (p *Parser) parseQuotedField() (string, error) {
+	name := p.current.Value
+	p.advance() // consume string
+
+	if p.current.Type != TokenRightBracket {
+		return "", fmt.Errorf("expected ']' after field name at position %d", p.current.Pos)
+	}
+	p.advance() // consume ']'
+
+	return name, nil
+}
+
+// parseIndexSelector parses the inside of an index bracket: a single
+// index, a union of indexes, or a slice.
+func (p *Parser) parseIndexSelector() (Node, error) {
+	var parts [3]*int
+	part := 0
+	for {
+		if p.current.Type == TokenNumber {
+			index, err := strconv.Atoi(p.current.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid array index at position %d: %s",
+					p.current.Pos, p.current.Value)
+			}
+			parts[part] = &index
+			p.advance() // consume number
+		}
+		if p.current.Type != TokenColon {
+			break
+		}
+		if part == 2 {
+			return nil, fmt.Errorf("too many ':' in slice at position %d", p.current.Pos)
+		}
+		part++
+		p.advance() // consume ':'
+	}
+
+	switch {
+	case part > 0:
+		if parts[2] != nil && *parts[2] == 0 {
+			return nil, fmt.Errorf("slice step cannot be zero")
+		}
+		return &SliceNode{Start: parts[0], End: parts[1], Step: parts[2]}, nil
+	case parts[0] == nil:
+		return nil, fmt.Errorf("expected array index at position %d", p.current.Pos)
+	case p.current.Type != TokenComma:
+		return &IndexNode{Index: *parts[0]}, nil
+	}
+
+	indexes := []int{*parts[0]}
+	for p.current.Type == TokenComma {
+		p.advance() // consume ','
+		index, err := strconv.Atoi(p.current.Value)
+		if p.current.Type != TokenNumber || err != nil {
+			return nil, fmt.Errorf("expected array index at position %d", p.current.Pos)
+		}
+		indexes = append(indexes, index)
+		p.advance() // consume number
+	}
+	return &UnionNode{Indexes: indexes}, nil
+}
+
+// parseOr parses a filter expression: and-expressions joined by ||.
+func (p *Parser) parseOr() (FilterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("||") {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &OrExpr{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// parseAnd parses unary filter expressions joined by &&.
+func (p *Parser) parseAnd() (FilterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("&&") {
+		p.advance()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &AndExpr{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// parseUnary parses a negation, a parenthesised expression, exists(...)
+// or a comparison.
+func (p *Parser) parseUnary() (FilterExpr, error) {
+	switch {
+	case p.isOperator("!"):
+		p.advance()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{Expr: expr}, nil
+
+	case p.current.Type == TokenLeftParen:
+		p.advance() // consume '('
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.current.Type != TokenRightParen {
+			return nil, fmt.Errorf("expected ')' at position %d", p.current.Pos)
+		}
+		p.advance() // consume ')'
+		return expr, nil
+
+	case p.current.Type == TokenIdentifier && p.current.Value == "exists" && p.peek.Type == TokenLeftParen:
+		p.advance() // consume 'exists'
+		p.advance() // consume '('
+		operand, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		path, ok := operand.(*PathOperand)
+		if !ok {
+			return nil, fmt.Errorf("exists expects a path at position %d", p.current.Pos)
+		}
+		if p.current.Type != TokenRightParen {
+			return nil, fmt.Errorf("expected ')' at position %d", p.current.Pos)
+		}
+		p.advance() // consume ')'
+		return &ExistsExpr{Path: path}, nil
+	}
+
+	return p.parseComparison()
+}
+
+// comparisonWords are the comparison operators written as words.
+var comparisonWords = map[string]bool{"contains": true, "startsWith": true, "endsWith": true}
+
+// parseComparison parses "operand op operand", or a lone path, which
+// tests that the path exists.
+func (p *Parser) parseComparison() (FilterExpr, error) {
+	pos := p.current.Pos
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	var op string
+	switch {
+	case p.current.Type == TokenOperator && p.current.Value != "&&" && p.current.Value != "||" && p.current.Value != "!":
+		op = p.current.Value
+	case p.current.Type == TokenIdentifier && comparisonWords[p.current.Value]:
+		op = p.current.Value
+	default:
+		path, ok := left.(*PathOperand)
+		if !ok {
+			return nil, fmt.Errorf("expected a comparison after literal at position %d", pos)
+		}
+		return &ExistsExpr{Path: path}, nil
+	}
+	opPos := p.current.Pos
+	p.advance() // consume operator
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	expr := &CompareExpr{Op: op, Left: left, Right: right}
+	if op == "=~" {
+		var pattern string
+		lit, ok := right.(*LiteralOperand)
+		if ok {
+			pattern, ok = lit.Value.(string)
+		}
+		if !ok {
+			return nil, fmt.Errorf("=~ expects a string pattern at position %d", opPos)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern at position %d: %v", opPos, err)
+		}
+		expr.re = re
+	}
+	return expr, nil
+}
+
+// parseOperand parses a literal or a path relative to the filtered
+// element: @, @.a.b[0], or a bare a.b[0].
+func (p *Parser) parseOperand() (Operand, error) {
+	tok := p.current
+	switch tok.Type {
+	case TokenString:
+		p.advance()
+		return &LiteralOperand{Value: tok.Value}, nil
+	case TokenNumber:
+		f, err := strconv.ParseFloat(tok.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number at position %d: %s", tok.Pos, tok.Value)
+		}
+		p.advance()
+		return &LiteralOperand{Value: f}, nil
+	case TokenIdentifier:
+		switch tok.Value {
+		case "true", "false":
+			p.advance()
+			return &LiteralOperand{Value: tok.Value == "true"}, nil
+		case "null":
+			p.advance()
+			return &LiteralOperand{Value: nil}, nil
+		}
+		p.advance()
+		return p.parseOperandPath([]interface{}{tok.Value})
+	case TokenAt:
+		p.advance()
+		return p.parseOperandPath(nil)
+	}
+	return nil, fmt.Errorf("expected a value or path at position %d, got %s", tok.Pos, TokenTypeName(tok.Type))
+}
+
+// parseOperandPath parses the .field, ["field"] and [index] steps of a
+// path.
+func (p *Parser) parseOperandPath(path []interface{}) (Operand, error) {
+	for {
+		switch p.current.Type {
+		case TokenDot:
+			p.advance()
+			if p.current.Type != TokenIdentifier {
+				return nil, fmt.Errorf("expected identifier at position %d, got %s",
+					p.current.Pos, TokenTypeName(p.current.Type))
+			}
+			path = append(path, p.current.Value)
+			p.advance()
+		case TokenLeftBracket:
+			p.advance()
+			if p.current.Type == TokenString {
+				name, err := p.parseQuotedField()
+				if err != nil {
+					return nil, err
+				}
+				path = append(path, name)
+				continue
+			}
+			index, err := strconv.Atoi(p.current.Value)
+			if p.current.Type != TokenNumber || err != nil {
+				return nil, fmt.Errorf("expected array index at position %d", p.current.Pos)
+			}
+			p.advance()
+			if p.current.Type != TokenRightBracket {
+				return nil, fmt.Errorf("expected ']' at position %d", p.current.Pos)
+			}
+			p.advance()
+			path = append(path, index)
+		default:
+			return &PathOperand{Path: path}, nil
+		}
+	}
+}
+
+func (p *Parser) isOperator(op string) bool {
+	return p.current.Type == TokenOperator && p.current.Value == op
+}
+
 // advance moves to the next token.
 func (p *Parser) advance() {
 	p.current = p.peek
@@ -150,6 +545,16 @@ func SimplifyNode(node Node) []interface{} {
 			path = append(path, n.Index)
 		case *WildcardNode:
 			path = append(path, Wildcard{})
+		case *FilterNode:
+			path = append(path, Filter{Expr: n.Expr})
+		case *SliceNode:
+			path = append(path, Slice{Start: n.Start, End: n.End, Step: n.Step})
+		case *UnionNode:
+			path = append(path, Union{Indexes: n.Indexes})
+		case *RecursiveDescentNode:
+			path = append(path, RecursiveDescent{})
+		case *CallNode:
+			path = append(path, callFromNode(n))
 		case *DotNode:
 			traverse(n.Left)
 			traverse(n.Right)
@@ -164,6 +569,22 @@ func SimplifyNode(node Node) []interface{} {
 	return path
 }
 
+// callFromNode converts a CallNode to a Call segment.
+func callFromNode(n *CallNode) Call {
+	call := Call{Name: n.Name, Args: make([]interface{}, len(n.Args))}
+	for i, arg := range n.Args {
+		switch a := arg.(type) {
+		case *LiteralNode:
+			call.Args[i] = a.Value
+		case *CurrentNode:
+			call.Args[i] = []interface{}{}
+		default:
+			call.Args[i] = SimplifyNode(a)
+		}
+	}
+	return call
+}
+
 // PathFromQuery converts a query string directly to a path.
 // This is a convenience function for simple queries.
 func PathFromQuery(queryStr string) ([]interface{}, error) {
@@ -0,0 +1,124 @@
+package query_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ha1tch/queryfy/query"
+)
+
+var pathsData = map[string]interface{}{
+	"customers": []interface{}{
+		map[string]interface{}{
+			"name": "Ann",
+			"orders": []interface{}{
+				map[string]interface{}{"total": 10.0},
+				map[string]interface{}{"total": 120.0},
+			},
+		},
+		map[string]interface{}{
+			"name":   "Ben",
+			"orders": []interface{}{map[string]interface{}{"total": 300.0}},
+		},
+	},
+}
+
+// matchPaths returns the PathString of every match, failing on error.
+func matchPaths(t *testing.T, data interface{}, queryStr string) []string {
+	t.Helper()
+	matches, err := query.ExecuteWithPaths(data, queryStr)
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %v", queryStr, err)
+	}
+	paths := make([]string, len(matches))
+	for i, m := range matches {
+		paths[i] = m.PathString()
+	}
+	return paths
+}
+
+func expectPaths(t *testing.T, data interface{}, queryStr string, want ...string) {
+	t.Helper()
+	if got := matchPaths(t, data, queryStr); !reflect.DeepEqual(got, want) {
+		t.Errorf("%s:\n got %q\nwant %q", queryStr, got, want)
+	}
+}
+
+// ======================================================================
+// ExecuteWithPaths
+// ======================================================================
+
+func TestExecuteWithPaths_Wildcards(t *testing.T) {
+	expectPaths(t, pathsData, "customers[*].orders[*].total",
+		"customers[0].orders[0].total",
+		"customers[0].orders[1].total",
+		"customers[1].orders[0].total")
+	expectPaths(t, pathsData, "customers[?(@.name == 'Ben')].orders[0]",
+		"customers[1].orders[0]")
+	expectPaths(t, pathsData, "customers[*].orders[?(@.total > 100)].total",
+		"customers[0].orders[1].total",
+		"customers[1].orders[0].total")
+}
+
+func TestExecuteWithPaths_Values(t *testing.T) {
+	matches, err := query.ExecuteWithPaths(pathsData, "customers[*].orders[-1].total")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []query.Match{
+		{Path: []interface{}{"customers", 0, "orders", 1, "total"}, Value: 120.0},
+		{Path: []interface{}{"customers", 1, "orders", 0, "total"}, Value: 300.0},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("got %#v\nwant %#v", matches, want)
+	}
+
+	// Each match's path reaches its value again
+	for _, m := range matches {
+		got, err := query.ExecutePath(pathsData, m.Path)
+		if err != nil || got != m.Value {
+			t.Errorf("%s: got %v, %v", m.PathString(), got, err)
+		}
+	}
+}
+
+func TestExecuteWithPaths_IndexesAreConcrete(t *testing.T) {
+	data := map[string]interface{}{"n": []interface{}{0.0, 1.0, 2.0, 3.0, 4.0}}
+	expectPaths(t, data, "n[-1]", "n[4]")
+	expectPaths(t, data, "n[::-2]", "n[4]", "n[2]", "n[0]")
+	expectPaths(t, data, "n[3,-5]", "n[3]", "n[0]")
+}
+
+func TestExecuteWithPaths_RecursiveDescent(t *testing.T) {
+	expectPaths(t, pathsData, "customers..total",
+		"customers[0].orders[0].total",
+		"customers[0].orders[1].total",
+		"customers[1].orders[0].total")
+
+	type line struct {
+		SKU string
+	}
+	type order struct {
+		Lines []line
+	}
+	expectPaths(t, map[string]interface{}{"o": order{Lines: []line{{"a"}, {"b"}}}}, "o..SKU",
+		"o.Lines[0].SKU", "o.Lines[1].SKU")
+}
+
+func TestExecuteWithPaths_Single(t *testing.T) {
+	expectPaths(t, pathsData, "customers[1].name", "customers[1].name")
+	expectPaths(t, pathsData, "", "")
+	if got := matchPaths(t, pathsData, "customers[5:]"); len(got) != 0 {
+		t.Errorf("expected no matches, got %q", got)
+	}
+}
+
+func TestExecuteWithPaths_Errors(t *testing.T) {
+	_, err := query.ExecuteWithPaths(pathsData, "customers[*].email")
+	if err == nil || err.Error() != `at customers[0].email: field "email" not found` {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := query.ExecuteWithPaths(pathsData, "customers["); err == nil {
+		t.Error("expected a parse error")
+	}
+}
@@ -0,0 +1,182 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ParsePointer splits a JSON Pointer (RFC 6901) into its unescaped
+// reference tokens. The empty pointer refers to the whole document and
+// has no tokens.
+func ParsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON Pointer %q: must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, fmt.Errorf("invalid JSON Pointer %q: bad escape", pointer)
+			}
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// PointerFromPath formats a concrete path of field names and
+// non-negative indexes as a JSON Pointer: items[0].price becomes
+// /items/0/price. Paths with expanding segments, calls or negative
+// indexes name no single location and are rejected.
+func PointerFromPath(path []interface{}) (string, error) {
+	var b strings.Builder
+	for _, segment := range path {
+		b.WriteByte('/')
+		switch seg := segment.(type) {
+		case string:
+			b.WriteString(escapePointerToken(seg))
+		case int:
+			if seg < 0 {
+				return "", fmt.Errorf("negative index %d has no JSON Pointer form", seg)
+			}
+			b.WriteString(strconv.Itoa(seg))
+		default:
+			return "", fmt.Errorf("%s has no JSON Pointer form", formatPath([]interface{}{segment}))
+		}
+	}
+	return b.String(), nil
+}
+
+// PathFromPointer converts a JSON Pointer to a path. Tokens that are
+// array indexes in the pointer syntax (0, 1, 12, but not 01 or -1)
+// become int segments and the rest field names, so an object key such
+// as "0" is read as an index; ExecutePointer, which sees the data,
+// does not have this ambiguity.
+func PathFromPointer(pointer string) ([]interface{}, error) {
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	path := make([]interface{}, len(tokens))
+	for i, token := range tokens {
+		if index, ok := pointerIndex(token); ok {
+			path[i] = index
+		} else {
+			path[i] = token
+		}
+	}
+	return path, nil
+}
+
+// PointerFromQuery converts a query naming a single location, such as
+// items[0].price, to a JSON Pointer.
+func PointerFromQuery(queryStr string) (string, error) {
+	if queryStr == "" {
+		return "", nil
+	}
+	path, err := PathFromQuery(queryStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid query: %w", err)
+	}
+	return PointerFromPath(path)
+}
+
+// QueryFromPointer converts a JSON Pointer to a query: /items/0/price
+// becomes items[0].price, quoting field names that are not identifiers.
+// The root pointer "" becomes the empty query.
+func QueryFromPointer(pointer string) (string, error) {
+	path, err := PathFromPointer(pointer)
+	if err != nil {
+		return "", err
+	}
+	return formatQuery(path), nil
+}
+
+// ExecutePointer returns the value a JSON Pointer refers to. Each token
+// is read as an index when the value it applies to is an array and as a
+// field name otherwise, as RFC 6901 specifies.
+func ExecutePointer(data interface{}, pointer string) (interface{}, error) {
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	current := data
+	for i, token := range tokens {
+		if current == nil {
+			return nil, fmt.Errorf("cannot access %q on nil value", token)
+		}
+		next, err := pointerStep(current, token)
+		if err != nil {
+			return nil, fmt.Errorf("at %s: %w", formatPointer(tokens[:i+1]), err)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// Pointer formats the match's path as a JSON Pointer, such as
+// "/customers/3/orders/1/total". The root is the empty string.
+func (m Match) Pointer() string {
+	pointer, _ := PointerFromPath(m.Path)
+	return pointer
+}
+
+// pointerStep applies one reference token to value.
+func pointerStep(value interface{}, token string) (interface{}, error) {
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return getField(value, token)
+	}
+
+	index, ok := pointerIndex(token)
+	if !ok {
+		if token == "-" {
+			return nil, fmt.Errorf("index - refers past the end of the array (length %d)", rv.Len())
+		}
+		return nil, fmt.Errorf("invalid array index %q", token)
+	}
+	return getIndex(value, index)
+}
+
+// pointerIndex parses a token written as an array index: 0 or a
+// decimal without leading zeros.
+func pointerIndex(token string) (int, bool) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, false
+	}
+	for i := 0; i < len(token); i++ {
+		if !isDigit(token[i]) {
+			return 0, false
+		}
+	}
+	index, err := strconv.Atoi(token)
+	return index, err == nil
+}
+
+// escapePointerToken escapes ~ and / in a reference token.
+func escapePointerToken(token string) string {
+	if !strings.ContainsAny(token, "~/") {
+		return token
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// formatPointer joins unescaped tokens into a JSON Pointer.
+func formatPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(escapePointerToken(token))
+	}
+	return b.String()
+}
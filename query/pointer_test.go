@@ -0,0 +1,130 @@
+package query_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ha1tch/queryfy/query"
+)
+
+var pointerData = map[string]interface{}{
+	"items": []interface{}{
+		map[string]interface{}{"price": 2.5},
+		map[string]interface{}{"price": 4.0},
+	},
+	"a/b": map[string]interface{}{"m~n": "escaped"},
+	"0":   "key that looks like an index",
+	"":    "empty key",
+}
+
+// ======================================================================
+// Conversions
+// ======================================================================
+
+func TestPointer_Conversions(t *testing.T) {
+	tests := []struct {
+		query   string
+		pointer string
+	}{
+		{"", ""},
+		{"items", "/items"},
+		{"items[0].price", "/items/0/price"},
+		{`["a/b"]["m~n"]`, "/a~1b/m~0n"},
+		{`headers["content-type"]`, "/headers/content-type"},
+	}
+	for _, tt := range tests {
+		pointer, err := query.PointerFromQuery(tt.query)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.query, err)
+		} else if pointer != tt.pointer {
+			t.Errorf("PointerFromQuery(%s) = %q, want %q", tt.query, pointer, tt.pointer)
+		}
+
+		q, err := query.QueryFromPointer(tt.pointer)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.pointer, err)
+		} else if q != tt.query {
+			t.Errorf("QueryFromPointer(%q) = %s, want %s", tt.pointer, q, tt.query)
+		}
+	}
+
+	path, err := query.PathFromPointer("/items/10/01/-")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []interface{}{"items", 10, "01", "-"}; !reflect.DeepEqual(path, want) {
+		t.Errorf("got %v, want %v", path, want)
+	}
+}
+
+func TestPointer_ConversionErrors(t *testing.T) {
+	for _, q := range []string{"items[*].price", "items[-1]", "items[0:2]", "sum(items)", "items[?(@.price > 1)]"} {
+		if _, err := query.PointerFromQuery(q); err == nil {
+			t.Errorf("%s: expected error", q)
+		}
+	}
+	for _, p := range []string{"items", "/items/~2", "/a~"} {
+		if _, err := query.QueryFromPointer(p); err == nil {
+			t.Errorf("%q: expected error", p)
+		}
+	}
+}
+
+// ======================================================================
+// ExecutePointer
+// ======================================================================
+
+func TestExecutePointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    interface{}
+	}{
+		{"/items/1/price", 4.0},
+		{"/a~1b/m~0n", "escaped"},
+		{"/0", "key that looks like an index"},
+		{"/", "empty key"},
+	}
+	for _, tt := range tests {
+		got, err := query.ExecutePointer(pointerData, tt.pointer)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.pointer, err)
+		} else if got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.pointer, got, tt.want)
+		}
+	}
+
+	root, err := query.ExecutePointer(pointerData, "")
+	if err != nil || !reflect.DeepEqual(root, pointerData) {
+		t.Errorf("the empty pointer should return the document, got %v, %v", root, err)
+	}
+}
+
+func TestExecutePointer_Errors(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    string
+	}{
+		{"/items/2", "at /items/2: index 2 out of bounds"},
+		{"/items/01", `at /items/01: invalid array index "01"`},
+		{"/items/-", "at /items/-: index - refers past the end"},
+		{"/items/0/cost", `at /items/0/cost: field "cost" not found`},
+		{"items", "must start with /"},
+	}
+	for _, tt := range tests {
+		_, err := query.ExecutePointer(pointerData, tt.pointer)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want error containing %q", tt.pointer, err, tt.want)
+		}
+	}
+}
+
+func TestMatch_Pointer(t *testing.T) {
+	matches, err := query.ExecuteWithPaths(pointerData, "items[*].price")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matches) != 2 || matches[1].Pointer() != "/items/1/price" {
+		t.Errorf("unexpected matches: %v", matches)
+	}
+}
@@ -2,8 +2,14 @@
 //
 // The query language supports:
 //   - Field access: "field" or "object.field"
-//   - Array indexing: "array[0]" or "array[0].field"
+//   - Array indexing: "array[0]", "array[-1]" or "array[0].field"
+//   - Slices and unions: "array[1:3]", "array[::2]" or "array[0,2,5]"
 //   - Nested access: "user.address.street"
+//   - Quoted field names: `headers["content-type"]` or "['a.b'].c"
+//   - Wildcards: "items[*].price"
+//   - Filters: "items[?(@.price > 10)].name" or `users[?status=="active"]`
+//   - Recursive descent: "order..id" (every id at any depth under order)
+//   - Functions: "sum(items[*].price)" or "orders[*].count(items)"
 //
 // Example:
 //
@@ -22,66 +28,140 @@
 package query
 
 import (
+	"container/list"
 	"sync"
 )
 
-// QueryCache caches parsed queries for performance.
-var queryCache = &cache{
-	queries: make(map[string]*Query),
+// DefaultCacheSize is the number of compiled queries the query cache
+// holds before it evicts the least recently used.
+const DefaultCacheSize = 1000
+
+// queryCache caches compiled queries for ExecuteCached.
+var queryCache = newLRUCache(DefaultCacheSize)
+
+// CacheStats reports the activity of the query cache since it was
+// created or last cleared.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int // queries currently cached
+	Capacity  int // queries the cache can hold
 }
 
-// cache is a simple thread-safe cache for parsed queries.
-type cache struct {
-	mu      sync.RWMutex
-	queries map[string]*Query
+// lruCache is a thread-safe, bounded cache of compiled queries that
+// evicts the least recently used entry when full.
+type lruCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // most recently used at the front
+	entries  map[string]*list.Element
+	stats    CacheStats
 }
 
-// get retrieves a query from the cache.
-func (c *cache) get(key string) (*Query, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	q, ok := c.queries[key]
-	return q, ok
+// lruEntry is the value of an element in lruCache.order.
+type lruEntry struct {
+	key   string
+	query *CompiledQuery
 }
 
-// set stores a query in the cache.
-func (c *cache) set(key string, query *Query) {
+func newLRUCache(capacity int) *lruCache {
+	return &lruCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get retrieves a query from the cache, marking it recently used.
+func (c *lruCache) get(key string) (*CompiledQuery, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry).query, true
+}
 
-	// Simple cache eviction: if cache is too large, clear it
-	if len(c.queries) > 1000 {
-		c.queries = make(map[string]*Query)
+// set stores a query in the cache, evicting if it is full.
+func (c *lruCache) set(key string, query *CompiledQuery) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.capacity <= 0 {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*lruEntry).query = query
+		c.order.MoveToFront(elem)
+		return
 	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, query: query})
+	c.evict()
+}
 
-	c.queries[key] = query
+// evict removes least recently used entries until the cache is within
+// capacity. The caller holds c.mu.
+func (c *lruCache) evict() {
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+		c.stats.Evictions++
+	}
 }
 
 // ExecuteCached executes a query with caching.
 // This is the recommended way to execute queries in production.
+//
+// Compiled queries are kept in a cache of DefaultCacheSize entries,
+// evicting the least recently used; see SetCacheSize and CacheStats.
+// Queries that fail to parse are not cached.
 func ExecuteCached(data interface{}, queryStr string) (interface{}, error) {
-	// Check cache first
-	if cached, ok := queryCache.get(queryStr); ok {
-		path := SimplifyNode(cached.Root.Child)
-		return ExecutePath(data, path)
+	compiled, ok := queryCache.get(queryStr)
+	if !ok {
+		var err error
+		if compiled, err = Compile(queryStr); err != nil {
+			return nil, err
+		}
+		queryCache.set(queryStr, compiled)
 	}
-
-	// Parse and cache
-	query, err := ParseQuery(queryStr)
-	if err != nil {
-		return nil, err
-	}
-
-	queryCache.set(queryStr, query)
-
-	path := SimplifyNode(query.Root.Child)
-	return ExecutePath(data, path)
+	return compiled.Execute(data)
 }
 
-// ClearCache clears the query cache.
+// ClearCache clears the query cache and resets its statistics.
 // This is mainly useful for testing.
 func ClearCache() {
 	queryCache.mu.Lock()
 	defer queryCache.mu.Unlock()
-	queryCache.queries = make(map[string]*Query)
+	queryCache.order.Init()
+	queryCache.entries = make(map[string]*list.Element)
+	queryCache.stats = CacheStats{}
+}
+
+// SetCacheSize changes how many queries the cache holds, evicting the
+// least recently used if it now holds too many. A size of 0 or less
+// disables caching.
+func SetCacheSize(n int) {
+	queryCache.mu.Lock()
+	defer queryCache.mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	queryCache.capacity = n
+	queryCache.evict()
+}
+
+// GetCacheStats returns the query cache's hit, miss and eviction counts
+// and its current size and capacity.
+func GetCacheStats() CacheStats {
+	queryCache.mu.Lock()
+	defer queryCache.mu.Unlock()
+	stats := queryCache.stats
+	stats.Size = queryCache.order.Len()
+	stats.Capacity = queryCache.capacity
+	return stats
 }
@@ -0,0 +1,113 @@
+package query_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ha1tch/queryfy/query"
+)
+
+var quotedData = map[string]interface{}{
+	"headers": map[string]interface{}{
+		"content-type": "application/json",
+		"x.forwarded":  "yes",
+	},
+	"a.b":      map[string]interface{}{"c": 1.0},
+	"user id":  42.0,
+	"größe":    "L",
+	`say "hi"`: map[string]interface{}{`back\slash`: true},
+	"items": []interface{}{
+		map[string]interface{}{"unit price": 2.5, "tags": []interface{}{"x"}},
+		map[string]interface{}{"unit price": 4.0, "tags": []interface{}{}},
+	},
+}
+
+// ======================================================================
+// Quoted field names
+// ======================================================================
+
+func TestQuoted_Execute(t *testing.T) {
+	tests := []struct {
+		query string
+		want  interface{}
+	}{
+		{`headers["content-type"]`, "application/json"},
+		{`headers['x.forwarded']`, "yes"},
+		{`['a.b'].c`, 1.0},
+		{`["user id"]`, 42.0},
+		{`["größe"]`, "L"},
+		{`["say \"hi\""]["back\\slash"]`, true},
+		{`items[1]["unit price"]`, 4.0},
+		{`items[*]["unit price"]`, []interface{}{2.5, 4.0}},
+		{`items[?(@["unit price"] > 3)]["unit price"]`, []interface{}{4.0}},
+		{`sum(items[*]["unit price"])`, 6.5},
+		{`items..["unit price"]`, []interface{}{2.5, 4.0}},
+	}
+	for _, tt := range tests {
+		got, err := query.Execute(quotedData, tt.query)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQuoted_Errors(t *testing.T) {
+	for _, q := range []string{`headers["content-type"`, `headers["content-type]`, `headers["a" "b"]`} {
+		if _, err := query.ParseQuery(q); err == nil {
+			t.Errorf("%s: expected parse error", q)
+		}
+	}
+}
+
+func TestQuoted_RoundTrip(t *testing.T) {
+	queries := []string{
+		`headers["content-type"]`,
+		`["a.b"].c`,
+		`["say \"hi\""]["back\\slash"]`,
+		`items[0]["unit price"]`,
+		`items[0].tags[0]`,
+		`items..["unit price"]`,
+		`items..[*]`,
+		`items[?(@["unit price"] > 3)].tags`,
+		`sum(items[*]["unit price"])`,
+	}
+	for _, q := range queries {
+		parsed, err := query.ParseQuery(q)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", q, err)
+		}
+		if got := parsed.String(); got != q {
+			t.Errorf("Query.String() = %s, want %s", got, q)
+		}
+
+		// Match paths parse back to the same concrete path
+		matches, err := query.ExecuteWithPaths(quotedData, q)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", q, err)
+		}
+		for _, m := range matches {
+			again, err := query.PathFromQuery(m.PathString())
+			if err != nil {
+				t.Errorf("%s: match path %s does not parse: %v", q, m.PathString(), err)
+				continue
+			}
+			if !reflect.DeepEqual(again, m.Path) {
+				t.Errorf("%s: %s parses to %v, want %v", q, m.PathString(), again, m.Path)
+			}
+		}
+	}
+}
+
+func TestQuoted_MatchPaths(t *testing.T) {
+	expectPaths(t, quotedData, `items[*]["unit price"]`,
+		`items[0]["unit price"]`, `items[1]["unit price"]`)
+
+	_, err := query.Execute(quotedData, `headers["accept-language"]`)
+	if err == nil || err.Error() != `at headers["accept-language"]: field "accept-language" not found` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
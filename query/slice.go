@@ -0,0 +1,103 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Slice is a path segment that selects a range of array elements,
+// written [start:end:step] in a query. Any part may be omitted, and
+// negative start and end count from the end of the array, as in Python:
+// items[-3:] is the last three elements and items[::2] every other one.
+// Like Wildcard, it expands to a slice of results.
+type Slice struct {
+	Start, End, Step *int
+}
+
+// String returns the slice in query syntax.
+func (s Slice) String() string {
+	part := func(p *int) string {
+		if p == nil {
+			return ""
+		}
+		return strconv.Itoa(*p)
+	}
+	out := "[" + part(s.Start) + ":" + part(s.End)
+	if s.Step != nil {
+		out += ":" + part(s.Step)
+	}
+	return out + "]"
+}
+
+// Indexes returns the positions the slice selects in an array of
+// length n, in order.
+func (s Slice) Indexes(n int) []int {
+	step := 1
+	if s.Step != nil {
+		step = *s.Step
+	}
+	if step == 0 {
+		return nil
+	}
+
+	// Python's slice bounds: clamp to [0, n] going forward and to
+	// [-1, n-1] going backward
+	lower, upper := 0, n
+	if step < 0 {
+		lower, upper = -1, n-1
+	}
+	bound := func(p *int, def int) int {
+		if p == nil {
+			return def
+		}
+		i := *p
+		if i < 0 {
+			i += n
+		}
+		if i < lower {
+			return lower
+		}
+		if i > upper {
+			return upper
+		}
+		return i
+	}
+
+	var out []int
+	if step > 0 {
+		for i, end := bound(s.Start, lower), bound(s.End, upper); i < end; i += step {
+			out = append(out, i)
+		}
+	} else {
+		for i, end := bound(s.Start, upper), bound(s.End, lower); i > end; i += step {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// Union is a path segment that selects several array elements by
+// index, written [0,2,-1] in a query. Like Wildcard, it expands to a
+// slice of results. Indexes out of range are skipped, as in RFC 9535
+// JSONPath.
+type Union struct {
+	Indexes []int
+}
+
+// String returns the union in query syntax.
+func (u Union) String() string {
+	parts := make([]string, len(u.Indexes))
+	for i, index := range u.Indexes {
+		parts[i] = strconv.Itoa(index)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+// resolveIndex turns a possibly negative index into a position in an
+// array of length n. The second result is false if it is out of bounds.
+func resolveIndex(index, n int) (int, bool) {
+	if index < 0 {
+		index += n
+	}
+	return index, index >= 0 && index < n
+}
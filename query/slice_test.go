@@ -0,0 +1,153 @@
+package query_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ha1tch/queryfy/query"
+)
+
+var sliceData = map[string]interface{}{
+	"n": []interface{}{0.0, 1.0, 2.0, 3.0, 4.0, 5.0},
+	"items": []interface{}{
+		map[string]interface{}{"price": 1.0},
+		map[string]interface{}{"price": 2.0},
+		map[string]interface{}{"price": 3.0},
+		map[string]interface{}{"price": 4.0},
+	},
+}
+
+func expectSliceQuery(t *testing.T, queryStr string, want interface{}) {
+	t.Helper()
+	got, err := query.Execute(sliceData, queryStr)
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %v", queryStr, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s:\n got %v\nwant %v", queryStr, got, want)
+	}
+}
+
+// ======================================================================
+// Negative indexes: items[-1]
+// ======================================================================
+
+func TestNegativeIndex(t *testing.T) {
+	expectSliceQuery(t, "n[-1]", 5.0)
+	expectSliceQuery(t, "n[-6]", 0.0)
+	expectSliceQuery(t, "items[-2].price", 3.0)
+
+	if _, err := query.Execute(sliceData, "n[-7]"); err == nil {
+		t.Error("expected an out of bounds error")
+	}
+}
+
+// ======================================================================
+// Slices: items[start:end:step]
+// ======================================================================
+
+func TestSlice(t *testing.T) {
+	expectSliceQuery(t, "n[1:3]", []interface{}{1.0, 2.0})
+	expectSliceQuery(t, "n[:2]", []interface{}{0.0, 1.0})
+	expectSliceQuery(t, "n[4:]", []interface{}{4.0, 5.0})
+	expectSliceQuery(t, "n[:]", []interface{}{0.0, 1.0, 2.0, 3.0, 4.0, 5.0})
+	expectSliceQuery(t, "n[::2]", []interface{}{0.0, 2.0, 4.0})
+	expectSliceQuery(t, "n[1::2]", []interface{}{1.0, 3.0, 5.0})
+	expectSliceQuery(t, "n[-2:]", []interface{}{4.0, 5.0})
+	expectSliceQuery(t, "n[:-4]", []interface{}{0.0, 1.0})
+	expectSliceQuery(t, "n[::-1]", []interface{}{5.0, 4.0, 3.0, 2.0, 1.0, 0.0})
+	expectSliceQuery(t, "n[4:1:-2]", []interface{}{4.0, 2.0})
+	// Out of range bounds are clamped
+	expectSliceQuery(t, "n[3:100]", []interface{}{3.0, 4.0, 5.0})
+	expectSliceQuery(t, "n[10:]", []interface{}{})
+	expectSliceQuery(t, "n[-100:1]", []interface{}{0.0})
+}
+
+func TestSlice_LikeWildcard(t *testing.T) {
+	expectSliceQuery(t, "items[-3:].price", []interface{}{2.0, 3.0, 4.0})
+	expectSliceQuery(t, "items[:].price", []interface{}{1.0, 2.0, 3.0, 4.0})
+
+	all, _ := query.Execute(sliceData, "items[*].price")
+	sliced, _ := query.Execute(sliceData, "items[:].price")
+	if !reflect.DeepEqual(all, sliced) {
+		t.Errorf("items[:] differs from items[*]: %v vs %v", sliced, all)
+	}
+}
+
+func TestSlice_Indexes(t *testing.T) {
+	two, minusOne := 2, -1
+	tests := []struct {
+		slice query.Slice
+		n     int
+		want  []int
+	}{
+		{query.Slice{}, 3, []int{0, 1, 2}},
+		{query.Slice{Start: &two}, 3, []int{2}},
+		{query.Slice{Step: &minusOne}, 3, []int{2, 1, 0}},
+		{query.Slice{Step: &two}, 0, nil},
+	}
+	for _, tt := range tests {
+		if got := tt.slice.Indexes(tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s on %d: got %v, want %v", tt.slice, tt.n, got, tt.want)
+		}
+	}
+}
+
+// ======================================================================
+// Unions: items[0,2,5]
+// ======================================================================
+
+func TestUnion(t *testing.T) {
+	expectSliceQuery(t, "n[0,2,5]", []interface{}{0.0, 2.0, 5.0})
+	expectSliceQuery(t, "n[5,0]", []interface{}{5.0, 0.0})
+	expectSliceQuery(t, "n[0,-1]", []interface{}{0.0, 5.0})
+	expectSliceQuery(t, "items[1,3].price", []interface{}{2.0, 4.0})
+
+	// Out of range indexes select nothing, as slice bounds are clamped
+	expectSliceQuery(t, "n[0,9]", []interface{}{0.0})
+	expectSliceQuery(t, "n[-9,1,7]", []interface{}{1.0})
+	expectSliceQuery(t, "n[6,7]", []interface{}{})
+}
+
+func TestSliceAndUnion_Nested(t *testing.T) {
+	data := map[string]interface{}{
+		"rows": []interface{}{
+			[]interface{}{1.0, 2.0, 3.0},
+			[]interface{}{4.0, 5.0, 6.0},
+		},
+	}
+	got, err := query.Execute(data, "rows[*][-1]")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, []interface{}{3.0, 6.0}) {
+		t.Errorf("unexpected result %v", got)
+	}
+
+	got, err = query.Execute(data, "rows[0:2][0,2]")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, []interface{}{1.0, 3.0, 4.0, 6.0}) {
+		t.Errorf("unexpected result %v", got)
+	}
+}
+
+func TestSliceAndUnion_Parse(t *testing.T) {
+	path, err := query.PathFromQuery("items[1:-1:2].tags[0,-1]")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s, ok := path[1].(query.Slice); !ok || s.String() != "[1:-1:2]" {
+		t.Errorf("unexpected slice segment %#v", path[1])
+	}
+	if u, ok := path[3].(query.Union); !ok || u.String() != "[0,-1]" {
+		t.Errorf("unexpected union segment %#v", path[3])
+	}
+
+	for _, bad := range []string{"n[::0]", "n[1:2:3:4]", "n[0,]", "n[1,a]", "n[]", "n[,1]"} {
+		if _, err := query.ParseQuery(bad); err == nil {
+			t.Errorf("%s: expected a parse error", bad)
+		}
+	}
+}
@@ -54,6 +54,37 @@ func Query(data interface{}, queryStr string) (interface{}, error) {
 	return query.Execute(data, queryStr)
 }
 
+// QueryPointer returns the value a JSON Pointer (RFC 6901) such as
+// "/items/0/price" refers to. See query.ExecutePointer.
+func QueryPointer(data interface{}, pointer string) (interface{}, error) {
+	return query.ExecutePointer(data, pointer)
+}
+
+// Set stores value at the path a query names, creating missing maps and
+// arrays on the way, and returns the updated data:
+//
+//	data, err = queryfy.Set(data, "user.address.city", "Lisbon")
+//
+// Maps and slices are updated in place, but the root may be replaced,
+// so always use the result. See query.Set.
+func Set(data interface{}, queryStr string, value interface{}) (interface{}, error) {
+	return query.Set(data, queryStr, value)
+}
+
+// SetAll stores value at every location a query with wildcards or
+// filters reaches, such as "items[*].currency", and returns the updated
+// data. See query.SetAll.
+func SetAll(data interface{}, queryStr string, value interface{}) (interface{}, error) {
+	return query.SetAll(data, queryStr, value)
+}
+
+// Delete removes every value a query reaches, deleting map entries and
+// removing slice elements, and returns the updated data. Deleting a
+// path that does not exist is not an error. See query.Delete.
+func Delete(data interface{}, queryStr string) (interface{}, error) {
+	return query.Delete(data, queryStr)
+}
+
 // NewValidator creates a new validator with a schema.
 // The validator can be configured with different modes and options.
 func NewValidator(schema Schema) *Validator {
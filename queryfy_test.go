@@ -822,6 +822,38 @@ func TestQuery(t *testing.T) {
 	}
 }
 
+// Test write operations
+func TestSetAndDelete(t *testing.T) {
+	data := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"sku": "a"},
+			map[string]interface{}{"sku": "b", "tmp": true},
+		},
+	}
+
+	out, err := qf.Set(data, "user.address.city", "Lisbon")
+	if err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, _ := qf.Query(out, "user.address.city"); got != "Lisbon" {
+		t.Errorf("Set() city = %v", got)
+	}
+
+	if out, err = qf.SetAll(out, "items[*].currency", "EUR"); err != nil {
+		t.Fatalf("SetAll() error = %v", err)
+	}
+	if got, _ := qf.Query(out, "items[1].currency"); got != "EUR" {
+		t.Errorf("SetAll() currency = %v", got)
+	}
+
+	if out, err = qf.Delete(out, "items[*].tmp"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := qf.Query(out, "items[1].tmp"); err == nil {
+		t.Error("Delete() left items[1].tmp")
+	}
+}
+
 // Test error messages with paths
 func TestErrorPaths(t *testing.T) {
 	schema := builders.Object().
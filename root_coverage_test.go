@@ -238,6 +238,55 @@ func TestContext_AddFieldError(t *testing.T) {
 	}
 }
 
+func TestContext_JSONPointerPaths(t *testing.T) {
+	schema := builders.Object().Field("items", builders.Array().Of(
+		builders.Object().
+			Field("price", builders.Number().Min(0)).
+			Field("a/b", builders.String()),
+	))
+	data := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"price": 1.0, "a/b": "ok"},
+			map[string]interface{}{"price": -1.0, "a/b": 7.0},
+		},
+	}
+
+	ctx := queryfy.NewValidationContext(queryfy.Strict)
+	ctx.SetPathFormat(queryfy.JSONPointerPaths)
+	schema.Validate(data, ctx)
+
+	paths := map[string]bool{}
+	for _, fe := range ctx.Errors() {
+		paths[fe.Path] = true
+	}
+	if len(paths) != 2 || !paths["/items/1/price"] || !paths["/items/1/a~1b"] {
+		t.Errorf("unexpected error paths: %v", ctx.Errors())
+	}
+
+	// Reset keeps the format; the default is query notation
+	ctx.Reset()
+	if ctx.PathFormat() != queryfy.JSONPointerPaths {
+		t.Error("Reset should preserve the path format")
+	}
+	ctx = queryfy.NewValidationContext(queryfy.Strict)
+	schema.Validate(data, ctx)
+	for _, fe := range ctx.Errors() {
+		if fe.Path != "items[1].price" && fe.Path != "items[1].a/b" {
+			t.Errorf("unexpected default path %q", fe.Path)
+		}
+	}
+}
+
+func TestQueryPointer(t *testing.T) {
+	data := map[string]interface{}{
+		"items": []interface{}{map[string]interface{}{"price": 9.5}},
+	}
+	price, err := queryfy.QueryPointer(data, "/items/0/price")
+	if err != nil || price != 9.5 {
+		t.Errorf("got %v, %v", price, err)
+	}
+}
+
 // ======================================================================
 // ConvertToString edge cases
 // ======================================================================
@@ -82,11 +82,11 @@ func (s *BaseSchema) SetNullable(nullable bool) {
 func (s *BaseSchema) CheckRequired(value interface{}, ctx *ValidationContext) bool {
 	if value == nil {
 		if s.required {
-			ctx.AddError("field is required", nil)
+			ctx.AddCodedError(CodeRequired, nil, nil)
 			return false
 		}
 		if !s.nullable {
-			ctx.AddError("field cannot be null", nil)
+			ctx.AddCodedError(CodeNotNull, nil, nil)
 			return false
 		}
 		return false // Don't continue validation for nil values
@@ -0,0 +1,440 @@
+package queryfy
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ha1tch/queryfy/superjsonic"
+)
+
+// StreamFormat is the layout of a stream of records.
+type StreamFormat int
+
+const (
+	// StreamAuto reads a top-level array when the stream starts with
+	// '[', and newline-delimited JSON otherwise.
+	StreamAuto StreamFormat = iota
+	// StreamArray reads the elements of one top-level JSON array.
+	StreamArray
+	// StreamNDJSON reads newline-delimited JSON: one document per line,
+	// with blank lines ignored.
+	StreamNDJSON
+)
+
+// DefaultMaxRecordSize is the largest record, in bytes, a
+// StreamValidator reads unless MaxRecordSize is set.
+const DefaultMaxRecordSize = 64 << 20
+
+// RecordError reports the records of a stream that failed validation.
+type RecordError struct {
+	// Index is the zero-based position of the record in the stream
+	Index int
+	// Offset is the byte offset of the record in the stream
+	Offset int
+	// Err is a *ValidationError whose paths are relative to the record
+	// and whose offsets are offsets in the stream. For NDJSON a line
+	// that is not valid JSON is reported as a *superjsonic.SyntaxError.
+	Err error
+}
+
+// Error returns the record index and its errors.
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// StreamValidator validates every record of a stream against a schema,
+// reading one record at a time, so memory use is bounded by the largest
+// record rather than the size of the stream.
+type StreamValidator struct {
+	schema        Schema
+	mode          ValidationMode
+	format        StreamFormat
+	maxRecordSize int
+}
+
+// NewStreamValidator creates a stream validator in strict mode that
+// detects the stream format.
+func NewStreamValidator(schema Schema) *StreamValidator {
+	return &StreamValidator{
+		schema:        schema,
+		mode:          Strict,
+		format:        StreamAuto,
+		maxRecordSize: DefaultMaxRecordSize,
+	}
+}
+
+// Strict sets the validator to strict mode.
+func (v *StreamValidator) Strict() *StreamValidator {
+	v.mode = Strict
+	return v
+}
+
+// Loose sets the validator to loose mode.
+func (v *StreamValidator) Loose() *StreamValidator {
+	v.mode = Loose
+	return v
+}
+
+// Format sets the stream format.
+func (v *StreamValidator) Format(format StreamFormat) *StreamValidator {
+	v.format = format
+	return v
+}
+
+// MaxRecordSize sets the largest record, in bytes, that will be read.
+// A larger record stops the stream with an error.
+func (v *StreamValidator) MaxRecordSize(size int) *StreamValidator {
+	v.maxRecordSize = size
+	return v
+}
+
+// Validate reads records from r and validates each one, calling
+// onError for every record that fails. It returns the number of records
+// read. Validation failures do not stop the stream; onError can stop it
+// by returning an error, which Validate returns. I/O errors, records
+// over the size limit and malformed JSON in an array also stop it.
+//
+// To receive failures on a channel, send from onError:
+//
+//	failures := make(chan *queryfy.RecordError)
+//	go func() {
+//		defer close(failures)
+//		_, err := v.Validate(file, func(e *queryfy.RecordError) error {
+//			failures <- e
+//			return nil
+//		})
+//		...
+//	}()
+func (v *StreamValidator) Validate(r io.Reader, onError func(*RecordError) error) (int, error) {
+	s := &recordStream{
+		in:      bufio.NewReaderSize(r, 64<<10),
+		max:     v.maxRecordSize,
+		ctx:     NewValidationContext(v.mode),
+		reader:  NewJSONReader(nil),
+		schema:  v.schema,
+		onError: onError,
+	}
+
+	format := v.format
+	if format == StreamAuto {
+		format = StreamNDJSON
+		c, err := s.skipSpace()
+		if err == io.EOF {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if c == '[' {
+			format = StreamArray
+		}
+		s.unreadByte()
+	}
+
+	var err error
+	if format == StreamArray {
+		err = s.readArray()
+	} else {
+		err = s.readLines()
+	}
+	return s.records, err
+}
+
+// ValidateStream validates every record of a top-level JSON array or
+// newline-delimited JSON stream, calling onError for each record that
+// fails. See StreamValidator.
+func ValidateStream(r io.Reader, schema Schema, mode ValidationMode, onError func(*RecordError) error) (int, error) {
+	v := NewStreamValidator(schema)
+	v.mode = mode
+	return v.Validate(r, onError)
+}
+
+// recordStream splits a stream into records and validates them.
+type recordStream struct {
+	in      *bufio.Reader
+	offset  int    // offset in the stream of the next byte
+	buf     []byte // the current record, reused between records
+	max     int
+	records int
+
+	ctx     *ValidationContext
+	reader  *JSONReader
+	schema  Schema
+	onError func(*RecordError) error
+}
+
+// readArray reads the elements of a top-level array.
+func (s *recordStream) readArray() error {
+	c, err := s.skipSpace()
+	if err != nil {
+		return s.syntaxError(err, "expected '[' at start of array")
+	}
+	if c != '[' {
+		return s.syntaxErrorAt(s.offset-1, "expected '[' at start of array, got %q", c)
+	}
+
+	c, err = s.skipSpace()
+	if err != nil {
+		return s.syntaxError(err, "unexpected end of input")
+	}
+	if c != ']' {
+		for {
+			start := s.offset - 1
+			if err := s.readValue(c); err != nil {
+				return err
+			}
+			if err := s.validate(start, true); err != nil {
+				return err
+			}
+
+			c, err = s.skipSpace()
+			if err != nil {
+				return s.syntaxError(err, "unexpected end of input")
+			}
+			if c == ']' {
+				break
+			}
+			if c != ',' {
+				return s.syntaxErrorAt(s.offset-1, "expected ',' or ']' after array element, got %q", c)
+			}
+			if c, err = s.skipSpace(); err != nil {
+				return s.syntaxError(err, "unexpected end of input")
+			}
+		}
+	}
+
+	c, err = s.skipSpace()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.syntaxErrorAt(s.offset-1, "unexpected %q after top-level value", c)
+}
+
+// readLines reads newline-delimited records.
+func (s *recordStream) readLines() error {
+	for {
+		start := s.offset
+		s.buf = s.buf[:0]
+		for {
+			chunk, err := s.in.ReadSlice('\n')
+			s.offset += len(chunk)
+			if len(s.buf)+len(chunk) > s.max {
+				return s.tooLarge(start)
+			}
+			s.buf = append(s.buf, chunk...)
+			if err == bufio.ErrBufferFull {
+				continue
+			}
+			if err == io.EOF {
+				if len(bytes.TrimSpace(s.buf)) > 0 {
+					return s.validate(start, false)
+				}
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			break
+		}
+		if len(bytes.TrimSpace(s.buf)) == 0 {
+			continue
+		}
+		if err := s.validate(start, false); err != nil {
+			return err
+		}
+	}
+}
+
+// validate validates the record in buf, which starts at offset start.
+// A syntax error stops an array; in NDJSON it is reported for the
+// record.
+func (s *recordStream) validate(start int, inArray bool) error {
+	index := s.records
+	s.records++
+
+	s.ctx.Reset()
+	s.reader.Reset(s.buf)
+	err := s.reader.Validate(s.schema, s.ctx)
+	if err == nil {
+		// Reject trailing data in the record
+		_, err = s.reader.Next()
+	}
+
+	var syn *superjsonic.SyntaxError
+	switch {
+	case errors.As(err, &syn):
+		err = &superjsonic.SyntaxError{Offset: start + syn.Offset, Msg: syn.Msg}
+		if inArray {
+			return err
+		}
+	case err != nil:
+		return err
+	case !s.ctx.HasErrors():
+		return nil
+	default:
+		// The context is reused, so its errors are copied
+		errs := make([]FieldError, len(s.ctx.Errors()))
+		for i, fieldErr := range s.ctx.Errors() {
+			fieldErr.Offset += start
+			errs[i] = fieldErr
+		}
+		err = &ValidationError{Errors: errs}
+	}
+
+	if s.onError == nil {
+		return nil
+	}
+	return s.onError(&RecordError{Index: index, Offset: start, Err: err})
+}
+
+// readValue reads the value starting with first into buf. Objects,
+// arrays and strings are read to their closing byte, other values up
+// to the next delimiter; their syntax is checked when the record is
+// validated.
+func (s *recordStream) readValue(first byte) error {
+	start := s.offset - 1
+	s.buf = append(s.buf[:0], first)
+
+	switch first {
+	case ',', ':', ']', '}':
+		return s.syntaxErrorAt(start, "unexpected %q looking for a value", first)
+
+	case '{', '[':
+		depth, inString, escaped := 1, false, false
+		for depth > 0 {
+			c, err := s.readRecordByte(start)
+			if err != nil {
+				return err
+			}
+			switch {
+			case inString && escaped:
+				escaped = false
+			case inString && c == '\\':
+				escaped = true
+			case inString && c == '"':
+				inString = false
+			case inString:
+			case c == '"':
+				inString = true
+			case c == '{' || c == '[':
+				depth++
+			case c == '}' || c == ']':
+				depth--
+			}
+		}
+
+	case '"':
+		escaped := false
+		for {
+			c, err := s.readRecordByte(start)
+			if err != nil {
+				return err
+			}
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				break
+			}
+		}
+
+	default:
+		for {
+			c, err := s.readByte()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if isStreamDelimiter(c) {
+				s.unreadByte()
+				return nil
+			}
+			if len(s.buf) >= s.max {
+				return s.tooLarge(start)
+			}
+			s.buf = append(s.buf, c)
+		}
+	}
+	return nil
+}
+
+// readRecordByte reads the next byte of a record into buf.
+func (s *recordStream) readRecordByte(start int) (byte, error) {
+	c, err := s.readByte()
+	if err != nil {
+		return 0, s.syntaxError(err, "unexpected end of input")
+	}
+	if len(s.buf) >= s.max {
+		return 0, s.tooLarge(start)
+	}
+	s.buf = append(s.buf, c)
+	return c, nil
+}
+
+func (s *recordStream) readByte() (byte, error) {
+	c, err := s.in.ReadByte()
+	if err == nil {
+		s.offset++
+	}
+	return c, err
+}
+
+func (s *recordStream) unreadByte() {
+	s.in.UnreadByte()
+	s.offset--
+}
+
+// skipSpace reads up to and including the next byte that is not
+// whitespace.
+func (s *recordStream) skipSpace() (byte, error) {
+	for {
+		c, err := s.readByte()
+		if err != nil {
+			return 0, err
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+		default:
+			return c, nil
+		}
+	}
+}
+
+func (s *recordStream) tooLarge(start int) error {
+	return fmt.Errorf("record %d at offset %d exceeds the maximum size of %d bytes", s.records, start, s.max)
+}
+
+// syntaxError turns an unexpected end of input into a syntax error at
+// the current offset and passes other read errors through.
+func (s *recordStream) syntaxError(err error, msg string) error {
+	if err == io.EOF {
+		return &superjsonic.SyntaxError{Offset: s.offset, Msg: msg}
+	}
+	return err
+}
+
+func (s *recordStream) syntaxErrorAt(offset int, format string, args ...interface{}) error {
+	return &superjsonic.SyntaxError{Offset: offset, Msg: fmt.Sprintf(format, args...)}
+}
+
+func isStreamDelimiter(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', ',', ']', '}':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package queryfy_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ha1tch/queryfy"
+	"github.com/ha1tch/queryfy/builders"
+	"github.com/ha1tch/queryfy/superjsonic"
+)
+
+func streamSchema() queryfy.Schema {
+	return builders.Object().
+		Field("id", builders.Number().Integer().Required()).
+		Field("name", builders.String().Required().MinLength(2))
+}
+
+// collect validates a stream and returns the failing records.
+func collect(t *testing.T, v *queryfy.StreamValidator, input string) ([]*queryfy.RecordError, int, error) {
+	t.Helper()
+	var failures []*queryfy.RecordError
+	n, err := v.Validate(strings.NewReader(input), func(e *queryfy.RecordError) error {
+		failures = append(failures, e)
+		return nil
+	})
+	return failures, n, err
+}
+
+// ======================================================================
+// Arrays
+// ======================================================================
+
+func TestValidateStream_Array(t *testing.T) {
+	input := ` [ {"id":1,"name":"ok"}, {"id":2,"name":"x"} ,{"name":"no id"}, {"id":4,"name":"ok"} ] `
+	failures, n, err := collect(t, queryfy.NewStreamValidator(streamSchema()), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Errorf("records = %d, want 4", n)
+	}
+	if len(failures) != 2 || failures[0].Index != 1 || failures[1].Index != 2 {
+		t.Fatalf("failures = %v", failures)
+	}
+
+	first := failures[0]
+	if first.Offset != strings.Index(input, `{"id":2`) {
+		t.Errorf("record offset = %d", first.Offset)
+	}
+	var verr *queryfy.ValidationError
+	if !errors.As(first, &verr) || len(verr.Errors) != 1 {
+		t.Fatalf("error = %v", first.Err)
+	}
+	if e := verr.Errors[0]; e.Path != "name" || e.Offset != strings.Index(input, `"x"`) {
+		t.Errorf("field error = %+v", e)
+	}
+	if got := failures[1].Error(); got != "record 2: validation failed: id: field is required" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestValidateStream_ArrayOfScalars(t *testing.T) {
+	input := `[1, "two", 3.5, true, null, [4], {"s":"]"}]`
+	var indexes []int
+	n, err := queryfy.ValidateStream(strings.NewReader(input), builders.Number(), queryfy.Strict,
+		func(e *queryfy.RecordError) error {
+			indexes = append(indexes, e.Index)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 7 || fmt.Sprint(indexes) != "[1 3 4 5 6]" {
+		t.Errorf("records = %d, failures at %v", n, indexes)
+	}
+}
+
+func TestValidateStream_EmptyArray(t *testing.T) {
+	for _, input := range []string{`[]`, ` [ ] `, ``} {
+		failures, n, err := collect(t, queryfy.NewStreamValidator(streamSchema()), input)
+		if err != nil || n != 0 || len(failures) != 0 {
+			t.Errorf("%q: %d records, %v, %v", input, n, failures, err)
+		}
+	}
+}
+
+func TestValidateStream_ArraySyntaxErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		offset int
+	}{
+		{`[{"id":1,"name":"ok"} {"id":2}]`, 22},
+		{`[{"id":1,"name":"ok"},]`, 22},
+		{`[{"id":1 "name":"ok"}]`, 9},
+		{`[{"id":1,"name":"ok"}`, 21},
+		{`[1] 2`, 4},
+		{`[tru]`, 1},
+	}
+	for _, tt := range tests {
+		_, _, err := collect(t, queryfy.NewStreamValidator(builders.Object()).Loose(), tt.input)
+		var syn *superjsonic.SyntaxError
+		if !errors.As(err, &syn) {
+			t.Errorf("%s: error = %v, want a syntax error", tt.input, err)
+			continue
+		}
+		if syn.Offset != tt.offset {
+			t.Errorf("%s: offset = %d, want %d (%v)", tt.input, syn.Offset, tt.offset, err)
+		}
+	}
+}
+
+// ======================================================================
+// NDJSON
+// ======================================================================
+
+func TestValidateStream_NDJSON(t *testing.T) {
+	input := "{\"id\":1,\"name\":\"ok\"}\n\n{\"id\":1.5,\"name\":\"ok\"}\r\n{\"id\":3,\n{\"id\":4,\"name\":\"ok\"}"
+	failures, n, err := collect(t, queryfy.NewStreamValidator(streamSchema()), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 || len(failures) != 2 {
+		t.Fatalf("records = %d, failures = %v", n, failures)
+	}
+
+	var verr *queryfy.ValidationError
+	if !errors.As(failures[0], &verr) || failures[0].Index != 1 || verr.Errors[0].Path != "id" {
+		t.Errorf("first failure = %v", failures[0])
+	}
+	var syn *superjsonic.SyntaxError
+	if !errors.As(failures[1], &syn) || failures[1].Index != 2 {
+		t.Errorf("second failure = %v", failures[1])
+	}
+	if failures[1].Offset != strings.Index(input, `{"id":3`) {
+		t.Errorf("offset = %d", failures[1].Offset)
+	}
+}
+
+func TestValidateStream_ForcedFormat(t *testing.T) {
+	// Each line is an array, which auto-detection would read as one array
+	input := "[1,2]\n[3,\"x\"]\n"
+	v := queryfy.NewStreamValidator(builders.Array().Of(builders.Number())).Format(queryfy.StreamNDJSON)
+	failures, n, err := collect(t, v, input)
+	if err != nil || n != 2 || len(failures) != 1 || failures[0].Index != 1 {
+		t.Fatalf("records = %d, failures = %v, err = %v", n, failures, err)
+	}
+
+	_, _, err = collect(t, v.Format(queryfy.StreamArray), `{"id":1}`)
+	if err == nil {
+		t.Error("expected an error reading an object as an array")
+	}
+}
+
+// ======================================================================
+// Limits and stopping
+// ======================================================================
+
+func TestValidateStream_MaxRecordSize(t *testing.T) {
+	v := queryfy.NewStreamValidator(builders.String()).MaxRecordSize(8)
+	for _, input := range []string{`["short", "much too long"]`, "\"short\"\n\"much too long\"\n"} {
+		_, n, err := collect(t, v, input)
+		if err == nil || !strings.Contains(err.Error(), "exceeds the maximum size") || n != 1 {
+			t.Errorf("%q: records = %d, err = %v", input, n, err)
+		}
+	}
+}
+
+func TestValidateStream_CallbackStops(t *testing.T) {
+	stop := errors.New("stop")
+	input := `[1, 2, 3, 4]`
+	n, err := queryfy.ValidateStream(strings.NewReader(input), builders.String(), queryfy.Strict,
+		func(e *queryfy.RecordError) error {
+			if e.Index == 1 {
+				return stop
+			}
+			return nil
+		})
+	if err != stop || n != 2 {
+		t.Errorf("records = %d, err = %v", n, err)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, io.ErrUnexpectedEOF }
+
+func TestValidateStream_ReadError(t *testing.T) {
+	_, err := queryfy.ValidateStream(failingReader{}, builders.String(), queryfy.Strict, nil)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("err = %v", err)
+	}
+}
+
+// ======================================================================
+// Benchmarks
+// ======================================================================
+
+func BenchmarkValidateStream(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"id":%d,"name":"record %d"}`, i, i)
+	}
+	sb.WriteString("]")
+	input := sb.String()
+	schema := streamSchema()
+
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := queryfy.ValidateStream(strings.NewReader(input), schema, queryfy.Strict, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package superjsonic
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Valid reports whether data is a single well-formed JSON document.
+func Valid(data []byte) bool {
+	t := NewTokenizer(data)
+	for {
+		tok, err := t.Next()
+		if err != nil {
+			return false
+		}
+		if tok.Type == EOF {
+			return true
+		}
+	}
+}
+
+// Unquote decodes the raw bytes of a String or Key token, including its
+// quotes, into a Go string. The token must come from a Tokenizer, which
+// has already checked its escapes.
+func Unquote(raw []byte) (string, error) {
+	if len(raw) < 2 || raw[0] != '"' || raw[len(raw)-1] != '"' {
+		return "", fmt.Errorf("superjsonic: %q is not a quoted string", raw)
+	}
+	s := raw[1 : len(raw)-1]
+
+	// Fast path: nothing to unescape
+	escaped := false
+	for _, c := range s {
+		if c == '\\' {
+			escaped = true
+			break
+		}
+	}
+	if !escaped {
+		return string(s), nil
+	}
+
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c != '\\' {
+			out = append(out, c)
+			i++
+			continue
+		}
+		if i+1 >= len(s) {
+			return "", fmt.Errorf("superjsonic: truncated escape in %q", raw)
+		}
+		switch s[i+1] {
+		case '"', '\\', '/':
+			out = append(out, s[i+1])
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'u':
+			r, ok := hex4(s, i+2)
+			if !ok {
+				return "", fmt.Errorf("superjsonic: invalid \\u escape in %q", raw)
+			}
+			i += 6
+			if utf16.IsSurrogate(r) {
+				// A surrogate pair is two escapes; a lone surrogate
+				// decodes to the replacement character, as in
+				// encoding/json
+				if i+1 < len(s) && s[i] == '\\' && s[i+1] == 'u' {
+					if r2, ok := hex4(s, i+2); ok {
+						if dec := utf16.DecodeRune(r, r2); dec != utf8.RuneError {
+							out = utf8.AppendRune(out, dec)
+							i += 6
+							continue
+						}
+					}
+				}
+				r = utf8.RuneError
+			}
+			out = utf8.AppendRune(out, r)
+			continue
+		default:
+			return "", fmt.Errorf("superjsonic: invalid escape \\%c in %q", s[i+1], raw)
+		}
+		i += 2
+	}
+	return string(out), nil
+}
+
+// hex4 parses the four hex digits at s[i:].
+func hex4(s []byte, i int) (rune, bool) {
+	if i+4 > len(s) {
+		return 0, false
+	}
+	var r rune
+	for _, c := range s[i : i+4] {
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, false
+		}
+		r = r<<4 | rune(c)
+	}
+	return r, true
+}
+
+// Skip consumes the next value, including everything inside it when it
+// is an object or array.
+func (t *Tokenizer) Skip() error {
+	tok, err := t.Next()
+	if err != nil {
+		return err
+	}
+	if !tok.IsValue() {
+		return &SyntaxError{Offset: tok.Offset, Msg: fmt.Sprintf("expected a value, got %s", tok.Type)}
+	}
+	if tok.Type != ObjectStart && tok.Type != ArrayStart {
+		return nil
+	}
+	depth := t.Depth() - 1
+	for t.Depth() > depth {
+		if _, err := t.Next(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode consumes the next value and returns it with the types
+// encoding/json uses for interface{}: map[string]interface{},
+// []interface{}, string, float64, bool and nil.
+func (t *Tokenizer) Decode() (interface{}, error) {
+	tok, err := t.Next()
+	if err != nil {
+		return nil, err
+	}
+	return t.decodeFrom(tok)
+}
+
+// decodeFrom decodes the value that starts with tok.
+func (t *Tokenizer) decodeFrom(tok Token) (interface{}, error) {
+	switch tok.Type {
+	case ObjectStart:
+		obj := make(map[string]interface{})
+		for {
+			key, err := t.Next()
+			if err != nil {
+				return nil, err
+			}
+			if key.Type == ObjectEnd {
+				return obj, nil
+			}
+			name, err := Unquote(t.Bytes(key))
+			if err != nil {
+				return nil, err
+			}
+			value, err := t.Decode()
+			if err != nil {
+				return nil, err
+			}
+			obj[name] = value
+		}
+
+	case ArrayStart:
+		arr := make([]interface{}, 0)
+		for {
+			next, err := t.Next()
+			if err != nil {
+				return nil, err
+			}
+			if next.Type == ArrayEnd {
+				return arr, nil
+			}
+			value, err := t.decodeFrom(next)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+
+	case String:
+		return Unquote(t.Bytes(tok))
+
+	case Number:
+		f, err := strconv.ParseFloat(string(t.Bytes(tok)), 64)
+		if err != nil {
+			return nil, &SyntaxError{Offset: tok.Offset, Msg: fmt.Sprintf("number %s out of range", t.Bytes(tok))}
+		}
+		return f, nil
+
+	case True:
+		return true, nil
+	case False:
+		return false, nil
+	case Null:
+		return nil, nil
+	}
+	return nil, &SyntaxError{Offset: tok.Offset, Msg: fmt.Sprintf("expected a value, got %s", tok.Type)}
+}
@@ -0,0 +1,412 @@
+// Package superjsonic is a JSON tokenizer that works directly on the
+// input bytes. It checks the syntax of a document as it goes and hands
+// out tokens that locate values by byte offset, without building a
+// tree or allocating per token, so a caller can validate or decode
+// only what it needs.
+//
+// Example:
+//
+//	t := superjsonic.NewTokenizer(data)
+//	for {
+//		tok, err := t.Next()
+//		if err != nil {
+//			return err // a *superjsonic.SyntaxError
+//		}
+//		if tok.Type == superjsonic.EOF {
+//			break
+//		}
+//		fmt.Println(tok.Type, string(t.Bytes(tok)))
+//	}
+//
+// Commas and colons are checked but not returned as tokens. Object keys
+// are returned as Key tokens, so a consumer need not track whether a
+// string is a key or a value.
+package superjsonic
+
+import (
+	"fmt"
+)
+
+// TokenType identifies the kind of a token.
+type TokenType uint8
+
+const (
+	Invalid TokenType = iota
+	ObjectStart
+	ObjectEnd
+	ArrayStart
+	ArrayEnd
+	Key
+	String
+	Number
+	True
+	False
+	Null
+	EOF
+)
+
+var tokenTypeNames = [...]string{
+	Invalid:     "invalid",
+	ObjectStart: "{",
+	ObjectEnd:   "}",
+	ArrayStart:  "[",
+	ArrayEnd:    "]",
+	Key:         "key",
+	String:      "string",
+	Number:      "number",
+	True:        "true",
+	False:       "false",
+	Null:        "null",
+	EOF:         "end of input",
+}
+
+// String returns the name of the token type.
+func (t TokenType) String() string {
+	if int(t) < len(tokenTypeNames) {
+		return tokenTypeNames[t]
+	}
+	return fmt.Sprintf("TokenType(%d)", uint8(t))
+}
+
+// Token is a lexical token. Offset and Length locate it in the input;
+// for strings and keys they include the quotes.
+type Token struct {
+	Type   TokenType
+	Offset int
+	Length int
+}
+
+// IsValue reports whether the token starts a value: a scalar, or the
+// start of an object or array.
+func (t Token) IsValue() bool {
+	switch t.Type {
+	case ObjectStart, ArrayStart, String, Number, True, False, Null:
+		return true
+	}
+	return false
+}
+
+// SyntaxError describes malformed JSON.
+type SyntaxError struct {
+	Offset int    // byte offset of the problem in the input
+	Msg    string // description of the problem
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid JSON at offset %d: %s", e.Offset, e.Msg)
+}
+
+// MaxDepth is the deepest nesting of objects and arrays a Tokenizer
+// accepts.
+const MaxDepth = 10000
+
+// state says what the tokenizer expects next.
+type state uint8
+
+const (
+	stateValue       state = iota // a value
+	stateObjectFirst              // a key or '}'
+	stateArrayFirst               // a value or ']'
+	stateKey                      // a key
+	stateColon                    // ':' then a value
+	stateAfterValue               // ',' or the end of the container
+)
+
+// Tokenizer reads the tokens of one JSON document. The zero value is
+// not usable; use NewTokenizer or Reset. A Tokenizer may be reused with
+// Reset to avoid allocating.
+type Tokenizer struct {
+	data  []byte
+	pos   int
+	stack []byte // '{' or '[' for each open container
+	state state
+}
+
+// NewTokenizer returns a tokenizer for data. The tokenizer does not copy
+// data, which must not change while it is in use.
+func NewTokenizer(data []byte) *Tokenizer {
+	t := &Tokenizer{stack: make([]byte, 0, 16)}
+	t.Reset(data)
+	return t
+}
+
+// Reset prepares the tokenizer to read a new document.
+func (t *Tokenizer) Reset(data []byte) {
+	t.data = data
+	t.pos = 0
+	t.stack = t.stack[:0]
+	t.state = stateValue
+}
+
+// Bytes returns the raw input of a token.
+func (t *Tokenizer) Bytes(tok Token) []byte {
+	return t.data[tok.Offset : tok.Offset+tok.Length]
+}
+
+// Depth returns the number of objects and arrays currently open.
+func (t *Tokenizer) Depth() int {
+	return len(t.stack)
+}
+
+// Offset returns the offset of the next unread byte.
+func (t *Tokenizer) Offset() int {
+	return t.pos
+}
+
+// Peek returns the next token without consuming it.
+func (t *Tokenizer) Peek() (Token, error) {
+	pos, st, depth := t.pos, t.state, len(t.stack)
+	tok, err := t.Next()
+	// Restoring the length also restores a popped container, whose byte
+	// is still in the backing array
+	t.pos, t.state, t.stack = pos, st, t.stack[:depth]
+	return tok, err
+}
+
+// Next returns the next token. After the top-level value it returns
+// EOF; anything but whitespace after that value is an error.
+func (t *Tokenizer) Next() (Token, error) {
+	t.skipWhitespace()
+
+	switch t.state {
+	case stateAfterValue:
+		if len(t.stack) == 0 {
+			if t.pos < len(t.data) {
+				return Token{}, t.errorf("unexpected %s after top-level value", t.describe())
+			}
+			return Token{Type: EOF, Offset: t.pos}, nil
+		}
+		if t.pos >= len(t.data) {
+			return Token{}, t.errorf("unexpected end of input")
+		}
+		top := t.stack[len(t.stack)-1]
+		switch c := t.data[t.pos]; {
+		case c == ',':
+			t.pos++
+			t.skipWhitespace()
+			if top == '{' {
+				t.state = stateKey
+			} else {
+				t.state = stateValue
+			}
+		case c == '}' && top == '{', c == ']' && top == '[':
+			return t.close(), nil
+		case top == '{':
+			return Token{}, t.errorf("expected ',' or '}' after object value, got %s", t.describe())
+		default:
+			return Token{}, t.errorf("expected ',' or ']' after array element, got %s", t.describe())
+		}
+
+	case stateColon:
+		if t.pos >= len(t.data) || t.data[t.pos] != ':' {
+			return Token{}, t.errorf("expected ':' after object key, got %s", t.describe())
+		}
+		t.pos++
+		t.skipWhitespace()
+		t.state = stateValue
+
+	case stateObjectFirst:
+		if t.pos < len(t.data) && t.data[t.pos] == '}' {
+			return t.close(), nil
+		}
+		t.state = stateKey
+
+	case stateArrayFirst:
+		if t.pos < len(t.data) && t.data[t.pos] == ']' {
+			return t.close(), nil
+		}
+		t.state = stateValue
+	}
+
+	if t.pos >= len(t.data) {
+		return Token{}, t.errorf("unexpected end of input")
+	}
+
+	if t.state == stateKey {
+		if t.data[t.pos] != '"' {
+			return Token{}, t.errorf("expected string for object key, got %s", t.describe())
+		}
+		tok, err := t.scanString(Key)
+		if err != nil {
+			return Token{}, err
+		}
+		t.state = stateColon
+		return tok, nil
+	}
+
+	return t.scanValue()
+}
+
+// scanValue reads the token that starts a value.
+func (t *Tokenizer) scanValue() (Token, error) {
+	start := t.pos
+	switch c := t.data[t.pos]; c {
+	case '{', '[':
+		if len(t.stack) >= MaxDepth {
+			return Token{}, t.errorf("exceeded maximum nesting depth of %d", MaxDepth)
+		}
+		t.stack = append(t.stack, c)
+		t.pos++
+		if c == '{' {
+			t.state = stateObjectFirst
+			return Token{Type: ObjectStart, Offset: start, Length: 1}, nil
+		}
+		t.state = stateArrayFirst
+		return Token{Type: ArrayStart, Offset: start, Length: 1}, nil
+	case '"':
+		tok, err := t.scanString(String)
+		if err != nil {
+			return Token{}, err
+		}
+		t.state = stateAfterValue
+		return tok, nil
+	case 't':
+		return t.scanLiteral("true", True)
+	case 'f':
+		return t.scanLiteral("false", False)
+	case 'n':
+		return t.scanLiteral("null", Null)
+	}
+	if c := t.data[t.pos]; c == '-' || isDigit(c) {
+		return t.scanNumber()
+	}
+	return Token{}, t.errorf("unexpected %s looking for a value", t.describe())
+}
+
+// close consumes the '}' or ']' ending the innermost container.
+func (t *Tokenizer) close() Token {
+	typ := ObjectEnd
+	if t.stack[len(t.stack)-1] == '[' {
+		typ = ArrayEnd
+	}
+	t.stack = t.stack[:len(t.stack)-1]
+	t.pos++
+	t.state = stateAfterValue
+	return Token{Type: typ, Offset: t.pos - 1, Length: 1}
+}
+
+// scanString reads a string, checking its escapes and rejecting
+// unescaped control characters.
+func (t *Tokenizer) scanString(typ TokenType) (Token, error) {
+	start := t.pos
+	t.pos++ // opening quote
+	for t.pos < len(t.data) {
+		switch c := t.data[t.pos]; {
+		case c == '"':
+			t.pos++
+			return Token{Type: typ, Offset: start, Length: t.pos - start}, nil
+		case c == '\\':
+			if t.pos+1 >= len(t.data) {
+				return Token{}, t.errorf("unexpected end of input in string")
+			}
+			switch t.data[t.pos+1] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				t.pos += 2
+			case 'u':
+				if t.pos+6 > len(t.data) {
+					return Token{}, t.errorf("unexpected end of input in string")
+				}
+				for i := t.pos + 2; i < t.pos+6; i++ {
+					if !isHex(t.data[i]) {
+						return Token{}, &SyntaxError{Offset: t.pos, Msg: "invalid \\u escape in string"}
+					}
+				}
+				t.pos += 6
+			default:
+				return Token{}, t.errorf("invalid escape \\%c in string", t.data[t.pos+1])
+			}
+		case c < 0x20:
+			return Token{}, t.errorf("control character %#02x in string", c)
+		default:
+			t.pos++
+		}
+	}
+	return Token{}, &SyntaxError{Offset: start, Msg: "unterminated string"}
+}
+
+// scanNumber reads a number in the JSON grammar:
+// -? (0 | [1-9][0-9]*) (. [0-9]+)? ([eE] [+-]? [0-9]+)?
+func (t *Tokenizer) scanNumber() (Token, error) {
+	start := t.pos
+	if t.data[t.pos] == '-' {
+		t.pos++
+	}
+	switch {
+	case t.pos < len(t.data) && t.data[t.pos] == '0':
+		t.pos++
+	case t.pos < len(t.data) && isDigit(t.data[t.pos]):
+		t.skipDigits()
+	default:
+		return Token{}, t.errorf("invalid number: expected digit, got %s", t.describe())
+	}
+
+	if t.pos < len(t.data) && t.data[t.pos] == '.' {
+		t.pos++
+		if t.pos >= len(t.data) || !isDigit(t.data[t.pos]) {
+			return Token{}, t.errorf("invalid number: expected digit after decimal point, got %s", t.describe())
+		}
+		t.skipDigits()
+	}
+
+	if t.pos < len(t.data) && (t.data[t.pos] == 'e' || t.data[t.pos] == 'E') {
+		t.pos++
+		if t.pos < len(t.data) && (t.data[t.pos] == '+' || t.data[t.pos] == '-') {
+			t.pos++
+		}
+		if t.pos >= len(t.data) || !isDigit(t.data[t.pos]) {
+			return Token{}, t.errorf("invalid number: expected digit in exponent, got %s", t.describe())
+		}
+		t.skipDigits()
+	}
+
+	t.state = stateAfterValue
+	return Token{Type: Number, Offset: start, Length: t.pos - start}, nil
+}
+
+// scanLiteral reads true, false or null.
+func (t *Tokenizer) scanLiteral(word string, typ TokenType) (Token, error) {
+	start := t.pos
+	if len(t.data)-t.pos < len(word) || string(t.data[t.pos:t.pos+len(word)]) != word {
+		return Token{}, t.errorf("invalid literal, expected %s", word)
+	}
+	t.pos += len(word)
+	t.state = stateAfterValue
+	return Token{Type: typ, Offset: start, Length: len(word)}, nil
+}
+
+func (t *Tokenizer) skipDigits() {
+	for t.pos < len(t.data) && isDigit(t.data[t.pos]) {
+		t.pos++
+	}
+}
+
+func (t *Tokenizer) skipWhitespace() {
+	for t.pos < len(t.data) {
+		switch t.data[t.pos] {
+		case ' ', '\t', '\n', '\r':
+			t.pos++
+		default:
+			return
+		}
+	}
+}
+
+// describe names the byte at the current position for error messages.
+func (t *Tokenizer) describe() string {
+	if t.pos >= len(t.data) {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", t.data[t.pos])
+}
+
+func (t *Tokenizer) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Offset: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,220 @@
+package superjsonic_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ha1tch/queryfy/superjsonic"
+)
+
+// ======================================================================
+// Tokenizer
+// ======================================================================
+
+func TestTokenizer_Tokens(t *testing.T) {
+	data := []byte(` {"a": [1, -2.5e3, "x\n"], "b": {}, "c": [true, false, null]} `)
+	want := []struct {
+		typ superjsonic.TokenType
+		raw string
+	}{
+		{superjsonic.ObjectStart, "{"},
+		{superjsonic.Key, `"a"`},
+		{superjsonic.ArrayStart, "["},
+		{superjsonic.Number, "1"},
+		{superjsonic.Number, "-2.5e3"},
+		{superjsonic.String, `"x\n"`},
+		{superjsonic.ArrayEnd, "]"},
+		{superjsonic.Key, `"b"`},
+		{superjsonic.ObjectStart, "{"},
+		{superjsonic.ObjectEnd, "}"},
+		{superjsonic.Key, `"c"`},
+		{superjsonic.ArrayStart, "["},
+		{superjsonic.True, "true"},
+		{superjsonic.False, "false"},
+		{superjsonic.Null, "null"},
+		{superjsonic.ArrayEnd, "]"},
+		{superjsonic.ObjectEnd, "}"},
+		{superjsonic.EOF, ""},
+	}
+
+	tz := superjsonic.NewTokenizer(data)
+	for i, w := range want {
+		tok, err := tz.Next()
+		if err != nil {
+			t.Fatalf("token %d: %v", i, err)
+		}
+		if tok.Type != w.typ || string(tz.Bytes(tok)) != w.raw {
+			t.Fatalf("token %d = %s %q, want %s %q", i, tok.Type, tz.Bytes(tok), w.typ, w.raw)
+		}
+	}
+}
+
+func TestTokenizer_Peek(t *testing.T) {
+	tz := superjsonic.NewTokenizer([]byte(`[{"a":1}]`))
+	for {
+		peeked, err := tz.Peek()
+		if err != nil {
+			t.Fatal(err)
+		}
+		depth := tz.Depth()
+		tok, err := tz.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok != peeked {
+			t.Fatalf("Peek = %+v, Next = %+v", peeked, tok)
+		}
+		if tok.Type == superjsonic.ObjectEnd && tz.Depth() != depth-1 {
+			t.Errorf("depth after } = %d, want %d", tz.Depth(), depth-1)
+		}
+		if tok.Type == superjsonic.EOF {
+			break
+		}
+	}
+}
+
+func TestTokenizer_SyntaxErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		offset int
+		msg    string
+	}{
+		{``, 0, "unexpected end of input"},
+		{`{"a" 1}`, 5, "expected ':'"},
+		{`{"a":1,}`, 7, "expected string for object key"},
+		{`[1 2]`, 3, "expected ',' or ']'"},
+		{`[1,]`, 3, "looking for a value"},
+		{`{"a":1]`, 6, "expected ',' or '}'"},
+		{`"abc`, 0, "unterminated string"},
+		{`"a\x"`, 2, `invalid escape \x`},
+		{`"\u12g4"`, 1, `invalid \u escape`},
+		{"\"a\tb\"", 2, "control character"},
+		{`01`, 1, "after top-level value"},
+		{`-`, 1, "expected digit"},
+		{`1.`, 2, "after decimal point"},
+		{`1e+`, 3, "in exponent"},
+		{`tru`, 0, "expected true"},
+		{`{} {}`, 3, "after top-level value"},
+		{`[[1]`, 4, "unexpected end of input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tz := superjsonic.NewTokenizer([]byte(tt.input))
+			var err error
+			for err == nil {
+				var tok superjsonic.Token
+				tok, err = tz.Next()
+				if err == nil && tok.Type == superjsonic.EOF {
+					t.Fatal("expected a syntax error")
+				}
+			}
+			var syn *superjsonic.SyntaxError
+			if !errors.As(err, &syn) {
+				t.Fatalf("error %T is not a *SyntaxError", err)
+			}
+			if syn.Offset != tt.offset || !strings.Contains(syn.Msg, tt.msg) {
+				t.Errorf("error = %v, want offset %d and %q", err, tt.offset, tt.msg)
+			}
+			if superjsonic.Valid([]byte(tt.input)) {
+				t.Error("Valid = true")
+			}
+		})
+	}
+}
+
+func TestTokenizer_MaxDepth(t *testing.T) {
+	deep := strings.Repeat("[", superjsonic.MaxDepth+1) + strings.Repeat("]", superjsonic.MaxDepth+1)
+	if superjsonic.Valid([]byte(deep)) {
+		t.Error("nesting beyond MaxDepth accepted")
+	}
+	ok := strings.Repeat("[", superjsonic.MaxDepth) + strings.Repeat("]", superjsonic.MaxDepth)
+	if !superjsonic.Valid([]byte(ok)) {
+		t.Error("nesting at MaxDepth rejected")
+	}
+}
+
+func TestTokenizer_Reset(t *testing.T) {
+	tz := superjsonic.NewTokenizer([]byte(`[1`))
+	tz.Next()
+	tz.Reset([]byte(`true`))
+	tok, err := tz.Next()
+	if err != nil || tok.Type != superjsonic.True || tz.Depth() != 0 {
+		t.Fatalf("after Reset: %v %v depth %d", tok, err, tz.Depth())
+	}
+}
+
+// ======================================================================
+// Decoding
+// ======================================================================
+
+func TestDecode_MatchesEncodingJSON(t *testing.T) {
+	inputs := []string{
+		`{"name":"Ann","age":42,"tags":["a","b"],"nested":{"ok":true,"none":null}}`,
+		`[1.5, -0, 1e10, "é😀", "\"\\\/\b\f\n\r\t"]`,
+		`"lone \ud800 surrogate"`,
+		`[]`,
+		`{}`,
+	}
+	for _, input := range inputs {
+		var want interface{}
+		if err := json.Unmarshal([]byte(input), &want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := superjsonic.NewTokenizer([]byte(input)).Decode()
+		if err != nil {
+			t.Fatalf("Decode(%s): %v", input, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Decode(%s) = %#v, want %#v", input, got, want)
+		}
+	}
+}
+
+func TestSkip(t *testing.T) {
+	tz := superjsonic.NewTokenizer([]byte(`[{"a":[1,{"b":2}]}, "next"]`))
+	tz.Next()
+	if err := tz.Skip(); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := tz.Next()
+	if err != nil || string(tz.Bytes(tok)) != `"next"` {
+		t.Fatalf("after Skip: %s %v", tz.Bytes(tok), err)
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	got, err := superjsonic.Unquote([]byte(`"aA\n"`))
+	if err != nil || got != "aA\n" {
+		t.Errorf("Unquote = %q, %v", got, err)
+	}
+	if _, err := superjsonic.Unquote([]byte(`abc`)); err == nil {
+		t.Error("expected error for unquoted input")
+	}
+}
+
+// ======================================================================
+// Benchmarks
+// ======================================================================
+
+func BenchmarkTokenizer(b *testing.B) {
+	item := `{"id":123,"name":"widget","price":9.99,"tags":["a","b"],"active":true}`
+	data := []byte("[" + strings.Repeat(item+",", 999) + item + "]")
+	tz := superjsonic.NewTokenizer(nil)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tz.Reset(data)
+		for {
+			tok, err := tz.Next()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if tok.Type == superjsonic.EOF {
+				break
+			}
+		}
+	}
+}
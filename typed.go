@@ -0,0 +1,263 @@
+package queryfy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/ha1tch/queryfy/query"
+)
+
+// QueryString executes a query and returns its result as a string. The
+// result must be a string; other values are an error, not converted.
+func QueryString(data interface{}, queryStr string) (string, error) {
+	value, err := queryValue(data, queryStr)
+	if err != nil {
+		return "", err
+	}
+	s, err := AsString(value)
+	return s, queryError(queryStr, err)
+}
+
+// QueryInt executes a query and returns its result as an int. See
+// AsInt for the values accepted.
+func QueryInt(data interface{}, queryStr string) (int, error) {
+	value, err := queryValue(data, queryStr)
+	if err != nil {
+		return 0, err
+	}
+	n, err := AsInt(value)
+	return n, queryError(queryStr, err)
+}
+
+// QueryFloat executes a query and returns its result as a float64. See
+// AsFloat for the values accepted.
+func QueryFloat(data interface{}, queryStr string) (float64, error) {
+	value, err := queryValue(data, queryStr)
+	if err != nil {
+		return 0, err
+	}
+	f, err := AsFloat(value)
+	return f, queryError(queryStr, err)
+}
+
+// QueryBool executes a query and returns its result as a bool.
+func QueryBool(data interface{}, queryStr string) (bool, error) {
+	value, err := queryValue(data, queryStr)
+	if err != nil {
+		return false, err
+	}
+	b, err := AsBool(value)
+	return b, queryError(queryStr, err)
+}
+
+// QueryTime executes a query and returns its result as a time.Time. See
+// AsTime for the values accepted.
+func QueryTime(data interface{}, queryStr string, layouts ...string) (time.Time, error) {
+	value, err := queryValue(data, queryStr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := AsTime(value, layouts...)
+	return t, queryError(queryStr, err)
+}
+
+// QueryStrings executes a query and returns its result as a []string.
+// See AsStrings for the values accepted.
+func QueryStrings(data interface{}, queryStr string) ([]string, error) {
+	value, err := queryValue(data, queryStr)
+	if err != nil {
+		return nil, err
+	}
+	s, err := AsStrings(value)
+	return s, queryError(queryStr, err)
+}
+
+// AsString returns a query result as a string. The result must be a
+// string; other values are an error, not converted. AsString and the
+// other As functions are the conversions of the Query accessors, for
+// results obtained some other way, such as from a compiled query.
+func AsString(value interface{}) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", typeError("string", value)
+	}
+	return s, nil
+}
+
+// AsInt returns a query result as an int. Any Go numeric type,
+// json.Number, big number or Rational is accepted as long as the value
+// is a whole number that fits in an int, so the float64 values of
+// decoded JSON work. json.Number and big values are converted exactly.
+func AsInt(value interface{}) (int, error) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := rv.Int()
+		if n < math.MinInt || n > math.MaxInt {
+			return 0, fmt.Errorf("%d overflows int", n)
+		}
+		return int(n), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := rv.Uint()
+		if n > math.MaxInt {
+			return 0, fmt.Errorf("%d overflows int", n)
+		}
+		return int(n), nil
+	case reflect.Float32, reflect.Float64:
+		return floatToInt(rv.Float(), value)
+	}
+
+	if r, ok := ConvertToRat(value); ok {
+		if !r.IsInt() {
+			return 0, fmt.Errorf("%v is not a whole number", value)
+		}
+		n := r.Num()
+		if !n.IsInt64() || n.Int64() < math.MinInt || n.Int64() > math.MaxInt {
+			return 0, fmt.Errorf("%v overflows int", value)
+		}
+		return int(n.Int64()), nil
+	}
+	if v, ok := value.(json.Number); ok {
+		// Too many digits to convert exactly
+		if f, err := v.Float64(); err == nil || errors.Is(err, strconv.ErrRange) {
+			return floatToInt(f, value)
+		}
+	}
+	return 0, typeError("number", value)
+}
+
+// floatToInt converts a whole float to an int. value is the original
+// result, for error messages.
+func floatToInt(f float64, value interface{}) (int, error) {
+	if math.IsInf(f, 0) || f < math.MinInt || f >= math.MaxInt {
+		return 0, fmt.Errorf("%v overflows int", value)
+	}
+	if f != math.Trunc(f) {
+		return 0, fmt.Errorf("%v is not a whole number", value)
+	}
+	return int(f), nil
+}
+
+// AsFloat returns a query result as a float64. Any Go numeric type,
+// json.Number, big number or Rational is accepted; values beyond the
+// range of float64 are an error.
+func AsFloat(value interface{}) (float64, error) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	}
+
+	if v, ok := value.(json.Number); ok {
+		f, err := v.Float64()
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, fmt.Errorf("%v overflows float64", value)
+		}
+		if err == nil {
+			return f, nil
+		}
+	} else if r, ok := ConvertToRat(value); ok {
+		f, _ := r.Float64()
+		if math.IsInf(f, 0) {
+			return 0, fmt.Errorf("%v overflows float64", value)
+		}
+		return f, nil
+	}
+	return 0, typeError("number", value)
+}
+
+// AsBool returns a query result as a bool.
+func AsBool(value interface{}) (bool, error) {
+	b, ok := value.(bool)
+	if !ok {
+		return false, typeError("boolean", value)
+	}
+	return b, nil
+}
+
+// AsTime returns a query result as a time.Time. A time.Time is returned
+// as is; a string is parsed with the first of layouts that accepts it,
+// or as RFC 3339 when no layouts are given.
+func AsTime(value interface{}, layouts ...string) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v != nil {
+			return *v, nil
+		}
+	case string:
+		if len(layouts) == 0 {
+			layouts = []string{time.RFC3339}
+		}
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("%q is not a time in the expected format", v)
+	}
+	return time.Time{}, typeError("time", value)
+}
+
+// AsStrings returns a query result as a []string. The result must be an
+// array of strings, such as the result of a wildcard query like
+// "users[*].email"; null elements are an error.
+func AsStrings(value interface{}) ([]string, error) {
+	if s, ok := value.([]string); ok {
+		return append([]string(nil), s...), nil
+	}
+
+	items, ok := toSlice(value)
+	if !ok {
+		return nil, typeError("array of strings", value)
+	}
+	out := make([]string, len(items))
+	for i, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("element %d: expected string, got %s", i, describeType(item))
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+// queryValue executes a query for a typed accessor. Queries are cached,
+// since the accessors are typically called with the same few queries.
+func queryValue(data interface{}, queryStr string) (interface{}, error) {
+	value, err := query.ExecuteCached(data, queryStr)
+	if err != nil {
+		return nil, fmt.Errorf("query %q: %w", queryStr, err)
+	}
+	return value, nil
+}
+
+// queryError names the query in a conversion error.
+func queryError(queryStr string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("query %q: %w", queryStr, err)
+}
+
+func typeError(want string, value interface{}) error {
+	return fmt.Errorf("expected %s, got %s", want, describeType(value))
+}
+
+// describeType names the type of a query result for error messages.
+func describeType(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,124 @@
+package queryfy_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ha1tch/queryfy"
+)
+
+var typedData = map[string]interface{}{
+	"name":    "Ann",
+	"age":     42.0,
+	"height":  1.72,
+	"count":   int64(7),
+	"active":  true,
+	"created": "2024-03-01T10:00:00Z",
+	"tags":    []interface{}{"a", "b"},
+	"mixed":   []interface{}{"a", 1.0},
+	"labels":  []string{"x", "y"},
+	"missing": nil,
+}
+
+// ======================================================================
+// Typed accessors
+// ======================================================================
+
+func TestQueryTyped(t *testing.T) {
+	if s, err := queryfy.QueryString(typedData, "name"); err != nil || s != "Ann" {
+		t.Errorf("QueryString: got %q, %v", s, err)
+	}
+	if n, err := queryfy.QueryInt(typedData, "age"); err != nil || n != 42 {
+		t.Errorf("QueryInt: got %d, %v", n, err)
+	}
+	if n, err := queryfy.QueryInt(typedData, "count"); err != nil || n != 7 {
+		t.Errorf("QueryInt int64: got %d, %v", n, err)
+	}
+	if f, err := queryfy.QueryFloat(typedData, "height"); err != nil || f != 1.72 {
+		t.Errorf("QueryFloat: got %v, %v", f, err)
+	}
+	if f, err := queryfy.QueryFloat(typedData, "count"); err != nil || f != 7 {
+		t.Errorf("QueryFloat int64: got %v, %v", f, err)
+	}
+	if b, err := queryfy.QueryBool(typedData, "active"); err != nil || !b {
+		t.Errorf("QueryBool: got %v, %v", b, err)
+	}
+
+	want := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	if ts, err := queryfy.QueryTime(typedData, "created"); err != nil || !ts.Equal(want) {
+		t.Errorf("QueryTime: got %v, %v", ts, err)
+	}
+	if ts, err := queryfy.QueryTime(map[string]interface{}{"d": "01/03/2024"}, "d", "2006-01-02", "02/01/2006"); err != nil || ts.Month() != time.March {
+		t.Errorf("QueryTime with layouts: got %v, %v", ts, err)
+	}
+
+	if s, err := queryfy.QueryStrings(typedData, "tags"); err != nil || strings.Join(s, ",") != "a,b" {
+		t.Errorf("QueryStrings: got %v, %v", s, err)
+	}
+	if s, err := queryfy.QueryStrings(typedData, "labels"); err != nil || len(s) != 2 {
+		t.Errorf("QueryStrings []string: got %v, %v", s, err)
+	}
+}
+
+func TestQueryTyped_NumberTypes(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	data := map[string]interface{}{
+		"id":    json.Number("9007199254740993"),
+		"price": json.Number("19.99"),
+		"whole": json.Number("3e2"),
+		"big":   big.NewInt(12),
+		"huge":  huge,
+		"inf":   json.Number("1e999"),
+	}
+
+	// 2^53+1 is exact, not rounded through float64
+	if n, err := queryfy.QueryInt(data, "id"); err != nil || n != 9007199254740993 {
+		t.Errorf("QueryInt json.Number: got %d, %v", n, err)
+	}
+	if n, err := queryfy.QueryInt(data, "whole"); err != nil || n != 300 {
+		t.Errorf("QueryInt exponent: got %d, %v", n, err)
+	}
+	if n, err := queryfy.QueryInt(data, "big"); err != nil || n != 12 {
+		t.Errorf("QueryInt *big.Int: got %d, %v", n, err)
+	}
+	if f, err := queryfy.QueryFloat(data, "price"); err != nil || f != 19.99 {
+		t.Errorf("QueryFloat json.Number: got %v, %v", f, err)
+	}
+
+	errs := map[string]func() error{
+		"19.99 is not a whole number": func() error { _, err := queryfy.QueryInt(data, "price"); return err },
+		"overflows int":               func() error { _, err := queryfy.QueryInt(data, "huge"); return err },
+		"1e999 overflows int":         func() error { _, err := queryfy.QueryInt(data, "inf"); return err },
+		"1e999 overflows float64":     func() error { _, err := queryfy.QueryFloat(data, "inf"); return err },
+	}
+	for want, call := range errs {
+		if err := call(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v, want error containing %q", err, want)
+		}
+	}
+}
+
+func TestQueryTyped_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		call func() error
+		want string
+	}{
+		{"wrong type", func() error { _, err := queryfy.QueryString(typedData, "age"); return err }, `query "age": expected string, got float64`},
+		{"null", func() error { _, err := queryfy.QueryString(typedData, "missing"); return err }, "expected string, got null"},
+		{"fraction", func() error { _, err := queryfy.QueryInt(typedData, "height"); return err }, "1.72 is not a whole number"},
+		{"not a number", func() error { _, err := queryfy.QueryFloat(typedData, "name"); return err }, "expected number, got string"},
+		{"bad time", func() error { _, err := queryfy.QueryTime(typedData, "name"); return err }, "not a time in the expected format"},
+		{"mixed array", func() error { _, err := queryfy.QueryStrings(typedData, "mixed"); return err }, "element 1: expected string, got float64"},
+		{"no field", func() error { _, err := queryfy.QueryInt(typedData, "weight"); return err }, `field "weight" not found`},
+	}
+	for _, tt := range tests {
+		err := tt.call()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want error containing %q", tt.name, err, tt.want)
+		}
+	}
+}
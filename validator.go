@@ -1,7 +1,9 @@
 package queryfy
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"reflect"
 )
 
@@ -49,7 +51,7 @@ func ValidateValue(value interface{}, expectedType SchemaType, ctx *ValidationCo
 	case TypeAny:
 		return true
 	default:
-		ctx.AddError(fmt.Sprintf("unknown schema type: %s", expectedType), value)
+		ctx.AddCodedError(CodeUnknownType, Params{"type": string(expectedType)}, value)
 		return false
 	}
 }
@@ -65,7 +67,7 @@ func validateString(value interface{}, ctx *ValidationContext) bool {
 				return true
 			}
 		}
-		ctx.AddError(fmt.Sprintf("expected string, got %T", value), value)
+		ctx.AddCodedError(CodeType, Params{"expected": "string", "actual": fmt.Sprintf("%T", value)}, value)
 		return false
 	}
 }
@@ -74,7 +76,8 @@ func validateNumber(value interface{}, ctx *ValidationContext) bool {
 	switch value.(type) {
 	case int, int8, int16, int32, int64,
 		uint, uint8, uint16, uint32, uint64,
-		float32, float64:
+		float32, float64, json.Number,
+		*big.Int, *big.Float, *big.Rat, Rational:
 		return true
 	default:
 		if ctx.Mode() == Loose {
@@ -85,7 +88,7 @@ func validateNumber(value interface{}, ctx *ValidationContext) bool {
 				}
 			}
 		}
-		ctx.AddError(fmt.Sprintf("expected number, got %T", value), value)
+		ctx.AddCodedError(CodeType, Params{"expected": "number", "actual": fmt.Sprintf("%T", value)}, value)
 		return false
 	}
 }
@@ -103,7 +106,7 @@ func validateBool(value interface{}, ctx *ValidationContext) bool {
 				}
 			}
 		}
-		ctx.AddError(fmt.Sprintf("expected boolean, got %T", value), value)
+		ctx.AddCodedError(CodeType, Params{"expected": "boolean", "actual": fmt.Sprintf("%T", value)}, value)
 		return false
 	}
 }
@@ -116,11 +119,11 @@ func validateObject(value interface{}, ctx *ValidationContext) bool {
 		if rv.Type().Key().Kind() == reflect.String {
 			return true
 		}
-		ctx.AddError("expected object with string keys", value)
+		ctx.AddCodedError(CodeType, Params{"expected": "object with string keys", "actual": fmt.Sprintf("%T", value)}, value)
 		return false
 	}
 
-	ctx.AddError(fmt.Sprintf("expected object, got %T", value), value)
+	ctx.AddCodedError(CodeType, Params{"expected": "object", "actual": fmt.Sprintf("%T", value)}, value)
 	return false
 }
 
@@ -131,7 +134,7 @@ func validateArray(value interface{}, ctx *ValidationContext) bool {
 	case reflect.Slice, reflect.Array:
 		return true
 	default:
-		ctx.AddError(fmt.Sprintf("expected array, got %T", value), value)
+		ctx.AddCodedError(CodeType, Params{"expected": "array", "actual": fmt.Sprintf("%T", value)}, value)
 		return false
 	}
 }